@@ -49,7 +49,12 @@ func main() {
 	r := gin.Default()
 
 	// Setup routes
-	routes.SetupRoutes(r, db, cfg.AllowedOrigins, cfg.JWTExpiryHours)
+	scheduler := routes.SetupRoutes(r, db, cfg)
+
+	// Start background jobs; replicas with jobs disabled still run the
+	// local ones that clean up their own memory
+	scheduler.Start(cfg.JobsEnabled)
+	defer scheduler.Stop()
 
 	// Start server
 	serverAddress := ":" + cfg.ServerPort
@@ -0,0 +1,192 @@
+// Fires hundreds of parallel debits at the same wallet to verify that the
+// balance check and decrement in the wallet service are atomic.
+// Run against a development database only: the chosen wallets are reset.
+// The same checks run under go test in internal/wallet when TEST_DB_DSN is set.
+//
+//	go run ./cmd/tools/test-concurrent-debit -wallet 3 -peer 4 -workers 500
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"wallet-point/config"
+	"wallet-point/internal/wallet"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func main() {
+	walletID := flag.Uint("wallet", 0, "wallet ID to drain (will be reset)")
+	peerID := flag.Uint("peer", 0, "second wallet ID for the crossfire phase (will be reset)")
+	workers := flag.Int("workers", 300, "number of parallel debits")
+	balance := flag.Int("balance", 100, "starting balance")
+	amount := flag.Int("amount", 1, "points per debit")
+	flag.Parse()
+
+	if *walletID == 0 {
+		log.Fatal("-wallet is required")
+	}
+
+	cfg := config.LoadConfig()
+	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+		cfg.DBUser, cfg.DBPassword, cfg.DBHost, cfg.DBPort, cfg.DBName)
+
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	service := wallet.NewWalletService(wallet.NewWalletRepository(db), db)
+
+	failed := !drainWallet(service, uint(*walletID), *workers, *balance, *amount)
+	if *peerID != 0 {
+		failed = !crossfire(service, uint(*walletID), uint(*peerID), *workers, *balance, *amount) || failed
+	}
+
+	if failed {
+		fmt.Println("❌ Concurrency test failed")
+		os.Exit(1)
+	}
+	fmt.Println("✅ Concurrency test passed")
+}
+
+// drainWallet races more debits than the balance can cover against one wallet
+func drainWallet(service *wallet.WalletService, walletID uint, workers, balance, amount int) bool {
+	fmt.Printf("== Drain: %d parallel debits of %d against wallet %d (balance %d)\n", workers, amount, walletID, balance)
+	resetBalance(service, walletID, balance)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	succeeded, insufficient := 0, 0
+	var unexpected []error
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := service.DebitWithTransaction(nil, walletID, amount, "marketplace", fmt.Sprintf("Concurrency test debit #%d", i))
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				succeeded++
+			case errors.Is(err, wallet.ErrInsufficientBalance):
+				insufficient++
+			default:
+				unexpected = append(unexpected, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	expected := balance / amount
+	if expected > workers {
+		expected = workers
+	}
+
+	w, err := service.GetWalletByID(walletID)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ok := true
+	fmt.Printf("   succeeded=%d insufficient=%d unexpected=%d final_balance=%d\n", succeeded, insufficient, len(unexpected), w.Balance)
+	if succeeded != expected {
+		fmt.Printf("   expected %d successful debits\n", expected)
+		ok = false
+	}
+	if w.Balance != balance-succeeded*amount || w.Balance < 0 {
+		fmt.Printf("   expected final balance %d\n", balance-succeeded*amount)
+		ok = false
+	}
+	for _, err := range unexpected {
+		fmt.Printf("   unexpected error: %v\n", err)
+		ok = false
+	}
+	return checkReconciled(service, walletID) && ok
+}
+
+// crossfire moves points in both directions between two wallets at once,
+// which deadlocks unless wallets are always locked in the same order
+func crossfire(service *wallet.WalletService, a, b uint, workers, balance, amount int) bool {
+	fmt.Printf("== Crossfire: %d parallel transfers between wallets %d and %d\n", workers, a, b)
+	resetBalance(service, a, balance)
+	resetBalance(service, b, balance)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var unexpected []error
+
+	for i := 0; i < workers; i++ {
+		from, to := a, b
+		if i%2 == 1 {
+			from, to = b, a
+		}
+
+		wg.Add(1)
+		go func(from, to uint) {
+			defer wg.Done()
+			_, _, err := service.PostJournal(nil, "transfer", "Concurrency test transfer", []wallet.LedgerLeg{
+				{WalletID: from, Direction: "debit", Amount: amount, Type: "transfer_out", Description: "Concurrency test transfer"},
+				{WalletID: to, Direction: "credit", Amount: amount, Type: "transfer_in", Description: "Concurrency test transfer"},
+			})
+			if err != nil && !errors.Is(err, wallet.ErrInsufficientBalance) {
+				mu.Lock()
+				unexpected = append(unexpected, err)
+				mu.Unlock()
+			}
+		}(from, to)
+	}
+	wg.Wait()
+
+	wa, err := service.GetWalletByID(a)
+	if err != nil {
+		log.Fatal(err)
+	}
+	wb, err := service.GetWalletByID(b)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ok := true
+	fmt.Printf("   balances=%d/%d unexpected=%d\n", wa.Balance, wb.Balance, len(unexpected))
+	if wa.Balance+wb.Balance != 2*balance || wa.Balance < 0 || wb.Balance < 0 {
+		fmt.Printf("   expected balances to sum to %d and stay non-negative\n", 2*balance)
+		ok = false
+	}
+	for _, err := range unexpected {
+		fmt.Printf("   unexpected error: %v\n", err)
+		ok = false
+	}
+	return checkReconciled(service, a) && checkReconciled(service, b) && ok
+}
+
+func resetBalance(service *wallet.WalletService, walletID uint, balance int) {
+	err := service.ResetWallet(&wallet.ResetWalletRequest{
+		WalletID:   walletID,
+		NewBalance: balance,
+		Reason:     "Concurrency test setup",
+	}, 0)
+	if err != nil {
+		log.Fatalf("failed to reset wallet %d: %v", walletID, err)
+	}
+}
+
+func checkReconciled(service *wallet.WalletService, walletID uint) bool {
+	report, err := service.ReconcileWallet(walletID)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if !report.Consistent {
+		fmt.Printf("   wallet %d drifted from ledger: %+v\n", walletID, *report)
+		return false
+	}
+	return true
+}
@@ -4,6 +4,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -24,6 +25,18 @@ type Config struct {
 	MaxUploadSize      int64
 	UploadPath         string
 	ExternalAPITimeout int
+	IdempotencyWindow  time.Duration
+	PointExpiry        PointExpiryConfig
+	JobsEnabled        bool
+	QRSigningKey       string // Base64 Ed25519 seed; derived from JWTSecret when empty
+	SettlementCutoff   string // Daily merchant settlement cutoff, HH:MM Asia/Jakarta
+}
+
+// PointExpiryConfig controls end-of-term expiry of earned points
+type PointExpiryConfig struct {
+	TermEnds    string // Comma separated MM-DD, "off" disables expiry
+	Types       string // Comma separated wallet transaction types that expire
+	WarningDays int
 }
 
 func LoadConfig() *Config {
@@ -50,6 +63,24 @@ func LoadConfig() *Config {
 		apiTimeout = 30
 	}
 
+	// Parse idempotency replay window
+	idempotencyHours, err := strconv.Atoi(getEnv("IDEMPOTENCY_WINDOW_HOURS", "24"))
+	if err != nil || idempotencyHours <= 0 {
+		idempotencyHours = 24
+	}
+
+	// Parse point expiry settings
+	expiryWarningDays, err := strconv.Atoi(getEnv("POINT_EXPIRY_WARNING_DAYS", "14"))
+	if err != nil || expiryWarningDays < 0 {
+		expiryWarningDays = 14
+	}
+
+	// Background jobs can be switched off, e.g. on replicas that only serve traffic
+	jobsEnabled, err := strconv.ParseBool(getEnv("JOBS_ENABLED", "true"))
+	if err != nil {
+		jobsEnabled = true
+	}
+
 	serverHost := getEnv("SERVER_HOST", "localhost")
 	serverPort := getEnv("SERVER_PORT", "8102")
 
@@ -63,15 +94,28 @@ func LoadConfig() *Config {
 		DBUser:             getEnv("DB_USER", "root"),
 		DBPassword:         getEnv("DB_PASSWORD", ""),
 		DBName:             getEnv("DB_NAME", "wallet_point"),
-		JWTSecret:          getEnv("JWT_SECRET", "change-this-secret-key-in-production"),
+		JWTSecret:          getEnv("JWT_SECRET", DefaultJWTSecret),
 		JWTExpiryHours:     jwtExpiry,
 		AllowedOrigins:     getEnv("ALLOWED_ORIGINS", "*"),
 		MaxUploadSize:      maxUploadSize,
 		UploadPath:         getEnv("UPLOAD_PATH", "./uploads"),
 		ExternalAPITimeout: apiTimeout,
+		IdempotencyWindow:  time.Duration(idempotencyHours) * time.Hour,
+		PointExpiry: PointExpiryConfig{
+			TermEnds:    getEnv("POINT_EXPIRY_TERM_ENDS", "01-31,07-31"),
+			Types:       getEnv("POINT_EXPIRY_TYPES", "mission,task"),
+			WarningDays: expiryWarningDays,
+		},
+		JobsEnabled:      jobsEnabled,
+		QRSigningKey:     os.Getenv("QR_SIGNING_KEY"),
+		SettlementCutoff: getEnv("SETTLEMENT_CUTOFF", "00:00"),
 	}
 }
 
+// DefaultJWTSecret is the JWT secret used when JWT_SECRET is unset. Keys
+// must never be derived from it.
+const DefaultJWTSecret = "change-this-secret-key-in-production"
+
 func getEnv(key, defaultValue string) string {
 	value := os.Getenv(key)
 	if value == "" {
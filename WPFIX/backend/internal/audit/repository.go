@@ -16,6 +16,10 @@ func (r *AuditRepository) Create(log *AuditLog) error {
 	return r.db.Create(log).Error
 }
 
+func (r *AuditRepository) CreateWithTx(tx *gorm.DB, log *AuditLog) error {
+	return tx.Create(log).Error
+}
+
 func (r *AuditRepository) FindAll(params AuditListParams) ([]AuditLogWithUser, int64, error) {
 	var logs []AuditLogWithUser
 	var total int64
@@ -3,6 +3,8 @@ package audit
 import (
 	"math"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type AuditService struct {
@@ -15,7 +17,17 @@ func NewAuditService(repo *AuditRepository) *AuditService {
 
 // LogActivity records a system activity
 func (s *AuditService) LogActivity(params CreateAuditParams) error {
-	log := &AuditLog{
+	return s.repo.Create(newAuditLog(params))
+}
+
+// LogActivityWithTx records a system activity as part of tx, so it commits
+// or rolls back with the change it describes
+func (s *AuditService) LogActivityWithTx(tx *gorm.DB, params CreateAuditParams) error {
+	return s.repo.CreateWithTx(tx, newAuditLog(params))
+}
+
+func newAuditLog(params CreateAuditParams) *AuditLog {
+	return &AuditLog{
 		UserID:    params.UserID,
 		Action:    params.Action,
 		Entity:    params.Entity,
@@ -25,8 +37,6 @@ func (s *AuditService) LogActivity(params CreateAuditParams) error {
 		UserAgent: params.UserAgent,
 		CreatedAt: time.Now(),
 	}
-
-	return s.repo.Create(log)
 }
 
 // GetLogs retrieves logs for admin
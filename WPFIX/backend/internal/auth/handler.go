@@ -1,6 +1,8 @@
 package auth
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"wallet-point/internal/audit"
 	"wallet-point/utils"
@@ -149,6 +151,43 @@ func (h *AuthHandler) UpdateProfile(c *gin.Context) {
 	})
 }
 
+// UpdatePrivacy handles the user's handle and discovery setting
+func (h *AuthHandler) UpdatePrivacy(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	var req UpdatePrivacyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	user, err := h.service.UpdatePrivacy(userID, &req)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, ErrHandleTaken) {
+			status = http.StatusConflict
+		}
+		utils.ErrorResponse(c, status, err.Error(), nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Privacy settings updated successfully", user)
+
+	handle := "none"
+	if user.Handle != nil {
+		handle = *user.Handle
+	}
+	// Log activity
+	h.auditService.LogActivity(audit.CreateAuditParams{
+		UserID:    userID,
+		Action:    "UPDATE_PRIVACY",
+		Entity:    "USER",
+		EntityID:  userID,
+		Details:   fmt.Sprintf("User set handle %s, discoverable %t", handle, user.Discoverable),
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+}
+
 // UpdatePassword handles user password change
 func (h *AuthHandler) UpdatePassword(c *gin.Context) {
 	userID := c.GetUint("user_id")
@@ -12,6 +12,8 @@ type User struct {
 	NimNip       string    `json:"nim_nip" gorm:"uniqueIndex;not null"`
 	Role         string    `json:"role" gorm:"type:enum('admin','dosen','mahasiswa','merchant');not null"`
 	Status       string    `json:"status" gorm:"type:enum('active','inactive','suspended');default:'active'"`
+	Handle       *string   `json:"handle" gorm:"type:varchar(30);uniqueIndex"` // Personal short name for receiving transfers
+	Discoverable bool      `json:"discoverable" gorm:"default:true;not null"`  // Others may find the user by NIM, email or handle
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 }
@@ -51,6 +53,13 @@ type UpdateProfileRequest struct {
 	FullName string `json:"full_name" binding:"required"`
 }
 
+// UpdatePrivacyRequest sets the handle and whether others may find the user.
+// An empty handle removes it.
+type UpdatePrivacyRequest struct {
+	Handle       *string `json:"handle" binding:"omitempty,max=30"`
+	Discoverable *bool   `json:"discoverable"`
+}
+
 type UpdatePasswordRequest struct {
 	OldPassword string `json:"old_password" binding:"required"`
 	NewPassword string `json:"new_password" binding:"required,min=6"`
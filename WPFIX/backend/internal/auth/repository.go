@@ -58,6 +58,14 @@ func (r *AuthRepository) CheckNimNipExists(nimNip string) (bool, error) {
 	err := r.db.Model(&User{}).Where("nim_nip = ?", nimNip).Count(&count).Error
 	return count > 0, err
 }
+
+// CheckHandleTaken checks if another user already uses a handle
+func (r *AuthRepository) CheckHandleTaken(handle string, userID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&User{}).Where("handle = ? AND id <> ?", handle, userID).Count(&count).Error
+	return count > 0, err
+}
+
 func (r *AuthRepository) Update(userID uint, updates map[string]interface{}) error {
 	return r.db.Model(&User{}).Where("id = ?", userID).Updates(updates).Error
 }
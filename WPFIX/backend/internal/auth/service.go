@@ -3,9 +3,18 @@ package auth
 import (
 	"errors"
 	"fmt"
+	"regexp"
+	"strings"
 	"wallet-point/utils"
 )
 
+var ErrHandleTaken = errors.New("handle is already taken")
+
+// handlePattern allows lowercase letters, digits, dots and underscores and
+// needs at least one letter so a handle never looks like a NIM
+var handlePattern = regexp.MustCompile(`^[a-z0-9_.]{3,30}$`)
+var handleLetter = regexp.MustCompile(`[a-z]`)
+
 type AuthService struct {
 	repo      *AuthRepository
 	jwtExpiry int
@@ -120,6 +129,40 @@ func (s *AuthService) UpdateProfile(userID uint, req *UpdateProfileRequest) (*Us
 	return s.repo.FindByID(userID)
 }
 
+// UpdatePrivacy sets the user's handle and discoverability
+func (s *AuthService) UpdatePrivacy(userID uint, req *UpdatePrivacyRequest) (*User, error) {
+	updates := map[string]interface{}{}
+
+	if req.Handle != nil {
+		handle := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(*req.Handle), "@"))
+		if handle == "" {
+			updates["handle"] = nil
+		} else {
+			if !handlePattern.MatchString(handle) || !handleLetter.MatchString(handle) {
+				return nil, errors.New("handle must be 3-30 lowercase letters, digits, dots or underscores with at least one letter")
+			}
+			taken, err := s.repo.CheckHandleTaken(handle, userID)
+			if err != nil {
+				return nil, err
+			}
+			if taken {
+				return nil, ErrHandleTaken
+			}
+			updates["handle"] = handle
+		}
+	}
+	if req.Discoverable != nil {
+		updates["discoverable"] = *req.Discoverable
+	}
+
+	if len(updates) > 0 {
+		if err := s.repo.Update(userID, updates); err != nil {
+			return nil, err
+		}
+	}
+	return s.repo.FindByID(userID)
+}
+
 // UpdatePassword updates user password after verifying old password
 func (s *AuthService) UpdatePassword(userID uint, req *UpdatePasswordRequest) error {
 	user, err := s.repo.FindByID(userID)
@@ -4,8 +4,13 @@ import (
 	"log"
 	"wallet-point/internal/audit"
 	"wallet-point/internal/auth"
+	"wallet-point/internal/idempotency"
+	"wallet-point/internal/jobs"
 	"wallet-point/internal/marketplace"
+	"wallet-point/internal/merchant"
 	"wallet-point/internal/mission"
+	"wallet-point/internal/notification"
+	"wallet-point/internal/settlement"
 	"wallet-point/internal/transfer"
 	"wallet-point/internal/wallet"
 
@@ -19,7 +24,11 @@ func Migrate(db *gorm.DB) {
 	db.Exec("ALTER TABLE users MODIFY COLUMN role ENUM('admin', 'dosen', 'mahasiswa', 'merchant') NOT NULL")
 	db.Exec("ALTER TABLE missions MODIFY COLUMN type ENUM('quiz', 'task', 'assignment') NOT NULL")
 	db.Exec("ALTER TABLE mission_submissions MODIFY COLUMN status ENUM('pending', 'approved', 'rejected') NOT NULL DEFAULT 'pending'")
-	db.Exec("ALTER TABLE wallet_transactions MODIFY COLUMN type ENUM('mission', 'task', 'transfer_in', 'transfer_out', 'marketplace', 'marketplace_sale', 'external', 'adjustment', 'topup') NOT NULL")
+	db.Exec("ALTER TABLE missions MODIFY COLUMN payout_rule ENUM('proportional', 'threshold', 'bands') DEFAULT 'proportional'")
+	db.Exec("ALTER TABLE wallet_transactions MODIFY COLUMN type ENUM('mission', 'task', 'transfer_in', 'transfer_out', 'marketplace', 'marketplace_sale', 'external', 'adjustment', 'topup', 'reversal', 'expiry', 'settlement', 'conversion') NOT NULL")
+	db.Exec("ALTER TABLE wallet_transactions MODIFY COLUMN status ENUM('success', 'failed', 'pending', 'reversed', 'released', 'captured') DEFAULT 'success'")
+	db.Exec("ALTER TABLE transfers MODIFY COLUMN status ENUM('success', 'failed', 'reversed', 'escrowed', 'delivered', 'disputed', 'refunded') DEFAULT 'success'")
+	db.Exec("ALTER TABLE marketplace_transactions MODIFY COLUMN status ENUM('success', 'failed', 'partially_refunded', 'reversed') DEFAULT 'success'")
 
 	// Cleanup: Remove legacy tables
 	db.Exec("DROP TABLE IF EXISTS task_submissions")
@@ -30,18 +39,79 @@ func Migrate(db *gorm.DB) {
 		&wallet.Wallet{},
 		&wallet.WalletTransaction{},
 		&wallet.PaymentToken{},
+		&wallet.BillItem{},
+		&wallet.LedgerJournal{},
+		&wallet.LedgerEntry{},
+		&wallet.RoleLimit{},
+		&wallet.PointAsset{},
+		&wallet.AssetBalance{},
+		&wallet.ConversionRule{},
 		&transfer.Transfer{},
+		&transfer.PointRequest{},
+		&transfer.PointRequestRecipient{},
+		&transfer.ScheduledTransfer{},
+		&transfer.ScheduledTransferRun{},
+		&transfer.TransferRules{},
+		&transfer.TransferFlag{},
+		&notification.Notification{},
 		&marketplace.Product{},
 		&marketplace.MarketplaceTransaction{},
 		&audit.AuditLog{},
 		&mission.Mission{},
 		&mission.MissionQuestion{},
 		&mission.MissionSubmission{},
+		&mission.BankQuestion{},
+		&mission.QuizDraw{},
+		&mission.QuizSession{},
+		&mission.PeerReview{},
+		&idempotency.IdempotencyKey{},
+		&jobs.JobRun{},
+		&jobs.JobLease{},
+		&settlement.SettlementBatch{},
+		&merchant.Merchant{},
+		&merchant.Terminal{},
+		&merchant.Cashier{},
 	)
 
 	if err != nil {
 		log.Fatal("❌ Migration failed:", err)
 	}
 
+	// Existing balances, ledger entries and transactions belong to the default asset
+	seeded, err := wallet.NewWalletRepository(db).SeedDefaultAsset()
+	if err != nil {
+		log.Fatal("❌ Default point asset seed failed:", err)
+	}
+	if seeded {
+		log.Printf("🪙 Created the default point asset %q", wallet.DefaultAsset)
+	}
+
+	// Seed the ledger with existing balances so reconciliation starts without drift
+	opened, err := wallet.NewWalletRepository(db).BackfillOpeningBalances()
+	if err != nil {
+		log.Fatal("❌ Ledger backfill failed:", err)
+	}
+	if opened > 0 {
+		log.Printf("📒 Posted opening ledger balances for %d wallets", opened)
+	}
+
+	// Approvals before attempts were tracked were paid when approved
+	rewarded, err := mission.NewMissionRepository(db).BackfillRewarded()
+	if err != nil {
+		log.Fatal("❌ Mission reward backfill failed:", err)
+	}
+	if rewarded > 0 {
+		log.Printf("🎯 Marked %d approved submissions as rewarded", rewarded)
+	}
+
+	// Existing merchant users become owners of a merchant over their own wallet
+	merchants, err := merchant.NewRepository(db).BackfillMerchants()
+	if err != nil {
+		log.Fatal("❌ Merchant backfill failed:", err)
+	}
+	if merchants > 0 {
+		log.Printf("🏪 Created merchant profiles for %d merchant users", merchants)
+	}
+
 	log.Println("✅ Database migration completed")
 }
@@ -55,7 +55,7 @@ func (h *Handler) SyncPoints(c *gin.Context) {
 
 	log, err := h.service.SyncPoints(userID.(uint), &req)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		utils.ErrorFromErr(c, http.StatusInternalServerError, err)
 		return
 	}
 
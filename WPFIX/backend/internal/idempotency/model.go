@@ -0,0 +1,30 @@
+package idempotency
+
+import (
+	"time"
+)
+
+// IdempotencyKey stores the outcome of a money-moving request so retries
+// with the same Idempotency-Key header replay it instead of running twice
+type IdempotencyKey struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	UserID       uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_idempotency_user_key"`
+	Key          string    `json:"key" gorm:"column:idempotency_key;size:255;not null;uniqueIndex:idx_idempotency_user_key"`
+	Method       string    `json:"method" gorm:"size:10;not null"`
+	Path         string    `json:"path" gorm:"size:255;not null"`
+	RequestHash  string    `json:"request_hash" gorm:"size:64;not null"`
+	StatusCode   int       `json:"status_code" gorm:"default:0"` // 0 while the original request is in flight
+	ResponseBody string    `json:"response_body" gorm:"type:mediumtext"`
+	ExpiresAt    time.Time `json:"expires_at" gorm:"not null;index"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+func (IdempotencyKey) TableName() string {
+	return "idempotency_keys"
+}
+
+// InFlight reports whether the original request has not finished yet
+func (k *IdempotencyKey) InFlight() bool {
+	return k.StatusCode == 0
+}
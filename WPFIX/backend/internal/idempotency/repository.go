@@ -0,0 +1,55 @@
+package idempotency
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// FindByKey finds a stored key for a user
+func (r *Repository) FindByKey(userID uint, key string) (*IdempotencyKey, error) {
+	var record IdempotencyKey
+	err := r.db.Where("user_id = ? AND idempotency_key = ?", userID, key).First(&record).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &record, nil
+}
+
+// Create reserves a key; fails on the unique index if it already exists
+func (r *Repository) Create(record *IdempotencyKey) error {
+	return r.db.Create(record).Error
+}
+
+// Complete stores the final response for a reserved key
+func (r *Repository) Complete(id uint, statusCode int, body string) error {
+	return r.db.Model(&IdempotencyKey{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status_code":   statusCode,
+			"response_body": body,
+		}).Error
+}
+
+// Delete removes a key so the request can be retried
+func (r *Repository) Delete(id uint) error {
+	return r.db.Delete(&IdempotencyKey{}, id).Error
+}
+
+// DeleteExpired purges keys whose replay window has passed
+func (r *Repository) DeleteExpired(now time.Time) (int64, error) {
+	result := r.db.Where("expires_at < ?", now).Delete(&IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,87 @@
+package idempotency
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrKeyReused is returned when a key is sent again with a different request
+	ErrKeyReused = errors.New("idempotency key was already used with a different request")
+	// ErrRequestInFlight is returned when the original request is still running
+	ErrRequestInFlight = errors.New("a request with this idempotency key is still being processed")
+)
+
+type Service struct {
+	repo   *Repository
+	window time.Duration
+}
+
+func NewService(repo *Repository, window time.Duration) *Service {
+	return &Service{
+		repo:   repo,
+		window: window,
+	}
+}
+
+// Begin reserves a key for a new request. When the key was already used with an
+// identical request inside the replay window, the stored record is returned instead.
+func (s *Service) Begin(userID uint, key, method, path, requestHash string) (reserved *IdempotencyKey, replay *IdempotencyKey, err error) {
+	// A few attempts cover racing with a concurrent retry or an expired key
+	for attempt := 0; attempt < 3; attempt++ {
+		existing, err := s.repo.FindByKey(userID, key)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if existing != nil {
+			if time.Now().After(existing.ExpiresAt) {
+				if err := s.repo.Delete(existing.ID); err != nil {
+					return nil, nil, err
+				}
+				continue
+			}
+			if existing.RequestHash != requestHash {
+				return nil, nil, ErrKeyReused
+			}
+			if existing.InFlight() {
+				return nil, nil, ErrRequestInFlight
+			}
+			return nil, existing, nil
+		}
+
+		record := &IdempotencyKey{
+			UserID:      userID,
+			Key:         key,
+			Method:      method,
+			Path:        path,
+			RequestHash: requestHash,
+			ExpiresAt:   time.Now().Add(s.window),
+		}
+		if err := s.repo.Create(record); err != nil {
+			// Lost the race to another request with the same key: look again
+			if other, _ := s.repo.FindByKey(userID, key); other == nil {
+				return nil, nil, err
+			}
+			continue
+		}
+		return record, nil, nil
+	}
+
+	return nil, nil, ErrRequestInFlight
+}
+
+// Complete stores the response so later retries can replay it
+func (s *Service) Complete(record *IdempotencyKey, statusCode int, body string) error {
+	return s.repo.Complete(record.ID, statusCode, body)
+}
+
+// Release drops a reservation so the client may retry, e.g. after a server error
+func (s *Service) Release(record *IdempotencyKey) error {
+	return s.repo.Delete(record.ID)
+}
+
+// PurgeExpired removes keys older than the replay window
+func (s *Service) PurgeExpired() (int64, error) {
+	return s.repo.DeleteExpired(time.Now())
+}
@@ -0,0 +1,130 @@
+package jobs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed five-field cron expression:
+// minute hour day-of-month month day-of-week
+type Schedule struct {
+	expr    string
+	minutes map[int]bool
+	hours   map[int]bool
+	days    map[int]bool
+	months  map[int]bool
+	weekday map[int]bool
+	// A day field starting with * leaves the other one to pick the days;
+	// when both are restricted a day matching either fires
+	anyDay     bool
+	anyWeekday bool
+}
+
+// ParseSchedule parses a cron expression such as "*/5 * * * *" or "0 2 * * 1-5".
+// Fields accept *, single values, ranges (a-b), lists (a,b) and steps (*/n, a-b/n).
+// Like standard cron, "0 0 1 * 1" fires on the 1st and on every Monday.
+func ParseSchedule(expr string) (*Schedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields", expr)
+	}
+
+	bounds := [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 6}}
+	sets := make([]map[int]bool, 5)
+	for i, field := range fields {
+		set, err := parseField(field, bounds[i][0], bounds[i][1])
+		if err != nil {
+			return nil, fmt.Errorf("cron expression %q: %w", expr, err)
+		}
+		sets[i] = set
+	}
+
+	return &Schedule{
+		expr:    expr,
+		minutes: sets[0],
+		hours:   sets[1],
+		days:    sets[2],
+		months:  sets[3],
+		weekday: sets[4],
+
+		anyDay:     strings.HasPrefix(fields[2], "*"),
+		anyWeekday: strings.HasPrefix(fields[4], "*"),
+	}, nil
+}
+
+func parseField(field string, min, max int) (map[int]bool, error) {
+	set := make(map[int]bool)
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid step in %q", part)
+			}
+			step = n
+			part = part[:i]
+		}
+
+		lo, hi := min, max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if lo, err = strconv.Atoi(bounds[0]); err != nil {
+				return nil, fmt.Errorf("invalid value %q", part)
+			}
+			hi = lo
+			if len(bounds) == 2 {
+				if hi, err = strconv.Atoi(bounds[1]); err != nil {
+					return nil, fmt.Errorf("invalid range %q", part)
+				}
+			} else if step > 1 {
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return nil, fmt.Errorf("%q is out of range %d-%d", part, min, max)
+		}
+
+		for v := lo; v <= hi; v += step {
+			set[v] = true
+		}
+	}
+	return set, nil
+}
+
+// Matches reports whether the schedule fires in the minute containing t
+func (s *Schedule) Matches(t time.Time) bool {
+	return s.minutes[t.Minute()] &&
+		s.hours[t.Hour()] &&
+		s.months[int(t.Month())] &&
+		s.matchesDay(t)
+}
+
+// matchesDay applies the cron day rule: with both day-of-month and
+// day-of-week restricted, either one matching is enough
+func (s *Schedule) matchesDay(t time.Time) bool {
+	day, weekday := s.days[t.Day()], s.weekday[int(t.Weekday())]
+	if s.anyDay || s.anyWeekday {
+		return day && weekday
+	}
+	return day || weekday
+}
+
+// Next returns the first minute after t at which the schedule fires
+func (s *Schedule) Next(t time.Time) time.Time {
+	next := t.Truncate(time.Minute).Add(time.Minute)
+	// A year of minutes covers every valid expression
+	for i := 0; i < 366*24*60; i++ {
+		if s.Matches(next) {
+			return next
+		}
+		next = next.Add(time.Minute)
+	}
+	return time.Time{}
+}
+
+func (s *Schedule) String() string {
+	return s.expr
+}
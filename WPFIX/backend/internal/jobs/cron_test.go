@@ -0,0 +1,75 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseScheduleErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+	}{
+		{"too few fields", "* * * *"},
+		{"too many fields", "* * * * * *"},
+		{"minute out of range", "60 * * * *"},
+		{"day of month zero", "0 0 0 * *"},
+		{"month out of range", "0 0 1 13 *"},
+		{"weekday out of range", "0 0 * * 7"},
+		{"reversed range", "0 5-2 * * *"},
+		{"zero step", "*/0 * * * *"},
+		{"bad step", "*/x * * * *"},
+		{"not a number", "a * * * *"},
+		{"bad range end", "0 1-x * * *"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseSchedule(tt.expr); err == nil {
+				t.Errorf("ParseSchedule(%q) succeeded, want an error", tt.expr)
+			}
+		})
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	// 2026-03-04 is a Wednesday
+	from := time.Date(2026, 3, 4, 10, 17, 42, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		expr string
+		want time.Time
+	}{
+		{"every minute", "* * * * *", time.Date(2026, 3, 4, 10, 18, 0, 0, time.UTC)},
+		{"step", "*/15 * * * *", time.Date(2026, 3, 4, 10, 30, 0, 0, time.UTC)},
+		{"step from value", "5/20 * * * *", time.Date(2026, 3, 4, 10, 25, 0, 0, time.UTC)},
+		{"list", "0 9,12,18 * * *", time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC)},
+		{"range with step", "0 8-20/4 * * *", time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC)},
+		{"daily rolls to tomorrow", "0 2 * * *", time.Date(2026, 3, 5, 2, 0, 0, 0, time.UTC)},
+		{"weekdays", "30 1 * * 1-5", time.Date(2026, 3, 5, 1, 30, 0, 0, time.UTC)},
+		{"sunday", "0 0 * * 0", time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC)},
+		{"day of month", "0 0 15 * *", time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"month", "0 0 1 6 *", time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{"month rolls to next year", "0 0 1 1 *", time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"day of month or weekday, weekday first", "0 0 20 * 5", time.Date(2026, 3, 6, 0, 0, 0, 0, time.UTC)},
+		{"day of month or weekday, day first", "0 0 5 * 1", time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC)},
+		{"starred day of month keeps weekday", "0 0 */1 * 1", time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC)},
+		{"starred weekday keeps day of month", "0 0 10 * */2", time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := ParseSchedule(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseSchedule(%q) error = %v", tt.expr, err)
+			}
+			if got := s.Next(from); !got.Equal(tt.want) {
+				t.Errorf("Next(%s) = %s, want %s", from, got, tt.want)
+			}
+			if !s.Matches(tt.want) {
+				t.Errorf("Matches(%s) = false for its own next run", tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package jobs
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"wallet-point/internal/audit"
+	"wallet-point/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	scheduler    *Scheduler
+	auditService *audit.AuditService
+}
+
+func NewHandler(scheduler *Scheduler, auditService *audit.AuditService) *Handler {
+	return &Handler{scheduler: scheduler, auditService: auditService}
+}
+
+// GetJobs handles listing background jobs and their run history
+// @Summary List background jobs
+// @Description Registered jobs with schedule, next run and last run, plus the run history (Admin only)
+// @Tags Admin - Monitoring
+// @Security BearerAuth
+// @Produce json
+// @Param name query string false "Filter runs by job name"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} utils.Response{data=JobListResponse}
+// @Router /admin/jobs [get]
+func (h *Handler) GetJobs(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	jobs, err := h.scheduler.ListJobs()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve jobs", err.Error())
+		return
+	}
+
+	runs, total, err := h.scheduler.GetRuns(c.Query("name"), page, limit)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve job runs", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Jobs retrieved successfully", JobListResponse{
+		Jobs:       jobs,
+		Runs:       runs,
+		Total:      total,
+		Page:       page,
+		Limit:      limit,
+		TotalPages: int(math.Ceil(float64(total) / float64(limit))),
+	})
+}
+
+// RunJob handles triggering a job manually
+// @Summary Run a background job now
+// @Description Runs the job immediately and returns the recorded run (Admin only)
+// @Tags Admin - Monitoring
+// @Security BearerAuth
+// @Produce json
+// @Param name path string true "Job name"
+// @Success 200 {object} utils.Response{data=JobRun}
+// @Failure 404 {object} utils.Response
+// @Failure 409 {object} utils.Response
+// @Router /admin/jobs/{name}/run [post]
+func (h *Handler) RunJob(c *gin.Context) {
+	adminID := c.GetUint("user_id")
+	name := c.Param("name")
+
+	run, err := h.scheduler.RunNow(name)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrJobNotFound):
+			utils.ErrorResponse(c, http.StatusNotFound, err.Error(), nil)
+		case errors.Is(err, ErrJobRunning):
+			utils.ErrorResponse(c, http.StatusConflict, err.Error(), nil)
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to run job", err.Error())
+		}
+		return
+	}
+
+	message := "Job completed successfully"
+	if run.Status == "failed" {
+		message = "Job failed"
+	}
+	utils.SuccessResponse(c, http.StatusOK, message, run)
+
+	// Log activity
+	h.auditService.LogActivity(audit.CreateAuditParams{
+		UserID:    adminID,
+		Action:    "RUN_JOB",
+		Entity:    "JOB",
+		EntityID:  run.ID,
+		Details:   fmt.Sprintf("Admin ran job %s: %s (%s)", name, run.Status, run.Message),
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+}
@@ -0,0 +1,54 @@
+package jobs
+
+import (
+	"time"
+)
+
+// JobRun records one execution of a background job
+type JobRun struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	JobName    string     `json:"job_name" gorm:"size:100;not null;index"`
+	Trigger    string     `json:"trigger" gorm:"type:enum('schedule','manual');not null"`
+	Status     string     `json:"status" gorm:"type:enum('running','success','failed');default:'running'"`
+	Affected   int64      `json:"affected"` // Rows or items the job changed
+	Message    string     `json:"message" gorm:"size:500"`
+	Owner      string     `json:"owner" gorm:"size:100"` // Replica that ran the job
+	StartedAt  time.Time  `json:"started_at" gorm:"index"`
+	FinishedAt *time.Time `json:"finished_at"`
+	DurationMs int64      `json:"duration_ms"`
+}
+
+func (JobRun) TableName() string {
+	return "job_runs"
+}
+
+// JobLease makes sure only one replica runs a job at a time
+type JobLease struct {
+	Name        string    `json:"name" gorm:"primaryKey;size:100"`
+	Owner       string    `json:"owner" gorm:"size:100;not null"`
+	LockedUntil time.Time `json:"locked_until" gorm:"not null"`
+}
+
+func (JobLease) TableName() string {
+	return "job_leases"
+}
+
+// JobInfo describes a registered job for the admin list
+type JobInfo struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Schedule    string     `json:"schedule"`
+	Local       bool       `json:"local"`
+	NextRunAt   *time.Time `json:"next_run_at"`
+	LastRun     *JobRun    `json:"last_run"`
+}
+
+// JobListResponse is returned by GET /admin/jobs
+type JobListResponse struct {
+	Jobs       []JobInfo `json:"jobs"`
+	Runs       []JobRun  `json:"runs"`
+	Total      int64     `json:"total"`
+	Page       int       `json:"page"`
+	Limit      int       `json:"limit"`
+	TotalPages int       `json:"total_pages"`
+}
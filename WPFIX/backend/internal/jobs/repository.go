@@ -0,0 +1,110 @@
+package jobs
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// AcquireLease takes the lease for a job unless another owner holds an unexpired one
+func (r *Repository) AcquireLease(name, owner string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	until := now.Add(ttl)
+
+	result := r.db.Model(&JobLease{}).
+		Where("name = ? AND (locked_until < ? OR owner = ?)", name, now, owner).
+		Updates(map[string]interface{}{"owner": owner, "locked_until": until})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 1 {
+		return true, nil
+	}
+
+	// No row updated: either the lease is held or it does not exist yet
+	var lease JobLease
+	err := r.db.Where("name = ?", name).First(&lease).Error
+	if err == nil {
+		return false, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, err
+	}
+
+	if err := r.db.Create(&JobLease{Name: name, Owner: owner, LockedUntil: until}).Error; err != nil {
+		// Another replica created it first
+		return false, nil
+	}
+	return true, nil
+}
+
+// ReleaseLease frees a lease held by owner
+func (r *Repository) ReleaseLease(name, owner string) error {
+	return r.db.Model(&JobLease{}).
+		Where("name = ? AND owner = ?", name, owner).
+		Update("locked_until", time.Now()).Error
+}
+
+// CreateRun stores the start of a job run
+func (r *Repository) CreateRun(run *JobRun) error {
+	return r.db.Create(run).Error
+}
+
+// FinishRun stores the outcome of a job run
+func (r *Repository) FinishRun(run *JobRun) error {
+	return r.db.Model(&JobRun{}).
+		Where("id = ?", run.ID).
+		Updates(map[string]interface{}{
+			"status":      run.Status,
+			"affected":    run.Affected,
+			"message":     run.Message,
+			"finished_at": run.FinishedAt,
+			"duration_ms": run.DurationMs,
+		}).Error
+}
+
+// GetRuns lists job runs, newest first, optionally for one job
+func (r *Repository) GetRuns(jobName string, limit, offset int) ([]JobRun, int64, error) {
+	var runs []JobRun
+	var total int64
+
+	query := r.db.Model(&JobRun{})
+	if jobName != "" {
+		query = query.Where("job_name = ?", jobName)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("started_at DESC, id DESC").Limit(limit).Offset(offset).Find(&runs).Error
+	return runs, total, err
+}
+
+// GetLastRun returns the most recent run of a job
+func (r *Repository) GetLastRun(jobName string) (*JobRun, error) {
+	var run JobRun
+	err := r.db.Where("job_name = ?", jobName).Order("started_at DESC, id DESC").First(&run).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &run, nil
+}
+
+// DeleteRunsBefore purges job history older than cutoff
+func (r *Repository) DeleteRunsBefore(cutoff time.Time) (int64, error) {
+	result := r.db.Where("started_at < ?", cutoff).Delete(&JobRun{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,281 @@
+package jobs
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrJobNotFound is returned for names that were never registered
+	ErrJobNotFound = errors.New("job not found")
+	// ErrJobRunning is returned when another run holds the job's lease
+	ErrJobRunning = errors.New("job is already running")
+)
+
+// Job is a unit of background work. Run returns how many items it changed.
+type Job struct {
+	Name        string
+	Description string
+	Schedule    string
+	// Local jobs clean up in-process state, so every replica runs them
+	// and no DB lease is taken
+	Local bool
+	// LeaseTTL bounds how long a crashed replica can block the job (default 10m)
+	LeaseTTL time.Duration
+	Run      func() (int64, error)
+
+	schedule *Schedule
+}
+
+// Scheduler runs registered jobs on their cron schedules inside the server
+type Scheduler struct {
+	repo    *Repository
+	owner   string
+	mu      sync.Mutex
+	jobs    map[string]*Job
+	running map[string]bool
+	stop    chan struct{}
+	// localOnly keeps the replica out of the shared job rotation
+	localOnly bool
+}
+
+func NewScheduler(repo *Repository) *Scheduler {
+	host, _ := os.Hostname()
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+
+	return &Scheduler{
+		repo:    repo,
+		owner:   fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(suffix)),
+		jobs:    make(map[string]*Job),
+		running: make(map[string]bool),
+	}
+}
+
+// Register adds a job; the schedule must be a valid cron expression
+func (s *Scheduler) Register(job Job) error {
+	schedule, err := ParseSchedule(job.Schedule)
+	if err != nil {
+		return err
+	}
+	if job.LeaseTTL <= 0 {
+		job.LeaseTTL = 10 * time.Minute
+	}
+	job.schedule = schedule
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.jobs[job.Name]; exists {
+		return fmt.Errorf("job %q is already registered", job.Name)
+	}
+	s.jobs[job.Name] = &job
+	return nil
+}
+
+// Start checks the schedules at the top of every minute until Stop is called.
+// Without shared only Local jobs run, as every replica needs their cleanup.
+func (s *Scheduler) Start(shared bool) {
+	s.mu.Lock()
+	if s.stop != nil {
+		s.mu.Unlock()
+		return
+	}
+	s.stop = make(chan struct{})
+	s.localOnly = !shared
+	stop := s.stop
+	s.mu.Unlock()
+
+	if shared {
+		log.Printf("⏰ Job scheduler started with %d jobs", len(s.jobs))
+	} else {
+		log.Println("⏰ Job scheduler started with local jobs only")
+	}
+
+	go func() {
+		for {
+			now := time.Now()
+			next := now.Truncate(time.Minute).Add(time.Minute)
+			select {
+			case <-stop:
+				return
+			case <-time.After(next.Sub(now)):
+			}
+
+			for _, job := range s.dueJobs(next) {
+				go func(job *Job) {
+					if _, err := s.execute(job, "schedule"); err != nil && !errors.Is(err, ErrJobRunning) {
+						log.Printf("[Jobs] %s failed to start: %v", job.Name, err)
+					}
+				}(job)
+			}
+		}
+	}()
+}
+
+// Stop ends the schedule loop; runs already in progress finish on their own
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
+
+func (s *Scheduler) dueJobs(at time.Time) []*Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []*Job
+	for _, job := range s.jobs {
+		if s.localOnly && !job.Local {
+			continue
+		}
+		if job.schedule.Matches(at) {
+			due = append(due, job)
+		}
+	}
+	return due
+}
+
+// RunNow triggers a job manually and waits for it to finish
+func (s *Scheduler) RunNow(name string) (*JobRun, error) {
+	s.mu.Lock()
+	job, ok := s.jobs[name]
+	s.mu.Unlock()
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+	return s.execute(job, "manual")
+}
+
+// execute runs a job once, guarded by the in-process flag and, for shared
+// jobs, the DB lease so other replicas skip it
+func (s *Scheduler) execute(job *Job, trigger string) (*JobRun, error) {
+	s.mu.Lock()
+	if s.running[job.Name] {
+		s.mu.Unlock()
+		return nil, ErrJobRunning
+	}
+	s.running[job.Name] = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.running, job.Name)
+		s.mu.Unlock()
+	}()
+
+	if !job.Local {
+		acquired, err := s.repo.AcquireLease(job.Name, s.owner, job.LeaseTTL)
+		if err != nil {
+			return nil, err
+		}
+		if !acquired {
+			return nil, ErrJobRunning
+		}
+		defer func() {
+			if err := s.repo.ReleaseLease(job.Name, s.owner); err != nil {
+				log.Printf("[Jobs] failed to release lease of %s: %v", job.Name, err)
+			}
+		}()
+	}
+
+	run := &JobRun{
+		JobName:   job.Name,
+		Trigger:   trigger,
+		Status:    "running",
+		Owner:     s.owner,
+		StartedAt: time.Now(),
+	}
+	if err := s.repo.CreateRun(run); err != nil {
+		return nil, err
+	}
+
+	affected, err := runSafely(job)
+
+	finished := time.Now()
+	run.FinishedAt = &finished
+	run.DurationMs = finished.Sub(run.StartedAt).Milliseconds()
+	run.Affected = affected
+	run.Status = "success"
+	run.Message = fmt.Sprintf("%d affected", affected)
+	if err != nil {
+		run.Status = "failed"
+		run.Message = err.Error()
+		if len(run.Message) > 500 {
+			run.Message = run.Message[:500]
+		}
+		log.Printf("[Jobs] %s failed: %v", job.Name, err)
+	}
+
+	if err := s.repo.FinishRun(run); err != nil {
+		log.Printf("[Jobs] failed to record run of %s: %v", job.Name, err)
+	}
+	return run, nil
+}
+
+// runSafely turns a panicking job into a failed run
+func runSafely(job *Job) (affected int64, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return job.Run()
+}
+
+// ListJobs returns every registered job with its next and last run
+func (s *Scheduler) ListJobs() ([]JobInfo, error) {
+	s.mu.Lock()
+	jobs := make([]*Job, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, job)
+	}
+	s.mu.Unlock()
+
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].Name < jobs[j].Name })
+
+	now := time.Now()
+	infos := make([]JobInfo, 0, len(jobs))
+	for _, job := range jobs {
+		lastRun, err := s.repo.GetLastRun(job.Name)
+		if err != nil {
+			return nil, err
+		}
+		info := JobInfo{
+			Name:        job.Name,
+			Description: job.Description,
+			Schedule:    job.schedule.String(),
+			Local:       job.Local,
+			LastRun:     lastRun,
+		}
+		if next := job.schedule.Next(now); !next.IsZero() {
+			info.NextRunAt = &next
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// GetRuns lists the job history, optionally filtered by job name
+func (s *Scheduler) GetRuns(jobName string, page, limit int) ([]JobRun, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 20
+	}
+	return s.repo.GetRuns(jobName, limit, (page-1)*limit)
+}
+
+// PurgeHistory removes runs older than retention
+func (s *Scheduler) PurgeHistory(retention time.Duration) (int64, error) {
+	return s.repo.DeleteRunsBefore(time.Now().Add(-retention))
+}
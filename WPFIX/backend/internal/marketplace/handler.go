@@ -238,7 +238,7 @@ func (h *MarketplaceHandler) Purchase(c *gin.Context) {
 
 	txn, err := h.service.PurchaseProduct(userID, &req)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		utils.ErrorFromErr(c, http.StatusBadRequest, err)
 		return
 	}
 
@@ -9,6 +9,7 @@ type Product struct {
 	Name        string    `json:"name" gorm:"not null"`
 	Description string    `json:"description" gorm:"type:text"`
 	Price       int       `json:"price" gorm:"not null"`
+	Asset       string    `json:"asset" gorm:"size:30;default:'points';not null"` // Point asset the price is paid in
 	Stock       int       `json:"stock" gorm:"default:0;not null"`
 	ImageURL    string    `json:"image_url" gorm:"size:500"`
 	Status      string    `json:"status" gorm:"type:enum('active','inactive');default:'active'"`
@@ -22,19 +23,21 @@ func (Product) TableName() string {
 }
 
 type MarketplaceTransaction struct {
-	ID            uint      `json:"id" gorm:"primaryKey"`
-	WalletID      uint      `json:"wallet_id" gorm:"not null;index"`
-	ProductID     uint      `json:"product_id" gorm:"not null;index"`
-	Amount        int       `json:"amount" gorm:"not null"`                           // Individual item price
-	TotalAmount   int       `json:"total_amount" gorm:"column:total_amount;not null"` // This fixes the DB constraint error
-	Quantity      int       `json:"quantity" gorm:"default:1;not null"`
-	StudentName   string    `json:"student_name" gorm:"size:255"`
-	StudentNPM    string    `json:"student_npm" gorm:"size:100"`
-	StudentMajor  string    `json:"student_major" gorm:"size:255"`
-	StudentBatch  string    `json:"student_batch" gorm:"size:50"`
-	PaymentMethod string    `json:"payment_method" gorm:"size:50;default:'wallet'"`
-	Status        string    `json:"status" gorm:"type:enum('success','failed');default:'success'"`
-	CreatedAt     time.Time `json:"created_at"`
+	ID               uint      `json:"id" gorm:"primaryKey"`
+	WalletID         uint      `json:"wallet_id" gorm:"not null;index"`
+	ProductID        uint      `json:"product_id" gorm:"not null;index"`
+	Amount           int       `json:"amount" gorm:"not null"`                           // Individual item price
+	TotalAmount      int       `json:"total_amount" gorm:"column:total_amount;not null"` // This fixes the DB constraint error
+	Quantity         int       `json:"quantity" gorm:"default:1;not null"`
+	StudentName      string    `json:"student_name" gorm:"size:255"`
+	StudentNPM       string    `json:"student_npm" gorm:"size:100"`
+	StudentMajor     string    `json:"student_major" gorm:"size:255"`
+	StudentBatch     string    `json:"student_batch" gorm:"size:50"`
+	PaymentMethod    string    `json:"payment_method" gorm:"size:50;default:'wallet'"`
+	Status           string    `json:"status" gorm:"type:enum('success','failed','partially_refunded','reversed');default:'success'"`
+	RefundedQuantity int       `json:"refunded_quantity" gorm:"default:0;not null"`
+	JournalID        *uint     `json:"journal_id" gorm:"index"`
+	CreatedAt        time.Time `json:"created_at"`
 }
 
 type PurchaseRequest struct {
@@ -75,6 +78,7 @@ type CreateProductRequest struct {
 	Name        string `json:"name" binding:"required"`
 	Description string `json:"description"`
 	Price       int    `json:"price" binding:"required,gt=0"`
+	Asset       string `json:"asset" binding:"omitempty,max=30"` // Empty = default asset
 	Stock       int    `json:"stock" binding:"gte=0"`
 	ImageURL    string `json:"image_url"`
 }
@@ -83,6 +87,7 @@ type UpdateProductRequest struct {
 	Name        string `json:"name,omitempty"`
 	Description string `json:"description,omitempty"`
 	Price       int    `json:"price,omitempty" binding:"omitempty,gt=0"`
+	Asset       string `json:"asset,omitempty" binding:"omitempty,max=30"`
 	Stock       int    `json:"stock,omitempty" binding:"omitempty,gte=0"`
 	ImageURL    string `json:"image_url,omitempty"`
 	Status      string `json:"status,omitempty" binding:"omitempty,oneof=active inactive"`
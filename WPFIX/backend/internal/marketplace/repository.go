@@ -4,6 +4,7 @@ import (
 	"errors"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type MarketplaceRepository struct {
@@ -81,6 +82,23 @@ func (r *MarketplaceRepository) UpdateStock(tx *gorm.DB, productID uint, delta i
 		Error
 }
 
+// DecrementStock reduces stock only if enough units remain
+func (r *MarketplaceRepository) DecrementStock(tx *gorm.DB, productID uint, quantity int) error {
+	if tx == nil {
+		tx = r.db
+	}
+	result := tx.Model(&Product{}).
+		Where("id = ? AND stock >= ?", productID, quantity).
+		Update("stock", gorm.Expr("stock - ?", quantity))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("product out of stock")
+	}
+	return nil
+}
+
 // CreateTransaction creates a marketplace transaction record
 func (r *MarketplaceRepository) CreateTransaction(tx *gorm.DB, transaction *MarketplaceTransaction) error {
 	if tx == nil {
@@ -89,6 +107,29 @@ func (r *MarketplaceRepository) CreateTransaction(tx *gorm.DB, transaction *Mark
 	return tx.Create(transaction).Error
 }
 
+// LockTransactionByJournal finds the purchase posted by a journal and locks it for update
+func (r *MarketplaceRepository) LockTransactionByJournal(tx *gorm.DB, journalID uint) (*MarketplaceTransaction, error) {
+	var transaction MarketplaceTransaction
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("journal_id = ?", journalID).
+		First(&transaction).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+// UpdateTransaction updates a marketplace transaction record
+func (r *MarketplaceRepository) UpdateTransaction(tx *gorm.DB, id uint, updates map[string]interface{}) error {
+	if tx == nil {
+		tx = r.db
+	}
+	return tx.Model(&MarketplaceTransaction{}).Where("id = ?", id).Updates(updates).Error
+}
+
 // GetAllTransactions retrieves marketplace transactions for admin
 func (r *MarketplaceRepository) GetAllTransactions(limit, offset int) ([]MarketplaceTransactionWithDetails, int64, error) {
 	var transactions []MarketplaceTransactionWithDetails
@@ -56,10 +56,16 @@ func (s *MarketplaceService) GetProductByID(productID uint) (*Product, error) {
 
 // CreateProduct creates a new product
 func (s *MarketplaceService) CreateProduct(req *CreateProductRequest, adminID uint) (*Product, error) {
+	asset, err := s.walletService.GetAsset(req.Asset)
+	if err != nil {
+		return nil, err
+	}
+
 	product := &Product{
 		Name:        req.Name,
 		Description: req.Description,
 		Price:       req.Price,
+		Asset:       asset.Code,
 		Stock:       req.Stock,
 		ImageURL:    req.ImageURL,
 		Status:      "active",
@@ -93,6 +99,12 @@ func (s *MarketplaceService) UpdateProduct(productID uint, req *UpdateProductReq
 	if req.Price > 0 {
 		updates["price"] = req.Price
 	}
+	if req.Asset != "" {
+		if _, err := s.walletService.GetAsset(req.Asset); err != nil {
+			return nil, err
+		}
+		updates["asset"] = req.Asset
+	}
 	if req.Stock >= 0 {
 		updates["stock"] = req.Stock
 	}
@@ -130,14 +142,16 @@ func (s *MarketplaceService) PurchaseProduct(userID uint, req *PurchaseRequest)
 	// Start transaction
 	tx := s.db.Begin()
 	var err error
+	var token *wallet.PaymentToken
 
 	defer func() {
 		if r := recover(); r != nil {
 			tx.Rollback()
 		} else if err != nil {
 			tx.Rollback()
-		} else {
-			tx.Commit()
+		} else if tx.Commit().Error == nil && token != nil {
+			// Watchers of the QR token learn it was paid once the purchase commits
+			s.walletService.PublishTokenStatus(token)
 		}
 	}()
 
@@ -158,14 +172,7 @@ func (s *MarketplaceService) PurchaseProduct(userID uint, req *PurchaseRequest)
 		return nil, err
 	}
 
-	// 2. Validate QR if needed (Legacy / External QR Token)
-	if req.PaymentMethod == "qr" && req.PaymentToken != "" {
-		if err = s.walletService.ValidateAndConsumeToken(req.PaymentToken, userID, product.Price); err != nil {
-			return nil, err
-		}
-	}
-
-	// 3. Get Wallets
+	// 2. Get Wallets
 	var studentWallet *wallet.Wallet
 	studentWallet, err = s.walletService.GetWalletByUserID(userID)
 	if err != nil {
@@ -190,30 +197,53 @@ func (s *MarketplaceService) PurchaseProduct(userID uint, req *PurchaseRequest)
 
 	totalPrice := product.Price * quantity
 
-	// 4. Check Balance (Only if not already paid via external QR token)
-	if req.PaymentToken == "" {
-		if studentWallet.Balance < totalPrice {
-			err = fmt.Errorf("insufficient balance. Required: %d", totalPrice)
-			return nil, err
-		}
-
-		// 5. Debit Student Wallet
-		err = s.walletService.DebitWithTransaction(tx, studentWallet.ID, totalPrice, "marketplace", fmt.Sprintf("Buy %dx %s", quantity, product.Name))
+	// 3. A QR token must cover the whole purchase; its hold is captured by
+	// the purchase journal below
+	if req.PaymentMethod == "qr" && req.PaymentToken != "" {
+		token, err = s.walletService.ValidateAndConsumeToken(tx, req.PaymentToken, userID, totalPrice, product.Asset)
 		if err != nil {
 			return nil, err
 		}
+	}
 
-		// 6. Credit Creator Wallet (Admin/Merchant)
-		if creatorWallet != nil {
-			err = s.walletService.CreditWithTransaction(tx, creatorWallet.ID, totalPrice, "marketplace_sale", fmt.Sprintf("Sale %dx %s to %s", quantity, product.Name, req.StudentName))
-			if err != nil {
-				return nil, err
-			}
+	// 4. Debit Student Wallet and credit Creator Wallet (Admin/Merchant) as one journal.
+	// Without a creator wallet, or for products priced in another asset
+	// than the default one, the points leave circulation instead.
+	buyDesc := fmt.Sprintf("Buy %dx %s", quantity, product.Name)
+	debitLeg := wallet.LedgerLeg{
+		WalletID:    studentWallet.ID,
+		Asset:       product.Asset,
+		Direction:   "debit",
+		Amount:      totalPrice,
+		Type:        "marketplace",
+		Description: buyDesc,
+	}
+	if token != nil {
+		debitLeg.ReferenceID = &token.ID
+		debitLeg.TransactionID = token.HoldID
+	}
+	creditLeg := wallet.LedgerLeg{Account: wallet.AccountRedemption, Asset: product.Asset, Direction: "credit", Amount: totalPrice}
+	if creatorWallet != nil && product.Asset == wallet.DefaultAsset {
+		creditLeg = wallet.LedgerLeg{
+			WalletID:    creatorWallet.ID,
+			Direction:   "credit",
+			Amount:      totalPrice,
+			Type:        "marketplace_sale",
+			Description: fmt.Sprintf("Sale %dx %s to %s", quantity, product.Name, req.StudentName),
 		}
 	}
 
-	// 7. Reduce Stock
-	err = s.repo.UpdateStock(tx, product.ID, -quantity)
+	var journal *wallet.LedgerJournal
+	journal, _, err = s.walletService.PostJournal(tx, "marketplace_purchase", buyDesc, []wallet.LedgerLeg{debitLeg, creditLeg})
+	if errors.Is(err, wallet.ErrInsufficientBalance) {
+		err = fmt.Errorf("insufficient balance. Required: %d", totalPrice)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 7. Reduce Stock, failing if a concurrent purchase took the last units
+	err = s.repo.DecrementStock(tx, product.ID, quantity)
 	if err != nil {
 		return nil, err
 	}
@@ -231,6 +261,7 @@ func (s *MarketplaceService) PurchaseProduct(userID uint, req *PurchaseRequest)
 		StudentBatch:  req.StudentBatch,
 		PaymentMethod: req.PaymentMethod,
 		Status:        "success",
+		JournalID:     &journal.ID,
 	}
 
 	err = s.repo.CreateTransaction(tx, txn)
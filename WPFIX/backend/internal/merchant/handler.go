@@ -0,0 +1,393 @@
+package merchant
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"wallet-point/internal/audit"
+	"wallet-point/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service      *Service
+	auditService *audit.AuditService
+}
+
+func NewHandler(service *Service, auditService *audit.AuditService) *Handler {
+	return &Handler{service: service, auditService: auditService}
+}
+
+// errorStatus maps merchant errors to HTTP statuses
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrMerchantNotFound), errors.Is(err, ErrTerminalNotFound), errors.Is(err, ErrCashierNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrNotOwner):
+		return http.StatusForbidden
+	case errors.Is(err, ErrAlreadyLinked):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+func parseID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid ID", nil)
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// GetProfile handles a merchant user viewing their merchant
+// @Summary Get my merchant profile
+// @Description Merchant profile with terminals; owners also see their cashiers
+// @Tags Merchant
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} utils.Response{data=MerchantProfile}
+// @Failure 404 {object} utils.Response
+// @Router /merchant/profile [get]
+func (h *Handler) GetProfile(c *gin.Context) {
+	profile, err := h.service.GetProfile(c.GetUint("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, errorStatus(err), err.Error(), nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Merchant profile retrieved successfully", profile)
+}
+
+// UpdateProfile handles the owner editing the merchant profile
+// @Summary Update my merchant profile
+// @Description Change display name, category or logo (owner only)
+// @Tags Merchant
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body UpdateMerchantRequest true "Profile fields"
+// @Success 200 {object} utils.Response{data=Merchant}
+// @Failure 403 {object} utils.Response
+// @Router /merchant/profile [put]
+func (h *Handler) UpdateProfile(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	var req UpdateMerchantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	m, err := h.service.UpdateProfile(userID, &req)
+	if err != nil {
+		utils.ErrorResponse(c, errorStatus(err), err.Error(), nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Merchant profile updated successfully", m)
+
+	h.auditService.LogActivity(audit.CreateAuditParams{
+		UserID:    userID,
+		Action:    "UPDATE_MERCHANT",
+		Entity:    "MERCHANT",
+		EntityID:  m.ID,
+		Details:   "Merchant owner updated profile: " + m.Name,
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+}
+
+// CreateTerminal handles the owner adding a terminal
+// @Summary Add terminal
+// @Description Add a point-of-sale terminal to my merchant (owner only)
+// @Tags Merchant
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body CreateTerminalRequest true "Terminal"
+// @Success 201 {object} utils.Response{data=Terminal}
+// @Failure 400 {object} utils.Response
+// @Router /merchant/terminals [post]
+func (h *Handler) CreateTerminal(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	var req CreateTerminalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	terminal, err := h.service.CreateTerminal(userID, &req)
+	if err != nil {
+		utils.ErrorResponse(c, errorStatus(err), err.Error(), nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Terminal created successfully", terminal)
+
+	h.auditService.LogActivity(audit.CreateAuditParams{
+		UserID:    userID,
+		Action:    "CREATE_TERMINAL",
+		Entity:    "MERCHANT_TERMINAL",
+		EntityID:  terminal.ID,
+		Details:   fmt.Sprintf("Merchant %d added terminal %s (%s)", terminal.MerchantID, terminal.Code, terminal.Name),
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+}
+
+// UpdateTerminal handles the owner renaming or (de)activating a terminal
+// @Summary Update terminal
+// @Description Rename or (de)activate a terminal (owner only)
+// @Tags Merchant
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Terminal ID"
+// @Param request body UpdateTerminalRequest true "Terminal fields"
+// @Success 200 {object} utils.Response{data=Terminal}
+// @Failure 404 {object} utils.Response
+// @Router /merchant/terminals/{id} [put]
+func (h *Handler) UpdateTerminal(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	terminalID, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateTerminalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	terminal, err := h.service.UpdateTerminal(userID, terminalID, &req)
+	if err != nil {
+		utils.ErrorResponse(c, errorStatus(err), err.Error(), nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Terminal updated successfully", terminal)
+
+	h.auditService.LogActivity(audit.CreateAuditParams{
+		UserID:    userID,
+		Action:    "UPDATE_TERMINAL",
+		Entity:    "MERCHANT_TERMINAL",
+		EntityID:  terminal.ID,
+		Details:   fmt.Sprintf("Terminal %s is now %s (%s)", terminal.Code, terminal.Status, terminal.Name),
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+}
+
+// CreateCashier handles the owner creating a cashier login
+// @Summary Add cashier
+// @Description Create a cashier login that collects into my merchant wallet (owner only)
+// @Tags Merchant
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body CreateCashierRequest true "Cashier login"
+// @Success 201 {object} utils.Response{data=CashierWithUser}
+// @Failure 400 {object} utils.Response
+// @Router /merchant/cashiers [post]
+func (h *Handler) CreateCashier(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	var req CreateCashierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	cashier, err := h.service.CreateCashier(userID, &req)
+	if err != nil {
+		utils.ErrorResponse(c, errorStatus(err), err.Error(), nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Cashier created successfully", cashier)
+
+	h.auditService.LogActivity(audit.CreateAuditParams{
+		UserID:    userID,
+		Action:    "CREATE_CASHIER",
+		Entity:    "MERCHANT_CASHIER",
+		EntityID:  cashier.ID,
+		Details:   fmt.Sprintf("Merchant %d added cashier %s (%s)", cashier.MerchantID, cashier.FullName, cashier.Email),
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+}
+
+// DeactivateCashier handles the owner revoking a cashier
+// @Summary Deactivate cashier
+// @Description Deactivate a cashier and their login (owner only)
+// @Tags Merchant
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Cashier ID"
+// @Success 200 {object} utils.Response{data=Cashier}
+// @Failure 404 {object} utils.Response
+// @Router /merchant/cashiers/{id} [delete]
+func (h *Handler) DeactivateCashier(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	cashierID, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	cashier, err := h.service.DeactivateCashier(userID, cashierID)
+	if err != nil {
+		utils.ErrorResponse(c, errorStatus(err), err.Error(), nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Cashier deactivated successfully", cashier)
+
+	h.auditService.LogActivity(audit.CreateAuditParams{
+		UserID:    userID,
+		Action:    "DEACTIVATE_CASHIER",
+		Entity:    "MERCHANT_CASHIER",
+		EntityID:  cashier.ID,
+		Details:   fmt.Sprintf("Merchant %d deactivated cashier user %d", cashier.MerchantID, cashier.UserID),
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+}
+
+// GetAll handles admins listing merchants
+// @Summary List merchants
+// @Description Merchants with their owners (Admin only)
+// @Tags Admin - Merchants
+// @Security BearerAuth
+// @Produce json
+// @Param status query string false "active or inactive"
+// @Param category query string false "Category"
+// @Param search query string false "Name contains"
+// @Param page query int false "Page number"
+// @Param limit query int false "Items per page"
+// @Success 200 {object} utils.Response{data=MerchantListResponse}
+// @Router /admin/merchants [get]
+func (h *Handler) GetAll(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	response, err := h.service.ListMerchants(MerchantListParams{
+		Status:   c.Query("status"),
+		Category: c.Query("category"),
+		Search:   c.Query("search"),
+		Page:     page,
+		Limit:    limit,
+	})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve merchants", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Merchants retrieved successfully", response)
+}
+
+// Create handles admins registering a merchant
+// @Summary Create merchant
+// @Description Register a merchant owned by an existing merchant user (Admin only)
+// @Tags Admin - Merchants
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body CreateMerchantRequest true "Merchant"
+// @Success 201 {object} utils.Response{data=Merchant}
+// @Failure 409 {object} utils.Response
+// @Router /admin/merchants [post]
+func (h *Handler) Create(c *gin.Context) {
+	adminID := c.GetUint("user_id")
+
+	var req CreateMerchantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	m, err := h.service.CreateMerchant(&req)
+	if err != nil {
+		utils.ErrorResponse(c, errorStatus(err), err.Error(), nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Merchant created successfully", m)
+
+	h.auditService.LogActivity(audit.CreateAuditParams{
+		UserID:    adminID,
+		Action:    "CREATE_MERCHANT",
+		Entity:    "MERCHANT",
+		EntityID:  m.ID,
+		Details:   fmt.Sprintf("Admin registered merchant %s for user %d", m.Name, m.OwnerID),
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+}
+
+// Update handles admins editing or (de)activating a merchant
+// @Summary Update merchant
+// @Description Update merchant profile or status (Admin only)
+// @Tags Admin - Merchants
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Merchant ID"
+// @Param request body UpdateMerchantRequest true "Merchant fields"
+// @Success 200 {object} utils.Response{data=Merchant}
+// @Failure 404 {object} utils.Response
+// @Router /admin/merchants/{id} [put]
+func (h *Handler) Update(c *gin.Context) {
+	adminID := c.GetUint("user_id")
+	merchantID, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateMerchantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	m, err := h.service.UpdateMerchant(merchantID, &req)
+	if err != nil {
+		utils.ErrorResponse(c, errorStatus(err), err.Error(), nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Merchant updated successfully", m)
+
+	h.auditService.LogActivity(audit.CreateAuditParams{
+		UserID:    adminID,
+		Action:    "UPDATE_MERCHANT",
+		Entity:    "MERCHANT",
+		EntityID:  m.ID,
+		Details:   fmt.Sprintf("Admin updated merchant %s (status %s)", m.Name, m.Status),
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+}
+
+// GetDirectory handles students browsing merchants they can pay
+// @Summary List merchants
+// @Description Active merchants; pass merchant_id when generating a payment token
+// @Tags Mahasiswa
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} utils.Response{data=[]MerchantSummary}
+// @Router /mahasiswa/merchants [get]
+func (h *Handler) GetDirectory(c *gin.Context) {
+	merchants, err := h.service.GetDirectory()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve merchants", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Merchants retrieved successfully", merchants)
+}
@@ -0,0 +1,129 @@
+package merchant
+
+import "time"
+
+// Merchant is a business that accepts points. Its owner, terminals and
+// cashiers all collect into one settlement wallet (the owner's wallet).
+type Merchant struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	OwnerID   uint      `json:"owner_id" gorm:"not null;uniqueIndex"` // Merchant user that manages the profile
+	WalletID  uint      `json:"wallet_id" gorm:"not null"`            // Shared settlement wallet
+	Name      string    `json:"name" gorm:"size:100;not null"`        // Display name shown to payers
+	Category  string    `json:"category" gorm:"size:50"`
+	LogoURL   string    `json:"logo_url" gorm:"size:500"`
+	Status    string    `json:"status" gorm:"type:enum('active','inactive');default:'active'"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (Merchant) TableName() string {
+	return "merchants"
+}
+
+// Terminal is a point of sale (counter, kiosk, device) of a merchant
+type Terminal struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	MerchantID uint      `json:"merchant_id" gorm:"not null;uniqueIndex:idx_terminal_merchant_code"`
+	Code       string    `json:"code" gorm:"size:30;not null;uniqueIndex:idx_terminal_merchant_code"`
+	Name       string    `json:"name" gorm:"size:100;not null"`
+	Status     string    `json:"status" gorm:"type:enum('active','inactive');default:'active'"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func (Terminal) TableName() string {
+	return "merchant_terminals"
+}
+
+// Cashier links a merchant-role login to the merchant it collects for
+type Cashier struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	MerchantID uint      `json:"merchant_id" gorm:"not null;index"`
+	UserID     uint      `json:"user_id" gorm:"not null;uniqueIndex"`
+	TerminalID *uint     `json:"terminal_id"` // Default terminal, nil when the cashier picks one per scan
+	Status     string    `json:"status" gorm:"type:enum('active','inactive');default:'active'"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func (Cashier) TableName() string {
+	return "merchant_cashiers"
+}
+
+// CashierWithUser adds the login details of a cashier
+type CashierWithUser struct {
+	Cashier
+	FullName string `json:"full_name"`
+	Email    string `json:"email"`
+}
+
+// MerchantWithOwner adds the owner login for admin listings
+type MerchantWithOwner struct {
+	Merchant
+	OwnerName  string `json:"owner_name"`
+	OwnerEmail string `json:"owner_email"`
+}
+
+// MerchantProfile is what a merchant user sees about the merchant they work for
+type MerchantProfile struct {
+	Merchant  Merchant          `json:"merchant"`
+	Role      string            `json:"role"` // owner or cashier
+	Terminals []Terminal        `json:"terminals"`
+	Cashiers  []CashierWithUser `json:"cashiers,omitempty"` // Owner only
+}
+
+// MerchantSummary is the public face of a merchant in the payer directory
+type MerchantSummary struct {
+	ID       uint   `json:"id"`
+	Name     string `json:"name"`
+	Category string `json:"category"`
+	LogoURL  string `json:"logo_url"`
+}
+
+type CreateMerchantRequest struct {
+	OwnerID  uint   `json:"owner_id" binding:"required"`
+	Name     string `json:"name" binding:"required,max=100"`
+	Category string `json:"category" binding:"omitempty,max=50"`
+	LogoURL  string `json:"logo_url" binding:"omitempty,max=500"`
+}
+
+type UpdateMerchantRequest struct {
+	Name     string `json:"name,omitempty" binding:"omitempty,max=100"`
+	Category string `json:"category,omitempty" binding:"omitempty,max=50"`
+	LogoURL  string `json:"logo_url,omitempty" binding:"omitempty,max=500"`
+	Status   string `json:"status,omitempty" binding:"omitempty,oneof=active inactive"` // Admin only
+}
+
+type CreateTerminalRequest struct {
+	Code string `json:"code" binding:"required,max=30"`
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+type UpdateTerminalRequest struct {
+	Name   string `json:"name,omitempty" binding:"omitempty,max=100"`
+	Status string `json:"status,omitempty" binding:"omitempty,oneof=active inactive"`
+}
+
+type CreateCashierRequest struct {
+	Email      string `json:"email" binding:"required,email"`
+	Password   string `json:"password" binding:"required,min=6"`
+	FullName   string `json:"full_name" binding:"required"`
+	NimNip     string `json:"nim_nip" binding:"required"`
+	TerminalID *uint  `json:"terminal_id"`
+}
+
+type MerchantListParams struct {
+	Status   string
+	Category string
+	Search   string
+	Page     int
+	Limit    int
+}
+
+type MerchantListResponse struct {
+	Merchants  []MerchantWithOwner `json:"merchants"`
+	Total      int64               `json:"total"`
+	Page       int                 `json:"page"`
+	Limit      int                 `json:"limit"`
+	TotalPages int                 `json:"total_pages"`
+}
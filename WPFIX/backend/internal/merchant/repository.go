@@ -0,0 +1,180 @@
+package merchant
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrMerchantNotFound = errors.New("merchant not found")
+	ErrTerminalNotFound = errors.New("terminal not found")
+	ErrCashierNotFound  = errors.New("cashier not found")
+)
+
+type Repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// FindByID finds a merchant by ID
+func (r *Repository) FindByID(id uint) (*Merchant, error) {
+	var m Merchant
+	if err := r.db.First(&m, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMerchantNotFound
+		}
+		return nil, err
+	}
+	return &m, nil
+}
+
+// FindByOwnerID finds the merchant a user owns, nil when they own none
+func (r *Repository) FindByOwnerID(userID uint) (*Merchant, error) {
+	var m Merchant
+	if err := r.db.Where("owner_id = ?", userID).First(&m).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &m, nil
+}
+
+// FindCashierByUserID finds the cashier link of a user, nil when there is none
+func (r *Repository) FindCashierByUserID(userID uint) (*Cashier, error) {
+	var cashier Cashier
+	if err := r.db.Where("user_id = ?", userID).First(&cashier).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &cashier, nil
+}
+
+// FindCashier finds a cashier of a merchant
+func (r *Repository) FindCashier(merchantID, cashierID uint) (*Cashier, error) {
+	var cashier Cashier
+	if err := r.db.Where("id = ? AND merchant_id = ?", cashierID, merchantID).First(&cashier).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCashierNotFound
+		}
+		return nil, err
+	}
+	return &cashier, nil
+}
+
+// FindTerminal finds a terminal of a merchant
+func (r *Repository) FindTerminal(merchantID, terminalID uint) (*Terminal, error) {
+	var terminal Terminal
+	if err := r.db.Where("id = ? AND merchant_id = ?", terminalID, merchantID).First(&terminal).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTerminalNotFound
+		}
+		return nil, err
+	}
+	return &terminal, nil
+}
+
+// GetTerminals lists the terminals of a merchant
+func (r *Repository) GetTerminals(merchantID uint) ([]Terminal, error) {
+	terminals := []Terminal{}
+	err := r.db.Where("merchant_id = ?", merchantID).Order("code ASC").Find(&terminals).Error
+	return terminals, err
+}
+
+// GetCashiers lists the cashiers of a merchant with their logins
+func (r *Repository) GetCashiers(merchantID uint) ([]CashierWithUser, error) {
+	cashiers := []CashierWithUser{}
+	err := r.db.Table("merchant_cashiers mc").
+		Select("mc.*, u.full_name, u.email").
+		Joins("JOIN users u ON u.id = mc.user_id").
+		Where("mc.merchant_id = ?", merchantID).
+		Order("mc.id ASC").
+		Scan(&cashiers).Error
+	return cashiers, err
+}
+
+// IsLinked reports whether a user already owns or works for a merchant
+func (r *Repository) IsLinked(userID uint) (bool, error) {
+	var count int64
+	err := r.db.Raw(`SELECT (SELECT COUNT(*) FROM merchants WHERE owner_id = ?) +
+		(SELECT COUNT(*) FROM merchant_cashiers WHERE user_id = ?)`, userID, userID).Scan(&count).Error
+	return count > 0, err
+}
+
+// Create inserts a merchant
+func (r *Repository) Create(m *Merchant) error {
+	return r.db.Create(m).Error
+}
+
+// Update updates merchant columns
+func (r *Repository) Update(id uint, updates map[string]interface{}) error {
+	return r.db.Model(&Merchant{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// CreateTerminal inserts a terminal; a duplicate code fails the unique index
+func (r *Repository) CreateTerminal(terminal *Terminal) error {
+	return r.db.Create(terminal).Error
+}
+
+// UpdateTerminal updates terminal columns
+func (r *Repository) UpdateTerminal(id uint, updates map[string]interface{}) error {
+	return r.db.Model(&Terminal{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// List returns merchants matching the filters, newest first
+func (r *Repository) List(params MerchantListParams) ([]MerchantWithOwner, int64, error) {
+	var merchants []MerchantWithOwner
+	var total int64
+
+	query := r.db.Table("merchants m").
+		Select("m.*, u.full_name as owner_name, u.email as owner_email").
+		Joins("LEFT JOIN users u ON u.id = m.owner_id")
+
+	if params.Status != "" {
+		query = query.Where("m.status = ?", params.Status)
+	}
+	if params.Category != "" {
+		query = query.Where("m.category = ?", params.Category)
+	}
+	if params.Search != "" {
+		query = query.Where("m.name LIKE ?", "%"+params.Search+"%")
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (params.Page - 1) * params.Limit
+	err := query.Order("m.created_at DESC").Limit(params.Limit).Offset(offset).Scan(&merchants).Error
+	return merchants, total, err
+}
+
+// GetDirectory lists active merchants for payers
+func (r *Repository) GetDirectory() ([]MerchantSummary, error) {
+	merchants := []MerchantSummary{}
+	err := r.db.Model(&Merchant{}).
+		Select("id, name, category, logo_url").
+		Where("status = ?", "active").
+		Order("name ASC").
+		Scan(&merchants).Error
+	return merchants, err
+}
+
+// BackfillMerchants gives every existing merchant user that is not a cashier
+// a merchant profile over their own wallet. Returns the number created.
+func (r *Repository) BackfillMerchants() (int64, error) {
+	result := r.db.Exec(`INSERT INTO merchants (owner_id, wallet_id, name, status, created_at, updated_at)
+		SELECT u.id, w.id, u.full_name, 'active', NOW(), NOW()
+		FROM users u
+		JOIN wallets w ON w.user_id = u.id
+		WHERE u.role = 'merchant'
+		AND NOT EXISTS (SELECT 1 FROM merchants m WHERE m.owner_id = u.id)
+		AND NOT EXISTS (SELECT 1 FROM merchant_cashiers mc WHERE mc.user_id = u.id)`)
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,385 @@
+package merchant
+
+import (
+	"errors"
+	"math"
+	"wallet-point/internal/auth"
+	"wallet-point/internal/wallet"
+	"wallet-point/utils"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNotOwner         = errors.New("only the merchant owner can manage the merchant")
+	ErrMerchantInactive = errors.New("merchant is inactive")
+	ErrCashierInactive  = errors.New("cashier account is inactive")
+	ErrTerminalInactive = errors.New("terminal is inactive")
+	ErrAlreadyLinked    = errors.New("user already belongs to a merchant")
+)
+
+// Service manages merchant profiles, terminals and cashiers, and resolves
+// them for the wallet service (it implements wallet.MerchantDirectory)
+type Service struct {
+	repo          *Repository
+	authRepo      *auth.AuthRepository
+	walletService *wallet.WalletService
+	db            *gorm.DB
+}
+
+func NewService(repo *Repository, authRepo *auth.AuthRepository, walletService *wallet.WalletService, db *gorm.DB) *Service {
+	return &Service{
+		repo:          repo,
+		authRepo:      authRepo,
+		walletService: walletService,
+		db:            db,
+	}
+}
+
+// membership returns the merchant a user works for and whether they own it
+func (s *Service) membership(userID uint) (*Merchant, *Cashier, error) {
+	owned, err := s.repo.FindByOwnerID(userID)
+	if err != nil || owned != nil {
+		return owned, nil, err
+	}
+
+	cashier, err := s.repo.FindCashierByUserID(userID)
+	if err != nil || cashier == nil {
+		return nil, nil, err
+	}
+	m, err := s.repo.FindByID(cashier.MerchantID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return m, cashier, nil
+}
+
+// ownedMerchant returns the merchant a user owns, ErrNotOwner for cashiers
+func (s *Service) ownedMerchant(userID uint) (*Merchant, error) {
+	m, cashier, err := s.membership(userID)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return nil, ErrMerchantNotFound
+	}
+	if cashier != nil {
+		return nil, ErrNotOwner
+	}
+	return m, nil
+}
+
+// AccountForCashier resolves the merchant, wallet and terminal a payment
+// captured by userID is collected for. terminalID 0 uses the cashier's
+// default terminal. Returns nil when the user is not linked to a merchant.
+func (s *Service) AccountForCashier(userID uint, terminalID uint) (*wallet.MerchantAccount, error) {
+	m, cashier, err := s.membership(userID)
+	if err != nil || m == nil {
+		return nil, err
+	}
+	if m.Status != "active" {
+		return nil, ErrMerchantInactive
+	}
+	if cashier != nil && cashier.Status != "active" {
+		return nil, ErrCashierInactive
+	}
+
+	if terminalID == 0 && cashier != nil && cashier.TerminalID != nil {
+		terminalID = *cashier.TerminalID
+	}
+
+	account := &wallet.MerchantAccount{
+		MerchantID: m.ID,
+		Name:       m.Name,
+		WalletID:   m.WalletID,
+		CashierID:  userID,
+	}
+	if terminalID != 0 {
+		terminal, err := s.repo.FindTerminal(m.ID, terminalID)
+		if err != nil {
+			return nil, err
+		}
+		if terminal.Status != "active" {
+			return nil, ErrTerminalInactive
+		}
+		account.TerminalID = &terminal.ID
+	}
+
+	return account, nil
+}
+
+// GetMerchantAccount returns an active merchant by ID for token generation
+func (s *Service) GetMerchantAccount(merchantID uint) (*wallet.MerchantAccount, error) {
+	m, err := s.repo.FindByID(merchantID)
+	if err != nil {
+		return nil, err
+	}
+	if m.Status != "active" {
+		return nil, ErrMerchantInactive
+	}
+	return &wallet.MerchantAccount{MerchantID: m.ID, Name: m.Name, WalletID: m.WalletID}, nil
+}
+
+// GetProfile returns the merchant a user owns or works for. Only owners see
+// the cashier list.
+func (s *Service) GetProfile(userID uint) (*MerchantProfile, error) {
+	m, cashier, err := s.membership(userID)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return nil, ErrMerchantNotFound
+	}
+
+	terminals, err := s.repo.GetTerminals(m.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := &MerchantProfile{Merchant: *m, Role: "owner", Terminals: terminals}
+	if cashier != nil {
+		profile.Role = "cashier"
+		return profile, nil
+	}
+
+	profile.Cashiers, err = s.repo.GetCashiers(m.ID)
+	if err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// UpdateProfile lets the owner change the display name, category and logo
+func (s *Service) UpdateProfile(userID uint, req *UpdateMerchantRequest) (*Merchant, error) {
+	m, err := s.ownedMerchant(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Activation is an admin decision
+	req.Status = ""
+	return s.update(m.ID, req)
+}
+
+func (s *Service) update(merchantID uint, req *UpdateMerchantRequest) (*Merchant, error) {
+	updates := make(map[string]interface{})
+	if req.Name != "" {
+		updates["name"] = req.Name
+	}
+	if req.Category != "" {
+		updates["category"] = req.Category
+	}
+	if req.LogoURL != "" {
+		updates["logo_url"] = req.LogoURL
+	}
+	if req.Status != "" {
+		updates["status"] = req.Status
+	}
+
+	if len(updates) > 0 {
+		if err := s.repo.Update(merchantID, updates); err != nil {
+			return nil, errors.New("failed to update merchant")
+		}
+	}
+
+	return s.repo.FindByID(merchantID)
+}
+
+// CreateTerminal adds a terminal to the owner's merchant
+func (s *Service) CreateTerminal(userID uint, req *CreateTerminalRequest) (*Terminal, error) {
+	m, err := s.ownedMerchant(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	terminal := &Terminal{MerchantID: m.ID, Code: req.Code, Name: req.Name, Status: "active"}
+	if err := s.repo.CreateTerminal(terminal); err != nil {
+		return nil, errors.New("terminal code already used")
+	}
+	return terminal, nil
+}
+
+// UpdateTerminal renames or (de)activates a terminal of the owner's merchant
+func (s *Service) UpdateTerminal(userID, terminalID uint, req *UpdateTerminalRequest) (*Terminal, error) {
+	m, err := s.ownedMerchant(userID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.repo.FindTerminal(m.ID, terminalID); err != nil {
+		return nil, err
+	}
+
+	updates := make(map[string]interface{})
+	if req.Name != "" {
+		updates["name"] = req.Name
+	}
+	if req.Status != "" {
+		updates["status"] = req.Status
+	}
+	if len(updates) > 0 {
+		if err := s.repo.UpdateTerminal(terminalID, updates); err != nil {
+			return nil, errors.New("failed to update terminal")
+		}
+	}
+
+	return s.repo.FindTerminal(m.ID, terminalID)
+}
+
+// CreateCashier creates a merchant login that collects into the owner's
+// merchant wallet
+func (s *Service) CreateCashier(userID uint, req *CreateCashierRequest) (*CashierWithUser, error) {
+	m, err := s.ownedMerchant(userID)
+	if err != nil {
+		return nil, err
+	}
+	if req.TerminalID != nil {
+		if _, err := s.repo.FindTerminal(m.ID, *req.TerminalID); err != nil {
+			return nil, err
+		}
+	}
+
+	exists, err := s.authRepo.CheckEmailExists(req.Email)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, errors.New("email already registered")
+	}
+	exists, err = s.authRepo.CheckNimNipExists(req.NimNip)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, errors.New("NIM/NIP already registered")
+	}
+
+	hashedPassword, err := utils.HashPassword(req.Password)
+	if err != nil {
+		return nil, errors.New("failed to secure password")
+	}
+
+	user := &auth.User{
+		Email:        req.Email,
+		PasswordHash: hashedPassword,
+		FullName:     req.FullName,
+		NimNip:       req.NimNip,
+		Role:         "merchant",
+		Status:       "active",
+	}
+	cashier := &Cashier{MerchantID: m.ID, TerminalID: req.TerminalID, Status: "active"}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return errors.New("failed to create user")
+		}
+		cashier.UserID = user.ID
+		return tx.Create(cashier).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &CashierWithUser{Cashier: *cashier, FullName: user.FullName, Email: user.Email}, nil
+}
+
+// DeactivateCashier revokes a cashier; their login is deactivated as well
+func (s *Service) DeactivateCashier(userID, cashierID uint) (*Cashier, error) {
+	m, err := s.ownedMerchant(userID)
+	if err != nil {
+		return nil, err
+	}
+	cashier, err := s.repo.FindCashier(m.ID, cashierID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&Cashier{}).Where("id = ?", cashier.ID).Update("status", "inactive").Error; err != nil {
+			return err
+		}
+		return tx.Model(&auth.User{}).Where("id = ?", cashier.UserID).Update("status", "inactive").Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cashier.Status = "inactive"
+	return cashier, nil
+}
+
+// ListMerchants lists merchants with their owners (Admin)
+func (s *Service) ListMerchants(params MerchantListParams) (*MerchantListResponse, error) {
+	if params.Page < 1 {
+		params.Page = 1
+	}
+	if params.Limit < 1 {
+		params.Limit = 20
+	}
+
+	merchants, total, err := s.repo.List(params)
+	if err != nil {
+		return nil, err
+	}
+	if merchants == nil {
+		merchants = []MerchantWithOwner{}
+	}
+
+	return &MerchantListResponse{
+		Merchants:  merchants,
+		Total:      total,
+		Page:       params.Page,
+		Limit:      params.Limit,
+		TotalPages: int(math.Ceil(float64(total) / float64(params.Limit))),
+	}, nil
+}
+
+// CreateMerchant registers a merchant owned by an existing merchant user,
+// settling into that user's wallet (Admin)
+func (s *Service) CreateMerchant(req *CreateMerchantRequest) (*Merchant, error) {
+	owner, err := s.authRepo.FindByID(req.OwnerID)
+	if err != nil {
+		return nil, err
+	}
+	if owner.Role != "merchant" {
+		return nil, errors.New("owner must be a merchant user")
+	}
+
+	linked, err := s.repo.IsLinked(owner.ID)
+	if err != nil {
+		return nil, err
+	}
+	if linked {
+		return nil, ErrAlreadyLinked
+	}
+
+	ownerWallet, err := s.walletService.GetWalletByUserID(owner.ID)
+	if err != nil {
+		return nil, errors.New("owner wallet not found")
+	}
+
+	m := &Merchant{
+		OwnerID:  owner.ID,
+		WalletID: ownerWallet.ID,
+		Name:     req.Name,
+		Category: req.Category,
+		LogoURL:  req.LogoURL,
+		Status:   "active",
+	}
+	if err := s.repo.Create(m); err != nil {
+		return nil, errors.New("failed to create merchant")
+	}
+	return m, nil
+}
+
+// UpdateMerchant updates any merchant, including its status (Admin)
+func (s *Service) UpdateMerchant(merchantID uint, req *UpdateMerchantRequest) (*Merchant, error) {
+	if _, err := s.repo.FindByID(merchantID); err != nil {
+		return nil, err
+	}
+	return s.update(merchantID, req)
+}
+
+// GetDirectory lists active merchants students can pay
+func (s *Service) GetDirectory() ([]MerchantSummary, error) {
+	return s.repo.GetDirectory()
+}
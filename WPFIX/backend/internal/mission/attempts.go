@@ -0,0 +1,126 @@
+package mission
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Error codes returned when the attempt policy refuses a submission
+const (
+	CodeAttemptPending      = "ATTEMPT_PENDING_REVIEW"
+	CodeAttemptLimitReached = "ATTEMPT_LIMIT_REACHED"
+	CodeResubmitNotAllowed  = "RESUBMIT_NOT_ALLOWED"
+	CodeAttemptCooldown     = "ATTEMPT_COOLDOWN"
+)
+
+// AttemptError is a submission refused by the mission's attempt policy
+type AttemptError struct {
+	code    string
+	status  int
+	message string
+	retryAt *time.Time
+}
+
+func (e *AttemptError) Error() string   { return e.message }
+func (e *AttemptError) Code() string    { return e.code }
+func (e *AttemptError) HTTPStatus() int { return e.status }
+
+// checkAttempt decides whether a student with these attempts (oldest first)
+// may submit again now
+func checkAttempt(mission *Mission, attempts []MissionSubmission, now time.Time) *AttemptError {
+	if len(attempts) == 0 {
+		return nil
+	}
+	last := attempts[len(attempts)-1]
+
+	if last.Status == "pending" {
+		return &AttemptError{code: CodeAttemptPending, status: http.StatusConflict, message: "your previous attempt is still waiting for review"}
+	}
+	if len(attempts) >= mission.MaxAttempts {
+		if mission.MaxAttempts <= 1 {
+			return &AttemptError{code: CodeAttemptLimitReached, status: http.StatusConflict, message: "you have already submitted this mission"}
+		}
+		return &AttemptError{code: CodeAttemptLimitReached, status: http.StatusConflict, message: fmt.Sprintf("you have used all %d attempts for this mission", mission.MaxAttempts)}
+	}
+	if mission.ResubmitPolicy != "after_review" && last.Status != "rejected" {
+		return &AttemptError{code: CodeResubmitNotAllowed, status: http.StatusConflict, message: "a new attempt is only allowed after the previous one is rejected"}
+	}
+	if mission.CooldownMinutes > 0 {
+		retryAt := last.CreatedAt.Add(time.Duration(mission.CooldownMinutes) * time.Minute)
+		if now.Before(retryAt) {
+			return &AttemptError{code: CodeAttemptCooldown, status: http.StatusTooManyRequests, message: fmt.Sprintf("next attempt allowed at %s", retryAt.Format(time.RFC3339)), retryAt: &retryAt}
+		}
+	}
+	return nil
+}
+
+// countingAttempt returns the reviewed attempt whose score counts under the
+// mission's score rule: the latest, or the first with the best score. It is
+// nil while no attempt is reviewed.
+func countingAttempt(mission *Mission, attempts []MissionSubmission) *MissionSubmission {
+	var counting *MissionSubmission
+	for i := range attempts {
+		a := &attempts[i]
+		if a.Status == "pending" {
+			continue
+		}
+		if counting == nil || mission.ScoreRule != "best" || a.Score > counting.Score {
+			counting = a
+		}
+	}
+	return counting
+}
+
+// effectiveScore applies the mission's score rule to the reviewed attempts
+func effectiveScore(mission *Mission, attempts []MissionSubmission) *int {
+	counting := countingAttempt(mission, attempts)
+	if counting == nil {
+		return nil
+	}
+	score := counting.Score
+	return &score
+}
+
+// GetSubmissionHistory returns a student's attempts at a mission with what
+// the attempt policy allows next
+func (s *MissionService) GetSubmissionHistory(missionID, studentID uint) (*SubmissionHistory, error) {
+	mission, err := s.repo.FindByID(missionID)
+	if err != nil {
+		return nil, err
+	}
+
+	attempts, err := s.repo.FindAttempts(s.db, missionID, studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	history := &SubmissionHistory{
+		MissionID:      missionID,
+		StudentID:      studentID,
+		MaxAttempts:    mission.MaxAttempts,
+		AttemptsUsed:   len(attempts),
+		AttemptsLeft:   max(mission.MaxAttempts-len(attempts), 0),
+		ResubmitPolicy: mission.ResubmitPolicy,
+		ScoreRule:      mission.ScoreRule,
+		EffectiveScore: effectiveScore(mission, attempts),
+		CanSubmit:      true,
+		Attempts:       attempts,
+	}
+	for _, a := range attempts {
+		if a.Rewarded {
+			history.Rewarded = true
+		}
+	}
+
+	now := s.db.NowFunc()
+	if mission.Deadline != nil && mission.Deadline.Before(now) {
+		history.CanSubmit = false
+		history.BlockedReason = "mission deadline has passed"
+	} else if attemptErr := checkAttempt(mission, attempts, now); attemptErr != nil {
+		history.CanSubmit = false
+		history.BlockedReason = attemptErr.message
+		history.NextAttemptAt = attemptErr.retryAt
+	}
+	return history, nil
+}
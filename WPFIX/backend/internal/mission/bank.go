@@ -0,0 +1,313 @@
+package mission
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrBankQuestionNotFound = errors.New("bank question not found")
+	ErrBankQuestionNotOwner = errors.New("only the dosen who added a bank question can change it")
+	ErrInvalidDifficulty    = errors.New("difficulty must be easy, medium or hard")
+)
+
+// BankQuestion is a reusable quiz question that quiz missions draw from
+type BankQuestion struct {
+	ID         uint        `json:"id" gorm:"primaryKey"`
+	OwnerID    uint        `json:"owner_id" gorm:"not null;index"`
+	Question   string      `json:"question" gorm:"type:text;not null"`
+	Options    JSONOptions `json:"options" gorm:"type:json"`
+	Answer     string      `json:"answer" gorm:"not null"`
+	MatchType  string      `json:"match_type" gorm:"type:enum('exact','case_insensitive','multi_select','numeric');default:'exact'"`
+	Tolerance  float64     `json:"tolerance" gorm:"default:0"`
+	Weight     int         `json:"weight" gorm:"default:1;not null"`
+	Difficulty string      `json:"difficulty" gorm:"type:enum('easy','medium','hard');default:'medium';index"`
+	Course     string      `json:"course" gorm:"size:100;index"`
+	Tags       []string    `json:"tags" gorm:"type:json;serializer:json"`
+	CreatedAt  time.Time   `json:"created_at"`
+	UpdatedAt  time.Time   `json:"updated_at"`
+}
+
+func (BankQuestion) TableName() string {
+	return "question_bank"
+}
+
+// QuizDraw is the questions one student got for a drawn quiz, in the order
+// shown with options shuffled. Grading uses this copy, so later bank edits
+// do not change it.
+type QuizDraw struct {
+	ID        uint              `json:"id" gorm:"primaryKey"`
+	MissionID uint              `json:"mission_id" gorm:"not null;uniqueIndex:idx_quiz_draw_student"`
+	StudentID uint              `json:"student_id" gorm:"not null;uniqueIndex:idx_quiz_draw_student"`
+	Questions []MissionQuestion `json:"questions" gorm:"type:json;serializer:json"` // IDs are bank question IDs
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+func (QuizDraw) TableName() string {
+	return "quiz_draws"
+}
+
+type BankQuestionRequest struct {
+	QuestionRequest
+	Difficulty string   `json:"difficulty" binding:"omitempty,oneof=easy medium hard"` // Default medium
+	Course     string   `json:"course" binding:"max=100"`
+	Tags       []string `json:"tags"`
+}
+
+type BankQuestionListParams struct {
+	Tag        string
+	Course     string
+	Difficulty string
+	Search     string
+	Page       int
+	Limit      int
+}
+
+type BankQuestionListResponse struct {
+	Questions  []BankQuestion `json:"questions"`
+	Total      int64          `json:"total"`
+	Page       int            `json:"page"`
+	Limit      int            `json:"limit"`
+	TotalPages int            `json:"total_pages"`
+}
+
+// bankFilter selects the bank questions a quiz mission draws from
+type bankFilter struct {
+	Tags       []string
+	Course     string
+	Difficulty string
+}
+
+func normalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	out := make([]string, 0, len(tags))
+	for _, t := range tags {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t != "" && !seen[t] {
+			seen[t] = true
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+func checkDifficulty(difficulty string) error {
+	switch difficulty {
+	case "", "easy", "medium", "hard":
+		return nil
+	}
+	return ErrInvalidDifficulty
+}
+
+func (s *MissionService) GetBankQuestions(params BankQuestionListParams) (*BankQuestionListResponse, error) {
+	if params.Page < 1 {
+		params.Page = 1
+	}
+	if params.Limit < 1 {
+		params.Limit = 20
+	}
+	params.Tag = strings.ToLower(strings.TrimSpace(params.Tag))
+
+	questions, total, err := s.repo.FindBankQuestions(params)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BankQuestionListResponse{
+		Questions:  questions,
+		Total:      total,
+		Page:       params.Page,
+		Limit:      params.Limit,
+		TotalPages: int(math.Ceil(float64(total) / float64(params.Limit))),
+	}, nil
+}
+
+func (s *MissionService) CreateBankQuestion(req *BankQuestionRequest, ownerID uint) (*BankQuestion, error) {
+	question, err := newBankQuestion(req)
+	if err != nil {
+		return nil, err
+	}
+	question.OwnerID = ownerID
+
+	if err := s.repo.CreateBankQuestion(question); err != nil {
+		return nil, err
+	}
+	return question, nil
+}
+
+func (s *MissionService) UpdateBankQuestion(id uint, req *BankQuestionRequest, ownerID uint) (*BankQuestion, error) {
+	existing, err := s.repo.FindBankQuestion(id)
+	if err != nil {
+		return nil, err
+	}
+	if existing.OwnerID != ownerID {
+		return nil, ErrBankQuestionNotOwner
+	}
+
+	question, err := newBankQuestion(req)
+	if err != nil {
+		return nil, err
+	}
+	question.ID = existing.ID
+	question.OwnerID = existing.OwnerID
+	question.CreatedAt = existing.CreatedAt
+
+	if err := s.repo.SaveBankQuestion(question); err != nil {
+		return nil, err
+	}
+	return question, nil
+}
+
+// DeleteBankQuestion removes a question from the bank. Students who already
+// drew it keep their copy.
+func (s *MissionService) DeleteBankQuestion(id uint, ownerID uint) error {
+	existing, err := s.repo.FindBankQuestion(id)
+	if err != nil {
+		return err
+	}
+	if existing.OwnerID != ownerID {
+		return ErrBankQuestionNotOwner
+	}
+	return s.repo.DeleteBankQuestion(id)
+}
+
+func newBankQuestion(req *BankQuestionRequest) (*BankQuestion, error) {
+	q, err := newQuestion(0, req.QuestionRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	question := &BankQuestion{
+		Question:   q.Question,
+		Options:    q.Options,
+		Answer:     q.Answer,
+		MatchType:  q.MatchType,
+		Tolerance:  q.Tolerance,
+		Weight:     q.Weight,
+		Difficulty: req.Difficulty,
+		Course:     strings.TrimSpace(req.Course),
+		Tags:       normalizeTags(req.Tags),
+	}
+	if question.Difficulty == "" {
+		question.Difficulty = "medium"
+	}
+	return question, nil
+}
+
+// checkDrawPool makes sure the bank has enough questions for a drawn quiz
+func (s *MissionService) checkDrawPool(mission *Mission) error {
+	if mission.DrawCount == 0 {
+		return nil
+	}
+	if mission.Type != "quiz" {
+		return errors.New("only quiz missions can draw questions from the bank")
+	}
+	if err := checkDifficulty(mission.DrawDifficulty); err != nil {
+		return err
+	}
+
+	ids, err := s.repo.FindBankQuestionIDs(s.db, mission.drawFilter())
+	if err != nil {
+		return err
+	}
+	if len(ids) < mission.DrawCount {
+		return fmt.Errorf("only %d bank questions match the draw, %d needed", len(ids), mission.DrawCount)
+	}
+	return nil
+}
+
+func (m *Mission) drawFilter() bankFilter {
+	return bankFilter{Tags: m.DrawTags, Course: m.DrawCourse, Difficulty: m.DrawDifficulty}
+}
+
+// studentQuestions returns the questions a student answers for a quiz: the
+// mission's own, or the student's draw from the bank, drawn on first use
+func (s *MissionService) studentQuestions(tx *gorm.DB, mission *Mission, studentID uint) ([]MissionQuestion, error) {
+	if mission.DrawCount == 0 {
+		return mission.Questions, nil
+	}
+
+	draw, err := s.repo.FindQuizDraw(tx, mission.ID, studentID)
+	if err == nil {
+		return draw.Questions, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	ids, err := s.repo.FindBankQuestionIDs(tx, mission.drawFilter())
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) < mission.DrawCount {
+		return nil, fmt.Errorf("the question bank no longer has %d questions for this quiz", mission.DrawCount)
+	}
+	rand.Shuffle(len(ids), func(i, j int) { ids[i], ids[j] = ids[j], ids[i] })
+	ids = ids[:mission.DrawCount]
+
+	bank, err := s.repo.FindBankQuestionsByID(tx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]BankQuestion, len(bank))
+	for _, q := range bank {
+		byID[q.ID] = q
+	}
+
+	draw = &QuizDraw{MissionID: mission.ID, StudentID: studentID}
+	for _, id := range ids {
+		q := byID[id]
+		options := append(JSONOptions{}, q.Options...)
+		rand.Shuffle(len(options), func(i, j int) { options[i], options[j] = options[j], options[i] })
+		draw.Questions = append(draw.Questions, MissionQuestion{
+			ID:        q.ID,
+			MissionID: mission.ID,
+			Question:  q.Question,
+			Options:   options,
+			Answer:    q.Answer,
+			MatchType: q.MatchType,
+			Tolerance: q.Tolerance,
+			Weight:    q.Weight,
+		})
+	}
+
+	// A concurrent request may have drawn first; keep whichever was stored
+	if err := s.repo.CreateQuizDraw(tx, draw); err != nil {
+		if existing, findErr := s.repo.FindQuizDraw(tx, mission.ID, studentID); findErr == nil {
+			return existing.Questions, nil
+		}
+		return nil, err
+	}
+	return draw.Questions, nil
+}
+
+// GetMissionForStudent returns a mission as a student sees it: their own
+// questions for a drawn quiz, and no answer key
+func (s *MissionService) GetMissionForStudent(missionID, studentID uint) (*Mission, error) {
+	mission, err := s.repo.FindByID(missionID)
+	if err != nil {
+		return nil, err
+	}
+	if mission.Type == "quiz" && mission.DrawCount > 0 {
+		if mission.Questions, err = s.studentQuestions(s.db, mission, studentID); err != nil {
+			return nil, err
+		}
+	}
+	mission.HideAnswers()
+	return mission, nil
+}
+
+// GetQuizDraw returns the questions a student drew, with answers, for review
+func (s *MissionService) GetQuizDraw(missionID, studentID uint) (*QuizDraw, error) {
+	draw, err := s.repo.FindQuizDraw(s.db, missionID, studentID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.New("student has not drawn questions for this quiz")
+	}
+	return draw, err
+}
@@ -0,0 +1,311 @@
+package mission
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// newQuestion builds a quiz question, checking its answer can be graded
+func newQuestion(missionID uint, q QuestionRequest) (MissionQuestion, error) {
+	question := MissionQuestion{
+		MissionID: missionID,
+		Question:  q.Question,
+		Options:   q.Options,
+		Answer:    q.Answer,
+		MatchType: q.MatchType,
+		Tolerance: q.Tolerance,
+		Weight:    q.Weight,
+	}
+	if question.MatchType == "" {
+		question.MatchType = "exact"
+	}
+	if question.Weight == 0 {
+		question.Weight = 1
+	}
+
+	switch question.MatchType {
+	case "multi_select":
+		if _, err := parseSelection(q.Answer); err != nil {
+			return question, fmt.Errorf("question %q: multi_select answer must be a JSON array of options", q.Question)
+		}
+	case "numeric":
+		if _, err := strconv.ParseFloat(strings.TrimSpace(q.Answer), 64); err != nil {
+			return question, fmt.Errorf("question %q: numeric answer must be a number", q.Question)
+		}
+	}
+	return question, nil
+}
+
+// newQuestions builds the questions of a quiz
+func newQuestions(missionID uint, reqs []QuestionRequest) ([]MissionQuestion, error) {
+	questions := make([]MissionQuestion, 0, len(reqs))
+	for _, q := range reqs {
+		question, err := newQuestion(missionID, q)
+		if err != nil {
+			return nil, err
+		}
+		questions = append(questions, question)
+	}
+	return questions, nil
+}
+
+// HideAnswers removes the answer key before a quiz is shown to students
+func (m *Mission) HideAnswers() {
+	for i := range m.Questions {
+		m.Questions[i].Answer = ""
+		m.Questions[i].Tolerance = 0
+	}
+}
+
+// parseSelection reads a multi_select answer: a JSON array of options
+func parseSelection(answer string) ([]string, error) {
+	var selected []string
+	if err := json.Unmarshal([]byte(answer), &selected); err != nil {
+		return nil, err
+	}
+	for i := range selected {
+		selected[i] = strings.TrimSpace(selected[i])
+	}
+	sort.Strings(selected)
+	return selected, nil
+}
+
+// matches reports whether a student's answer is correct for the question
+func (q *MissionQuestion) matches(answer string) bool {
+	switch q.MatchType {
+	case "case_insensitive":
+		return strings.EqualFold(strings.TrimSpace(answer), strings.TrimSpace(q.Answer))
+	case "multi_select":
+		want, err := parseSelection(q.Answer)
+		if err != nil {
+			return false
+		}
+		got, err := parseSelection(answer)
+		if err != nil || len(got) != len(want) {
+			return false
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				return false
+			}
+		}
+		return true
+	case "numeric":
+		want, err := strconv.ParseFloat(strings.TrimSpace(q.Answer), 64)
+		if err != nil {
+			return false
+		}
+		got, err := strconv.ParseFloat(strings.TrimSpace(answer), 64)
+		if err != nil {
+			return false
+		}
+		return math.Abs(got-want) <= q.Tolerance
+	default:
+		return answer == q.Answer
+	}
+}
+
+// gradeQuiz scores answers against the student's quiz questions, returning
+// the weighted percentage of correct answers
+func gradeQuiz(questions []MissionQuestion, answers []AnswerSubmission) (int, []QuestionGrade) {
+	given := make(map[uint]string, len(answers))
+	for _, a := range answers {
+		given[a.QuestionID] = a.Answer
+	}
+
+	var earned, total int
+	grades := make([]QuestionGrade, 0, len(questions))
+	for i := range questions {
+		q := &questions[i]
+		answer, ok := given[q.ID]
+		correct := ok && q.matches(answer)
+		if correct {
+			earned += q.Weight
+		}
+		total += q.Weight
+		grades = append(grades, QuestionGrade{QuestionID: q.ID, Correct: correct, Weight: q.Weight})
+	}
+	if total == 0 {
+		return 0, grades
+	}
+	return earned * 100 / total, grades
+}
+
+// scorePayout is what a score out of 100 pays under the mission's payout rule
+func scorePayout(mission *Mission, score int) int {
+	switch mission.PayoutRule {
+	case "threshold":
+		if score >= mission.PassScore {
+			return mission.Points
+		}
+		return 0
+	case "bands":
+		return mission.Points * bandPercent(mission.PayoutBands, score) / 100
+	default:
+		return mission.Points * min(score, 100) / 100
+	}
+}
+
+// approvedPayout is what an approved submission pays. Quizzes, rubric and
+// peer reviewed missions pay by their payout rule, nothing below a threshold
+// rule's pass score; other missions pay their full points.
+func approvedPayout(mission *Mission, score int) int {
+	if mission.Type == "quiz" || len(mission.Rubric) > 0 || mission.PeerReviewers > 0 {
+		return scorePayout(mission, score)
+	}
+	return mission.Points
+}
+
+// setRubricResult adds the rubric results of a review to the submission
+// columns to update
+func setRubricResult(updates map[string]interface{}, results []CriterionResult) error {
+	if results == nil {
+		return nil
+	}
+	encoded, err := json.Marshal(results)
+	if err != nil {
+		return err
+	}
+	updates["rubric_result"] = string(encoded)
+	return nil
+}
+
+// attemptPayout is what a reviewed attempt's grade earns
+func attemptPayout(mission *Mission, attempt *MissionSubmission) int {
+	if attempt.Status != "approved" {
+		return 0
+	}
+	return approvedPayout(mission, attempt.Score)
+}
+
+// settleReward gives the submission its new status and score among the
+// student's attempts, then brings what the mission paid the student to what
+// the attempt counting under the score rule earns, crediting or reclaiming
+// the difference. The mission's single reward moves to that attempt, so each
+// mission pays at most once. Points already spent cannot be reclaimed; they
+// are recorded as owed on the submission and count as paid on later grades.
+// It returns the submission columns to update.
+func (s *MissionService) settleReward(tx *gorm.DB, mission *Mission, submission *MissionSubmission, attempts []MissionSubmission) (map[string]interface{}, error) {
+	graded := make([]MissionSubmission, 0, len(attempts)+1)
+	paid, found := 0, false
+	for _, a := range attempts {
+		paid += a.RewardAmount + a.RewardOwed
+		if a.ID == submission.ID {
+			a.Status, a.Score = submission.Status, submission.Score
+			found = true
+		}
+		graded = append(graded, a)
+	}
+	if !found {
+		graded = append(graded, *submission)
+	}
+
+	target := 0
+	counting := countingAttempt(mission, graded)
+	if counting != nil {
+		target = attemptPayout(mission, counting)
+	}
+
+	delta, owed := target-paid, 0
+	if delta > 0 {
+		if err := s.walletService.ProcessMissionRewardWithTx(tx, submission.StudentID, mission.Asset, delta, mission.Title, mission.ID, 0); err != nil {
+			return nil, err
+		}
+	} else if delta < 0 {
+		reclaimed, err := s.walletService.ReclaimMissionRewardWithTx(tx, submission.StudentID, mission.Asset, -delta, mission.Title, mission.ID)
+		if err != nil {
+			return nil, err
+		}
+		owed = -delta - reclaimed
+	}
+
+	holder := func(id uint) (bool, int) {
+		if counting == nil || counting.ID != id || target == 0 {
+			return false, 0
+		}
+		return true, target
+	}
+	for _, a := range attempts {
+		if a.ID == submission.ID {
+			continue
+		}
+		if rewarded, amount := holder(a.ID); a.Rewarded != rewarded || a.RewardAmount != amount || a.RewardOwed != 0 {
+			if err := s.repo.UpdateSubmissionWithTx(tx, a.ID, map[string]interface{}{
+				"rewarded":      rewarded,
+				"reward_amount": amount,
+				"reward_owed":   0,
+			}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	rewarded, amount := holder(submission.ID)
+	return map[string]interface{}{
+		"rewarded":      rewarded,
+		"reward_amount": amount,
+		"reward_owed":   owed,
+	}, nil
+}
+
+// OverrideSubmission lets a dosen replace the grade of a submission that was
+// already reviewed or auto-graded, adjusting the reward to match
+func (s *MissionService) OverrideSubmission(submissionID uint, req *OverrideSubmissionRequest, reviewerID uint) (*MissionSubmission, error) {
+	submission, err := s.repo.FindSubmissionByID(submissionID)
+	if err != nil {
+		return nil, err
+	}
+	mission, err := s.repo.FindByID(submission.MissionID)
+	if err != nil {
+		return nil, err
+	}
+	if mission.Type == "quiz" && req.Score > 100 {
+		return nil, errors.New("quiz scores are percentages from 0 to 100")
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		attempts, err := s.repo.LockAttempts(tx, submission.MissionID, submission.StudentID)
+		if err != nil {
+			return err
+		}
+		for _, a := range attempts {
+			if a.ID == submissionID {
+				submission = &a
+			}
+		}
+		if submission.Status == "pending" {
+			return errors.New("submission has not been reviewed yet")
+		}
+
+		score, results, err := applyRubric(mission, req.Status, req.RubricScores, req.Score)
+		if err != nil {
+			return err
+		}
+
+		submission.Status, submission.Score = req.Status, score
+		updates, err := s.settleReward(tx, mission, submission, attempts)
+		if err != nil {
+			return err
+		}
+		if err := setRubricResult(updates, results); err != nil {
+			return err
+		}
+		updates["status"] = req.Status
+		updates["score"] = score
+		updates["validation_note"] = req.ReviewNote
+		updates["validated_by"] = reviewerID
+		updates["auto_graded"] = false
+		return s.repo.UpdateSubmissionWithTx(tx, submissionID, updates)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.FindSubmissionByID(submissionID)
+}
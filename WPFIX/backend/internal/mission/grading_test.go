@@ -0,0 +1,119 @@
+package mission
+
+import "testing"
+
+func TestQuestionMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		question MissionQuestion
+		answer   string
+		want     bool
+	}{
+		{"exact", MissionQuestion{MatchType: "exact", Answer: "Jakarta"}, "Jakarta", true},
+		{"exact is case sensitive", MissionQuestion{MatchType: "exact", Answer: "Jakarta"}, "jakarta", false},
+		{"case insensitive", MissionQuestion{MatchType: "case_insensitive", Answer: "Jakarta"}, " jAKARTA ", true},
+		{"case insensitive wrong", MissionQuestion{MatchType: "case_insensitive", Answer: "Jakarta"}, "Bandung", false},
+		{"multi select same order", MissionQuestion{MatchType: "multi_select", Answer: `["A","C"]`}, `["A","C"]`, true},
+		{"multi select any order", MissionQuestion{MatchType: "multi_select", Answer: `["A","C"]`}, `["C","A"]`, true},
+		{"multi select trims options", MissionQuestion{MatchType: "multi_select", Answer: `["A","C"]`}, `[" C","A "]`, true},
+		{"multi select missing option", MissionQuestion{MatchType: "multi_select", Answer: `["A","C"]`}, `["A"]`, false},
+		{"multi select extra option", MissionQuestion{MatchType: "multi_select", Answer: `["A","C"]`}, `["A","B","C"]`, false},
+		{"multi select not an array", MissionQuestion{MatchType: "multi_select", Answer: `["A"]`}, "A", false},
+		{"numeric exact", MissionQuestion{MatchType: "numeric", Answer: "3.14"}, "3.14", true},
+		{"numeric within tolerance", MissionQuestion{MatchType: "numeric", Answer: "3.14", Tolerance: 0.01}, "3.15", true},
+		{"numeric below tolerance", MissionQuestion{MatchType: "numeric", Answer: "3.14", Tolerance: 0.01}, "3.12", false},
+		{"numeric without tolerance", MissionQuestion{MatchType: "numeric", Answer: "10"}, "10.5", false},
+		{"numeric trims spaces", MissionQuestion{MatchType: "numeric", Answer: "10"}, " 10.0 ", true},
+		{"numeric not a number", MissionQuestion{MatchType: "numeric", Answer: "10", Tolerance: 1}, "ten", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.question.matches(tt.answer); got != tt.want {
+				t.Errorf("matches(%q) = %v, want %v", tt.answer, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGradeQuiz(t *testing.T) {
+	questions := []MissionQuestion{
+		{ID: 1, MatchType: "exact", Answer: "B", Weight: 1},
+		{ID: 2, MatchType: "multi_select", Answer: `["A","D"]`, Weight: 2},
+		{ID: 3, MatchType: "numeric", Answer: "9.8", Tolerance: 0.1, Weight: 3},
+	}
+
+	tests := []struct {
+		name        string
+		questions   []MissionQuestion
+		answers     []AnswerSubmission
+		wantScore   int
+		wantCorrect []bool
+	}{
+		{
+			name:      "all correct",
+			questions: questions,
+			answers: []AnswerSubmission{
+				{QuestionID: 1, Answer: "B"},
+				{QuestionID: 2, Answer: `["D","A"]`},
+				{QuestionID: 3, Answer: "9.75"},
+			},
+			wantScore:   100,
+			wantCorrect: []bool{true, true, true},
+		},
+		{
+			name:      "weighted partial",
+			questions: questions,
+			answers: []AnswerSubmission{
+				{QuestionID: 1, Answer: "B"},
+				{QuestionID: 2, Answer: `["A","D"]`},
+				{QuestionID: 3, Answer: "10"},
+			},
+			wantScore:   50,
+			wantCorrect: []bool{true, true, false},
+		},
+		{
+			name:      "rounds down",
+			questions: questions,
+			answers: []AnswerSubmission{
+				{QuestionID: 2, Answer: `["A","D"]`},
+			},
+			wantScore:   33,
+			wantCorrect: []bool{false, true, false},
+		},
+		{
+			name:      "unanswered and unknown questions",
+			questions: questions,
+			answers: []AnswerSubmission{
+				{QuestionID: 99, Answer: "B"},
+			},
+			wantScore:   0,
+			wantCorrect: []bool{false, false, false},
+		},
+		{
+			name:        "no questions",
+			wantScore:   0,
+			wantCorrect: []bool{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, grades := gradeQuiz(tt.questions, tt.answers)
+			if score != tt.wantScore {
+				t.Errorf("score = %d, want %d", score, tt.wantScore)
+			}
+			if len(grades) != len(tt.wantCorrect) {
+				t.Fatalf("got %d grades, want %d", len(grades), len(tt.wantCorrect))
+			}
+			for i, g := range grades {
+				if g.QuestionID != tt.questions[i].ID || g.Weight != tt.questions[i].Weight {
+					t.Errorf("grade %d = %+v, want question %d with weight %d", i, g, tt.questions[i].ID, tt.questions[i].Weight)
+				}
+				if g.Correct != tt.wantCorrect[i] {
+					t.Errorf("question %d correct = %v, want %v", g.QuestionID, g.Correct, tt.wantCorrect[i])
+				}
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package mission
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -53,7 +54,7 @@ func (h *MissionHandler) GetAllMissions(c *gin.Context) {
 	}
 
 	// Security: Students should only see active missions by default
-	userRole := c.GetString("user_role")
+	userRole := c.GetString("role")
 	if userRole == "mahasiswa" && params.Status == "" {
 		params.Status = "active"
 	}
@@ -85,7 +86,40 @@ func (h *MissionHandler) GetMissionByID(c *gin.Context) {
 
 	mission, err := h.service.GetMissionByID(uint(missionID))
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusNotFound, err.Error(), nil)
+		statusCode := http.StatusBadRequest
+		if err.Error() == "mission not found" {
+			statusCode = http.StatusNotFound
+		}
+		utils.ErrorResponse(c, statusCode, err.Error(), nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Mission retrieved successfully", mission)
+}
+
+// GetStudentMission handles a student opening a mission
+// @Summary Get mission as a student
+// @Description Mission details without the answer key; a bank-drawn quiz shows the student's own draw
+// @Tags Mahasiswa - Missions
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Mission ID"
+// @Success 200 {object} utils.Response{data=Mission}
+// @Router /mahasiswa/missions/{id} [get]
+func (h *MissionHandler) GetStudentMission(c *gin.Context) {
+	missionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid mission ID", nil)
+		return
+	}
+
+	mission, err := h.service.GetMissionForStudent(uint(missionID), c.GetUint("user_id"))
+	if err != nil {
+		statusCode := http.StatusBadRequest
+		if err.Error() == "mission not found" {
+			statusCode = http.StatusNotFound
+		}
+		utils.ErrorResponse(c, statusCode, err.Error(), nil)
 		return
 	}
 
@@ -244,7 +278,7 @@ func (h *MissionHandler) SubmitMission(c *gin.Context) {
 			// Process JSON request
 			submission, err := h.service.SubmitMission(&req, studentID)
 			if err != nil {
-				utils.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+				utils.ErrorFromErr(c, http.StatusBadRequest, err)
 				return
 			}
 			utils.SuccessResponse(c, http.StatusCreated, "Mission submitted successfully", submission)
@@ -305,7 +339,7 @@ func (h *MissionHandler) SubmitMission(c *gin.Context) {
 
 	submission, err := h.service.SubmitMission(&req, studentID)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		utils.ErrorFromErr(c, http.StatusBadRequest, err)
 		return
 	}
 
@@ -323,6 +357,76 @@ func (h *MissionHandler) SubmitMission(c *gin.Context) {
 	})
 }
 
+// StartQuiz handles starting or resuming a quiz attempt
+// @Summary Start quiz
+// @Description Open the next quiz attempt, or resume the running one; a timed quiz's clock starts now
+// @Tags Mahasiswa - Missions
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Mission ID"
+// @Success 200 {object} utils.Response{data=QuizSession}
+// @Router /mahasiswa/missions/{id}/start [post]
+func (h *MissionHandler) StartQuiz(c *gin.Context) {
+	studentID := c.GetUint("user_id")
+	missionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid mission ID", nil)
+		return
+	}
+
+	session, err := h.service.StartQuiz(uint(missionID), studentID)
+	if err != nil {
+		utils.ErrorFromErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Quiz started successfully", session)
+
+	// Log activity
+	h.auditService.LogActivity(audit.CreateAuditParams{
+		UserID:    studentID,
+		Action:    "START_QUIZ",
+		Entity:    "MISSION",
+		EntityID:  uint(missionID),
+		Details:   fmt.Sprintf("Student started quiz attempt %d", session.Attempt),
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+}
+
+// SaveQuizAnswers handles autosaving the answers of a running quiz
+// @Summary Autosave quiz answers
+// @Description Store the answers given so far; they are submitted if the time limit runs out
+// @Tags Mahasiswa - Missions
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Mission ID"
+// @Param request body SaveAnswersRequest true "Answers so far"
+// @Success 200 {object} utils.Response{data=QuizSession}
+// @Router /mahasiswa/missions/{id}/session [put]
+func (h *MissionHandler) SaveQuizAnswers(c *gin.Context) {
+	missionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid mission ID", nil)
+		return
+	}
+
+	var req SaveAnswersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	session, err := h.service.SaveQuizAnswers(uint(missionID), c.GetUint("user_id"), req.Answers)
+	if err != nil {
+		utils.ErrorFromErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Answers saved", session)
+}
+
 // GetAllSubmissions handles getting submissions
 // @Summary Get submissions
 // @Description Get mission submissions with filters
@@ -354,7 +458,7 @@ func (h *MissionHandler) GetAllSubmissions(c *gin.Context) {
 	}
 
 	// Security: If requester is a student, only show their own submissions
-	userRole := c.GetString("user_role")
+	userRole := c.GetString("role")
 	if userRole == "mahasiswa" {
 		params.StudentID = c.GetUint("user_id")
 	}
@@ -368,6 +472,58 @@ func (h *MissionHandler) GetAllSubmissions(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusOK, "Submissions retrieved successfully", response)
 }
 
+// GetSubmissionHistory handles a dosen viewing a student's attempts at a mission
+// @Summary Get submission history
+// @Description Every attempt of a student at a mission with the attempt policy state
+// @Tags Dosen - Missions
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Mission ID"
+// @Param student_id path int true "Student ID"
+// @Success 200 {object} utils.Response{data=SubmissionHistory}
+// @Router /dosen/missions/{id}/submissions/{student_id} [get]
+func (h *MissionHandler) GetSubmissionHistory(c *gin.Context) {
+	studentID, err := strconv.ParseUint(c.Param("student_id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid student ID", nil)
+		return
+	}
+	h.respondSubmissionHistory(c, uint(studentID))
+}
+
+// GetMySubmissionHistory handles a student viewing their own attempts
+// @Summary Get my submission history
+// @Description Own attempts at a mission with what the attempt policy allows next
+// @Tags Mahasiswa - Missions
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Mission ID"
+// @Success 200 {object} utils.Response{data=SubmissionHistory}
+// @Router /mahasiswa/missions/{id}/submissions [get]
+func (h *MissionHandler) GetMySubmissionHistory(c *gin.Context) {
+	h.respondSubmissionHistory(c, c.GetUint("user_id"))
+}
+
+func (h *MissionHandler) respondSubmissionHistory(c *gin.Context, studentID uint) {
+	missionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid mission ID", nil)
+		return
+	}
+
+	history, err := h.service.GetSubmissionHistory(uint(missionID), studentID)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "mission not found" {
+			statusCode = http.StatusNotFound
+		}
+		utils.ErrorResponse(c, statusCode, err.Error(), nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Submission history retrieved successfully", history)
+}
+
 // ReviewSubmission handles reviewing student submission
 // @Summary Review submission
 // @Description Dosen reviews and approves/rejects submission
@@ -394,7 +550,7 @@ func (h *MissionHandler) ReviewSubmission(c *gin.Context) {
 	}
 
 	if err := h.service.ReviewSubmission(uint(submissionID), &req, dosenID); err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		utils.ErrorFromErr(c, http.StatusBadRequest, err)
 		return
 	}
 
@@ -412,6 +568,51 @@ func (h *MissionHandler) ReviewSubmission(c *gin.Context) {
 	})
 }
 
+// OverrideSubmission handles replacing the grade of a reviewed submission
+// @Summary Override submission grade
+// @Description Dosen replaces the grade of an auto-graded or reviewed submission; the reward is topped up or reclaimed
+// @Tags Dosen - Missions
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Submission ID"
+// @Param request body OverrideSubmissionRequest true "New grade"
+// @Success 200 {object} utils.Response{data=MissionSubmission}
+// @Router /dosen/submissions/{id}/override [post]
+func (h *MissionHandler) OverrideSubmission(c *gin.Context) {
+	dosenID := c.GetUint("user_id")
+	submissionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid submission ID", nil)
+		return
+	}
+
+	var req OverrideSubmissionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	submission, err := h.service.OverrideSubmission(uint(submissionID), &req, dosenID)
+	if err != nil {
+		utils.ErrorFromErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Submission grade overridden successfully", submission)
+
+	// Log activity
+	h.auditService.LogActivity(audit.CreateAuditParams{
+		UserID:    dosenID,
+		Action:    "OVERRIDE_SUBMISSION",
+		Entity:    "SUBMISSION",
+		EntityID:  submission.ID,
+		Details:   fmt.Sprintf("Dosen overrode submission grade: %s, score %d, reward %d", req.Status, req.Score, submission.RewardAmount),
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+}
+
 // GetDosenStats handles getting Dosen dashboard stats
 // @Summary Get Dosen stats
 // @Description Get statistics for Dosen dashboard
@@ -431,3 +632,330 @@ func (h *MissionHandler) GetDosenStats(c *gin.Context) {
 
 	utils.SuccessResponse(c, http.StatusOK, "Stats retrieved successfully", stats)
 }
+
+// ========================================
+// QUESTION BANK (Dosen)
+// ========================================
+
+// bankErrorStatus maps question bank errors to HTTP statuses
+func bankErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrBankQuestionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrBankQuestionNotOwner):
+		return http.StatusForbidden
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// GetBankQuestions handles listing the question bank
+// @Summary List bank questions
+// @Description List reusable quiz questions, filtered by tag, course and difficulty
+// @Tags Dosen - Question Bank
+// @Security BearerAuth
+// @Produce json
+// @Param tag query string false "Filter by tag"
+// @Param course query string false "Filter by course"
+// @Param difficulty query string false "Filter by difficulty (easy, medium, hard)"
+// @Param search query string false "Search question text"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} utils.Response{data=BankQuestionListResponse}
+// @Router /dosen/question-bank [get]
+func (h *MissionHandler) GetBankQuestions(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	response, err := h.service.GetBankQuestions(BankQuestionListParams{
+		Tag:        c.Query("tag"),
+		Course:     c.Query("course"),
+		Difficulty: c.Query("difficulty"),
+		Search:     c.Query("search"),
+		Page:       page,
+		Limit:      limit,
+	})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve bank questions", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Bank questions retrieved successfully", response)
+}
+
+// CreateBankQuestion handles adding a question to the bank
+// @Summary Create bank question
+// @Description Add a reusable quiz question with tags, difficulty and course
+// @Tags Dosen - Question Bank
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body BankQuestionRequest true "Question"
+// @Success 201 {object} utils.Response{data=BankQuestion}
+// @Router /dosen/question-bank [post]
+func (h *MissionHandler) CreateBankQuestion(c *gin.Context) {
+	dosenID := c.GetUint("user_id")
+
+	var req BankQuestionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	question, err := h.service.CreateBankQuestion(&req, dosenID)
+	if err != nil {
+		utils.ErrorResponse(c, bankErrorStatus(err), err.Error(), nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Bank question created successfully", question)
+
+	// Log activity
+	h.auditService.LogActivity(audit.CreateAuditParams{
+		UserID:    dosenID,
+		Action:    "CREATE_BANK_QUESTION",
+		Entity:    "BANK_QUESTION",
+		EntityID:  question.ID,
+		Details:   fmt.Sprintf("Dosen added bank question (%s, %s)", question.Difficulty, question.Course),
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+}
+
+// UpdateBankQuestion handles editing a bank question
+// @Summary Update bank question
+// @Description Replace a bank question; only its owner can edit it. Existing student draws keep their copy.
+// @Tags Dosen - Question Bank
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Bank question ID"
+// @Param request body BankQuestionRequest true "Question"
+// @Success 200 {object} utils.Response{data=BankQuestion}
+// @Router /dosen/question-bank/{id} [put]
+func (h *MissionHandler) UpdateBankQuestion(c *gin.Context) {
+	dosenID := c.GetUint("user_id")
+	questionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid question ID", nil)
+		return
+	}
+
+	var req BankQuestionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	question, err := h.service.UpdateBankQuestion(uint(questionID), &req, dosenID)
+	if err != nil {
+		utils.ErrorResponse(c, bankErrorStatus(err), err.Error(), nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Bank question updated successfully", question)
+
+	// Log activity
+	h.auditService.LogActivity(audit.CreateAuditParams{
+		UserID:    dosenID,
+		Action:    "UPDATE_BANK_QUESTION",
+		Entity:    "BANK_QUESTION",
+		EntityID:  question.ID,
+		Details:   "Dosen updated bank question ID: " + strconv.FormatUint(questionID, 10),
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+}
+
+// DeleteBankQuestion handles removing a bank question
+// @Summary Delete bank question
+// @Description Remove a bank question; only its owner can delete it
+// @Tags Dosen - Question Bank
+// @Security BearerAuth
+// @Param id path int true "Bank question ID"
+// @Success 200 {object} utils.Response
+// @Router /dosen/question-bank/{id} [delete]
+func (h *MissionHandler) DeleteBankQuestion(c *gin.Context) {
+	dosenID := c.GetUint("user_id")
+	questionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid question ID", nil)
+		return
+	}
+
+	if err := h.service.DeleteBankQuestion(uint(questionID), dosenID); err != nil {
+		utils.ErrorResponse(c, bankErrorStatus(err), err.Error(), nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Bank question deleted successfully", nil)
+
+	// Log activity
+	h.auditService.LogActivity(audit.CreateAuditParams{
+		UserID:    dosenID,
+		Action:    "DELETE_BANK_QUESTION",
+		Entity:    "BANK_QUESTION",
+		EntityID:  uint(questionID),
+		Details:   "Dosen deleted bank question ID: " + strconv.FormatUint(questionID, 10),
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+}
+
+// GetQuizDraw handles showing the questions a student drew
+// @Summary Get a student's quiz draw
+// @Description Questions a student drew for a bank-drawn quiz, in their order and with answers, as graded
+// @Tags Dosen - Question Bank
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Mission ID"
+// @Param student_id path int true "Student ID"
+// @Success 200 {object} utils.Response{data=QuizDraw}
+// @Router /dosen/missions/{id}/draws/{student_id} [get]
+func (h *MissionHandler) GetQuizDraw(c *gin.Context) {
+	missionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid mission ID", nil)
+		return
+	}
+	studentID, err := strconv.ParseUint(c.Param("student_id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid student ID", nil)
+		return
+	}
+
+	draw, err := h.service.GetQuizDraw(uint(missionID), uint(studentID))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, err.Error(), nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Quiz draw retrieved successfully", draw)
+}
+
+// ========================================
+// PEER REVIEW
+// ========================================
+
+// peerErrorStatus maps peer review errors to HTTP statuses
+func peerErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrPeerReviewNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrPeerReviewDone), errors.Is(err, ErrPeerReviewOverdue):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// GetMyPeerReviews handles listing the reviews assigned to a student
+// @Summary List my peer reviews
+// @Description Submissions assigned to the student for review, without their authors
+// @Tags Mahasiswa - Peer Review
+// @Security BearerAuth
+// @Produce json
+// @Param status query string false "Filter by status (assigned, completed, expired)"
+// @Success 200 {object} utils.Response{data=[]PeerReviewAssignment}
+// @Router /mahasiswa/reviews [get]
+func (h *MissionHandler) GetMyPeerReviews(c *gin.Context) {
+	reviewerID := c.GetUint("user_id")
+
+	reviews, err := h.service.GetMyPeerReviews(reviewerID, c.Query("status"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get peer reviews", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Peer reviews retrieved successfully", reviews)
+}
+
+// GetReceivedPeerReviews handles listing the feedback peers gave a student
+// @Summary List received peer reviews
+// @Description Completed peer reviews of the student's submissions, without their reviewers
+// @Tags Mahasiswa - Peer Review
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} utils.Response{data=[]PeerFeedback}
+// @Router /mahasiswa/reviews/received [get]
+func (h *MissionHandler) GetReceivedPeerReviews(c *gin.Context) {
+	studentID := c.GetUint("user_id")
+
+	feedback, err := h.service.GetReceivedPeerReviews(studentID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get peer feedback", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Peer feedback retrieved successfully", feedback)
+}
+
+// SubmitPeerReview handles a student completing a peer review
+// @Summary Submit peer review
+// @Description Score an assigned submission, by rubric when the mission has one; pays the mission's peer reward
+// @Tags Mahasiswa - Peer Review
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Peer review ID"
+// @Param request body PeerReviewRequest true "Review"
+// @Success 200 {object} utils.Response{data=PeerReview}
+// @Router /mahasiswa/reviews/{id} [post]
+func (h *MissionHandler) SubmitPeerReview(c *gin.Context) {
+	reviewerID := c.GetUint("user_id")
+	reviewID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid review ID", nil)
+		return
+	}
+
+	var req PeerReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	review, err := h.service.SubmitPeerReview(uint(reviewID), reviewerID, &req)
+	if err != nil {
+		utils.ErrorResponse(c, peerErrorStatus(err), err.Error(), nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Peer review submitted successfully", review)
+
+	// Log activity
+	h.auditService.LogActivity(audit.CreateAuditParams{
+		UserID:    reviewerID,
+		Action:    "SUBMIT_PEER_REVIEW",
+		Entity:    "PEER_REVIEW",
+		EntityID:  review.ID,
+		Details:   fmt.Sprintf("Student peer reviewed a submission of mission %d: score %d, reward %d", review.MissionID, review.Score, review.RewardAmount),
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+}
+
+// GetMissionPeerReviews handles listing the peer reviews of a mission
+// @Summary Get mission peer reviews
+// @Description Every peer review of a mission with reviewer and author names
+// @Tags Dosen - Missions
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Mission ID"
+// @Success 200 {object} utils.Response{data=[]PeerReviewDetail}
+// @Router /dosen/missions/{id}/peer-reviews [get]
+func (h *MissionHandler) GetMissionPeerReviews(c *gin.Context) {
+	missionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid mission ID", nil)
+		return
+	}
+
+	reviews, err := h.service.GetMissionPeerReviews(uint(missionID))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, err.Error(), nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Peer reviews retrieved successfully", reviews)
+}
@@ -22,25 +22,47 @@ func (jo *JSONOptions) Scan(value interface{}) error {
 }
 
 type Mission struct {
-	ID          uint              `json:"id" gorm:"primaryKey"`
-	CreatorID   uint              `json:"creator_id" gorm:"column:creator_id;not null"`
-	Title       string            `json:"title" gorm:"not null"`
-	Description string            `json:"description" gorm:"type:text"`
-	Type        string            `json:"type" gorm:"type:enum('quiz','task','assignment');not null"`
-	Points      int               `json:"points" gorm:"column:points_reward;not null"`
-	Deadline    *time.Time        `json:"deadline" gorm:"column:deadline"`
-	Status      string            `json:"status" gorm:"type:enum('active','inactive','expired');default:'active'"`
-	Questions   []MissionQuestion `json:"questions,omitempty" gorm:"foreignKey:MissionID;constraint:OnDelete:CASCADE"`
-	CreatedAt   time.Time         `json:"created_at"`
-	UpdatedAt   time.Time         `json:"updated_at"`
+	ID               uint              `json:"id" gorm:"primaryKey"`
+	CreatorID        uint              `json:"creator_id" gorm:"column:creator_id;not null"`
+	Title            string            `json:"title" gorm:"not null"`
+	Description      string            `json:"description" gorm:"type:text"`
+	Type             string            `json:"type" gorm:"type:enum('quiz','task','assignment');not null"`
+	Points           int               `json:"points" gorm:"column:points_reward;not null"`
+	Asset            string            `json:"asset" gorm:"size:30;default:'points';not null"` // Point asset the reward is paid in
+	Deadline         *time.Time        `json:"deadline" gorm:"column:deadline"`
+	Status           string            `json:"status" gorm:"type:enum('active','inactive','expired');default:'active'"`
+	Questions        []MissionQuestion `json:"questions,omitempty" gorm:"foreignKey:MissionID;constraint:OnDelete:CASCADE"`
+	MaxAttempts      int               `json:"max_attempts" gorm:"default:1;not null"`
+	CooldownMinutes  int               `json:"cooldown_minutes" gorm:"default:0;not null"`                                                   // Wait after an attempt before the next one
+	ResubmitPolicy   string            `json:"resubmit_policy" gorm:"type:enum('after_rejection','after_review');default:'after_rejection'"` // When another attempt is allowed
+	ScoreRule        string            `json:"score_rule" gorm:"type:enum('latest','best');default:'latest'"`                                // Which reviewed attempt's score counts
+	PayoutRule       string            `json:"payout_rule" gorm:"type:enum('proportional','threshold','bands');default:'proportional'"`      // Scored reward: points x score%, all points from pass_score, or payout_bands
+	PassScore        int               `json:"pass_score" gorm:"default:0;not null"`                                                         // Minimum quiz score (0-100) to pass
+	DrawCount        int               `json:"draw_count" gorm:"default:0;not null"`                                                         // Questions drawn per student from the bank, 0 = own questions
+	DrawTags         []string          `json:"draw_tags" gorm:"serializer:json"`                                                             // Bank questions with any of these tags, empty = any
+	DrawCourse       string            `json:"draw_course" gorm:"size:100"`
+	DrawDifficulty   string            `json:"draw_difficulty" gorm:"size:10"`
+	TimeLimitMinutes int               `json:"time_limit_minutes" gorm:"default:0;not null"` // Quiz time from start, 0 = untimed
+	Rubric           []RubricCriterion `json:"rubric,omitempty" gorm:"serializer:json"`      // Criteria reviewers score task and assignment submissions on
+	PayoutBands      []PayoutBand      `json:"payout_bands,omitempty" gorm:"serializer:json"`
+	PeerReviewers    int               `json:"peer_reviewers" gorm:"default:0;not null"`                                  // Students reviewing each submission after the deadline, 0 = dosen reviews
+	PeerReward       int               `json:"peer_reward" gorm:"default:0;not null"`                                     // Paid per completed peer review
+	PeerAggregate    string            `json:"peer_aggregate" gorm:"type:enum('median','trimmed_mean');default:'median'"` // How peer scores combine into the grade
+	PeerReviewHours  int               `json:"peer_review_hours" gorm:"default:72;not null"`                              // Review window after the deadline
+	PeerAssignedAt   *time.Time        `json:"peer_assigned_at"`
+	CreatedAt        time.Time         `json:"created_at"`
+	UpdatedAt        time.Time         `json:"updated_at"`
 }
 
 type MissionQuestion struct {
 	ID        uint        `json:"id" gorm:"primaryKey"`
 	MissionID uint        `json:"mission_id" gorm:"not null;index"`
 	Question  string      `json:"question" gorm:"type:text;not null"`
-	Options   JSONOptions `json:"options" gorm:"type:json"` // Array of strings (options)
-	Answer    string      `json:"answer" gorm:"not null"`   // Correct answer or index
+	Options   JSONOptions `json:"options" gorm:"type:json"`         // Array of strings (options)
+	Answer    string      `json:"answer,omitempty" gorm:"not null"` // Correct answer; a JSON array of options for multi_select
+	MatchType string      `json:"match_type" gorm:"type:enum('exact','case_insensitive','multi_select','numeric');default:'exact'"`
+	Tolerance float64     `json:"tolerance,omitempty" gorm:"default:0"` // Allowed difference for numeric answers
+	Weight    int         `json:"weight" gorm:"default:1;not null"`
 }
 
 func (MissionQuestion) TableName() string {
@@ -52,45 +74,110 @@ func (Mission) TableName() string {
 }
 
 type MissionSubmission struct {
-	ID         uint      `json:"id" gorm:"primaryKey"`
-	MissionID  uint      `json:"mission_id" gorm:"not null;index"`
-	StudentID  uint      `json:"student_id" gorm:"not null;index"`
-	Content    string    `json:"content" gorm:"column:submission_content;type:text"`
-	FileURL    string    `json:"file_url" gorm:"size:500"`
-	Score      int       `json:"score" gorm:"default:0"` // Will be added by AutoMigrate
-	Status     string    `json:"status" gorm:"type:enum('pending','approved','rejected');default:'pending'"`
-	ReviewedBy *uint     `json:"reviewed_by" gorm:"column:validated_by"`
-	ReviewNote string    `json:"review_note" gorm:"column:validation_note;type:text"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	ID           uint              `json:"id" gorm:"primaryKey"`
+	MissionID    uint              `json:"mission_id" gorm:"not null;index"`
+	StudentID    uint              `json:"student_id" gorm:"not null;index"`
+	Content      string            `json:"content" gorm:"column:submission_content;type:text"`
+	FileURL      string            `json:"file_url" gorm:"size:500"`
+	Score        int               `json:"score" gorm:"default:0"` // Will be added by AutoMigrate
+	Status       string            `json:"status" gorm:"type:enum('pending','approved','rejected');default:'pending'"`
+	ReviewedBy   *uint             `json:"reviewed_by" gorm:"column:validated_by"`
+	ReviewNote   string            `json:"review_note" gorm:"column:validation_note;type:text"`
+	Attempt      int               `json:"attempt" gorm:"default:1;not null"`
+	Rewarded     bool              `json:"rewarded" gorm:"default:false;not null"` // The mission reward was paid for this attempt
+	RewardAmount int               `json:"reward_amount" gorm:"default:0;not null"`
+	RewardOwed   int               `json:"reward_owed" gorm:"default:0;not null"` // Reward a lower grade could not reclaim from the wallet
+	AutoGraded   bool              `json:"auto_graded" gorm:"default:false;not null"`
+	GradeDetails []QuestionGrade   `json:"grade_details,omitempty" gorm:"serializer:json"`
+	StartedAt    *time.Time        `json:"started_at"`  // When the quiz session of this attempt started
+	FinishedAt   *time.Time        `json:"finished_at"` // When it was submitted, or closed at its time limit
+	RubricResult []CriterionResult `json:"rubric_result,omitempty" gorm:"serializer:json"`
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
 }
 
 func (MissionSubmission) TableName() string {
 	return "mission_submissions"
 }
 
+// QuestionGrade is how one quiz answer was graded
+type QuestionGrade struct {
+	QuestionID uint `json:"question_id"`
+	Correct    bool `json:"correct"`
+	Weight     int  `json:"weight"`
+}
+
 type CreateMissionRequest struct {
 	Title       string            `json:"title" binding:"required"`
 	Description string            `json:"description"`
 	Type        string            `json:"type" binding:"required,oneof=quiz task assignment"`
 	Points      int               `json:"points" binding:"required,gt=0"`
+	Asset       string            `json:"asset" binding:"omitempty,max=30"` // Empty = default asset
 	Deadline    *time.Time        `json:"deadline"`
 	Questions   []QuestionRequest `json:"questions"`
+
+	MaxAttempts     int    `json:"max_attempts" binding:"omitempty,gte=1,lte=20"` // Default 1
+	CooldownMinutes int    `json:"cooldown_minutes" binding:"gte=0,lte=10080"`
+	ResubmitPolicy  string `json:"resubmit_policy" binding:"omitempty,oneof=after_rejection after_review"`
+	ScoreRule       string `json:"score_rule" binding:"omitempty,oneof=latest best"`
+	PayoutRule      string `json:"payout_rule" binding:"omitempty,oneof=proportional threshold bands"`
+	PassScore       int    `json:"pass_score" binding:"gte=0,lte=100"`
+
+	Rubric      []RubricCriterion `json:"rubric"` // Tasks and assignments only
+	PayoutBands []PayoutBand      `json:"payout_bands"`
+
+	DrawCount      int      `json:"draw_count" binding:"gte=0,lte=100"` // Draw from the question bank instead of questions
+	DrawTags       []string `json:"draw_tags"`
+	DrawCourse     string   `json:"draw_course" binding:"max=100"`
+	DrawDifficulty string   `json:"draw_difficulty"` // easy, medium, hard or empty for any
+
+	TimeLimitMinutes int `json:"time_limit_minutes" binding:"gte=0,lte=600"` // Quizzes only
+
+	PeerReviewers   int    `json:"peer_reviewers" binding:"gte=0,lte=10"` // Tasks and assignments with a deadline only
+	PeerReward      int    `json:"peer_reward" binding:"gte=0"`
+	PeerAggregate   string `json:"peer_aggregate" binding:"omitempty,oneof=median trimmed_mean"` // Default median
+	PeerReviewHours int    `json:"peer_review_hours" binding:"omitempty,gte=1,lte=720"`          // Default 72
 }
 
 type QuestionRequest struct {
-	Question string   `json:"question" binding:"required"`
-	Options  []string `json:"options"`
-	Answer   string   `json:"answer" binding:"required"`
+	Question  string   `json:"question" binding:"required"`
+	Options   []string `json:"options"`
+	Answer    string   `json:"answer" binding:"required"`
+	MatchType string   `json:"match_type" binding:"omitempty,oneof=exact case_insensitive multi_select numeric"`
+	Tolerance float64  `json:"tolerance" binding:"gte=0"`
+	Weight    int      `json:"weight" binding:"omitempty,gte=1,lte=100"` // Default 1
 }
 
 type UpdateMissionRequest struct {
 	Title       string            `json:"title,omitempty"`
 	Description string            `json:"description,omitempty"`
 	Points      int               `json:"points,omitempty" binding:"omitempty,gt=0"`
+	Asset       string            `json:"asset,omitempty" binding:"omitempty,max=30"`
 	Deadline    *time.Time        `json:"deadline,omitempty"`
 	Status      string            `json:"status,omitempty" binding:"omitempty,oneof=active inactive expired"`
 	Questions   []QuestionRequest `json:"questions,omitempty"`
+
+	MaxAttempts     int    `json:"max_attempts,omitempty" binding:"omitempty,gte=1,lte=20"`
+	CooldownMinutes *int   `json:"cooldown_minutes,omitempty" binding:"omitempty,gte=0,lte=10080"`
+	ResubmitPolicy  string `json:"resubmit_policy,omitempty" binding:"omitempty,oneof=after_rejection after_review"`
+	ScoreRule       string `json:"score_rule,omitempty" binding:"omitempty,oneof=latest best"`
+	PayoutRule      string `json:"payout_rule,omitempty" binding:"omitempty,oneof=proportional threshold bands"`
+	PassScore       *int   `json:"pass_score,omitempty" binding:"omitempty,gte=0,lte=100"`
+
+	Rubric      *[]RubricCriterion `json:"rubric,omitempty"`
+	PayoutBands *[]PayoutBand      `json:"payout_bands,omitempty"`
+
+	DrawCount      *int      `json:"draw_count,omitempty" binding:"omitempty,gte=0,lte=100"`
+	DrawTags       *[]string `json:"draw_tags,omitempty"`
+	DrawCourse     *string   `json:"draw_course,omitempty" binding:"omitempty,max=100"`
+	DrawDifficulty *string   `json:"draw_difficulty,omitempty"`
+
+	TimeLimitMinutes *int `json:"time_limit_minutes,omitempty" binding:"omitempty,gte=0,lte=600"`
+
+	PeerReviewers   *int   `json:"peer_reviewers,omitempty" binding:"omitempty,gte=0,lte=10"`
+	PeerReward      *int   `json:"peer_reward,omitempty" binding:"omitempty,gte=0"`
+	PeerAggregate   string `json:"peer_aggregate,omitempty" binding:"omitempty,oneof=median trimmed_mean"`
+	PeerReviewHours int    `json:"peer_review_hours,omitempty" binding:"omitempty,gte=1,lte=720"`
 }
 
 type SubmitMissionRequest struct {
@@ -105,10 +192,20 @@ type AnswerSubmission struct {
 	Answer     string `json:"answer"`
 }
 
+// OverrideSubmissionRequest replaces the grade of a reviewed or auto-graded
+// submission. For quizzes the score is a percentage.
+type OverrideSubmissionRequest struct {
+	Status       string        `json:"status" binding:"required,oneof=approved rejected"`
+	Score        int           `json:"score" binding:"gte=0"`
+	ReviewNote   string        `json:"review_note"`
+	RubricScores []RubricScore `json:"rubric_scores" binding:"dive"` // Replaces score on rubric missions
+}
+
 type ReviewSubmissionRequest struct {
-	Status     string `json:"status" binding:"required,oneof=approved rejected"`
-	Score      int    `json:"score" binding:"gte=0"`
-	ReviewNote string `json:"review_note"`
+	Status       string        `json:"status" binding:"required,oneof=approved rejected"`
+	Score        int           `json:"score" binding:"gte=0"`
+	ReviewNote   string        `json:"review_note"`
+	RubricScores []RubricScore `json:"rubric_scores" binding:"dive"` // Replaces score on rubric missions
 }
 
 type MissionWithCreator struct {
@@ -125,6 +222,24 @@ type SubmissionWithDetails struct {
 	ReviewerName string `json:"reviewer_name,omitempty"`
 }
 
+// SubmissionHistory is every attempt of one student at a mission and what
+// the attempt policy allows next
+type SubmissionHistory struct {
+	MissionID      uint                `json:"mission_id"`
+	StudentID      uint                `json:"student_id"`
+	MaxAttempts    int                 `json:"max_attempts"`
+	AttemptsUsed   int                 `json:"attempts_used"`
+	AttemptsLeft   int                 `json:"attempts_left"`
+	ResubmitPolicy string              `json:"resubmit_policy"`
+	ScoreRule      string              `json:"score_rule"`
+	EffectiveScore *int                `json:"effective_score"` // Per the score rule, nil until an attempt is reviewed
+	Rewarded       bool                `json:"rewarded"`
+	CanSubmit      bool                `json:"can_submit"`
+	BlockedReason  string              `json:"blocked_reason,omitempty"`
+	NextAttemptAt  *time.Time          `json:"next_attempt_at,omitempty"`
+	Attempts       []MissionSubmission `json:"attempts"`
+}
+
 type MissionListParams struct {
 	Type      string
 	Status    string
@@ -0,0 +1,408 @@
+package mission
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPeerReviewNotFound = errors.New("peer review not found")
+	ErrPeerReviewDone     = errors.New("this peer review is already closed")
+	ErrPeerReviewOverdue  = errors.New("the review window for this submission has closed")
+)
+
+// PeerReview assigns one student to review another's submission after the
+// mission deadline. Reviewers never see whose submission it is.
+type PeerReview struct {
+	ID           uint              `json:"id" gorm:"primaryKey"`
+	MissionID    uint              `json:"mission_id" gorm:"not null;index"`
+	SubmissionID uint              `json:"submission_id" gorm:"not null;uniqueIndex:idx_peer_review_pair"`
+	ReviewerID   uint              `json:"reviewer_id" gorm:"not null;uniqueIndex:idx_peer_review_pair;index"`
+	Status       string            `json:"status" gorm:"type:enum('assigned','completed','expired');default:'assigned';index"`
+	Score        int               `json:"score" gorm:"default:0"`
+	Comment      string            `json:"comment" gorm:"type:text"`
+	RubricResult []CriterionResult `json:"rubric_result,omitempty" gorm:"serializer:json"`
+	RewardAmount int               `json:"reward_amount" gorm:"default:0;not null"`
+	DueAt        time.Time         `json:"due_at" gorm:"not null;index"`
+	CompletedAt  *time.Time        `json:"completed_at"`
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+}
+
+func (PeerReview) TableName() string {
+	return "peer_reviews"
+}
+
+type PeerReviewRequest struct {
+	Score        int           `json:"score" binding:"gte=0,lte=100"` // Ignored when the mission has a rubric
+	Comment      string        `json:"comment"`
+	RubricScores []RubricScore `json:"rubric_scores" binding:"dive"`
+}
+
+// PeerReviewAssignment is a review as its reviewer sees it: the submission
+// and what to score it on, without the author
+type PeerReviewAssignment struct {
+	PeerReview
+	MissionTitle       string            `json:"mission_title"`
+	MissionDescription string            `json:"mission_description"`
+	Rubric             []RubricCriterion `json:"rubric,omitempty" gorm:"serializer:json"`
+	PeerReward         int               `json:"peer_reward"`
+	Asset              string            `json:"asset"`
+	Content            string            `json:"content"`
+	FileURL            string            `json:"file_url"`
+}
+
+// PeerFeedback is a completed review as the reviewed student sees it,
+// without the reviewer
+type PeerFeedback struct {
+	ID           uint              `json:"id"`
+	MissionID    uint              `json:"mission_id"`
+	MissionTitle string            `json:"mission_title"`
+	SubmissionID uint              `json:"submission_id"`
+	Score        int               `json:"score"`
+	Comment      string            `json:"comment"`
+	RubricResult []CriterionResult `json:"rubric_result,omitempty" gorm:"serializer:json"`
+	CompletedAt  *time.Time        `json:"completed_at"`
+}
+
+// PeerReviewDetail is a review with both students named, for the dosen
+type PeerReviewDetail struct {
+	PeerReview
+	ReviewerName string `json:"reviewer_name"`
+	StudentID    uint   `json:"student_id"`
+	StudentName  string `json:"student_name"`
+}
+
+// checkPeerReview validates a mission's peer review settings
+func checkPeerReview(mission *Mission) error {
+	if mission.PeerReviewers == 0 {
+		return nil
+	}
+	if mission.Type == "quiz" {
+		return errors.New("quizzes are graded by their questions and cannot be peer reviewed")
+	}
+	if mission.Deadline == nil {
+		return errors.New("peer reviewed missions need a deadline to assign reviews at")
+	}
+	return nil
+}
+
+// peerScheduleChanged reports whether an update moves the settings that
+// assigned peer reviews were built from
+func peerScheduleChanged(mission *Mission, req *UpdateMissionRequest) bool {
+	if req.PeerReviewers != nil && *req.PeerReviewers != mission.PeerReviewers {
+		return true
+	}
+	if req.PeerReviewHours > 0 && req.PeerReviewHours != mission.PeerReviewHours {
+		return true
+	}
+	return req.Deadline != nil && (mission.Deadline == nil || !req.Deadline.Equal(*mission.Deadline))
+}
+
+// aggregatePeerScores combines peer scores into one grade: their median, or
+// their mean without the highest and lowest score once there are three
+func aggregatePeerScores(method string, scores []int) int {
+	sorted := append([]int(nil), scores...)
+	sort.Ints(sorted)
+	n := len(sorted)
+
+	if method == "trimmed_mean" {
+		if n >= 3 {
+			sorted = sorted[1 : n-1]
+		}
+		sum := 0
+		for _, score := range sorted {
+			sum += score
+		}
+		return int(math.Round(float64(sum) / float64(len(sorted))))
+	}
+
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return int(math.Round(float64(sorted[n/2-1]+sorted[n/2]) / 2))
+}
+
+// AssignPeerReviews hands out the submissions of peer reviewed missions
+// whose deadline has passed. Each student's latest pending attempt goes to
+// the next peer_reviewers students in a shuffled ring, so everyone reviews
+// as many submissions as they receive. A mission that fails is logged and
+// retried on the next run.
+func (s *MissionService) AssignPeerReviews() (int64, error) {
+	now := s.db.NowFunc()
+	missions, err := s.repo.FindMissionsDueForPeerReview(now)
+	if err != nil {
+		return 0, err
+	}
+
+	var assigned int64
+	for i := range missions {
+		mission := &missions[i]
+		var created int64
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			// Another run may have claimed the mission first
+			claimed, err := s.repo.ClaimPeerAssignment(tx, mission.ID, now)
+			if err != nil || !claimed {
+				return err
+			}
+
+			submissions, err := s.repo.FindLatestSubmissions(tx, mission.ID)
+			if err != nil {
+				return err
+			}
+			var pending []MissionSubmission
+			for _, sub := range submissions {
+				if sub.Status == "pending" {
+					pending = append(pending, sub)
+				}
+			}
+			// A lone submission has no peers and is left for the dosen
+			if len(pending) < 2 {
+				return nil
+			}
+
+			rand.Shuffle(len(pending), func(i, j int) { pending[i], pending[j] = pending[j], pending[i] })
+			reviewers := min(mission.PeerReviewers, len(pending)-1)
+			dueAt := mission.Deadline.Add(time.Duration(mission.PeerReviewHours) * time.Hour)
+			reviews := make([]PeerReview, 0, len(pending)*reviewers)
+			for i, sub := range pending {
+				for k := 1; k <= reviewers; k++ {
+					reviews = append(reviews, PeerReview{
+						MissionID:    mission.ID,
+						SubmissionID: sub.ID,
+						ReviewerID:   pending[(i+k)%len(pending)].StudentID,
+						Status:       "assigned",
+						DueAt:        dueAt,
+					})
+				}
+			}
+			if err := s.repo.CreatePeerReviews(tx, reviews); err != nil {
+				return err
+			}
+			created = int64(len(reviews))
+			return nil
+		})
+		if err != nil {
+			log.Printf("[PeerReview] failed to assign reviews of mission %d: %v", mission.ID, err)
+			continue
+		}
+		assigned += created
+	}
+	return assigned, nil
+}
+
+// GetMyPeerReviews returns the reviews assigned to a student, newest first
+func (s *MissionService) GetMyPeerReviews(reviewerID uint, status string) ([]PeerReviewAssignment, error) {
+	return s.repo.FindPeerReviewAssignments(reviewerID, status)
+}
+
+// GetReceivedPeerReviews returns the completed reviews of a student's
+// submissions, without the reviewers
+func (s *MissionService) GetReceivedPeerReviews(studentID uint) ([]PeerFeedback, error) {
+	return s.repo.FindReceivedPeerReviews(studentID)
+}
+
+// GetMissionPeerReviews returns every review of a mission for its dosen
+func (s *MissionService) GetMissionPeerReviews(missionID uint) ([]PeerReviewDetail, error) {
+	if _, err := s.repo.FindByID(missionID); err != nil {
+		return nil, err
+	}
+	return s.repo.FindMissionPeerReviews(missionID)
+}
+
+// SubmitPeerReview records a student's review and pays them the mission's
+// peer reward. The last review of a submission grades it.
+func (s *MissionService) SubmitPeerReview(reviewID, reviewerID uint, req *PeerReviewRequest) (*PeerReview, error) {
+	review, err := s.repo.FindPeerReview(s.db, reviewID)
+	if err != nil {
+		return nil, err
+	}
+	if review.ReviewerID != reviewerID {
+		return nil, ErrPeerReviewNotFound
+	}
+	submission, err := s.repo.FindSubmissionByID(review.SubmissionID)
+	if err != nil {
+		return nil, err
+	}
+	mission, err := s.repo.FindByID(review.MissionID)
+	if err != nil {
+		return nil, err
+	}
+
+	score, results := req.Score, []CriterionResult(nil)
+	if len(mission.Rubric) > 0 {
+		if score, results, err = scoreRubric(mission.Rubric, req.RubricScores); err != nil {
+			return nil, err
+		}
+	}
+
+	now := s.db.NowFunc()
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// Reviews of one submission are serialised on its author, like the
+		// author's own submissions and dosen reviews
+		if _, err := s.repo.LockAttempts(tx, mission.ID, submission.StudentID); err != nil {
+			return err
+		}
+		if review, err = s.repo.FindPeerReview(tx, reviewID); err != nil {
+			return err
+		}
+		if review.Status != "assigned" {
+			return ErrPeerReviewDone
+		}
+		if now.After(review.DueAt) {
+			return ErrPeerReviewOverdue
+		}
+
+		updates := map[string]interface{}{
+			"status":        "completed",
+			"score":         score,
+			"comment":       strings.TrimSpace(req.Comment),
+			"reward_amount": mission.PeerReward,
+			"completed_at":  now,
+		}
+		if results != nil {
+			encoded, err := json.Marshal(results)
+			if err != nil {
+				return err
+			}
+			updates["rubric_result"] = string(encoded)
+		}
+		if err := s.repo.UpdatePeerReview(tx, reviewID, updates); err != nil {
+			return err
+		}
+		if mission.PeerReward > 0 {
+			if err := s.walletService.ProcessPeerReviewRewardWithTx(tx, reviewerID, mission.Asset, mission.PeerReward, mission.Title, mission.ID); err != nil {
+				return err
+			}
+		}
+
+		reviews, err := s.repo.FindSubmissionPeerReviews(tx, review.SubmissionID)
+		if err != nil {
+			return err
+		}
+		for _, r := range reviews {
+			if r.Status == "assigned" {
+				return nil
+			}
+		}
+		return s.gradeFromPeers(tx, mission, review.SubmissionID, reviews)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.FindPeerReview(s.db, reviewID)
+}
+
+// ClosePeerReviews expires reviews left undone past their window and grades
+// their submissions from the reviews that were completed. A submission that
+// fails to close is logged and retried on the next run.
+func (s *MissionService) ClosePeerReviews() (int64, error) {
+	now := s.db.NowFunc()
+	submissionIDs, err := s.repo.FindOverduePeerSubmissions(now)
+	if err != nil {
+		return 0, err
+	}
+
+	var closed int64
+	for _, submissionID := range submissionIDs {
+		expired, err := s.closePeerReviews(submissionID, now)
+		if err != nil {
+			log.Printf("[PeerReview] failed to close reviews of submission %d: %v", submissionID, err)
+			continue
+		}
+		closed += expired
+	}
+	return closed, nil
+}
+
+// closePeerReviews expires the overdue reviews of one submission and grades
+// it, returning how many reviews expired
+func (s *MissionService) closePeerReviews(submissionID uint, now time.Time) (int64, error) {
+	submission, err := s.repo.FindSubmissionByID(submissionID)
+	if err != nil {
+		return 0, err
+	}
+	mission, err := s.repo.FindByID(submission.MissionID)
+	if err != nil {
+		return 0, err
+	}
+
+	var expired int64
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := s.repo.LockAttempts(tx, mission.ID, submission.StudentID); err != nil {
+			return err
+		}
+		if expired, err = s.repo.ExpirePeerReviews(tx, submissionID, now); err != nil || expired == 0 {
+			return err
+		}
+
+		reviews, err := s.repo.FindSubmissionPeerReviews(tx, submissionID)
+		if err != nil {
+			return err
+		}
+		return s.gradeFromPeers(tx, mission, submissionID, reviews)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return expired, nil
+}
+
+// gradeFromPeers grades a pending submission with the aggregate of its
+// completed peer reviews and pays the reward like a dosen approval would.
+// A submission nobody reviewed, or one a dosen already graded, is left
+// alone. Callers hold the author's attempt lock.
+func (s *MissionService) gradeFromPeers(tx *gorm.DB, mission *Mission, submissionID uint, reviews []PeerReview) error {
+	var scores []int
+	for _, r := range reviews {
+		if r.Status == "completed" {
+			scores = append(scores, r.Score)
+		}
+	}
+	if len(scores) == 0 {
+		return nil
+	}
+
+	var submission MissionSubmission
+	if err := tx.First(&submission, submissionID).Error; err != nil {
+		return err
+	}
+	if submission.Status != "pending" {
+		return nil
+	}
+	attempts, err := s.repo.FindAttempts(tx, mission.ID, submission.StudentID)
+	if err != nil {
+		return err
+	}
+
+	score := aggregatePeerScores(mission.PeerAggregate, scores)
+	payout := scorePayout(mission, score)
+	status := "approved"
+	if payout == 0 {
+		status = "rejected"
+	}
+
+	submission.Status, submission.Score = status, score
+	updates, err := s.settleReward(tx, mission, &submission, attempts)
+	if err != nil {
+		return err
+	}
+	method := "median"
+	if mission.PeerAggregate == "trimmed_mean" {
+		method = "trimmed mean"
+	}
+	updates["status"] = status
+	updates["score"] = score
+	updates["validation_note"] = fmt.Sprintf("Peer reviewed: %s of %d scores", method, len(scores))
+	updates["auto_graded"] = true
+	return s.repo.UpdateSubmissionWithTx(tx, submissionID, updates)
+}
@@ -0,0 +1,39 @@
+package mission
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestAggregatePeerScores(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		scores []int
+		want   int
+	}{
+		{"median of one", "median", []int{72}, 72},
+		{"median of two rounds half up", "median", []int{70, 81}, 76},
+		{"median of odd count", "median", []int{90, 10, 60}, 60},
+		{"median of even count", "median", []int{40, 100, 80, 60}, 70},
+		{"median is the default", "", []int{50, 90, 70}, 70},
+		{"trimmed mean of one keeps it", "trimmed_mean", []int{64}, 64},
+		{"trimmed mean of two keeps both", "trimmed_mean", []int{20, 95}, 58},
+		{"trimmed mean of three keeps the middle", "trimmed_mean", []int{100, 0, 55}, 55},
+		{"trimmed mean drops one high and one low", "trimmed_mean", []int{10, 70, 80, 100, 90}, 80},
+		{"trimmed mean keeps repeated extremes", "trimmed_mean", []int{0, 0, 100, 100}, 50},
+		{"trimmed mean rounds", "trimmed_mean", []int{0, 66, 67, 100, 67}, 67},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scores := slices.Clone(tt.scores)
+			if got := aggregatePeerScores(tt.method, scores); got != tt.want {
+				t.Errorf("aggregatePeerScores(%q, %v) = %d, want %d", tt.method, tt.scores, got, tt.want)
+			}
+			if !slices.Equal(scores, tt.scores) {
+				t.Errorf("aggregatePeerScores reordered its input to %v", scores)
+			}
+		})
+	}
+}
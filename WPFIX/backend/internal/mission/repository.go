@@ -2,8 +2,10 @@ package mission
 
 import (
 	"errors"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type MissionRepository struct {
@@ -66,6 +68,14 @@ func (r *MissionRepository) Update(id uint, updates map[string]interface{}) erro
 	return r.db.Model(&Mission{}).Where("id = ?", id).Updates(updates).Error
 }
 
+// ExpireOverdue marks active missions whose deadline has passed as expired
+func (r *MissionRepository) ExpireOverdue(now time.Time) (int64, error) {
+	result := r.db.Model(&Mission{}).
+		Where("status = ? AND deadline IS NOT NULL AND deadline < ?", "active", now).
+		Update("status", "expired")
+	return result.RowsAffected, result.Error
+}
+
 func (r *MissionRepository) Delete(id uint) error {
 	return r.db.Delete(&Mission{}, id).Error
 }
@@ -75,6 +85,10 @@ func (r *MissionRepository) CreateSubmission(submission *MissionSubmission) erro
 	return r.db.Create(submission).Error
 }
 
+func (r *MissionRepository) CreateSubmissionWithTx(tx *gorm.DB, submission *MissionSubmission) error {
+	return tx.Create(submission).Error
+}
+
 func (r *MissionRepository) FindSubmissionByID(id uint) (*MissionSubmission, error) {
 	var submission MissionSubmission
 	err := r.db.First(&submission, id).Error
@@ -131,10 +145,311 @@ func (r *MissionRepository) UpdateSubmissionWithTx(tx *gorm.DB, id uint, updates
 	return tx.Model(&MissionSubmission{}).Where("id = ?", id).Updates(updates).Error
 }
 
-func (r *MissionRepository) CheckDuplicateSubmission(missionID, studentID uint) (bool, error) {
-	var count int64
-	err := r.db.Model(&MissionSubmission{}).
-		Where("mission_id = ? AND student_id = ?", missionID, studentID).
-		Count(&count).Error
-	return count > 0, err
+// LockAttempts locks the student's user row, serialising their submissions
+// and reviews, and returns their attempts at the mission oldest first
+func (r *MissionRepository) LockAttempts(tx *gorm.DB, missionID, studentID uint) ([]MissionSubmission, error) {
+	var locked struct{ ID uint }
+	if err := tx.Table("users").Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id").Where("id = ?", studentID).Scan(&locked).Error; err != nil {
+		return nil, err
+	}
+	return r.FindAttempts(tx, missionID, studentID)
+}
+
+// FindAttempts returns a student's submissions to a mission oldest first
+func (r *MissionRepository) FindAttempts(tx *gorm.DB, missionID, studentID uint) ([]MissionSubmission, error) {
+	var attempts []MissionSubmission
+	err := tx.Where("mission_id = ? AND student_id = ?", missionID, studentID).
+		Order("attempt ASC, id ASC").
+		Find(&attempts).Error
+	return attempts, err
+}
+
+// BackfillRewarded marks the first approved submission of each student and
+// mission as rewarded where none is, covering approvals paid before
+// submissions recorded their reward and its amount
+func (r *MissionRepository) BackfillRewarded() (int64, error) {
+	result := r.db.Exec(`UPDATE mission_submissions ms
+		JOIN (SELECT MIN(id) AS id FROM mission_submissions
+			WHERE status = 'approved'
+			GROUP BY mission_id, student_id
+			HAVING SUM(rewarded) = 0) first_approved ON first_approved.id = ms.id
+		SET ms.rewarded = true`)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	// Those approvals paid the mission's full points
+	err := r.db.Exec(`UPDATE mission_submissions ms
+		JOIN missions ON missions.id = ms.mission_id
+		SET ms.reward_amount = missions.points_reward
+		WHERE ms.rewarded = true AND ms.reward_amount = 0`).Error
+	return result.RowsAffected, err
+}
+
+// Question bank
+func (r *MissionRepository) FindBankQuestions(params BankQuestionListParams) ([]BankQuestion, int64, error) {
+	var questions []BankQuestion
+	var total int64
+
+	query := r.db.Model(&BankQuestion{})
+	if params.Tag != "" {
+		query = query.Where("JSON_CONTAINS(tags, JSON_QUOTE(?))", params.Tag)
+	}
+	if params.Course != "" {
+		query = query.Where("course = ?", params.Course)
+	}
+	if params.Difficulty != "" {
+		query = query.Where("difficulty = ?", params.Difficulty)
+	}
+	if params.Search != "" {
+		query = query.Where("question LIKE ?", "%"+params.Search+"%")
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (params.Page - 1) * params.Limit
+	err := query.Order("created_at DESC").
+		Limit(params.Limit).
+		Offset(offset).
+		Find(&questions).Error
+
+	return questions, total, err
+}
+
+func (r *MissionRepository) CreateBankQuestion(question *BankQuestion) error {
+	return r.db.Create(question).Error
+}
+
+func (r *MissionRepository) FindBankQuestion(id uint) (*BankQuestion, error) {
+	var question BankQuestion
+	err := r.db.First(&question, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrBankQuestionNotFound
+		}
+		return nil, err
+	}
+	return &question, nil
+}
+
+func (r *MissionRepository) SaveBankQuestion(question *BankQuestion) error {
+	return r.db.Save(question).Error
+}
+
+func (r *MissionRepository) DeleteBankQuestion(id uint) error {
+	return r.db.Delete(&BankQuestion{}, id).Error
+}
+
+// FindBankQuestionIDs returns the bank questions matching a draw; a question
+// matches when it has any of the filter's tags
+func (r *MissionRepository) FindBankQuestionIDs(tx *gorm.DB, filter bankFilter) ([]uint, error) {
+	query := tx.Model(&BankQuestion{})
+	if len(filter.Tags) > 0 {
+		tags := tx.Where("JSON_CONTAINS(tags, JSON_QUOTE(?))", filter.Tags[0])
+		for _, tag := range filter.Tags[1:] {
+			tags = tags.Or("JSON_CONTAINS(tags, JSON_QUOTE(?))", tag)
+		}
+		query = query.Where(tags)
+	}
+	if filter.Course != "" {
+		query = query.Where("course = ?", filter.Course)
+	}
+	if filter.Difficulty != "" {
+		query = query.Where("difficulty = ?", filter.Difficulty)
+	}
+
+	var ids []uint
+	err := query.Order("id").Pluck("id", &ids).Error
+	return ids, err
+}
+
+func (r *MissionRepository) FindBankQuestionsByID(tx *gorm.DB, ids []uint) ([]BankQuestion, error) {
+	var questions []BankQuestion
+	err := tx.Where("id IN ?", ids).Find(&questions).Error
+	return questions, err
+}
+
+func (r *MissionRepository) FindQuizDraw(tx *gorm.DB, missionID, studentID uint) (*QuizDraw, error) {
+	var draw QuizDraw
+	err := tx.Where("mission_id = ? AND student_id = ?", missionID, studentID).First(&draw).Error
+	if err != nil {
+		return nil, err
+	}
+	return &draw, nil
+}
+
+func (r *MissionRepository) CreateQuizDraw(tx *gorm.DB, draw *QuizDraw) error {
+	return tx.Create(draw).Error
+}
+
+// FindOpenQuizSession returns the student's latest unfinished quiz session
+func (r *MissionRepository) FindOpenQuizSession(tx *gorm.DB, missionID, studentID uint) (*QuizSession, error) {
+	var session QuizSession
+	err := tx.Where("mission_id = ? AND student_id = ? AND finished_at IS NULL", missionID, studentID).
+		Order("attempt DESC").
+		First(&session).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *MissionRepository) FindQuizSession(tx *gorm.DB, id uint) (*QuizSession, error) {
+	var session QuizSession
+	if err := tx.First(&session, id).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *MissionRepository) CreateQuizSession(tx *gorm.DB, session *QuizSession) error {
+	return tx.Create(session).Error
+}
+
+// SaveQuizSessionAnswers stores autosaved answers unless the session has
+// finished meanwhile
+func (r *MissionRepository) SaveQuizSessionAnswers(session *QuizSession) error {
+	return r.db.Model(session).Where("finished_at IS NULL").
+		Select("answers").
+		Updates(session).Error
+}
+
+func (r *MissionRepository) FinishQuizSession(tx *gorm.DB, id uint, finishedAt time.Time, submissionID *uint) error {
+	return tx.Model(&QuizSession{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"finished_at":   finishedAt,
+		"submission_id": submissionID,
+	}).Error
+}
+
+// FindExpiredQuizSessions returns unfinished sessions of existing missions
+// whose time ran out before the given time
+func (r *MissionRepository) FindExpiredQuizSessions(before time.Time) ([]QuizSession, error) {
+	var sessions []QuizSession
+	err := r.db.Joins("JOIN missions ON missions.id = quiz_sessions.mission_id").
+		Where("quiz_sessions.finished_at IS NULL AND quiz_sessions.expires_at < ?", before).
+		Order("quiz_sessions.expires_at ASC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// Peer review
+
+// FindMissionsDueForPeerReview returns peer reviewed missions whose deadline
+// has passed and whose reviews are not assigned yet
+func (r *MissionRepository) FindMissionsDueForPeerReview(now time.Time) ([]Mission, error) {
+	var missions []Mission
+	err := r.db.Where("peer_reviewers > 0 AND peer_assigned_at IS NULL AND deadline < ?", now).
+		Order("deadline ASC").
+		Find(&missions).Error
+	return missions, err
+}
+
+// ClaimPeerAssignment marks a mission's reviews as assigned, reporting false
+// if they already were
+func (r *MissionRepository) ClaimPeerAssignment(tx *gorm.DB, missionID uint, now time.Time) (bool, error) {
+	result := tx.Model(&Mission{}).
+		Where("id = ? AND peer_assigned_at IS NULL", missionID).
+		Update("peer_assigned_at", now)
+	return result.RowsAffected == 1, result.Error
+}
+
+// FindLatestSubmissions returns each student's latest attempt at a mission
+func (r *MissionRepository) FindLatestSubmissions(tx *gorm.DB, missionID uint) ([]MissionSubmission, error) {
+	var submissions []MissionSubmission
+	err := tx.Where("mission_id = ? AND id IN (?)", missionID,
+		tx.Model(&MissionSubmission{}).Select("MAX(id)").Where("mission_id = ?", missionID).Group("student_id")).
+		Order("id ASC").
+		Find(&submissions).Error
+	return submissions, err
+}
+
+func (r *MissionRepository) CreatePeerReviews(tx *gorm.DB, reviews []PeerReview) error {
+	return tx.Create(&reviews).Error
+}
+
+func (r *MissionRepository) FindPeerReview(tx *gorm.DB, id uint) (*PeerReview, error) {
+	var review PeerReview
+	err := tx.First(&review, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPeerReviewNotFound
+		}
+		return nil, err
+	}
+	return &review, nil
+}
+
+func (r *MissionRepository) UpdatePeerReview(tx *gorm.DB, id uint, updates map[string]interface{}) error {
+	return tx.Model(&PeerReview{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func (r *MissionRepository) FindSubmissionPeerReviews(tx *gorm.DB, submissionID uint) ([]PeerReview, error) {
+	var reviews []PeerReview
+	err := tx.Where("submission_id = ?", submissionID).Order("id ASC").Find(&reviews).Error
+	return reviews, err
+}
+
+// FindPeerReviewAssignments returns a reviewer's reviews with the submission
+// and mission, leaving out the author
+func (r *MissionRepository) FindPeerReviewAssignments(reviewerID uint, status string) ([]PeerReviewAssignment, error) {
+	var reviews []PeerReviewAssignment
+	query := r.db.Table("peer_reviews").
+		Select("peer_reviews.*, missions.title as mission_title, missions.description as mission_description, missions.rubric, missions.peer_reward, missions.asset, mission_submissions.submission_content as content, mission_submissions.file_url").
+		Joins("JOIN missions ON missions.id = peer_reviews.mission_id").
+		Joins("JOIN mission_submissions ON mission_submissions.id = peer_reviews.submission_id").
+		Where("peer_reviews.reviewer_id = ?", reviewerID)
+	if status != "" {
+		query = query.Where("peer_reviews.status = ?", status)
+	}
+	err := query.Order("peer_reviews.due_at ASC, peer_reviews.id ASC").Scan(&reviews).Error
+	return reviews, err
+}
+
+// FindReceivedPeerReviews returns the completed reviews of a student's
+// submissions, leaving out the reviewers
+func (r *MissionRepository) FindReceivedPeerReviews(studentID uint) ([]PeerFeedback, error) {
+	var feedback []PeerFeedback
+	err := r.db.Table("peer_reviews").
+		Select("peer_reviews.id, peer_reviews.mission_id, missions.title as mission_title, peer_reviews.submission_id, peer_reviews.score, peer_reviews.comment, peer_reviews.rubric_result, peer_reviews.completed_at").
+		Joins("JOIN missions ON missions.id = peer_reviews.mission_id").
+		Joins("JOIN mission_submissions ON mission_submissions.id = peer_reviews.submission_id").
+		Where("mission_submissions.student_id = ? AND peer_reviews.status = ?", studentID, "completed").
+		Order("peer_reviews.completed_at DESC").
+		Scan(&feedback).Error
+	return feedback, err
+}
+
+func (r *MissionRepository) FindMissionPeerReviews(missionID uint) ([]PeerReviewDetail, error) {
+	var reviews []PeerReviewDetail
+	err := r.db.Table("peer_reviews").
+		Select("peer_reviews.*, reviewers.full_name as reviewer_name, mission_submissions.student_id, students.full_name as student_name").
+		Joins("JOIN mission_submissions ON mission_submissions.id = peer_reviews.submission_id").
+		Joins("LEFT JOIN users as reviewers ON reviewers.id = peer_reviews.reviewer_id").
+		Joins("LEFT JOIN users as students ON students.id = mission_submissions.student_id").
+		Where("peer_reviews.mission_id = ?", missionID).
+		Order("peer_reviews.submission_id ASC, peer_reviews.id ASC").
+		Scan(&reviews).Error
+	return reviews, err
+}
+
+// FindOverduePeerSubmissions returns submissions with reviews still assigned
+// past their review window
+func (r *MissionRepository) FindOverduePeerSubmissions(now time.Time) ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&PeerReview{}).
+		Where("status = ? AND due_at < ?", "assigned", now).
+		Distinct().
+		Pluck("submission_id", &ids).Error
+	return ids, err
+}
+
+// ExpirePeerReviews closes a submission's reviews left undone past their window
+func (r *MissionRepository) ExpirePeerReviews(tx *gorm.DB, submissionID uint, now time.Time) (int64, error) {
+	result := tx.Model(&PeerReview{}).
+		Where("submission_id = ? AND status = ? AND due_at < ?", submissionID, "assigned", now).
+		Update("status", "expired")
+	return result.RowsAffected, result.Error
 }
@@ -0,0 +1,185 @@
+package mission
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// RubricCriterion is one aspect of a task that reviewers score by picking
+// one of its levels
+type RubricCriterion struct {
+	Name        string        `json:"name"`
+	Description string        `json:"description,omitempty"`
+	Weight      int           `json:"weight"`
+	Levels      []RubricLevel `json:"levels"`
+}
+
+type RubricLevel struct {
+	Label       string `json:"label"`
+	Points      int    `json:"points"`
+	Description string `json:"description,omitempty"`
+}
+
+// PayoutBand pays Percent of the mission points to scores from MinScore up
+type PayoutBand struct {
+	MinScore int `json:"min_score"`
+	Percent  int `json:"percent"`
+}
+
+// RubricScore is the level a reviewer picked for one criterion
+type RubricScore struct {
+	Criterion string `json:"criterion" binding:"required"`
+	Level     string `json:"level" binding:"required"`
+	Comment   string `json:"comment"`
+}
+
+// CriterionResult is how a submission scored on one criterion, kept with
+// the submission so later rubric edits do not change it
+type CriterionResult struct {
+	Criterion string `json:"criterion"`
+	Weight    int    `json:"weight"`
+	Level     string `json:"level"`
+	Points    int    `json:"points"`
+	MaxPoints int    `json:"max_points"`
+	Comment   string `json:"comment,omitempty"`
+}
+
+func (c *RubricCriterion) maxPoints() int {
+	best := 0
+	for _, l := range c.Levels {
+		best = max(best, l.Points)
+	}
+	return best
+}
+
+// checkRubric validates a mission's rubric: named criteria with a positive
+// weight, and levels of which the best is worth more than zero
+func checkRubric(missionType string, rubric []RubricCriterion) error {
+	if len(rubric) == 0 {
+		return nil
+	}
+	if missionType == "quiz" {
+		return errors.New("quizzes are graded by their questions and cannot have a rubric")
+	}
+
+	names := make(map[string]bool, len(rubric))
+	for i := range rubric {
+		c := &rubric[i]
+		c.Name = strings.TrimSpace(c.Name)
+		if c.Name == "" {
+			return errors.New("every rubric criterion needs a name")
+		}
+		if names[strings.ToLower(c.Name)] {
+			return fmt.Errorf("rubric criterion %q appears twice", c.Name)
+		}
+		names[strings.ToLower(c.Name)] = true
+		if c.Weight < 1 {
+			return fmt.Errorf("rubric criterion %q needs a weight of at least 1", c.Name)
+		}
+		if len(c.Levels) == 0 {
+			return fmt.Errorf("rubric criterion %q needs levels", c.Name)
+		}
+
+		labels := make(map[string]bool, len(c.Levels))
+		for j := range c.Levels {
+			l := &c.Levels[j]
+			l.Label = strings.TrimSpace(l.Label)
+			if l.Label == "" || labels[strings.ToLower(l.Label)] {
+				return fmt.Errorf("levels of rubric criterion %q need distinct labels", c.Name)
+			}
+			labels[strings.ToLower(l.Label)] = true
+			if l.Points < 0 {
+				return fmt.Errorf("level %q of rubric criterion %q has negative points", l.Label, c.Name)
+			}
+		}
+		if c.maxPoints() == 0 {
+			return fmt.Errorf("rubric criterion %q needs a level worth more than 0 points", c.Name)
+		}
+	}
+	return nil
+}
+
+// checkBands validates payout bands and sorts them by score
+func checkBands(rule string, bands []PayoutBand) error {
+	if rule == "bands" && len(bands) == 0 {
+		return errors.New("the bands payout rule needs payout bands")
+	}
+	for _, b := range bands {
+		if b.MinScore < 0 || b.MinScore > 100 || b.Percent < 0 || b.Percent > 100 {
+			return errors.New("payout bands need a min_score and percent from 0 to 100")
+		}
+	}
+	sort.Slice(bands, func(i, j int) bool { return bands[i].MinScore < bands[j].MinScore })
+	return nil
+}
+
+// bandPercent is the percent paid by the highest band the score reaches
+func bandPercent(bands []PayoutBand, score int) int {
+	percent := 0
+	for _, b := range bands {
+		if score >= b.MinScore {
+			percent = b.Percent
+		}
+	}
+	return percent
+}
+
+// scoreRubric totals a review: each criterion counts its picked level's
+// share of the criterion's best level, weighted, as a score out of 100
+// rounded down like quiz scores
+func scoreRubric(rubric []RubricCriterion, scores []RubricScore) (int, []CriterionResult, error) {
+	picked := make(map[string]RubricScore, len(scores))
+	for _, s := range scores {
+		picked[strings.ToLower(strings.TrimSpace(s.Criterion))] = s
+	}
+
+	var earned float64
+	var total int
+	results := make([]CriterionResult, 0, len(rubric))
+	for _, c := range rubric {
+		s, ok := picked[strings.ToLower(c.Name)]
+		if !ok {
+			return 0, nil, fmt.Errorf("rubric criterion %q has not been scored", c.Name)
+		}
+
+		var level *RubricLevel
+		for i := range c.Levels {
+			if strings.EqualFold(c.Levels[i].Label, strings.TrimSpace(s.Level)) {
+				level = &c.Levels[i]
+			}
+		}
+		if level == nil {
+			return 0, nil, fmt.Errorf("rubric criterion %q has no level %q", c.Name, s.Level)
+		}
+
+		best := c.maxPoints()
+		earned += float64(c.Weight*level.Points) / float64(best)
+		total += c.Weight
+		results = append(results, CriterionResult{
+			Criterion: c.Name,
+			Weight:    c.Weight,
+			Level:     level.Label,
+			Points:    level.Points,
+			MaxPoints: best,
+			Comment:   strings.TrimSpace(s.Comment),
+		})
+	}
+	// The epsilon keeps float error from taking a point off exact scores
+	return int(math.Floor(earned*100/float64(total) + 1e-9)), results, nil
+}
+
+// applyRubric gives the score of a review. Missions with a rubric compute
+// it from the rubric, which an approval must fill in; otherwise the
+// reviewer's score stands.
+func applyRubric(mission *Mission, status string, scores []RubricScore, score int) (int, []CriterionResult, error) {
+	if len(mission.Rubric) == 0 {
+		return score, nil, nil
+	}
+	if len(scores) == 0 && status == "rejected" {
+		return score, nil, nil
+	}
+	return scoreRubric(mission.Rubric, scores)
+}
@@ -0,0 +1,97 @@
+package mission
+
+import "testing"
+
+func TestScoreRubric(t *testing.T) {
+	levels := func(points ...int) []RubricLevel {
+		out := make([]RubricLevel, len(points))
+		for i, p := range points {
+			out[i] = RubricLevel{Label: string(rune('A' + i)), Points: p}
+		}
+		return out
+	}
+
+	tests := []struct {
+		name    string
+		rubric  []RubricCriterion
+		scores  []RubricScore
+		want    int
+		wantErr bool
+	}{
+		{
+			name:   "best level",
+			rubric: []RubricCriterion{{Name: "Isi", Weight: 1, Levels: levels(0, 5, 10)}},
+			scores: []RubricScore{{Criterion: "Isi", Level: "C"}},
+			want:   100,
+		},
+		{
+			name:   "exact share survives float error",
+			rubric: []RubricCriterion{{Name: "Isi", Weight: 1, Levels: levels(0, 29, 100)}},
+			scores: []RubricScore{{Criterion: "Isi", Level: "B"}},
+			want:   29,
+		},
+		{
+			name:   "thirds round down",
+			rubric: []RubricCriterion{{Name: "Isi", Weight: 1, Levels: levels(0, 1, 2, 3)}},
+			scores: []RubricScore{{Criterion: "Isi", Level: "C"}},
+			want:   66,
+		},
+		{
+			name: "weighted criteria",
+			rubric: []RubricCriterion{
+				{Name: "Isi", Weight: 3, Levels: levels(0, 2, 4)},
+				{Name: "Tata Bahasa", Weight: 1, Levels: levels(0, 10)},
+			},
+			scores: []RubricScore{
+				{Criterion: "isi ", Level: "b"},
+				{Criterion: "Tata Bahasa", Level: "B"},
+			},
+			want: 62,
+		},
+		{
+			name: "worst levels",
+			rubric: []RubricCriterion{
+				{Name: "Isi", Weight: 2, Levels: levels(0, 5)},
+				{Name: "Format", Weight: 1, Levels: levels(1, 3)},
+			},
+			scores: []RubricScore{
+				{Criterion: "Isi", Level: "A"},
+				{Criterion: "Format", Level: "A"},
+			},
+			want: 11,
+		},
+		{
+			name:    "unscored criterion",
+			rubric:  []RubricCriterion{{Name: "Isi", Weight: 1, Levels: levels(0, 5)}},
+			scores:  []RubricScore{{Criterion: "Format", Level: "A"}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown level",
+			rubric:  []RubricCriterion{{Name: "Isi", Weight: 1, Levels: levels(0, 5)}},
+			scores:  []RubricScore{{Criterion: "Isi", Level: "Z"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, results, err := scoreRubric(tt.rubric, tt.scores)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("scoreRubric() = %d, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("scoreRubric() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("scoreRubric() = %d, want %d", got, tt.want)
+			}
+			if len(results) != len(tt.rubric) {
+				t.Errorf("got %d criterion results, want %d", len(results), len(tt.rubric))
+			}
+		})
+	}
+}
@@ -3,7 +3,9 @@ package mission
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"math"
+	"strings"
 	"wallet-point/internal/wallet"
 
 	"gorm.io/gorm"
@@ -25,23 +27,74 @@ func NewMissionService(repo *MissionRepository, walletService *wallet.WalletServ
 
 // Mission Management
 func (s *MissionService) CreateMission(req *CreateMissionRequest, creatorID uint) (*Mission, error) {
+	asset, err := s.walletService.GetAsset(req.Asset)
+	if err != nil {
+		return nil, err
+	}
+
 	mission := &Mission{
-		Title:       req.Title,
-		Description: req.Description,
-		Type:        req.Type,
-		Points:      req.Points,
-		Deadline:    req.Deadline,
-		Status:      "active",
-		CreatorID:   creatorID,
+		Title:            req.Title,
+		Description:      req.Description,
+		Type:             req.Type,
+		Points:           req.Points,
+		Asset:            asset.Code,
+		Deadline:         req.Deadline,
+		Status:           "active",
+		CreatorID:        creatorID,
+		MaxAttempts:      req.MaxAttempts,
+		CooldownMinutes:  req.CooldownMinutes,
+		ResubmitPolicy:   req.ResubmitPolicy,
+		ScoreRule:        req.ScoreRule,
+		PayoutRule:       req.PayoutRule,
+		PassScore:        req.PassScore,
+		DrawCount:        req.DrawCount,
+		DrawTags:         normalizeTags(req.DrawTags),
+		DrawCourse:       strings.TrimSpace(req.DrawCourse),
+		DrawDifficulty:   req.DrawDifficulty,
+		TimeLimitMinutes: req.TimeLimitMinutes,
+		PeerReviewers:    req.PeerReviewers,
+		PeerReward:       req.PeerReward,
+		PeerAggregate:    req.PeerAggregate,
+		PeerReviewHours:  req.PeerReviewHours,
+	}
+	if mission.MaxAttempts == 0 {
+		mission.MaxAttempts = 1
+	}
+	if mission.ResubmitPolicy == "" {
+		mission.ResubmitPolicy = "after_rejection"
+	}
+	if mission.ScoreRule == "" {
+		mission.ScoreRule = "latest"
+	}
+	if mission.PayoutRule == "" {
+		mission.PayoutRule = "proportional"
+	}
+	if err := checkRubric(mission.Type, req.Rubric); err != nil {
+		return nil, err
+	}
+	if err := checkBands(mission.PayoutRule, req.PayoutBands); err != nil {
+		return nil, err
+	}
+	mission.Rubric = req.Rubric
+	mission.PayoutBands = req.PayoutBands
+	if mission.PeerAggregate == "" {
+		mission.PeerAggregate = "median"
+	}
+	if mission.PeerReviewHours == 0 {
+		mission.PeerReviewHours = 72
+	}
+	if err := checkPeerReview(mission); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkDrawPool(mission); err != nil {
+		return nil, err
 	}
 
-	if req.Type == "quiz" && len(req.Questions) > 0 {
-		for _, q := range req.Questions {
-			mission.Questions = append(mission.Questions, MissionQuestion{
-				Question: q.Question,
-				Options:  q.Options,
-				Answer:   q.Answer,
-			})
+	if req.Type == "quiz" && len(req.Questions) > 0 && mission.DrawCount == 0 {
+		mission.Questions, err = newQuestions(0, req.Questions)
+		if err != nil {
+			return nil, err
 		}
 	}
 
@@ -82,7 +135,7 @@ func (s *MissionService) GetAllMissions(params MissionListParams) (*MissionListR
 
 func (s *MissionService) UpdateMission(id uint, req *UpdateMissionRequest) (*Mission, error) {
 	// Check if exists
-	_, err := s.repo.FindByID(id)
+	mission, err := s.repo.FindByID(id)
 	if err != nil {
 		return nil, err
 	}
@@ -97,12 +150,125 @@ func (s *MissionService) UpdateMission(id uint, req *UpdateMissionRequest) (*Mis
 	if req.Points > 0 {
 		updates["points_reward"] = req.Points
 	}
+	if req.Asset != "" {
+		if _, err := s.walletService.GetAsset(req.Asset); err != nil {
+			return nil, err
+		}
+		updates["asset"] = req.Asset
+	}
 	if req.Deadline != nil {
 		updates["deadline"] = req.Deadline
 	}
 	if req.Status != "" {
 		updates["status"] = req.Status
 	}
+	if req.MaxAttempts > 0 {
+		updates["max_attempts"] = req.MaxAttempts
+	}
+	if req.CooldownMinutes != nil {
+		updates["cooldown_minutes"] = *req.CooldownMinutes
+	}
+	if req.ResubmitPolicy != "" {
+		updates["resubmit_policy"] = req.ResubmitPolicy
+	}
+	if req.ScoreRule != "" {
+		updates["score_rule"] = req.ScoreRule
+	}
+	if req.PayoutRule != "" {
+		updates["payout_rule"] = req.PayoutRule
+	}
+	if req.PassScore != nil {
+		updates["pass_score"] = *req.PassScore
+	}
+	if req.TimeLimitMinutes != nil {
+		updates["time_limit_minutes"] = *req.TimeLimitMinutes
+	}
+	if req.Rubric != nil {
+		if err := checkRubric(mission.Type, *req.Rubric); err != nil {
+			return nil, err
+		}
+		rubric, err := json.Marshal(*req.Rubric)
+		if err != nil {
+			return nil, err
+		}
+		updates["rubric"] = string(rubric)
+	}
+	if req.PayoutRule != "" || req.PayoutBands != nil {
+		rule, bands := mission.PayoutRule, mission.PayoutBands
+		if req.PayoutRule != "" {
+			rule = req.PayoutRule
+		}
+		if req.PayoutBands != nil {
+			bands = *req.PayoutBands
+		}
+		if err := checkBands(rule, bands); err != nil {
+			return nil, err
+		}
+		if req.PayoutBands != nil {
+			encoded, err := json.Marshal(bands)
+			if err != nil {
+				return nil, err
+			}
+			updates["payout_bands"] = string(encoded)
+		}
+	}
+	if req.DrawCount != nil || req.DrawTags != nil || req.DrawCourse != nil || req.DrawDifficulty != nil {
+		if req.DrawCount != nil {
+			mission.DrawCount = *req.DrawCount
+			updates["draw_count"] = mission.DrawCount
+		}
+		if req.DrawTags != nil {
+			mission.DrawTags = normalizeTags(*req.DrawTags)
+			tags, err := json.Marshal(mission.DrawTags)
+			if err != nil {
+				return nil, err
+			}
+			updates["draw_tags"] = string(tags)
+		}
+		if req.DrawCourse != nil {
+			mission.DrawCourse = strings.TrimSpace(*req.DrawCourse)
+			updates["draw_course"] = mission.DrawCourse
+		}
+		if req.DrawDifficulty != nil {
+			mission.DrawDifficulty = *req.DrawDifficulty
+			updates["draw_difficulty"] = mission.DrawDifficulty
+		}
+		// Students who already drew keep their questions
+		if err := s.checkDrawPool(mission); err != nil {
+			return nil, err
+		}
+	}
+	if req.PeerReviewers != nil || req.PeerReward != nil || req.PeerAggregate != "" || req.PeerReviewHours > 0 || req.Deadline != nil {
+		if mission.PeerAssignedAt != nil && peerScheduleChanged(mission, req) {
+			return nil, errors.New("peer reviews are already assigned; the reviewers, review window and deadline can no longer change")
+		}
+		if req.PeerReviewers != nil {
+			mission.PeerReviewers = *req.PeerReviewers
+			updates["peer_reviewers"] = mission.PeerReviewers
+		}
+		if req.PeerReward != nil {
+			updates["peer_reward"] = *req.PeerReward
+		}
+		if req.PeerAggregate != "" {
+			updates["peer_aggregate"] = req.PeerAggregate
+		}
+		if req.PeerReviewHours > 0 {
+			updates["peer_review_hours"] = req.PeerReviewHours
+		}
+		if req.Deadline != nil {
+			mission.Deadline = req.Deadline
+		}
+		if err := checkPeerReview(mission); err != nil {
+			return nil, err
+		}
+	}
+
+	var questions []MissionQuestion
+	if req.Questions != nil {
+		if questions, err = newQuestions(id, req.Questions); err != nil {
+			return nil, err
+		}
+	}
 
 	if len(updates) > 0 {
 		if err := s.repo.Update(id, updates); err != nil {
@@ -119,14 +285,8 @@ func (s *MissionService) UpdateMission(id uint, req *UpdateMissionRequest) (*Mis
 			}
 
 			// Add new questions
-			for _, q := range req.Questions {
-				newQ := MissionQuestion{
-					MissionID: id,
-					Question:  q.Question,
-					Options:   q.Options,
-					Answer:    q.Answer,
-				}
-				if err := tx.Create(&newQ).Error; err != nil {
+			for i := range questions {
+				if err := tx.Create(&questions[i]).Error; err != nil {
 					return err
 				}
 			}
@@ -149,6 +309,11 @@ func (s *MissionService) DeleteMission(id uint) error {
 	return s.repo.Delete(id)
 }
 
+// ExpireOverdueMissions flips active missions past their deadline to expired
+func (s *MissionService) ExpireOverdueMissions() (int64, error) {
+	return s.repo.ExpireOverdue(s.db.NowFunc())
+}
+
 // Submission Management
 func (s *MissionService) SubmitMission(req *SubmitMissionRequest, studentID uint) (*MissionSubmission, error) {
 	// Check if mission exists
@@ -162,15 +327,6 @@ func (s *MissionService) SubmitMission(req *SubmitMissionRequest, studentID uint
 		return nil, errors.New("mission deadline has passed")
 	}
 
-	// Check duplicate submission
-	exists, err := s.repo.CheckDuplicateSubmission(req.MissionID, studentID)
-	if err != nil {
-		return nil, err
-	}
-	if exists {
-		return nil, errors.New("you have already submitted this mission")
-	}
-
 	submission := &MissionSubmission{
 		MissionID: req.MissionID,
 		StudentID: studentID,
@@ -184,7 +340,47 @@ func (s *MissionService) SubmitMission(req *SubmitMissionRequest, studentID uint
 		submission.Content = string(answersBytes)
 	}
 
-	if err := s.repo.CreateSubmission(submission); err != nil {
+	// The attempt policy is checked with the student's attempts locked, so
+	// concurrent submissions cannot both take the last attempt
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		attempts, err := s.repo.LockAttempts(tx, req.MissionID, studentID)
+		if err != nil {
+			return err
+		}
+		now := s.db.NowFunc()
+		if attemptErr := checkAttempt(mission, attempts, now); attemptErr != nil {
+			return attemptErr
+		}
+
+		submission.Attempt = len(attempts) + 1
+		if mission.Type != "quiz" {
+			return s.repo.CreateSubmissionWithTx(tx, submission)
+		}
+
+		session, err := s.submitSession(tx, mission, studentID, submission.Attempt, now)
+		if err != nil {
+			return err
+		}
+		if session != nil {
+			submission.StartedAt = &session.StartedAt
+			submission.FinishedAt = &now
+		}
+
+		questions, err := s.studentQuestions(tx, mission, studentID)
+		if err != nil {
+			return err
+		}
+		if len(questions) > 0 {
+			err = s.gradeSubmission(tx, mission, questions, submission, attempts, req.Answers)
+		} else {
+			err = s.repo.CreateSubmissionWithTx(tx, submission)
+		}
+		if err != nil || session == nil {
+			return err
+		}
+		return s.repo.FinishQuizSession(tx, session.ID, now, &submission.ID)
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -197,43 +393,88 @@ func (s *MissionService) ReviewSubmission(submissionID uint, req *ReviewSubmissi
 		return err
 	}
 
-	if submission.Status != "pending" {
-		return errors.New("submission has already been reviewed")
-	}
-
 	// Start a transaction for the review and potential wallet reward
 	return s.db.Transaction(func(tx *gorm.DB) error {
-		updates := map[string]interface{}{
-			"status":          req.Status,
-			"score":           req.Score,
-			"validation_note": req.ReviewNote,
-			"validated_by":    reviewerID,
+		// Lock the student's attempts so the reward is paid at most once per
+		// mission, however many attempts are approved
+		attempts, err := s.repo.LockAttempts(tx, submission.MissionID, submission.StudentID)
+		if err != nil {
+			return err
 		}
 
-		// Update submission status
-		if err := s.repo.UpdateSubmissionWithTx(tx, submissionID, updates); err != nil {
+		for _, a := range attempts {
+			if a.ID == submissionID {
+				submission = &a
+			}
+		}
+		if submission.Status != "pending" {
+			return errors.New("submission has already been reviewed")
+		}
+
+		mission, err := s.repo.FindByID(submission.MissionID)
+		if err != nil {
 			return err
 		}
 
-		// If approved, reward points
-		if req.Status == "approved" {
-			mission, err := s.repo.FindByID(submission.MissionID)
-			if err != nil {
-				return err
-			}
+		score, results, err := applyRubric(mission, req.Status, req.RubricScores, req.Score)
+		if err != nil {
+			return err
+		}
 
-			// for now we'll assume it handles its own internal transaction if needed,
-			// though nested transactions in GORM/MySQL are safe.
-			err = s.walletService.ProcessMissionRewardWithTx(tx, submission.StudentID, mission.Points, mission.Title, mission.ID, reviewerID)
-			if err != nil {
-				return err
-			}
+		// The reward follows the attempt that counts under the score rule
+		submission.Status, submission.Score = req.Status, score
+		updates, err := s.settleReward(tx, mission, submission, attempts)
+		if err != nil {
+			return err
 		}
+		if err := setRubricResult(updates, results); err != nil {
+			return err
+		}
+		updates["status"] = req.Status
+		updates["score"] = score
+		updates["validation_note"] = req.ReviewNote
+		updates["validated_by"] = reviewerID
 
-		return nil
+		// Update submission status
+		return s.repo.UpdateSubmissionWithTx(tx, submissionID, updates)
 	})
 }
 
+// gradeSubmission scores a quiz attempt as it is submitted and pays its
+// reward under the mission's payout rule
+func (s *MissionService) gradeSubmission(tx *gorm.DB, mission *Mission, questions []MissionQuestion, submission *MissionSubmission, attempts []MissionSubmission, answers []AnswerSubmission) error {
+	score, grades := gradeQuiz(questions, answers)
+	payout := scorePayout(mission, score)
+
+	correct := 0
+	for _, g := range grades {
+		if g.Correct {
+			correct++
+		}
+	}
+
+	submission.Score = score
+	submission.AutoGraded = true
+	submission.GradeDetails = grades
+	submission.Status = "rejected"
+	if payout > 0 {
+		submission.Status = "approved"
+	}
+	submission.ReviewNote = fmt.Sprintf("Auto-graded: %d/%d correct, score %d%%", correct, len(grades), score)
+	if err := s.repo.CreateSubmissionWithTx(tx, submission); err != nil {
+		return err
+	}
+
+	updates, err := s.settleReward(tx, mission, submission, attempts)
+	if err != nil {
+		return err
+	}
+	submission.Rewarded = updates["rewarded"].(bool)
+	submission.RewardAmount = updates["reward_amount"].(int)
+	submission.RewardOwed = updates["reward_owed"].(int)
+	return s.repo.UpdateSubmissionWithTx(tx, submission.ID, updates)
+}
+
 func (s *MissionService) GetAllSubmissions(params SubmissionListParams) (*SubmissionListResponse, error) {
 	if params.Page < 1 {
 		params.Page = 1
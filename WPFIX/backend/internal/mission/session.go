@@ -0,0 +1,264 @@
+package mission
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Error codes returned when a quiz session refuses answers
+const (
+	CodeQuizNotStarted = "QUIZ_NOT_STARTED"
+	CodeQuizTimeUp     = "QUIZ_TIME_UP"
+)
+
+// sessionGrace is how long after its time limit a quiz is still accepted,
+// covering the trip from the student's browser
+const sessionGrace = 30 * time.Second
+
+// QuizSession is one started quiz attempt: when it started, when its time
+// runs out and the answers autosaved so far
+type QuizSession struct {
+	ID               uint               `json:"id" gorm:"primaryKey"`
+	MissionID        uint               `json:"mission_id" gorm:"not null;uniqueIndex:idx_quiz_session_attempt"`
+	StudentID        uint               `json:"student_id" gorm:"not null;uniqueIndex:idx_quiz_session_attempt"`
+	Attempt          int                `json:"attempt" gorm:"not null;uniqueIndex:idx_quiz_session_attempt"`
+	Answers          []AnswerSubmission `json:"answers" gorm:"type:json;serializer:json"`
+	StartedAt        time.Time          `json:"started_at" gorm:"not null"`
+	ExpiresAt        *time.Time         `json:"expires_at" gorm:"index"` // Nil for untimed quizzes
+	FinishedAt       *time.Time         `json:"finished_at"`
+	SubmissionID     *uint              `json:"submission_id"`
+	RemainingSeconds *int               `json:"remaining_seconds,omitempty" gorm:"-"`
+	CreatedAt        time.Time          `json:"created_at"`
+	UpdatedAt        time.Time          `json:"updated_at"`
+}
+
+func (QuizSession) TableName() string {
+	return "quiz_sessions"
+}
+
+type SaveAnswersRequest struct {
+	Answers []AnswerSubmission `json:"answers" binding:"required"`
+}
+
+func (q *QuizSession) expired(now time.Time) bool {
+	return q.ExpiresAt != nil && now.After(q.ExpiresAt.Add(sessionGrace))
+}
+
+func (q *QuizSession) setRemaining(now time.Time) {
+	if q.ExpiresAt == nil {
+		return
+	}
+	remaining := max(int(q.ExpiresAt.Sub(now).Seconds()), 0)
+	q.RemainingSeconds = &remaining
+}
+
+// StartQuiz opens the student's next quiz attempt, or resumes the one
+// already running. A timed quiz gets its time limit from now, cut short by
+// the mission deadline.
+func (s *MissionService) StartQuiz(missionID, studentID uint) (*QuizSession, error) {
+	mission, err := s.repo.FindByID(missionID)
+	if err != nil {
+		return nil, err
+	}
+	if mission.Type != "quiz" {
+		return nil, errors.New("only quizzes can be started")
+	}
+	if mission.Status != "active" {
+		return nil, errors.New("mission is not active")
+	}
+	if mission.Deadline != nil && mission.Deadline.Before(s.db.NowFunc()) {
+		return nil, errors.New("mission deadline has passed")
+	}
+
+	// A session left past its time limit is submitted first, so it counts
+	// as an attempt
+	open, err := s.repo.FindOpenQuizSession(s.db, missionID, studentID)
+	if err == nil && open.expired(s.db.NowFunc()) {
+		if _, err := s.closeSession(open.ID); err != nil {
+			return nil, err
+		}
+	} else if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	now := s.db.NowFunc()
+	var session *QuizSession
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		attempts, err := s.repo.LockAttempts(tx, missionID, studentID)
+		if err != nil {
+			return err
+		}
+
+		open, err := s.repo.FindOpenQuizSession(tx, missionID, studentID)
+		if err == nil && open.Attempt == len(attempts)+1 {
+			session = open
+			return nil
+		}
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if attemptErr := checkAttempt(mission, attempts, now); attemptErr != nil {
+			return attemptErr
+		}
+		// Draw a bank quiz now so the questions are fixed from the start
+		if _, err := s.studentQuestions(tx, mission, studentID); err != nil {
+			return err
+		}
+
+		session = &QuizSession{
+			MissionID: missionID,
+			StudentID: studentID,
+			Attempt:   len(attempts) + 1,
+			StartedAt: now,
+		}
+		if mission.TimeLimitMinutes > 0 {
+			expiresAt := now.Add(time.Duration(mission.TimeLimitMinutes) * time.Minute)
+			if mission.Deadline != nil && mission.Deadline.Before(expiresAt) {
+				expiresAt = *mission.Deadline
+			}
+			session.ExpiresAt = &expiresAt
+		}
+		return s.repo.CreateQuizSession(tx, session)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	session.setRemaining(now)
+	return session, nil
+}
+
+// SaveQuizAnswers autosaves the answers of the student's running quiz
+func (s *MissionService) SaveQuizAnswers(missionID, studentID uint, answers []AnswerSubmission) (*QuizSession, error) {
+	now := s.db.NowFunc()
+	session, err := s.repo.FindOpenQuizSession(s.db, missionID, studentID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, &AttemptError{code: CodeQuizNotStarted, status: http.StatusConflict, message: "start the quiz before answering"}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if session.expired(now) {
+		return nil, &AttemptError{code: CodeQuizTimeUp, status: http.StatusConflict, message: "the quiz time limit has passed"}
+	}
+
+	session.Answers = answers
+	if err := s.repo.SaveQuizSessionAnswers(session); err != nil {
+		return nil, err
+	}
+	session.setRemaining(now)
+	return session, nil
+}
+
+// submitSession returns the running session of the attempt being submitted.
+// A timed quiz must have been started and still be within its time limit.
+func (s *MissionService) submitSession(tx *gorm.DB, mission *Mission, studentID uint, attempt int, now time.Time) (*QuizSession, error) {
+	session, err := s.repo.FindOpenQuizSession(tx, mission.ID, studentID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if err != nil || session.Attempt != attempt {
+		if mission.TimeLimitMinutes > 0 {
+			return nil, &AttemptError{code: CodeQuizNotStarted, status: http.StatusConflict, message: "start the quiz before submitting it"}
+		}
+		return nil, nil
+	}
+	if session.expired(now) {
+		return nil, &AttemptError{code: CodeQuizTimeUp, status: http.StatusConflict, message: "the quiz time limit has passed; your autosaved answers will be submitted"}
+	}
+	return session, nil
+}
+
+// CloseExpiredQuizSessions submits the autosaved answers of quiz sessions
+// past their time limit. A session that fails to close is logged and retried
+// on the next run.
+func (s *MissionService) CloseExpiredQuizSessions() (int64, error) {
+	sessions, err := s.repo.FindExpiredQuizSessions(s.db.NowFunc().Add(-sessionGrace))
+	if err != nil {
+		return 0, err
+	}
+
+	var closed int64
+	for _, session := range sessions {
+		ok, err := s.closeSession(session.ID)
+		if err != nil {
+			log.Printf("[QuizSession] failed to close session %d: %v", session.ID, err)
+			continue
+		}
+		if ok {
+			closed++
+		}
+	}
+	return closed, nil
+}
+
+// closeSession finishes a quiz session at its time limit, submitting and
+// grading its autosaved answers as the attempt
+func (s *MissionService) closeSession(sessionID uint) (bool, error) {
+	closed := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		session, err := s.repo.FindQuizSession(tx, sessionID)
+		if err != nil {
+			return err
+		}
+		attempts, err := s.repo.LockAttempts(tx, session.MissionID, session.StudentID)
+		if err != nil {
+			return err
+		}
+		// Re-read under the lock; the student may have just submitted
+		if session, err = s.repo.FindQuizSession(tx, sessionID); err != nil {
+			return err
+		}
+		if session.FinishedAt != nil {
+			return nil
+		}
+
+		finishedAt := s.db.NowFunc()
+		if session.ExpiresAt != nil {
+			finishedAt = *session.ExpiresAt
+		}
+		closed = true
+		if session.Attempt != len(attempts)+1 {
+			return s.repo.FinishQuizSession(tx, session.ID, finishedAt, nil)
+		}
+
+		mission, err := s.repo.FindByID(session.MissionID)
+		if err != nil {
+			return err
+		}
+		content, err := json.Marshal(session.Answers)
+		if err != nil {
+			return err
+		}
+		submission := &MissionSubmission{
+			MissionID:  session.MissionID,
+			StudentID:  session.StudentID,
+			Content:    string(content),
+			Status:     "pending",
+			Attempt:    session.Attempt,
+			StartedAt:  &session.StartedAt,
+			FinishedAt: &finishedAt,
+		}
+
+		questions, err := s.studentQuestions(tx, mission, session.StudentID)
+		if err != nil {
+			return err
+		}
+		if len(questions) > 0 {
+			err = s.gradeSubmission(tx, mission, questions, submission, attempts, session.Answers)
+		} else {
+			err = s.repo.CreateSubmissionWithTx(tx, submission)
+		}
+		if err != nil {
+			return err
+		}
+		return s.repo.FinishQuizSession(tx, session.ID, finishedAt, &submission.ID)
+	})
+	return closed, err
+}
@@ -0,0 +1,92 @@
+package notification
+
+import (
+	"net/http"
+	"strconv"
+	"wallet-point/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// GetMine handles listing the current user's notifications
+// @Summary List my notifications
+// @Description Notifications of the current user, newest first, with the unread count
+// @Tags Notifications
+// @Security BearerAuth
+// @Produce json
+// @Param unread query bool false "Only unread notifications"
+// @Param page query int false "Page number"
+// @Param limit query int false "Items per page"
+// @Success 200 {object} utils.Response{data=ListResponse}
+// @Router /notifications [get]
+func (h *Handler) GetMine(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	response, err := h.service.List(ListParams{
+		UserID:     c.GetUint("user_id"),
+		UnreadOnly: c.Query("unread") == "true",
+		Page:       page,
+		Limit:      limit,
+	})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve notifications", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Notifications retrieved successfully", response)
+}
+
+// MarkRead handles marking one notification as read
+// @Summary Mark notification as read
+// @Tags Notifications
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Notification ID"
+// @Success 200 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Router /notifications/{id}/read [post]
+func (h *Handler) MarkRead(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid notification ID", nil)
+		return
+	}
+
+	updated, err := h.service.MarkRead(c.GetUint("user_id"), uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update notification", err.Error())
+		return
+	}
+	if updated == 0 {
+		utils.ErrorResponse(c, http.StatusNotFound, "Notification not found or already read", nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Notification marked as read", nil)
+}
+
+// MarkAllRead handles marking every notification of the user as read
+// @Summary Mark all notifications as read
+// @Tags Notifications
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} utils.Response
+// @Router /notifications/read-all [post]
+func (h *Handler) MarkAllRead(c *gin.Context) {
+	updated, err := h.service.MarkRead(c.GetUint("user_id"), 0)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update notifications", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Notifications marked as read", gin.H{"updated": updated})
+}
@@ -0,0 +1,46 @@
+package notification
+
+import "time"
+
+// Notification is an in-app message for one user
+type Notification struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	Type      string     `json:"type" gorm:"size:50;not null"` // e.g. scheduled_transfer_skipped
+	Title     string     `json:"title" gorm:"size:150;not null"`
+	Message   string     `json:"message" gorm:"type:text"`
+	Entity    string     `json:"entity" gorm:"size:50"` // What the notification is about, e.g. SCHEDULED_TRANSFER
+	EntityID  uint       `json:"entity_id"`
+	ReadAt    *time.Time `json:"read_at"`
+	CreatedAt time.Time  `json:"created_at" gorm:"index"`
+}
+
+func (Notification) TableName() string {
+	return "notifications"
+}
+
+// CreateParams describes a notification to send
+type CreateParams struct {
+	UserID   uint
+	Type     string
+	Title    string
+	Message  string
+	Entity   string
+	EntityID uint
+}
+
+type ListParams struct {
+	UserID     uint
+	UnreadOnly bool
+	Page       int
+	Limit      int
+}
+
+type ListResponse struct {
+	Notifications []Notification `json:"notifications"`
+	Unread        int64          `json:"unread"`
+	Total         int64          `json:"total"`
+	Page          int            `json:"page"`
+	Limit         int            `json:"limit"`
+	TotalPages    int            `json:"total_pages"`
+}
@@ -0,0 +1,54 @@
+package notification
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{db: db}
+}
+
+func (r *Repository) Create(n *Notification) error {
+	return r.db.Create(n).Error
+}
+
+// List returns a user's notifications, newest first
+func (r *Repository) List(params ListParams) ([]Notification, int64, error) {
+	notifications := []Notification{}
+	var total int64
+
+	query := r.db.Model(&Notification{}).Where("user_id = ?", params.UserID)
+	if params.UnreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (params.Page - 1) * params.Limit
+	err := query.Order("created_at DESC, id DESC").Limit(params.Limit).Offset(offset).Find(&notifications).Error
+	return notifications, total, err
+}
+
+// CountUnread counts a user's unread notifications
+func (r *Repository) CountUnread(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
+
+// MarkRead marks one notification of a user as read; all of them when id is 0
+func (r *Repository) MarkRead(userID, id uint) (int64, error) {
+	query := r.db.Model(&Notification{}).Where("user_id = ? AND read_at IS NULL", userID)
+	if id != 0 {
+		query = query.Where("id = ?", id)
+	}
+	result := query.Update("read_at", time.Now())
+	return result.RowsAffected, result.Error
+}
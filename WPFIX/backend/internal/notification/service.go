@@ -0,0 +1,63 @@
+package notification
+
+import (
+	"log"
+	"math"
+)
+
+type Service struct {
+	repo *Repository
+}
+
+func NewService(repo *Repository) *Service {
+	return &Service{repo: repo}
+}
+
+// Notify stores a notification. Failures are logged, not returned: a lost
+// notification must never undo the action it reports.
+func (s *Service) Notify(params CreateParams) {
+	n := &Notification{
+		UserID:   params.UserID,
+		Type:     params.Type,
+		Title:    params.Title,
+		Message:  params.Message,
+		Entity:   params.Entity,
+		EntityID: params.EntityID,
+	}
+	if err := s.repo.Create(n); err != nil {
+		log.Printf("[Notification] failed to notify user %d (%s): %v", params.UserID, params.Type, err)
+	}
+}
+
+// List returns a page of a user's notifications with their unread count
+func (s *Service) List(params ListParams) (*ListResponse, error) {
+	if params.Page < 1 {
+		params.Page = 1
+	}
+	if params.Limit < 1 {
+		params.Limit = 20
+	}
+
+	notifications, total, err := s.repo.List(params)
+	if err != nil {
+		return nil, err
+	}
+	unread, err := s.repo.CountUnread(params.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListResponse{
+		Notifications: notifications,
+		Unread:        unread,
+		Total:         total,
+		Page:          params.Page,
+		Limit:         params.Limit,
+		TotalPages:    int(math.Ceil(float64(total) / float64(params.Limit))),
+	}, nil
+}
+
+// MarkRead marks one notification (or all with id 0) as read
+func (s *Service) MarkRead(userID, id uint) (int64, error) {
+	return s.repo.MarkRead(userID, id)
+}
@@ -0,0 +1,78 @@
+package pubsub
+
+import "sync"
+
+// Hub fans out messages published on a topic to its current subscribers.
+// MemoryHub only reaches subscribers of the same process; a multi-instance
+// deployment can implement Hub on top of a broker (Redis, NATS, ...).
+type Hub interface {
+	// Publish delivers data to every subscriber of topic without blocking
+	Publish(topic string, data interface{})
+	// Subscribe returns a channel of messages for topic and a function
+	// that must be called to unsubscribe
+	Subscribe(topic string) (<-chan interface{}, func())
+}
+
+// subscriberBuffer is how many messages a slow subscriber may fall behind
+// before new messages are dropped for it
+const subscriberBuffer = 8
+
+// MemoryHub is an in-process Hub
+type MemoryHub struct {
+	mu     sync.RWMutex
+	topics map[string]map[chan interface{}]struct{}
+}
+
+func NewMemoryHub() *MemoryHub {
+	return &MemoryHub{
+		topics: make(map[string]map[chan interface{}]struct{}),
+	}
+}
+
+// Publish delivers data to every subscriber of topic. Subscribers whose
+// buffer is full miss the message rather than stall the publisher.
+func (h *MemoryHub) Publish(topic string, data interface{}) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for ch := range h.topics[topic] {
+		select {
+		case ch <- data:
+		default:
+		}
+	}
+}
+
+// Subscribe registers a subscriber on topic
+func (h *MemoryHub) Subscribe(topic string) (<-chan interface{}, func()) {
+	ch := make(chan interface{}, subscriberBuffer)
+
+	h.mu.Lock()
+	if h.topics[topic] == nil {
+		h.topics[topic] = make(map[chan interface{}]struct{})
+	}
+	h.topics[topic][ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.topics[topic], ch)
+			if len(h.topics[topic]) == 0 {
+				delete(h.topics, topic)
+			}
+			h.mu.Unlock()
+			close(ch)
+		})
+	}
+
+	return ch, unsubscribe
+}
+
+// Subscribers returns the number of subscribers of topic
+func (h *MemoryHub) Subscribers(topic string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.topics[topic])
+}
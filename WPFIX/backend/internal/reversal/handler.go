@@ -0,0 +1,75 @@
+package reversal
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"wallet-point/internal/audit"
+	"wallet-point/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service      *Service
+	auditService *audit.AuditService
+}
+
+func NewHandler(service *Service, auditService *audit.AuditService) *Handler {
+	return &Handler{service: service, auditService: auditService}
+}
+
+// Reverse handles reversing a completed transaction
+// @Summary Reverse or refund a transaction
+// @Description Post a compensating journal for a wallet transaction. Marketplace purchases can be refunded partially by quantity (Admin only)
+// @Tags Admin - Transactions
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Wallet transaction ID"
+// @Param request body ReverseRequest true "Reversal details"
+// @Success 200 {object} utils.Response{data=ReversalResult}
+// @Failure 400 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Router /admin/transactions/{id}/reverse [post]
+func (h *Handler) Reverse(c *gin.Context) {
+	adminID := c.GetUint("user_id")
+
+	transactionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid transaction ID", nil)
+		return
+	}
+
+	var req ReverseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	result, err := h.service.Reverse(uint(transactionID), &req)
+	if err != nil {
+		statusCode := http.StatusBadRequest
+		switch err.Error() {
+		case "transaction not found", "journal not found":
+			statusCode = http.StatusNotFound
+		case "transaction has already been reversed":
+			statusCode = http.StatusConflict
+		}
+		utils.ErrorFromErr(c, statusCode, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Transaction reversed successfully", result)
+
+	// Log activity
+	h.auditService.LogActivity(audit.CreateAuditParams{
+		UserID:    adminID,
+		Action:    "REVERSE_TRANSACTION",
+		Entity:    "TRANSACTION",
+		EntityID:  result.TransactionID,
+		Details:   fmt.Sprintf("Reversed %s journal #%d with journal #%d (%s) | Reason: %s", result.Kind, result.JournalID, result.ReversalJournalID, result.Status, req.Reason),
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+}
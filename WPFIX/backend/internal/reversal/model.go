@@ -0,0 +1,20 @@
+package reversal
+
+import "wallet-point/internal/wallet"
+
+// ReverseRequest is the body of POST /admin/transactions/:id/reverse
+type ReverseRequest struct {
+	Reason   string `json:"reason" binding:"required,max=255"`
+	Quantity int    `json:"quantity" binding:"omitempty,gt=0"` // Marketplace purchases only, defaults to all remaining units
+}
+
+// ReversalResult describes the compensating journal that was posted
+type ReversalResult struct {
+	TransactionID     uint                       `json:"transaction_id"` // Wallet transaction the admin asked to reverse
+	JournalID         uint                       `json:"journal_id"`
+	ReversalJournalID uint                       `json:"reversal_journal_id"`
+	Kind              string                     `json:"kind"` // transfer, marketplace_purchase or the journal type
+	Status            string                     `json:"status"`
+	RefundedQuantity  int                        `json:"refunded_quantity,omitempty"`
+	Transactions      []wallet.WalletTransaction `json:"transactions"`
+}
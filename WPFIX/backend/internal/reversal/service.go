@@ -0,0 +1,148 @@
+package reversal
+
+import (
+	"errors"
+	"fmt"
+	"wallet-point/internal/marketplace"
+	"wallet-point/internal/transfer"
+	"wallet-point/internal/wallet"
+
+	"gorm.io/gorm"
+)
+
+// Service reverses completed point movements by posting compensating
+// journals and restoring the records they touched (transfer status,
+// purchase refunds and product stock)
+type Service struct {
+	walletService   *wallet.WalletService
+	transferRepo    *transfer.Repository
+	marketplaceRepo *marketplace.MarketplaceRepository
+	db              *gorm.DB
+}
+
+func NewService(walletService *wallet.WalletService, transferRepo *transfer.Repository, marketplaceRepo *marketplace.MarketplaceRepository, db *gorm.DB) *Service {
+	return &Service{
+		walletService:   walletService,
+		transferRepo:    transferRepo,
+		marketplaceRepo: marketplaceRepo,
+		db:              db,
+	}
+}
+
+// Reverse undoes the journal behind a wallet transaction. Marketplace
+// purchases may be refunded partially by quantity; everything else is
+// reversed in full.
+func (s *Service) Reverse(transactionID uint, req *ReverseRequest) (*ReversalResult, error) {
+	var result *ReversalResult
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		txn, err := s.walletService.GetTransactionByID(tx, transactionID)
+		if err != nil {
+			return err
+		}
+		if txn.JournalID == nil {
+			return errors.New("transaction predates the ledger and cannot be reversed")
+		}
+		if txn.Status == "reversed" {
+			return errors.New("transaction has already been reversed")
+		}
+		if txn.Type == "reversal" {
+			return errors.New("reversal journals cannot be reversed")
+		}
+		journalID := *txn.JournalID
+
+		result = &ReversalResult{TransactionID: txn.ID, JournalID: journalID}
+
+		purchase, err := s.marketplaceRepo.LockTransactionByJournal(tx, journalID)
+		if err != nil {
+			return err
+		}
+		if purchase != nil {
+			return s.refundPurchase(tx, purchase, req, result)
+		}
+
+		if req.Quantity > 0 {
+			return errors.New("quantity only applies to marketplace purchases")
+		}
+
+		reversal, txns, err := s.walletService.ReverseJournal(tx, journalID, 1, 1, true, req.Reason)
+		if err != nil {
+			return mapReversalError(err)
+		}
+		result.ReversalJournalID = reversal.ID
+		result.Transactions = txns
+		result.Kind = txn.Type
+		result.Status = "reversed"
+
+		t, err := s.transferRepo.LockByJournalID(tx, journalID)
+		if err != nil {
+			return err
+		}
+		if t != nil {
+			result.Kind = "transfer"
+			return s.transferRepo.UpdateStatus(tx, t.ID, "reversed")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// refundPurchase returns points for some or all units of a purchase and puts them back in stock
+func (s *Service) refundPurchase(tx *gorm.DB, purchase *marketplace.MarketplaceTransaction, req *ReverseRequest, result *ReversalResult) error {
+	remaining := purchase.Quantity - purchase.RefundedQuantity
+	if remaining <= 0 {
+		return errors.New("transaction has already been reversed")
+	}
+
+	quantity := req.Quantity
+	if quantity == 0 {
+		quantity = remaining
+	}
+	if quantity > remaining {
+		return fmt.Errorf("only %d unit(s) left to refund", remaining)
+	}
+
+	refunded := purchase.RefundedQuantity + quantity
+	final := refunded == purchase.Quantity
+
+	reason := fmt.Sprintf("refund %d/%d unit(s): %s", quantity, purchase.Quantity, req.Reason)
+	reversal, txns, err := s.walletService.ReverseJournal(tx, *purchase.JournalID, quantity, purchase.Quantity, final, reason)
+	if err != nil {
+		return mapReversalError(err)
+	}
+
+	status := "partially_refunded"
+	if final {
+		status = "reversed"
+	}
+	if err := s.marketplaceRepo.UpdateTransaction(tx, purchase.ID, map[string]interface{}{
+		"refunded_quantity": refunded,
+		"status":            status,
+	}); err != nil {
+		return err
+	}
+
+	// Refunded units go back on the shelf
+	if err := s.marketplaceRepo.UpdateStock(tx, purchase.ProductID, quantity); err != nil {
+		return err
+	}
+
+	result.ReversalJournalID = reversal.ID
+	result.Transactions = txns
+	result.Kind = "marketplace_purchase"
+	result.Status = status
+	result.RefundedQuantity = refunded
+	return nil
+}
+
+// mapReversalError explains why a compensating journal could not be posted
+func mapReversalError(err error) error {
+	if errors.Is(err, wallet.ErrInsufficientBalance) {
+		return errors.New("cannot reverse: the receiving wallet no longer holds enough points")
+	}
+	return err
+}
@@ -0,0 +1,129 @@
+package settlement
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strconv"
+	"wallet-point/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func listParams(c *gin.Context) SettlementListParams {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	return SettlementListParams{
+		Status: c.Query("status"),
+		Page:   page,
+		Limit:  limit,
+	}
+}
+
+// GetMySettlements handles a merchant listing their settlement batches
+// @Summary List my settlement batches
+// @Description Settlement batches of the merchant plus the sales since the last cutoff
+// @Tags Merchant
+// @Security BearerAuth
+// @Produce json
+// @Param status query string false "open or settled"
+// @Param page query int false "Page number"
+// @Param limit query int false "Items per page"
+// @Success 200 {object} utils.Response{data=SettlementListResponse}
+// @Router /merchant/settlements [get]
+func (h *Handler) GetMySettlements(c *gin.Context) {
+	merchantID := c.GetUint("user_id")
+
+	response, err := h.service.GetMerchantSettlements(merchantID, listParams(c))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Settlements retrieved successfully", response)
+}
+
+// GetAllSettlements handles admins listing batches of every merchant
+// @Summary List all settlement batches
+// @Description Open and settled batches of every merchant (Admin only)
+// @Tags Admin - Settlements
+// @Security BearerAuth
+// @Produce json
+// @Param status query string false "open or settled"
+// @Param merchant_id query int false "Merchant user ID"
+// @Param page query int false "Page number"
+// @Param limit query int false "Items per page"
+// @Success 200 {object} utils.Response{data=SettlementListResponse}
+// @Router /admin/settlements [get]
+func (h *Handler) GetAllSettlements(c *gin.Context) {
+	params := listParams(c)
+	if merchantID, err := strconv.ParseUint(c.Query("merchant_id"), 10, 32); err == nil {
+		params.MerchantID = uint(merchantID)
+	}
+
+	response, err := h.service.GetAllSettlements(params)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Settlements retrieved successfully", response)
+}
+
+// GetStatement handles downloading a batch statement. Merchants only see
+// their own batches; admins see every batch.
+// @Summary Download settlement statement
+// @Description Statement of a settlement batch as CSV (default) or PDF
+// @Tags Merchant
+// @Security BearerAuth
+// @Produce text/csv,application/pdf
+// @Param id path int true "Batch ID"
+// @Param format query string false "csv or pdf"
+// @Success 200 {file} file
+// @Router /merchant/settlements/{id}/statement [get]
+func (h *Handler) GetStatement(c *gin.Context) {
+	batchID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid batch ID", nil)
+		return
+	}
+
+	var merchantID uint
+	if c.GetString("role") != "admin" {
+		merchantID = c.GetUint("user_id")
+	}
+
+	statement, err := h.service.GetStatement(uint(batchID), merchantID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrBatchNotFound) {
+			status = http.StatusNotFound
+		}
+		utils.ErrorResponse(c, status, err.Error(), nil)
+		return
+	}
+
+	switch c.DefaultQuery("format", "csv") {
+	case "csv":
+		var buf bytes.Buffer
+		if err := statement.WriteCSV(&buf); err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to build statement", err.Error())
+			return
+		}
+		c.Header("Content-Disposition", `attachment; filename="`+statement.FileName()+`.csv"`)
+		c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+	case "pdf":
+		c.Header("Content-Disposition", `attachment; filename="`+statement.FileName()+`.pdf"`)
+		c.Data(http.StatusOK, "application/pdf", statement.PDF())
+	default:
+		utils.ErrorResponse(c, http.StatusBadRequest, "format must be csv or pdf", nil)
+	}
+}
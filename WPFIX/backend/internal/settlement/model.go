@@ -0,0 +1,74 @@
+package settlement
+
+import "time"
+
+// SettlementBatch groups a merchant's sales between two daily cutoffs.
+// A batch is "open" until its net amount has been moved out of the
+// merchant's operating wallet, then "settled". While the wallet cannot
+// cover it the batch stays open with its shortfall.
+type SettlementBatch struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	MerchantID    uint       `json:"merchant_id" gorm:"not null;index"` // Merchant user
+	WalletID      uint       `json:"wallet_id" gorm:"not null;uniqueIndex:idx_settlement_wallet_period"`
+	PeriodStart   time.Time  `json:"period_start" gorm:"not null"`
+	PeriodEnd     time.Time  `json:"period_end" gorm:"not null;uniqueIndex:idx_settlement_wallet_period"` // Cutoff, exclusive
+	SalesCount    int        `json:"sales_count" gorm:"not null;default:0"`
+	GrossAmount   int        `json:"gross_amount" gorm:"not null;default:0"`   // Sales credited in the period
+	RefundAmount  int        `json:"refund_amount" gorm:"not null;default:0"`  // Reversals debited in the period
+	NetAmount     int        `json:"net_amount" gorm:"not null;default:0"`     // Gross minus refunds
+	SettledAmount int        `json:"settled_amount" gorm:"not null;default:0"` // What was actually moved out
+	Shortfall     int        `json:"shortfall" gorm:"not null;default:0"`      // Net amount the wallet could not cover at the last attempt
+	Status        string     `json:"status" gorm:"type:enum('open','settled');default:'open';index"`
+	JournalID     *uint      `json:"journal_id"`
+	SettledAt     *time.Time `json:"settled_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+func (SettlementBatch) TableName() string {
+	return "settlement_batches"
+}
+
+// SettlementBatchWithMerchant adds the merchant name for admin listings
+type SettlementBatchWithMerchant struct {
+	SettlementBatch
+	MerchantName string `json:"merchant_name"`
+}
+
+// SettlementListParams filters batch listings
+type SettlementListParams struct {
+	MerchantID uint
+	WalletID   uint
+	Status     string
+	Page       int
+	Limit      int
+}
+
+// SettlementListResponse is a page of batches. Current summarises the sales
+// since the last cutoff that will go into the next batch (merchant view).
+type SettlementListResponse struct {
+	Batches    []SettlementBatchWithMerchant `json:"batches"`
+	Current    *PeriodSummary                `json:"current,omitempty"`
+	Total      int64                         `json:"total"`
+	Page       int                           `json:"page"`
+	Limit      int                           `json:"limit"`
+	TotalPages int                           `json:"total_pages"`
+}
+
+// PeriodSummary totals a merchant wallet's sales and refunds in a period
+type PeriodSummary struct {
+	PeriodStart  time.Time `json:"period_start"`
+	PeriodEnd    time.Time `json:"period_end"`
+	SalesCount   int       `json:"sales_count"`
+	GrossAmount  int       `json:"gross_amount"`
+	RefundAmount int       `json:"refund_amount"`
+	NetAmount    int       `json:"net_amount"`
+}
+
+// merchantWallet is a merchant user and their wallet
+type merchantWallet struct {
+	UserID    uint
+	WalletID  uint
+	FullName  string
+	CreatedAt time.Time
+}
@@ -0,0 +1,161 @@
+package settlement
+
+import (
+	"errors"
+	"time"
+	"wallet-point/internal/wallet"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrBatchNotFound = errors.New("settlement batch not found")
+
+type Repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// FindMerchantWallets returns every merchant user with a wallet
+func (r *Repository) FindMerchantWallets() ([]merchantWallet, error) {
+	var merchants []merchantWallet
+	err := r.db.Table("users u").
+		Select("u.id as user_id, w.id as wallet_id, u.full_name, w.created_at").
+		Joins("JOIN wallets w ON w.user_id = u.id").
+		Where("u.role = ?", "merchant").
+		Order("u.id ASC").
+		Scan(&merchants).Error
+	return merchants, err
+}
+
+// LastPeriodEnd returns the cutoff of the wallet's latest batch, nil when it has none
+func (r *Repository) LastPeriodEnd(walletID uint) (*time.Time, error) {
+	var batch SettlementBatch
+	err := r.db.Where("wallet_id = ?", walletID).Order("period_end DESC").First(&batch).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &batch.PeriodEnd, nil
+}
+
+// SumPeriod totals the sales credited to and refunds debited from a wallet in
+// [start, end). Only the default asset is settled.
+func (r *Repository) SumPeriod(walletID uint, start, end time.Time) (*PeriodSummary, error) {
+	var row struct {
+		SalesCount   int
+		GrossAmount  int
+		RefundAmount int
+	}
+	err := r.db.Model(&wallet.WalletTransaction{}).
+		Select(`COALESCE(SUM(CASE WHEN type = 'marketplace_sale' AND direction = 'credit' THEN 1 ELSE 0 END), 0) as sales_count,
+			COALESCE(SUM(CASE WHEN type = 'marketplace_sale' AND direction = 'credit' THEN amount ELSE 0 END), 0) as gross_amount,
+			COALESCE(SUM(CASE WHEN type = 'reversal' AND direction = 'debit' THEN amount ELSE 0 END), 0) as refund_amount`).
+		Where("wallet_id = ? AND asset = ? AND status IN ? AND created_at >= ? AND created_at < ?", walletID, wallet.DefaultAsset, []string{"success", "reversed"}, start, end).
+		Scan(&row).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &PeriodSummary{
+		PeriodStart:  start,
+		PeriodEnd:    end,
+		SalesCount:   row.SalesCount,
+		GrossAmount:  row.GrossAmount,
+		RefundAmount: row.RefundAmount,
+		NetAmount:    row.GrossAmount - row.RefundAmount,
+	}, nil
+}
+
+// Create inserts a batch; a batch for the same wallet and cutoff fails the unique index
+func (r *Repository) Create(batch *SettlementBatch) error {
+	return r.db.Create(batch).Error
+}
+
+// FindOpen returns every batch still waiting to be settled, oldest first
+func (r *Repository) FindOpen() ([]SettlementBatch, error) {
+	var batches []SettlementBatch
+	err := r.db.Where("status = ?", "open").Order("period_end ASC, id ASC").Find(&batches).Error
+	return batches, err
+}
+
+// LockOpen locks an open batch for settling, nil when it was settled meanwhile
+func (r *Repository) LockOpen(tx *gorm.DB, id uint) (*SettlementBatch, error) {
+	var batch SettlementBatch
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND status = ?", id, "open").
+		First(&batch).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// Update updates batch columns
+func (r *Repository) Update(tx *gorm.DB, id uint, updates map[string]interface{}) error {
+	return tx.Model(&SettlementBatch{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// FindByID finds a batch with its merchant name
+func (r *Repository) FindByID(id uint) (*SettlementBatchWithMerchant, error) {
+	var batch SettlementBatchWithMerchant
+	result := r.db.Table("settlement_batches sb").
+		Select("sb.*, u.full_name as merchant_name").
+		Joins("LEFT JOIN users u ON sb.merchant_id = u.id").
+		Where("sb.id = ?", id).
+		Limit(1).
+		Scan(&batch)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrBatchNotFound
+	}
+	return &batch, nil
+}
+
+// List returns batches matching the filters, newest period first
+func (r *Repository) List(params SettlementListParams) ([]SettlementBatchWithMerchant, int64, error) {
+	var batches []SettlementBatchWithMerchant
+	var total int64
+
+	query := r.db.Table("settlement_batches sb").
+		Select("sb.*, u.full_name as merchant_name").
+		Joins("LEFT JOIN users u ON sb.merchant_id = u.id")
+
+	if params.MerchantID != 0 {
+		query = query.Where("sb.merchant_id = ?", params.MerchantID)
+	}
+	if params.WalletID != 0 {
+		query = query.Where("sb.wallet_id = ?", params.WalletID)
+	}
+	if params.Status != "" {
+		query = query.Where("sb.status = ?", params.Status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (params.Page - 1) * params.Limit
+	err := query.Order("sb.period_end DESC, sb.id DESC").Limit(params.Limit).Offset(offset).Scan(&batches).Error
+	return batches, total, err
+}
+
+// GetStatementLines returns the sales and refunds that make up a batch
+func (r *Repository) GetStatementLines(walletID uint, start, end time.Time) ([]wallet.WalletTransaction, error) {
+	var lines []wallet.WalletTransaction
+	err := r.db.Where("wallet_id = ? AND asset = ? AND status IN ? AND created_at >= ? AND created_at < ?", walletID, wallet.DefaultAsset, []string{"success", "reversed"}, start, end).
+		Where("(type = 'marketplace_sale' AND direction = 'credit') OR (type = 'reversal' AND direction = 'debit')").
+		Order("created_at ASC, id ASC").
+		Find(&lines).Error
+	return lines, err
+}
@@ -0,0 +1,308 @@
+package settlement
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"wallet-point/internal/wallet"
+
+	"gorm.io/gorm"
+)
+
+// Service cuts merchant sales into daily settlement batches and moves each
+// batch's net amount out of the merchant's operating wallet into the
+// settlement account
+type Service struct {
+	repo          *Repository
+	walletService *wallet.WalletService
+	db            *gorm.DB
+	cutoffHour    int
+	cutoffMinute  int
+	location      *time.Location
+}
+
+// NewService parses the daily cutoff ("HH:MM", Asia/Jakarta time)
+func NewService(repo *Repository, walletService *wallet.WalletService, db *gorm.DB, cutoff string) *Service {
+	location, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		location = time.Local
+	}
+
+	s := &Service{
+		repo:          repo,
+		walletService: walletService,
+		db:            db,
+		location:      location,
+	}
+
+	parts := strings.Split(strings.TrimSpace(cutoff), ":")
+	hour, errHour := strconv.Atoi(parts[0])
+	minute := 0
+	var errMinute error
+	if len(parts) == 2 {
+		minute, errMinute = strconv.Atoi(parts[1])
+	}
+	if len(parts) > 2 || errHour != nil || errMinute != nil || hour < 0 || hour > 23 || minute < 0 || minute > 59 {
+		log.Printf("⚠️  Invalid settlement cutoff %q (expected HH:MM), using 00:00", cutoff)
+		hour, minute = 0, 0
+	}
+	s.cutoffHour, s.cutoffMinute = hour, minute
+
+	return s
+}
+
+// LastCutoff returns the most recent cutoff at or before now
+func (s *Service) LastCutoff(now time.Time) time.Time {
+	now = now.In(s.location)
+	cutoff := time.Date(now.Year(), now.Month(), now.Day(), s.cutoffHour, s.cutoffMinute, 0, 0, s.location)
+	if cutoff.After(now) {
+		cutoff = cutoff.AddDate(0, 0, -1)
+	}
+	return cutoff
+}
+
+// RunCutoff closes the sales of every merchant up to the last cutoff into a
+// batch, then settles all open batches. Batches that fail to settle stay
+// open and are retried on the next run. Returns the number of batches settled.
+func (s *Service) RunCutoff() (int64, error) {
+	cutoff := s.LastCutoff(time.Now())
+
+	created, err := s.createBatches(cutoff)
+	if err != nil {
+		return 0, err
+	}
+	if created > 0 {
+		log.Printf("[Settlement] created %d batches up to %s", created, cutoff.Format(time.RFC3339))
+	}
+
+	open, err := s.repo.FindOpen()
+	if err != nil {
+		return 0, err
+	}
+
+	var settled int64
+	var lastErr error
+	for _, batch := range open {
+		ok, err := s.settle(batch.ID)
+		if err != nil {
+			log.Printf("[Settlement] failed to settle batch %d: %v", batch.ID, err)
+			lastErr = err
+			continue
+		}
+		if ok {
+			settled++
+		}
+	}
+
+	return settled, lastErr
+}
+
+// createBatches opens a batch for every merchant with activity between its
+// previous cutoff and this one
+func (s *Service) createBatches(cutoff time.Time) (int, error) {
+	merchants, err := s.repo.FindMerchantWallets()
+	if err != nil {
+		return 0, err
+	}
+
+	created := 0
+	for _, m := range merchants {
+		start, err := s.periodStart(m.WalletID, m.CreatedAt)
+		if err != nil {
+			return created, err
+		}
+		if !start.Before(cutoff) {
+			continue
+		}
+
+		summary, err := s.repo.SumPeriod(m.WalletID, start, cutoff)
+		if err != nil {
+			return created, err
+		}
+		// Quiet days roll into the next batch instead of producing empty ones
+		if summary.SalesCount == 0 && summary.RefundAmount == 0 {
+			continue
+		}
+
+		batch := &SettlementBatch{
+			MerchantID:   m.UserID,
+			WalletID:     m.WalletID,
+			PeriodStart:  start,
+			PeriodEnd:    cutoff,
+			SalesCount:   summary.SalesCount,
+			GrossAmount:  summary.GrossAmount,
+			RefundAmount: summary.RefundAmount,
+			NetAmount:    summary.NetAmount,
+			Status:       "open",
+		}
+		if err := s.repo.Create(batch); err != nil {
+			// Another replica may have created it first; the unique index keeps one
+			log.Printf("[Settlement] could not create batch for wallet %d: %v", m.WalletID, err)
+			continue
+		}
+		created++
+	}
+
+	return created, nil
+}
+
+// periodStart is where a merchant's next batch begins: the previous cutoff,
+// or when the wallet was created for its first batch
+func (s *Service) periodStart(walletID uint, walletCreatedAt time.Time) (time.Time, error) {
+	last, err := s.repo.LastPeriodEnd(walletID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if last != nil {
+		return last.In(s.location), nil
+	}
+	return walletCreatedAt.In(s.location), nil
+}
+
+// settle moves the net amount of an open batch out of the merchant wallet.
+// A batch the wallet's available points cannot cover stays open with its
+// shortfall recorded and is retried on the next run. It reports whether the
+// batch was settled.
+func (s *Service) settle(batchID uint) (bool, error) {
+	settled := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		batch, err := s.repo.LockOpen(tx, batchID)
+		if err != nil || batch == nil {
+			return err
+		}
+
+		// Lock the merchant wallet so payments and holds cannot spend the
+		// points between reading the balance and posting the settlement
+		balance, err := s.walletService.LockBalanceSummary(tx, batch.WalletID)
+		if err != nil {
+			return err
+		}
+
+		amount := max(batch.NetAmount, 0)
+		if amount > balance.AvailableBalance {
+			shortfall := amount - max(balance.AvailableBalance, 0)
+			log.Printf("[Settlement] batch %d is short %d points, retrying on the next run", batch.ID, shortfall)
+			return s.repo.Update(tx, batch.ID, map[string]interface{}{"shortfall": shortfall})
+		}
+
+		now := time.Now()
+		updates := map[string]interface{}{
+			"status":         "settled",
+			"settled_amount": amount,
+			"shortfall":      0,
+			"settled_at":     now,
+		}
+
+		if amount > 0 {
+			description := fmt.Sprintf("Settlement batch #%d (%s - %s)", batch.ID,
+				batch.PeriodStart.In(s.location).Format("2006-01-02 15:04"), batch.PeriodEnd.In(s.location).Format("2006-01-02 15:04"))
+			batchID := batch.ID
+			journal, _, err := s.walletService.PostJournal(tx, "settlement", description, []wallet.LedgerLeg{
+				{
+					WalletID:    batch.WalletID,
+					Direction:   "debit",
+					Amount:      amount,
+					Type:        "settlement",
+					Description: description,
+					ReferenceID: &batchID,
+				},
+				{Account: wallet.AccountSettlement, Direction: "credit", Amount: amount},
+			})
+			if err != nil {
+				return err
+			}
+			updates["journal_id"] = journal.ID
+		}
+
+		if err := s.repo.Update(tx, batch.ID, updates); err != nil {
+			return err
+		}
+		settled = true
+		return nil
+	})
+	return settled, err
+}
+
+// GetMerchantSettlements lists a merchant's batches with the running period.
+// Cashiers see the batches of the merchant wallet they collect into.
+func (s *Service) GetMerchantSettlements(userID uint, params SettlementListParams) (*SettlementListResponse, error) {
+	merchantWallet, err := s.walletService.GetMerchantWallet(userID)
+	if err != nil {
+		return nil, errors.New("merchant wallet not found")
+	}
+
+	params.MerchantID = 0
+	params.WalletID = merchantWallet.ID
+	response, err := s.list(params)
+	if err != nil {
+		return nil, err
+	}
+
+	start, err := s.periodStart(merchantWallet.ID, merchantWallet.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	current, err := s.repo.SumPeriod(merchantWallet.ID, start, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	response.Current = current
+
+	return response, nil
+}
+
+// GetAllSettlements lists batches of every merchant (Admin)
+func (s *Service) GetAllSettlements(params SettlementListParams) (*SettlementListResponse, error) {
+	return s.list(params)
+}
+
+func (s *Service) list(params SettlementListParams) (*SettlementListResponse, error) {
+	if params.Page < 1 {
+		params.Page = 1
+	}
+	if params.Limit < 1 {
+		params.Limit = 20
+	}
+
+	batches, total, err := s.repo.List(params)
+	if err != nil {
+		return nil, err
+	}
+	if batches == nil {
+		batches = []SettlementBatchWithMerchant{}
+	}
+
+	return &SettlementListResponse{
+		Batches:    batches,
+		Total:      total,
+		Page:       params.Page,
+		Limit:      params.Limit,
+		TotalPages: int(math.Ceil(float64(total) / float64(params.Limit))),
+	}, nil
+}
+
+// GetStatement returns a batch with its lines. A non-zero userID restricts
+// access to batches of the merchant wallet that user collects into.
+func (s *Service) GetStatement(batchID, userID uint) (*Statement, error) {
+	batch, err := s.repo.FindByID(batchID)
+	if err != nil {
+		return nil, err
+	}
+	if userID != 0 {
+		merchantWallet, err := s.walletService.GetMerchantWallet(userID)
+		if err != nil || batch.WalletID != merchantWallet.ID {
+			return nil, ErrBatchNotFound
+		}
+	}
+
+	lines, err := s.repo.GetStatementLines(batch.WalletID, batch.PeriodStart, batch.PeriodEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Statement{Batch: batch, Lines: lines, location: s.location}, nil
+}
@@ -0,0 +1,105 @@
+package settlement
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"wallet-point/internal/wallet"
+	"wallet-point/utils"
+)
+
+const statementTimeFormat = "2006-01-02 15:04"
+
+// Statement is a settlement batch with the sales and refunds it covers
+type Statement struct {
+	Batch    *SettlementBatchWithMerchant
+	Lines    []wallet.WalletTransaction
+	location *time.Location
+}
+
+// FileName is the download name of the statement without extension
+func (st *Statement) FileName() string {
+	return fmt.Sprintf("settlement-%d-%s", st.Batch.ID, st.Batch.PeriodEnd.In(st.location).Format("20060102"))
+}
+
+// signedAmount shows refunds as negative amounts
+func signedAmount(line wallet.WalletTransaction) int {
+	if line.Direction == "debit" {
+		return -line.Amount
+	}
+	return line.Amount
+}
+
+// WriteCSV writes one row per sale or refund followed by the batch totals
+func (st *Statement) WriteCSV(w io.Writer) error {
+	b := st.Batch
+	writer := csv.NewWriter(w)
+
+	rows := [][]string{
+		{"batch_id", "merchant", "period_start", "period_end", "status"},
+		{strconv.Itoa(int(b.ID)), b.MerchantName, b.PeriodStart.In(st.location).Format(statementTimeFormat), b.PeriodEnd.In(st.location).Format(statementTimeFormat), b.Status},
+		{},
+		{"transaction_id", "date", "type", "description", "amount"},
+	}
+	for _, line := range st.Lines {
+		rows = append(rows, []string{
+			strconv.Itoa(int(line.ID)),
+			line.CreatedAt.In(st.location).Format(statementTimeFormat),
+			line.Type,
+			line.Description,
+			strconv.Itoa(signedAmount(line)),
+		})
+	}
+	rows = append(rows,
+		[]string{},
+		[]string{"sales_count", strconv.Itoa(b.SalesCount)},
+		[]string{"gross_amount", strconv.Itoa(b.GrossAmount)},
+		[]string{"refund_amount", strconv.Itoa(b.RefundAmount)},
+		[]string{"net_amount", strconv.Itoa(b.NetAmount)},
+		[]string{"settled_amount", strconv.Itoa(b.SettledAmount)},
+	)
+	if b.Shortfall > 0 {
+		rows = append(rows, []string{"shortfall", strconv.Itoa(b.Shortfall)})
+	}
+
+	if err := writer.WriteAll(rows); err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+// PDF renders the statement as a printable document
+func (st *Statement) PDF() []byte {
+	b := st.Batch
+	doc := utils.NewTextPDF(fmt.Sprintf("Settlement Statement #%d - %s", b.ID, b.MerchantName))
+
+	doc.Linef("Period   : %s - %s (WIB)", b.PeriodStart.In(st.location).Format(statementTimeFormat), b.PeriodEnd.In(st.location).Format(statementTimeFormat))
+	doc.Linef("Status   : %s", b.Status)
+	if b.SettledAt != nil {
+		doc.Linef("Settled  : %s", b.SettledAt.In(st.location).Format(statementTimeFormat))
+	}
+	doc.Line("")
+	doc.Linef("%-8s %-16s %-16s %-34s %10s", "ID", "Date", "Type", "Description", "Amount")
+	doc.Line(strings.Repeat("-", 90))
+	for _, line := range st.Lines {
+		description := line.Description
+		if len(description) > 34 {
+			description = description[:31] + "..."
+		}
+		doc.Linef("%-8d %-16s %-16s %-34s %10d", line.ID, line.CreatedAt.In(st.location).Format(statementTimeFormat), line.Type, description, signedAmount(line))
+	}
+	doc.Line("")
+	doc.Linef("%-20s %10d", "Sales", b.SalesCount)
+	doc.Linef("%-20s %10d", "Gross amount", b.GrossAmount)
+	doc.Linef("%-20s %10d", "Refunds", -b.RefundAmount)
+	doc.Linef("%-20s %10d", "Net amount", b.NetAmount)
+	doc.Linef("%-20s %10d", "Settled amount", b.SettledAmount)
+	if b.Shortfall > 0 {
+		doc.Linef("%-20s %10d", "Shortfall", b.Shortfall)
+	}
+
+	return doc.Bytes()
+}
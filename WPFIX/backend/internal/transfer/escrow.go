@@ -0,0 +1,386 @@
+package transfer
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+	"wallet-point/internal/notification"
+	"wallet-point/internal/wallet"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrEscrowNotFound = errors.New("escrow transfer not found")
+	ErrEscrowState    = errors.New("escrow transfer cannot change from its current status")
+)
+
+const (
+	// defaultDeliveryDays is how long the receiver has to deliver before the
+	// sender is refunded automatically
+	defaultDeliveryDays = 7
+	// confirmationWindow is how long the sender has to confirm or dispute a
+	// delivery before the points are released automatically
+	confirmationWindow = 72 * time.Hour
+	// dueEscrowsBatch bounds the escrows settled per job run
+	dueEscrowsBatch = 200
+)
+
+// escrowParties are the users on both sides of an escrow
+type escrowParties struct {
+	SenderUserID   uint
+	ReceiverUserID uint
+}
+
+// CreateEscrow locks the sender's points in escrow until the receiver
+// delivers and the sender confirms
+func (s *Service) CreateEscrow(senderUserID uint, req *CreateEscrowRequest) (*Transfer, error) {
+	receiverUserID, err := s.recipientUserID(req.Recipient, req.ReceiverUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	days := defaultDeliveryDays
+	if req.DeliverWithinDays > 0 {
+		days = req.DeliverWithinDays
+	}
+	deliverBy := time.Now().AddDate(0, 0, days)
+
+	var transfer *Transfer
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		transfer, err = s.postTransfer(tx, senderUserID, receiverUserID, req.Amount, req.Description, &deliverBy)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.notifyEscrow(receiverUserID, transfer, "escrow_created", "Points held in escrow for you",
+		fmt.Sprintf("%d points are held in escrow for \"%s\". Mark the item delivered by %s WIB to get paid.",
+			transfer.Amount, transfer.Description, deliverBy.In(scheduleLocation()).Format("2006-01-02 15:04")))
+
+	return s.escrowDetails(transfer)
+}
+
+// MarkEscrowDelivered is the receiver saying the item was handed over. The
+// sender then has the confirmation window to confirm or dispute.
+func (s *Service) MarkEscrowDelivered(transferID, userID uint) (*Transfer, error) {
+	var transfer *Transfer
+	var parties *escrowParties
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		transfer, parties, err = s.lockEscrow(tx, transferID)
+		if err != nil {
+			return err
+		}
+		if parties.ReceiverUserID != userID {
+			return ErrEscrowNotFound
+		}
+		if transfer.Status != "escrowed" {
+			return ErrEscrowState
+		}
+
+		now := time.Now()
+		releaseAt := now.Add(confirmationWindow)
+		transfer.Status = "delivered"
+		transfer.DeliveredAt = &now
+		transfer.ReleaseAt = &releaseAt
+		return s.repo.UpdateTransfer(tx, transfer.ID, map[string]interface{}{
+			"status":       transfer.Status,
+			"delivered_at": now,
+			"release_at":   releaseAt,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.notifyEscrow(parties.SenderUserID, transfer, "escrow_delivered", "Item marked as delivered",
+		fmt.Sprintf("The item for \"%s\" was marked delivered. Confirm to release %d points, or dispute it before %s WIB; otherwise the points are released automatically.",
+			transfer.Description, transfer.Amount, transfer.ReleaseAt.In(scheduleLocation()).Format("2006-01-02 15:04")))
+
+	return s.escrowDetails(transfer)
+}
+
+// ConfirmEscrow is the sender releasing the points to the receiver
+func (s *Service) ConfirmEscrow(transferID, userID uint) (*Transfer, error) {
+	var transfer *Transfer
+	var parties *escrowParties
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		transfer, parties, err = s.lockEscrow(tx, transferID)
+		if err != nil {
+			return err
+		}
+		if parties.SenderUserID != userID {
+			return ErrEscrowNotFound
+		}
+		if transfer.Status != "escrowed" && transfer.Status != "delivered" {
+			return ErrEscrowState
+		}
+		return s.settleEscrow(tx, transfer, parties, true, "")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.notifyEscrow(parties.ReceiverUserID, transfer, "escrow_released", "Escrow released",
+		fmt.Sprintf("%d points for \"%s\" were released to you.", transfer.Amount, transfer.Description))
+
+	return s.escrowDetails(transfer)
+}
+
+// DisputeEscrow moves an open escrow to the admin disputes queue. Either
+// side may dispute; automatic release and refund stop until an admin decides.
+func (s *Service) DisputeEscrow(transferID, userID uint, reason string) (*Transfer, error) {
+	var transfer *Transfer
+	var parties *escrowParties
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		transfer, parties, err = s.lockEscrow(tx, transferID)
+		if err != nil {
+			return err
+		}
+		if parties.SenderUserID != userID && parties.ReceiverUserID != userID {
+			return ErrEscrowNotFound
+		}
+		if transfer.Status != "escrowed" && transfer.Status != "delivered" {
+			return ErrEscrowState
+		}
+
+		transfer.Status = "disputed"
+		transfer.DisputeReason = reason
+		transfer.DisputedBy = &userID
+		return s.repo.UpdateTransfer(tx, transfer.ID, map[string]interface{}{
+			"status":         transfer.Status,
+			"dispute_reason": reason,
+			"disputed_by":    userID,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	other := parties.SenderUserID
+	if userID == parties.SenderUserID {
+		other = parties.ReceiverUserID
+	}
+	s.notifyEscrow(other, transfer, "escrow_disputed", "Escrow disputed",
+		fmt.Sprintf("The escrow for \"%s\" was disputed: %s. An admin will release or refund the %d points.", transfer.Description, reason, transfer.Amount))
+
+	return s.escrowDetails(transfer)
+}
+
+// ResolveEscrow is an admin's decision on a disputed escrow
+func (s *Service) ResolveEscrow(transferID uint, req *ResolveEscrowRequest) (*Transfer, error) {
+	var transfer *Transfer
+	var parties *escrowParties
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		transfer, parties, err = s.lockEscrow(tx, transferID)
+		if err != nil {
+			return err
+		}
+		if transfer.Status != "disputed" {
+			return ErrEscrowState
+		}
+		return s.settleEscrow(tx, transfer, parties, req.Action == "release", req.Note)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	outcome := "released to the receiver"
+	if transfer.Status == "refunded" {
+		outcome = "refunded to the sender"
+	}
+	message := fmt.Sprintf("The dispute about \"%s\" was resolved: %d points were %s.", transfer.Description, transfer.Amount, outcome)
+	if req.Note != "" {
+		message += " Note: " + req.Note
+	}
+	s.notifyEscrow(parties.SenderUserID, transfer, "escrow_resolved", "Escrow dispute resolved", message)
+	s.notifyEscrow(parties.ReceiverUserID, transfer, "escrow_resolved", "Escrow dispute resolved", message)
+
+	return s.escrowDetails(transfer)
+}
+
+// SettleDueEscrows releases deliveries the sender neither confirmed nor
+// disputed in time and refunds escrows that were never delivered
+func (s *Service) SettleDueEscrows() (int64, error) {
+	due, err := s.repo.FindDueEscrows(time.Now(), dueEscrowsBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	var settled int64
+	for _, candidate := range due {
+		var transfer *Transfer
+		var parties *escrowParties
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			transfer, parties, err = s.lockEscrow(tx, candidate.ID)
+			if err != nil {
+				return err
+			}
+
+			// Re-check under the lock: the escrow may have moved on meanwhile
+			now := time.Now()
+			switch {
+			case transfer.Status == "delivered" && transfer.ReleaseAt != nil && !transfer.ReleaseAt.After(now):
+				return s.settleEscrow(tx, transfer, parties, true, "Released automatically after the confirmation window")
+			case transfer.Status == "escrowed" && transfer.DeliverBy != nil && !transfer.DeliverBy.After(now):
+				return s.settleEscrow(tx, transfer, parties, false, "Refunded automatically: not delivered in time")
+			}
+			transfer = nil
+			return nil
+		})
+		if err != nil {
+			log.Printf("[Escrow] transfer %d: %v", candidate.ID, err)
+			continue
+		}
+		if transfer == nil {
+			continue
+		}
+
+		settled++
+		outcome := "released to the receiver: the delivery was not disputed in time"
+		if transfer.Status == "refunded" {
+			outcome = "refunded to the sender: the item was not delivered in time"
+		}
+		message := fmt.Sprintf("%d points for \"%s\" were %s.", transfer.Amount, transfer.Description, outcome)
+		s.notifyEscrow(parties.SenderUserID, transfer, "escrow_settled", "Escrow settled", message)
+		s.notifyEscrow(parties.ReceiverUserID, transfer, "escrow_settled", "Escrow settled", message)
+	}
+	return settled, nil
+}
+
+// settleEscrow moves the escrowed points to the receiver (release) or back
+// to the sender (refund) and closes the escrow
+func (s *Service) settleEscrow(tx *gorm.DB, transfer *Transfer, parties *escrowParties, release bool, note string) error {
+	journalType := "escrow_refund"
+	walletID := transfer.SenderWalletID
+	description := fmt.Sprintf("Escrow #%d refunded", transfer.ID)
+	status := "refunded"
+	if release {
+		journalType = "escrow_release"
+		walletID = transfer.ReceiverWalletID
+		description = fmt.Sprintf("Escrow #%d released from user %d", transfer.ID, parties.SenderUserID)
+		status = "success"
+	}
+
+	journal, _, err := s.walletService.PostJournal(tx, journalType, description, []wallet.LedgerLeg{
+		{
+			Account:   wallet.AccountEscrow,
+			Direction: "debit",
+			Amount:    transfer.Amount,
+		},
+		{
+			WalletID:    walletID,
+			Direction:   "credit",
+			Amount:      transfer.Amount,
+			Type:        "transfer_in",
+			Description: description,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	transfer.Status = status
+	transfer.SettledAt = &now
+	transfer.SettlementJournalID = &journal.ID
+	transfer.ResolutionNote = note
+	return s.repo.UpdateTransfer(tx, transfer.ID, map[string]interface{}{
+		"status":                status,
+		"settled_at":            now,
+		"settlement_journal_id": journal.ID,
+		"resolution_note":       note,
+	})
+}
+
+// lockEscrow locks an escrow transfer and looks up who is on both sides
+func (s *Service) lockEscrow(tx *gorm.DB, transferID uint) (*Transfer, *escrowParties, error) {
+	transfer, err := s.repo.LockTransfer(tx, transferID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if transfer == nil || transfer.Type != "escrow" {
+		return nil, nil, ErrEscrowNotFound
+	}
+
+	var wallets []struct {
+		ID     uint
+		UserID uint
+	}
+	if err := tx.Table("wallets").Select("id, user_id").
+		Where("id IN ?", []uint{transfer.SenderWalletID, transfer.ReceiverWalletID}).
+		Scan(&wallets).Error; err != nil {
+		return nil, nil, err
+	}
+
+	parties := &escrowParties{}
+	for _, w := range wallets {
+		if w.ID == transfer.SenderWalletID {
+			parties.SenderUserID = w.UserID
+		}
+		if w.ID == transfer.ReceiverWalletID {
+			parties.ReceiverUserID = w.UserID
+		}
+	}
+	return transfer, parties, nil
+}
+
+// GetUserEscrows lists the escrows a user sent or received
+func (s *Service) GetUserEscrows(userID uint, status string, limit, offset int) ([]Transfer, int64, error) {
+	w, err := s.walletService.GetWalletByUserID(userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	transfers, total, err := s.repo.FindEscrows(w.ID, status, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := s.populateTransferDetails(transfers); err != nil {
+		return nil, 0, err
+	}
+	return transfers, total, nil
+}
+
+// GetDisputedEscrows is the admin disputes queue
+func (s *Service) GetDisputedEscrows(limit, offset int) ([]Transfer, int64, error) {
+	transfers, total, err := s.repo.FindEscrows(0, "disputed", limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := s.populateTransferDetails(transfers); err != nil {
+		return nil, 0, err
+	}
+	return transfers, total, nil
+}
+
+// escrowDetails fills in the names of an escrow for the response
+func (s *Service) escrowDetails(transfer *Transfer) (*Transfer, error) {
+	transfers := []Transfer{*transfer}
+	if err := s.populateTransferDetails(transfers); err != nil {
+		return nil, err
+	}
+	return &transfers[0], nil
+}
+
+// notifyEscrow tells one side of an escrow what happened
+func (s *Service) notifyEscrow(userID uint, transfer *Transfer, kind, title, message string) {
+	if s.notifier == nil {
+		return
+	}
+	s.notifier.Notify(notification.CreateParams{
+		UserID:   userID,
+		Type:     kind,
+		Title:    title,
+		Message:  message,
+		Entity:   "TRANSFER",
+		EntityID: transfer.ID,
+	})
+}
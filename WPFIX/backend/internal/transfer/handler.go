@@ -1,9 +1,11 @@
 package transfer
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"wallet-point/internal/audit"
 	"wallet-point/utils"
 
@@ -47,10 +49,16 @@ func (h *Handler) CreateTransfer(c *gin.Context) {
 		return
 	}
 
+	receiverUserID, err := h.service.recipientUserID(req.Recipient, req.ReceiverUserID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, err.Error(), nil)
+		return
+	}
+
 	// Create transfer
-	transfer, err := h.service.CreateTransfer(senderUserID.(uint), req.ReceiverUserID, req.Amount, req.Description)
+	transfer, err := h.service.CreateTransfer(senderUserID.(uint), receiverUserID, req.Amount, req.Description)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		utils.ErrorFromErr(c, http.StatusBadRequest, err)
 		return
 	}
 
@@ -64,7 +72,7 @@ func (h *Handler) CreateTransfer(c *gin.Context) {
 		Action:    "TRANSFER_POINTS",
 		Entity:    "TRANSFER",
 		EntityID:  transfer.ID,
-		Details:   fmt.Sprintf("Transferred %d points to user %d", req.Amount, req.ReceiverUserID),
+		Details:   fmt.Sprintf("Transferred %d points to user %d", req.Amount, receiverUserID),
 		IPAddress: c.ClientIP(),
 		UserAgent: c.Request.UserAgent(),
 	})
@@ -194,7 +202,7 @@ func (h *Handler) GetAllTransfers(c *gin.Context) {
 
 // GetRecipientInfo handles GET /transfer/recipient/:id
 // @Summary Get recipient info for verification
-// @Description Find recipient name and role by ID
+// @Description Find the masked recipient name and role by ID. Users who opted out of discovery are not found
 // @Tags Transfer
 // @Produce json
 // @Param id path int true "User ID"
@@ -217,3 +225,833 @@ func (h *Handler) GetRecipientInfo(c *gin.Context) {
 
 	utils.SuccessResponse(c, http.StatusOK, "Recipient found", recipient)
 }
+
+// ResolveRecipient handles GET /transfer/recipient?q=
+// @Summary Find a recipient by NIM, email or handle
+// @Description Resolve an identifier to a masked recipient. "@name" is a handle. Users who opted out of discovery are not found
+// @Tags Transfer
+// @Produce json
+// @Param q query string true "NIM, email or @handle"
+// @Success 200 {object} RecipientSummary
+// @Failure 404 {object} utils.Response
+// @Security BearerAuth
+// @Router /mahasiswa/transfer/recipient [get]
+func (h *Handler) ResolveRecipient(c *gin.Context) {
+	q := c.Query("q")
+	if q == "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Query parameter q is required", nil)
+		return
+	}
+
+	recipient, err := h.service.ResolveRecipient(q)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrRecipientNotFound) {
+			status = http.StatusNotFound
+		}
+		utils.ErrorResponse(c, status, err.Error(), nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Recipient found", recipient)
+}
+
+// pointRequestErrorStatus maps point request errors to HTTP statuses
+func pointRequestErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrPointRequestNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrPointRequestAnswered), errors.Is(err, ErrPointRequestClosed), errors.Is(err, ErrPointRequestExpired):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// CreatePointRequest handles POST /transfer/requests
+// @Summary Request points from classmates
+// @Description Ask one or several users for points with per-person amounts or an even split
+// @Tags Transfer
+// @Accept json
+// @Produce json
+// @Param request body CreatePointRequest true "Recipients and amounts"
+// @Success 201 {object} utils.Response{data=PointRequest}
+// @Failure 400 {object} utils.ErrorResponse
+// @Security BearerAuth
+// @Router /mahasiswa/transfer/requests [post]
+func (h *Handler) CreatePointRequest(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	var req CreatePointRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	request, err := h.service.CreatePointRequest(userID, &req)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Point request sent successfully", request)
+
+	h.auditService.LogActivity(audit.CreateAuditParams{
+		UserID:    userID,
+		Action:    "REQUEST_POINTS",
+		Entity:    "POINT_REQUEST",
+		EntityID:  request.ID,
+		Details:   fmt.Sprintf("Requested %d points from %d users", request.TotalAmount, len(request.Recipients)),
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+}
+
+// GetIncomingPointRequests handles GET /transfer/requests/incoming
+// @Summary Get incoming point requests
+// @Description Shares other users asked the current user to pay
+// @Tags Transfer
+// @Produce json
+// @Param status query string false "pending, accepted, declined, expired or cancelled"
+// @Param limit query int false "Limit" default(20)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} utils.SuccessResponse
+// @Security BearerAuth
+// @Router /mahasiswa/transfer/requests/incoming [get]
+func (h *Handler) GetIncomingPointRequests(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	requests, total, err := h.service.GetIncomingPointRequests(userID, c.Query("status"), limit, offset)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Incoming point requests retrieved successfully", gin.H{
+		"requests": requests,
+		"total":    total,
+		"limit":    limit,
+		"offset":   offset,
+	})
+}
+
+// GetOutgoingPointRequests handles GET /transfer/requests/outgoing
+// @Summary Get outgoing point requests
+// @Description Point requests created by the current user with each recipient's answer
+// @Tags Transfer
+// @Produce json
+// @Param status query string false "open, closed or cancelled"
+// @Param limit query int false "Limit" default(20)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} utils.SuccessResponse
+// @Security BearerAuth
+// @Router /mahasiswa/transfer/requests/outgoing [get]
+func (h *Handler) GetOutgoingPointRequests(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	requests, total, err := h.service.GetOutgoingPointRequests(userID, c.Query("status"), limit, offset)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Outgoing point requests retrieved successfully", gin.H{
+		"requests": requests,
+		"total":    total,
+		"limit":    limit,
+		"offset":   offset,
+	})
+}
+
+func parseRequestID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid ID", nil)
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// AcceptPointRequest handles POST /transfer/requests/:id/accept
+// @Summary Accept a point request
+// @Description Pay the current user's share as a transfer to the requester
+// @Tags Transfer
+// @Produce json
+// @Param id path int true "Point request ID"
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 409 {object} utils.ErrorResponse
+// @Security BearerAuth
+// @Router /mahasiswa/transfer/requests/{id}/accept [post]
+func (h *Handler) AcceptPointRequest(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	requestID, ok := parseRequestID(c)
+	if !ok {
+		return
+	}
+
+	transfer, err := h.service.AcceptPointRequest(requestID, userID)
+	if err != nil {
+		utils.ErrorFromErr(c, pointRequestErrorStatus(err), err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Point request paid successfully", gin.H{
+		"transfer": transfer,
+	})
+
+	h.auditService.LogActivity(audit.CreateAuditParams{
+		UserID:    userID,
+		Action:    "ACCEPT_POINT_REQUEST",
+		Entity:    "POINT_REQUEST",
+		EntityID:  requestID,
+		Details:   fmt.Sprintf("Paid %d points for point request #%d (transfer %d)", transfer.Amount, requestID, transfer.ID),
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+}
+
+// DeclinePointRequest handles POST /transfer/requests/:id/decline
+// @Summary Decline a point request
+// @Description Decline the current user's share of a point request
+// @Tags Transfer
+// @Produce json
+// @Param id path int true "Point request ID"
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 409 {object} utils.ErrorResponse
+// @Security BearerAuth
+// @Router /mahasiswa/transfer/requests/{id}/decline [post]
+func (h *Handler) DeclinePointRequest(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	requestID, ok := parseRequestID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeclinePointRequest(requestID, userID); err != nil {
+		utils.ErrorResponse(c, pointRequestErrorStatus(err), err.Error(), nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Point request declined", nil)
+
+	h.auditService.LogActivity(audit.CreateAuditParams{
+		UserID:    userID,
+		Action:    "DECLINE_POINT_REQUEST",
+		Entity:    "POINT_REQUEST",
+		EntityID:  requestID,
+		Details:   fmt.Sprintf("Declined point request #%d", requestID),
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+}
+
+// CancelPointRequest handles POST /transfer/requests/:id/cancel
+// @Summary Cancel a point request
+// @Description Withdraw the unanswered shares of an open request (requester only)
+// @Tags Transfer
+// @Produce json
+// @Param id path int true "Point request ID"
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 409 {object} utils.ErrorResponse
+// @Security BearerAuth
+// @Router /mahasiswa/transfer/requests/{id}/cancel [post]
+func (h *Handler) CancelPointRequest(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	requestID, ok := parseRequestID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.CancelPointRequest(requestID, userID); err != nil {
+		utils.ErrorResponse(c, pointRequestErrorStatus(err), err.Error(), nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Point request cancelled", nil)
+
+	h.auditService.LogActivity(audit.CreateAuditParams{
+		UserID:    userID,
+		Action:    "CANCEL_POINT_REQUEST",
+		Entity:    "POINT_REQUEST",
+		EntityID:  requestID,
+		Details:   fmt.Sprintf("Cancelled point request #%d", requestID),
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+}
+
+// scheduleErrorStatus maps scheduled transfer errors to HTTP statuses
+func scheduleErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrScheduleNotFound), errors.Is(err, ErrRecipientNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrScheduleState):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// CreateScheduledTransfer handles POST /transfer/schedules
+// @Summary Schedule a transfer
+// @Description Send points once at a future time or weekly/monthly until an end date
+// @Tags Transfer
+// @Accept json
+// @Produce json
+// @Param schedule body CreateScheduledTransferRequest true "Schedule"
+// @Success 201 {object} utils.Response{data=ScheduledTransfer}
+// @Failure 400 {object} utils.ErrorResponse
+// @Security BearerAuth
+// @Router /mahasiswa/transfer/schedules [post]
+func (h *Handler) CreateScheduledTransfer(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	var req CreateScheduledTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	schedule, err := h.service.CreateScheduledTransfer(userID, &req)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Transfer scheduled successfully", schedule)
+
+	h.auditService.LogActivity(audit.CreateAuditParams{
+		UserID:    userID,
+		Action:    "SCHEDULE_TRANSFER",
+		Entity:    "SCHEDULED_TRANSFER",
+		EntityID:  schedule.ID,
+		Details:   fmt.Sprintf("Scheduled %s transfer of %d points to user %d from %s", schedule.Frequency, schedule.Amount, schedule.ReceiverUserID, schedule.StartAt.Format("2006-01-02 15:04")),
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+}
+
+// GetScheduledTransfers handles GET /transfer/schedules
+// @Summary Get my scheduled transfers
+// @Description Scheduled and recurring transfers created by the current user
+// @Tags Transfer
+// @Produce json
+// @Param status query string false "active, paused, completed, failed or cancelled"
+// @Param limit query int false "Limit" default(20)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} utils.SuccessResponse
+// @Security BearerAuth
+// @Router /mahasiswa/transfer/schedules [get]
+func (h *Handler) GetScheduledTransfers(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	schedules, total, err := h.service.GetScheduledTransfers(userID, c.Query("status"), limit, offset)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Scheduled transfers retrieved successfully", gin.H{
+		"schedules": schedules,
+		"total":     total,
+		"limit":     limit,
+		"offset":    offset,
+	})
+}
+
+// GetScheduledTransferRuns handles GET /transfer/schedules/:id/runs
+// @Summary Get runs of a scheduled transfer
+// @Description Executed and skipped occurrences of one of my schedules
+// @Tags Transfer
+// @Produce json
+// @Param id path int true "Schedule ID"
+// @Param limit query int false "Limit" default(20)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Security BearerAuth
+// @Router /mahasiswa/transfer/schedules/{id}/runs [get]
+func (h *Handler) GetScheduledTransferRuns(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	scheduleID, ok := parseRequestID(c)
+	if !ok {
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	runs, total, err := h.service.GetScheduledTransferRuns(userID, scheduleID, limit, offset)
+	if err != nil {
+		utils.ErrorResponse(c, scheduleErrorStatus(err), err.Error(), nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Scheduled transfer runs retrieved successfully", gin.H{
+		"runs":   runs,
+		"total":  total,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// PauseScheduledTransfer handles POST /transfer/schedules/:id/pause
+// @Summary Pause a scheduled transfer
+// @Tags Transfer
+// @Produce json
+// @Param id path int true "Schedule ID"
+// @Success 200 {object} utils.Response{data=ScheduledTransfer}
+// @Failure 409 {object} utils.ErrorResponse
+// @Security BearerAuth
+// @Router /mahasiswa/transfer/schedules/{id}/pause [post]
+func (h *Handler) PauseScheduledTransfer(c *gin.Context) {
+	h.changeSchedule(c, "PAUSE_SCHEDULED_TRANSFER", "Scheduled transfer paused", h.service.PauseScheduledTransfer)
+}
+
+// ResumeScheduledTransfer handles POST /transfer/schedules/:id/resume
+// @Summary Resume a paused scheduled transfer
+// @Description Continues with the next future occurrence
+// @Tags Transfer
+// @Produce json
+// @Param id path int true "Schedule ID"
+// @Success 200 {object} utils.Response{data=ScheduledTransfer}
+// @Failure 409 {object} utils.ErrorResponse
+// @Security BearerAuth
+// @Router /mahasiswa/transfer/schedules/{id}/resume [post]
+func (h *Handler) ResumeScheduledTransfer(c *gin.Context) {
+	h.changeSchedule(c, "RESUME_SCHEDULED_TRANSFER", "Scheduled transfer resumed", h.service.ResumeScheduledTransfer)
+}
+
+// CancelScheduledTransfer handles POST /transfer/schedules/:id/cancel
+// @Summary Cancel a scheduled transfer
+// @Tags Transfer
+// @Produce json
+// @Param id path int true "Schedule ID"
+// @Success 200 {object} utils.Response{data=ScheduledTransfer}
+// @Failure 409 {object} utils.ErrorResponse
+// @Security BearerAuth
+// @Router /mahasiswa/transfer/schedules/{id}/cancel [post]
+func (h *Handler) CancelScheduledTransfer(c *gin.Context) {
+	h.changeSchedule(c, "CANCEL_SCHEDULED_TRANSFER", "Scheduled transfer cancelled", h.service.CancelScheduledTransfer)
+}
+
+// changeSchedule applies a status change to one of the user's schedules
+func (h *Handler) changeSchedule(c *gin.Context, action, message string, change func(userID, scheduleID uint) (*ScheduledTransfer, error)) {
+	userID := c.GetUint("user_id")
+	scheduleID, ok := parseRequestID(c)
+	if !ok {
+		return
+	}
+
+	schedule, err := change(userID, scheduleID)
+	if err != nil {
+		utils.ErrorResponse(c, scheduleErrorStatus(err), err.Error(), nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, message, schedule)
+
+	h.auditService.LogActivity(audit.CreateAuditParams{
+		UserID:    userID,
+		Action:    action,
+		Entity:    "SCHEDULED_TRANSFER",
+		EntityID:  schedule.ID,
+		Details:   fmt.Sprintf("Scheduled transfer #%d is now %s", schedule.ID, schedule.Status),
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+}
+
+// GetTransferRules handles GET /admin/transfer-rules
+// @Summary Get transfer rules
+// @Description Daily caps, velocity, account age, blocked receiver roles and the circular-flow window (Admin only)
+// @Tags Admin - Transfers
+// @Produce json
+// @Success 200 {object} utils.Response{data=TransferRules}
+// @Security BearerAuth
+// @Router /admin/transfer-rules [get]
+func (h *Handler) GetTransferRules(c *gin.Context) {
+	rules, err := h.service.GetTransferRules()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve transfer rules", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Transfer rules retrieved successfully", rules)
+}
+
+// UpdateTransferRules handles PUT /admin/transfer-rules
+// @Summary Update transfer rules
+// @Description Replace the transfer rules. Null disables a limit; they apply to the next transfer (Admin only)
+// @Tags Admin - Transfers
+// @Accept json
+// @Produce json
+// @Param request body UpdateTransferRulesRequest true "Rules"
+// @Success 200 {object} utils.Response{data=TransferRules}
+// @Failure 400 {object} utils.Response
+// @Security BearerAuth
+// @Router /admin/transfer-rules [put]
+func (h *Handler) UpdateTransferRules(c *gin.Context) {
+	adminID := c.GetUint("user_id")
+
+	var req UpdateTransferRulesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	rules, err := h.service.UpdateTransferRules(adminID, &req)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Transfer rules updated successfully", rules)
+
+	h.auditService.LogActivity(audit.CreateAuditParams{
+		UserID: adminID,
+		Action: "UPDATE_TRANSFER_RULES",
+		Entity: "TRANSFER",
+		Details: fmt.Sprintf("Admin updated transfer rules: daily cap %s, per hour %s, min account age %s days, blocked receiver roles [%s], circular window %dh",
+			formatRule(rules.DailyAmountCap), formatRule(rules.MaxTransfersPerHour), formatRule(rules.MinAccountAgeDays),
+			strings.Join(rules.BlockedReceiverRoles, ", "), rules.CircularWindowHours),
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+}
+
+// formatRule renders an optional rule limit for audit details
+func formatRule(limit *int) string {
+	if limit == nil {
+		return "none"
+	}
+	return strconv.Itoa(*limit)
+}
+
+// GetFlaggedTransfers handles GET /admin/transfers/flagged
+// @Summary Flagged transfers review queue
+// @Description Transfers flagged by the anomaly rules, e.g. circular flows (Admin only)
+// @Tags Admin - Transfers
+// @Produce json
+// @Param status query string false "open, cleared or confirmed"
+// @Param limit query int false "Limit" default(50)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} utils.Response
+// @Security BearerAuth
+// @Router /admin/transfers/flagged [get]
+func (h *Handler) GetFlaggedTransfers(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	flags, total, err := h.service.GetFlaggedTransfers(c.Query("status"), limit, offset)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Flagged transfers retrieved successfully", gin.H{
+		"flags":  flags,
+		"total":  total,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// ReviewTransferFlag handles POST /admin/transfers/flagged/:id/review
+// @Summary Review a flagged transfer
+// @Description Close a flag as cleared (harmless) or confirmed (abuse) (Admin only)
+// @Tags Admin - Transfers
+// @Accept json
+// @Produce json
+// @Param id path int true "Flag ID"
+// @Param request body ReviewTransferFlagRequest true "Review"
+// @Success 200 {object} utils.Response{data=TransferFlag}
+// @Failure 404 {object} utils.Response
+// @Failure 409 {object} utils.Response
+// @Security BearerAuth
+// @Router /admin/transfers/flagged/{id}/review [post]
+func (h *Handler) ReviewTransferFlag(c *gin.Context) {
+	adminID := c.GetUint("user_id")
+
+	flagID, ok := parseRequestID(c)
+	if !ok {
+		return
+	}
+
+	var req ReviewTransferFlagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	flag, err := h.service.ReviewTransferFlag(flagID, adminID, &req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, ErrFlagNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, ErrFlagReviewed):
+			status = http.StatusConflict
+		}
+		utils.ErrorResponse(c, status, err.Error(), nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Flagged transfer reviewed", flag)
+
+	h.auditService.LogActivity(audit.CreateAuditParams{
+		UserID:    adminID,
+		Action:    "REVIEW_TRANSFER_FLAG",
+		Entity:    "TRANSFER",
+		EntityID:  flag.TransferID,
+		Details:   fmt.Sprintf("Admin marked flag #%d as %s: %s", flag.ID, flag.Status, req.Note),
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+}
+
+// escrowErrorStatus maps escrow errors to HTTP statuses
+func escrowErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrEscrowNotFound), errors.Is(err, ErrRecipientNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrEscrowState):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// CreateEscrow handles POST /transfer/escrows
+// @Summary Create an escrow transfer
+// @Description Lock points for a peer-to-peer deal; they are released once the receiver delivers and the sender confirms
+// @Tags Transfer
+// @Accept json
+// @Produce json
+// @Param request body CreateEscrowRequest true "Escrow details"
+// @Success 201 {object} utils.Response{data=Transfer}
+// @Failure 400 {object} utils.Response
+// @Security BearerAuth
+// @Router /mahasiswa/transfer/escrows [post]
+func (h *Handler) CreateEscrow(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	var req CreateEscrowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	escrow, err := h.service.CreateEscrow(userID, &req)
+	if err != nil {
+		utils.ErrorFromErr(c, escrowErrorStatus(err), err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Points held in escrow", escrow)
+
+	h.auditService.LogActivity(audit.CreateAuditParams{
+		UserID:    userID,
+		Action:    "CREATE_ESCROW",
+		Entity:    "TRANSFER",
+		EntityID:  escrow.ID,
+		Details:   fmt.Sprintf("Escrowed %d points for %s", escrow.Amount, escrow.ReceiverName),
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+}
+
+// GetMyEscrows handles GET /transfer/escrows
+// @Summary List my escrow transfers
+// @Description Escrows the current user sent or received
+// @Tags Transfer
+// @Produce json
+// @Param status query string false "escrowed, delivered, disputed, success or refunded"
+// @Param limit query int false "Limit" default(20)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} utils.Response
+// @Security BearerAuth
+// @Router /mahasiswa/transfer/escrows [get]
+func (h *Handler) GetMyEscrows(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	escrows, total, err := h.service.GetUserEscrows(userID, c.Query("status"), limit, offset)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Escrows retrieved successfully", gin.H{
+		"escrows": escrows,
+		"total":   total,
+		"limit":   limit,
+		"offset":  offset,
+	})
+}
+
+// MarkEscrowDelivered handles POST /transfer/escrows/:id/deliver
+// @Summary Mark an escrow delivered
+// @Description The receiver marks the item handed over; the sender then has 72 hours to confirm or dispute
+// @Tags Transfer
+// @Produce json
+// @Param id path int true "Transfer ID"
+// @Success 200 {object} utils.Response{data=Transfer}
+// @Failure 404 {object} utils.Response
+// @Failure 409 {object} utils.Response
+// @Security BearerAuth
+// @Router /mahasiswa/transfer/escrows/{id}/deliver [post]
+func (h *Handler) MarkEscrowDelivered(c *gin.Context) {
+	h.changeEscrow(c, "DELIVER_ESCROW", "Escrow marked as delivered", h.service.MarkEscrowDelivered)
+}
+
+// ConfirmEscrow handles POST /transfer/escrows/:id/confirm
+// @Summary Confirm an escrow
+// @Description The sender confirms the deal and the points are released to the receiver
+// @Tags Transfer
+// @Produce json
+// @Param id path int true "Transfer ID"
+// @Success 200 {object} utils.Response{data=Transfer}
+// @Failure 404 {object} utils.Response
+// @Failure 409 {object} utils.Response
+// @Security BearerAuth
+// @Router /mahasiswa/transfer/escrows/{id}/confirm [post]
+func (h *Handler) ConfirmEscrow(c *gin.Context) {
+	h.changeEscrow(c, "CONFIRM_ESCROW", "Escrow released", h.service.ConfirmEscrow)
+}
+
+// DisputeEscrow handles POST /transfer/escrows/:id/dispute
+// @Summary Dispute an escrow
+// @Description Either side sends an open escrow to the admin disputes queue
+// @Tags Transfer
+// @Accept json
+// @Produce json
+// @Param id path int true "Transfer ID"
+// @Param request body DisputeEscrowRequest true "Reason"
+// @Success 200 {object} utils.Response{data=Transfer}
+// @Failure 404 {object} utils.Response
+// @Failure 409 {object} utils.Response
+// @Security BearerAuth
+// @Router /mahasiswa/transfer/escrows/{id}/dispute [post]
+func (h *Handler) DisputeEscrow(c *gin.Context) {
+	var req DisputeEscrowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	h.changeEscrow(c, "DISPUTE_ESCROW", "Escrow sent to the disputes queue", func(transferID, userID uint) (*Transfer, error) {
+		return h.service.DisputeEscrow(transferID, userID, req.Reason)
+	})
+}
+
+// changeEscrow applies a participant's step to an escrow
+func (h *Handler) changeEscrow(c *gin.Context, action, message string, change func(transferID, userID uint) (*Transfer, error)) {
+	userID := c.GetUint("user_id")
+	transferID, ok := parseRequestID(c)
+	if !ok {
+		return
+	}
+
+	escrow, err := change(transferID, userID)
+	if err != nil {
+		utils.ErrorFromErr(c, escrowErrorStatus(err), err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, message, escrow)
+
+	h.auditService.LogActivity(audit.CreateAuditParams{
+		UserID:    userID,
+		Action:    action,
+		Entity:    "TRANSFER",
+		EntityID:  escrow.ID,
+		Details:   fmt.Sprintf("Escrow #%d is now %s", escrow.ID, escrow.Status),
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+}
+
+// GetEscrowDisputes handles GET /admin/transfers/disputes
+// @Summary Escrow disputes queue
+// @Description Disputed escrow transfers waiting for an admin to release or refund them (Admin only)
+// @Tags Admin - Transfers
+// @Produce json
+// @Param limit query int false "Limit" default(50)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} utils.Response
+// @Security BearerAuth
+// @Router /admin/transfers/disputes [get]
+func (h *Handler) GetEscrowDisputes(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	escrows, total, err := h.service.GetDisputedEscrows(limit, offset)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Escrow disputes retrieved successfully", gin.H{
+		"escrows": escrows,
+		"total":   total,
+		"limit":   limit,
+		"offset":  offset,
+	})
+}
+
+// ResolveEscrowDispute handles POST /admin/transfers/disputes/:id/resolve
+// @Summary Resolve an escrow dispute
+// @Description Release the points to the receiver or refund them to the sender (Admin only)
+// @Tags Admin - Transfers
+// @Accept json
+// @Produce json
+// @Param id path int true "Transfer ID"
+// @Param request body ResolveEscrowRequest true "Decision"
+// @Success 200 {object} utils.Response{data=Transfer}
+// @Failure 404 {object} utils.Response
+// @Failure 409 {object} utils.Response
+// @Security BearerAuth
+// @Router /admin/transfers/disputes/{id}/resolve [post]
+func (h *Handler) ResolveEscrowDispute(c *gin.Context) {
+	adminID := c.GetUint("user_id")
+	transferID, ok := parseRequestID(c)
+	if !ok {
+		return
+	}
+
+	var req ResolveEscrowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	escrow, err := h.service.ResolveEscrow(transferID, &req)
+	if err != nil {
+		utils.ErrorFromErr(c, escrowErrorStatus(err), err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Escrow dispute resolved", escrow)
+
+	h.auditService.LogActivity(audit.CreateAuditParams{
+		UserID:    adminID,
+		Action:    "RESOLVE_ESCROW",
+		Entity:    "TRANSFER",
+		EntityID:  escrow.ID,
+		Details:   fmt.Sprintf("Admin chose %s for escrow #%d (%d points): %s", req.Action, escrow.ID, escrow.Amount, req.Note),
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+}
@@ -11,10 +11,23 @@ type Transfer struct {
 	ReceiverWalletID uint      `json:"receiver_wallet_id" gorm:"not null;index"`
 	Amount           int       `json:"amount" gorm:"not null"`
 	Description      string    `json:"description" gorm:"type:varchar(255)"`
-	Status           string    `json:"status" gorm:"type:enum('success','failed');default:'success'"`
+	Type             string    `json:"type" gorm:"type:enum('direct','escrow');default:'direct'"`
+	Status           string    `json:"status" gorm:"type:enum('success','failed','reversed','escrowed','delivered','disputed','refunded');default:'success';index"`
+	JournalID        *uint     `json:"journal_id" gorm:"index"`
 	CreatedAt        time.Time `json:"created_at"`
 	UpdatedAt        time.Time `json:"updated_at"`
 
+	// Escrow transfers hold the points in system:escrow until they are
+	// released to the receiver or refunded to the sender
+	DeliverBy           *time.Time `json:"deliver_by,omitempty"`   // Refunded automatically if not delivered by then
+	DeliveredAt         *time.Time `json:"delivered_at,omitempty"` // Receiver marked the item delivered
+	ReleaseAt           *time.Time `json:"release_at,omitempty"`   // Released automatically unless confirmed or disputed before
+	SettledAt           *time.Time `json:"settled_at,omitempty"`   // Released or refunded
+	SettlementJournalID *uint      `json:"settlement_journal_id,omitempty"`
+	DisputeReason       string     `json:"dispute_reason,omitempty" gorm:"type:varchar(255)"`
+	DisputedBy          *uint      `json:"disputed_by,omitempty"`
+	ResolutionNote      string     `json:"resolution_note,omitempty" gorm:"type:varchar(255)"`
+
 	// Virtual fields for response
 	SenderName   string `json:"sender_name,omitempty" gorm:"-"`
 	ReceiverName string `json:"receiver_name,omitempty" gorm:"-"`
@@ -27,13 +40,35 @@ func (Transfer) TableName() string {
 	return "transfers"
 }
 
-// TransferRequest represents the request body for creating a transfer
+// TransferRequest represents the request body for creating a transfer.
+// Recipient is a NIM, email or handle and takes precedence over ReceiverUserID.
 type TransferRequest struct {
-	ReceiverUserID uint   `json:"receiver_user_id" binding:"required"`
+	ReceiverUserID uint   `json:"receiver_user_id" binding:"required_without=Recipient"`
+	Recipient      string `json:"recipient" binding:"required_without=ReceiverUserID,max=100"`
 	Amount         int    `json:"amount" binding:"required,gt=0"`
 	Description    string `json:"description" binding:"max=255"`
 }
 
+// CreateEscrowRequest is the request body for an escrow transfer
+type CreateEscrowRequest struct {
+	ReceiverUserID    uint   `json:"receiver_user_id" binding:"required_without=Recipient"`
+	Recipient         string `json:"recipient" binding:"required_without=ReceiverUserID,max=100"` // NIM, email or handle
+	Amount            int    `json:"amount" binding:"required,gt=0"`
+	Description       string `json:"description" binding:"required,max=255"` // What is being bought
+	DeliverWithinDays int    `json:"deliver_within_days" binding:"omitempty,min=1,max=30"`
+}
+
+// DisputeEscrowRequest sends an escrow to the admin disputes queue
+type DisputeEscrowRequest struct {
+	Reason string `json:"reason" binding:"required,max=255"`
+}
+
+// ResolveEscrowRequest is an admin's decision on a disputed escrow
+type ResolveEscrowRequest struct {
+	Action string `json:"action" binding:"required,oneof=release refund"`
+	Note   string `json:"note" binding:"max=255"`
+}
+
 // TransferResponse represents the response for transfer operations
 type TransferResponse struct {
 	Transfer *Transfer `json:"transfer"`
@@ -47,3 +82,180 @@ type RecipientSummary struct {
 	Role     string `json:"role"`
 	NIM      string `json:"nim,omitempty"`
 }
+
+// PointRequest asks one or several students for points, e.g. to split the
+// bill of a group purchase. Each recipient accepts or declines their share;
+// an accepted share executes as a normal transfer to the requester.
+type PointRequest struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	RequesterID uint      `json:"requester_id" gorm:"not null;index"` // User receiving the points
+	Description string    `json:"description" gorm:"type:varchar(255)"`
+	TotalAmount int       `json:"total_amount" gorm:"not null"` // Sum of all shares
+	Status      string    `json:"status" gorm:"type:enum('open','closed','cancelled');default:'open';index"`
+	ExpiresAt   time.Time `json:"expires_at" gorm:"not null;index"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	Recipients []PointRequestRecipient `json:"recipients,omitempty" gorm:"foreignKey:RequestID"`
+}
+
+func (PointRequest) TableName() string {
+	return "point_requests"
+}
+
+// PointRequestRecipient is one person's share of a point request
+type PointRequestRecipient struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	RequestID   uint       `json:"request_id" gorm:"not null;uniqueIndex:idx_point_request_user"`
+	UserID      uint       `json:"user_id" gorm:"not null;uniqueIndex:idx_point_request_user;index"`
+	Amount      int        `json:"amount" gorm:"not null"`
+	Status      string     `json:"status" gorm:"type:enum('pending','accepted','declined','expired','cancelled');default:'pending'"`
+	TransferID  *uint      `json:"transfer_id"` // Transfer executed on accept
+	RespondedAt *time.Time `json:"responded_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+
+	// Virtual fields for response
+	FullName string `json:"full_name,omitempty" gorm:"-"`
+	NIM      string `json:"nim,omitempty" gorm:"-"`
+}
+
+func (PointRequestRecipient) TableName() string {
+	return "point_request_recipients"
+}
+
+// IncomingPointRequest is a share someone asked the current user to pay
+type IncomingPointRequest struct {
+	PointRequestRecipient
+	RequesterID   uint      `json:"requester_id"`
+	RequesterName string    `json:"requester_name"`
+	RequesterNIM  string    `json:"requester_nim"`
+	Description   string    `json:"description"`
+	ExpiresAt     time.Time `json:"expires_at"`
+}
+
+// PointRequestShare is one recipient in a create request
+type PointRequestShare struct {
+	UserID uint `json:"user_id" binding:"required"`
+	Amount int  `json:"amount" binding:"omitempty,gt=0"`
+}
+
+// CreatePointRequest is the request body for asking classmates for points.
+// With SplitAmount the total is divided evenly between the recipients (and
+// the requester when IncludeSelf is set) and per-person amounts are ignored.
+type CreatePointRequest struct {
+	Recipients  []PointRequestShare `json:"recipients" binding:"required,min=1,max=20,dive"`
+	SplitAmount int                 `json:"split_amount" binding:"omitempty,gt=0"`
+	IncludeSelf bool                `json:"include_self"`
+	Description string              `json:"description" binding:"max=255"`
+	ExpiryHours int                 `json:"expiry_hours" binding:"omitempty,gt=0,lte=336"` // Default 72
+}
+
+// ScheduledTransfer sends points at a future time, once or on a weekly or
+// monthly recurrence until EndAt. Each due occurrence is executed by the
+// background runner as a normal transfer.
+type ScheduledTransfer struct {
+	ID               uint       `json:"id" gorm:"primaryKey"`
+	SenderUserID     uint       `json:"sender_user_id" gorm:"not null;index"`
+	ReceiverUserID   uint       `json:"receiver_user_id" gorm:"not null;index"`
+	Amount           int        `json:"amount" gorm:"not null"`
+	Description      string     `json:"description" gorm:"type:varchar(255)"`
+	Frequency        string     `json:"frequency" gorm:"type:enum('once','weekly','monthly');not null"`
+	StartAt          time.Time  `json:"start_at" gorm:"not null"` // First occurrence, anchors the recurrence
+	EndAt            *time.Time `json:"end_at"`                   // No occurrence after this (recurring only)
+	NextRunAt        time.Time  `json:"next_run_at" gorm:"not null;index"`
+	Occurrences      int        `json:"occurrences" gorm:"not null;default:0"` // Occurrences passed, run or skipped
+	RunCount         int        `json:"run_count" gorm:"not null;default:0"`
+	SkippedCount     int        `json:"skipped_count" gorm:"not null;default:0"`
+	ConsecutiveSkips int        `json:"consecutive_skips" gorm:"not null;default:0"`
+	LastRunAt        *time.Time `json:"last_run_at"`
+	LastError        string     `json:"last_error" gorm:"type:varchar(255)"`
+	Status           string     `json:"status" gorm:"type:enum('active','paused','completed','failed','cancelled');default:'active';index"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+
+	// Virtual fields for response
+	ReceiverName string `json:"receiver_name,omitempty" gorm:"-"`
+	ReceiverNIM  string `json:"receiver_nim,omitempty" gorm:"-"`
+}
+
+func (ScheduledTransfer) TableName() string {
+	return "scheduled_transfers"
+}
+
+// ScheduledTransferRun records what happened to one occurrence of a schedule
+type ScheduledTransferRun struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	ScheduleID  uint      `json:"schedule_id" gorm:"not null;index"`
+	ScheduledAt time.Time `json:"scheduled_at" gorm:"not null"` // Occurrence time
+	Status      string    `json:"status" gorm:"type:enum('success','skipped');not null"`
+	TransferID  *uint     `json:"transfer_id"`
+	Error       string    `json:"error" gorm:"type:varchar(255)"` // Why the occurrence was skipped
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (ScheduledTransferRun) TableName() string {
+	return "scheduled_transfer_runs"
+}
+
+// CreateScheduledTransferRequest is the request body for scheduling a transfer
+type CreateScheduledTransferRequest struct {
+	ReceiverUserID uint       `json:"receiver_user_id" binding:"required_without=Recipient"`
+	Recipient      string     `json:"recipient" binding:"required_without=ReceiverUserID,max=100"` // NIM, email or handle
+	Amount         int        `json:"amount" binding:"required,gt=0"`
+	Description    string     `json:"description" binding:"max=255"`
+	Frequency      string     `json:"frequency" binding:"required,oneof=once weekly monthly"`
+	StartAt        time.Time  `json:"start_at" binding:"required"`
+	EndAt          *time.Time `json:"end_at"`
+}
+
+// TransferRules are the runtime-configurable limits every transfer is checked
+// against. There is a single row; nil limits are disabled.
+type TransferRules struct {
+	ID                   uint      `json:"-" gorm:"primaryKey"`
+	DailyAmountCap       *int      `json:"daily_amount_cap"`       // Points a user may send per day
+	MaxTransfersPerHour  *int      `json:"max_transfers_per_hour"` // Transfers a user may send in any 60 minutes
+	MinAccountAgeDays    *int      `json:"min_account_age_days"`   // Days since registration before a user may send
+	BlockedReceiverRoles []string  `json:"blocked_receiver_roles" gorm:"serializer:json;type:varchar(255)"`
+	CircularWindowHours  int       `json:"circular_window_hours"` // Look-back for circular flows; 0 disables flagging
+	UpdatedBy            uint      `json:"updated_by"`
+	UpdatedAt            time.Time `json:"updated_at"`
+}
+
+func (TransferRules) TableName() string {
+	return "transfer_rules"
+}
+
+// UpdateTransferRulesRequest replaces the transfer rules; null disables a limit
+type UpdateTransferRulesRequest struct {
+	DailyAmountCap       *int     `json:"daily_amount_cap" binding:"omitempty,gt=0"`
+	MaxTransfersPerHour  *int     `json:"max_transfers_per_hour" binding:"omitempty,gt=0"`
+	MinAccountAgeDays    *int     `json:"min_account_age_days" binding:"omitempty,gte=0"`
+	BlockedReceiverRoles []string `json:"blocked_receiver_roles" binding:"dive,oneof=admin dosen mahasiswa merchant"`
+	CircularWindowHours  int      `json:"circular_window_hours" binding:"gte=0,lte=720"`
+}
+
+// TransferFlag queues a completed transfer for admin review
+type TransferFlag struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	TransferID uint       `json:"transfer_id" gorm:"not null;index"`
+	Rule       string     `json:"rule" gorm:"type:varchar(50);not null"` // e.g. circular_flow
+	Details    string     `json:"details" gorm:"type:varchar(255)"`
+	Status     string     `json:"status" gorm:"type:enum('open','cleared','confirmed');default:'open';index"`
+	ReviewedBy *uint      `json:"reviewed_by"`
+	ReviewNote string     `json:"review_note" gorm:"type:varchar(255)"`
+	ReviewedAt *time.Time `json:"reviewed_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+
+	Transfer *Transfer `json:"transfer,omitempty" gorm:"foreignKey:TransferID"`
+}
+
+func (TransferFlag) TableName() string {
+	return "transfer_flags"
+}
+
+// ReviewTransferFlagRequest closes a flag as harmless or confirmed abuse
+type ReviewTransferFlagRequest struct {
+	Status string `json:"status" binding:"required,oneof=cleared confirmed"`
+	Note   string `json:"note" binding:"max=255"`
+}
@@ -0,0 +1,251 @@
+package transfer
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPointRequestNotFound = errors.New("point request not found")
+	ErrPointRequestAnswered = errors.New("point request has already been answered")
+	ErrPointRequestExpired  = errors.New("point request has expired")
+	ErrPointRequestClosed   = errors.New("point request is no longer open")
+)
+
+const defaultPointRequestExpiry = 72 * time.Hour
+
+// CreatePointRequest asks one or several users for points. Shares are either
+// the given per-person amounts or an even split of SplitAmount.
+func (s *Service) CreatePointRequest(requesterID uint, req *CreatePointRequest) (*PointRequest, error) {
+	seen := make(map[uint]bool)
+	for _, share := range req.Recipients {
+		if share.UserID == requesterID {
+			return nil, errors.New("cannot request points from yourself")
+		}
+		if seen[share.UserID] {
+			return nil, fmt.Errorf("user %d is listed more than once", share.UserID)
+		}
+		seen[share.UserID] = true
+
+		if _, err := s.walletService.GetWalletByUserID(share.UserID); err != nil {
+			return nil, fmt.Errorf("user %d not found or has no wallet", share.UserID)
+		}
+	}
+
+	amounts, err := shareAmounts(req)
+	if err != nil {
+		return nil, err
+	}
+
+	expiry := defaultPointRequestExpiry
+	if req.ExpiryHours > 0 {
+		expiry = time.Duration(req.ExpiryHours) * time.Hour
+	}
+
+	request := &PointRequest{
+		RequesterID: requesterID,
+		Description: req.Description,
+		Status:      "open",
+		ExpiresAt:   time.Now().Add(expiry),
+	}
+	for i, share := range req.Recipients {
+		request.Recipients = append(request.Recipients, PointRequestRecipient{
+			UserID: share.UserID,
+			Amount: amounts[i],
+			Status: "pending",
+		})
+		request.TotalAmount += amounts[i]
+	}
+
+	if err := s.repo.CreatePointRequest(request); err != nil {
+		return nil, errors.New("failed to create point request")
+	}
+
+	if err := s.populateRecipientDetails(request.Recipients); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// shareAmounts returns what each recipient is asked for. An even split gives
+// the remainder to the first recipients, one point each.
+func shareAmounts(req *CreatePointRequest) ([]int, error) {
+	amounts := make([]int, len(req.Recipients))
+
+	if req.SplitAmount == 0 {
+		for i, share := range req.Recipients {
+			if share.Amount <= 0 {
+				return nil, errors.New("every recipient needs an amount greater than 0, or set split_amount")
+			}
+			amounts[i] = share.Amount
+		}
+		return amounts, nil
+	}
+
+	people := len(req.Recipients)
+	if req.IncludeSelf {
+		people++
+	}
+	each := req.SplitAmount / people
+	if each == 0 {
+		return nil, errors.New("split amount is too small for the number of people")
+	}
+	remainder := req.SplitAmount % people
+	for i := range amounts {
+		amounts[i] = each
+		if i < remainder {
+			amounts[i]++
+		}
+	}
+	return amounts, nil
+}
+
+// AcceptPointRequest pays the current user's share as a transfer to the requester
+func (s *Service) AcceptPointRequest(requestID, userID uint) (*Transfer, error) {
+	var transfer *Transfer
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		request, share, err := s.answerablePointRequest(tx, requestID, userID)
+		if err != nil {
+			return err
+		}
+
+		description := fmt.Sprintf("Point request #%d", request.ID)
+		if request.Description != "" {
+			description += ": " + request.Description
+		}
+		transfer, err = s.executeTransfer(tx, userID, request.RequesterID, share.Amount, description)
+		if err != nil {
+			return err
+		}
+
+		if err := s.repo.UpdateRecipient(tx, share.ID, map[string]interface{}{
+			"status":       "accepted",
+			"transfer_id":  transfer.ID,
+			"responded_at": time.Now(),
+		}); err != nil {
+			return err
+		}
+		return s.repo.CloseIfAnswered(tx, request.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return transfer, nil
+}
+
+// DeclinePointRequest declines the current user's share
+func (s *Service) DeclinePointRequest(requestID, userID uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		request, share, err := s.answerablePointRequest(tx, requestID, userID)
+		if err != nil {
+			return err
+		}
+
+		if err := s.repo.UpdateRecipient(tx, share.ID, map[string]interface{}{
+			"status":       "declined",
+			"responded_at": time.Now(),
+		}); err != nil {
+			return err
+		}
+		return s.repo.CloseIfAnswered(tx, request.ID)
+	})
+}
+
+// answerablePointRequest locks a user's pending share of an open, unexpired request
+func (s *Service) answerablePointRequest(tx *gorm.DB, requestID, userID uint) (*PointRequest, *PointRequestRecipient, error) {
+	request, share, err := s.repo.LockPointRequestShare(tx, requestID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if share.Status != "pending" {
+		return nil, nil, ErrPointRequestAnswered
+	}
+	if request.Status != "open" {
+		return nil, nil, ErrPointRequestClosed
+	}
+	if time.Now().After(request.ExpiresAt) {
+		return nil, nil, ErrPointRequestExpired
+	}
+	return request, share, nil
+}
+
+// CancelPointRequest lets the requester withdraw the shares nobody answered yet
+func (s *Service) CancelPointRequest(requestID, requesterID uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		request, err := s.repo.LockPointRequest(tx, requestID)
+		if err != nil {
+			return err
+		}
+		if request.RequesterID != requesterID {
+			return ErrPointRequestNotFound
+		}
+		if request.Status != "open" {
+			return ErrPointRequestClosed
+		}
+		return s.repo.CancelPointRequest(tx, requestID)
+	})
+}
+
+func (s *Service) GetIncomingPointRequests(userID uint, status string, limit, offset int) ([]IncomingPointRequest, int64, error) {
+	requests, total, err := s.repo.FindIncomingPointRequests(userID, status, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	if requests == nil {
+		requests = []IncomingPointRequest{}
+	}
+	return requests, total, nil
+}
+
+func (s *Service) GetOutgoingPointRequests(userID uint, status string, limit, offset int) ([]PointRequest, int64, error) {
+	requests, total, err := s.repo.FindOutgoingPointRequests(userID, status, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range requests {
+		if err := s.populateRecipientDetails(requests[i].Recipients); err != nil {
+			return nil, 0, err
+		}
+	}
+	return requests, total, nil
+}
+
+// ExpirePointRequests expires unanswered shares of requests past their expiry
+func (s *Service) ExpirePointRequests() (int64, error) {
+	return s.repo.ExpirePointRequests(time.Now())
+}
+
+// populateRecipientDetails fills in the recipient names
+func (s *Service) populateRecipientDetails(recipients []PointRequestRecipient) error {
+	if len(recipients) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(recipients))
+	for i, r := range recipients {
+		ids[i] = r.UserID
+	}
+
+	var users []struct {
+		ID       uint
+		FullName string
+		NimNip   string
+	}
+	if err := s.db.Table("users").Select("id, full_name, nim_nip").Where("id IN ?", ids).Scan(&users).Error; err != nil {
+		return err
+	}
+
+	for _, u := range users {
+		for i := range recipients {
+			if recipients[i].UserID == u.ID {
+				recipients[i].FullName = u.FullName
+				recipients[i].NIM = u.NimNip
+			}
+		}
+	}
+	return nil
+}
@@ -1,7 +1,11 @@
 package transfer
 
 import (
+	"errors"
+	"time"
+
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Repository handles database operations for transfers
@@ -77,3 +81,370 @@ func (r *Repository) FindAll(limit, offset int) ([]Transfer, int64, error) {
 func (r *Repository) CreateWithTransaction(tx *gorm.DB, transfer *Transfer) error {
 	return tx.Create(transfer).Error
 }
+
+// LockByJournalID finds the transfer posted by a journal and locks it for update
+func (r *Repository) LockByJournalID(tx *gorm.DB, journalID uint) (*Transfer, error) {
+	var transfer Transfer
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("journal_id = ?", journalID).
+		First(&transfer).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+// UpdateStatus changes the status of a transfer
+func (r *Repository) UpdateStatus(tx *gorm.DB, id uint, status string) error {
+	return tx.Model(&Transfer{}).Where("id = ?", id).Update("status", status).Error
+}
+
+// CreatePointRequest inserts a point request together with its recipients
+func (r *Repository) CreatePointRequest(request *PointRequest) error {
+	return r.db.Create(request).Error
+}
+
+// LockPointRequest locks a point request for update
+func (r *Repository) LockPointRequest(tx *gorm.DB, requestID uint) (*PointRequest, error) {
+	var request PointRequest
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&request, requestID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPointRequestNotFound
+		}
+		return nil, err
+	}
+	return &request, nil
+}
+
+// LockPointRequestShare locks a point request and one recipient's share for answering
+func (r *Repository) LockPointRequestShare(tx *gorm.DB, requestID, userID uint) (*PointRequest, *PointRequestRecipient, error) {
+	request, err := r.LockPointRequest(tx, requestID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var recipient PointRequestRecipient
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("request_id = ? AND user_id = ?", requestID, userID).
+		First(&recipient).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrPointRequestNotFound
+		}
+		return nil, nil, err
+	}
+	return request, &recipient, nil
+}
+
+// UpdateRecipient updates a recipient's share
+func (r *Repository) UpdateRecipient(tx *gorm.DB, id uint, updates map[string]interface{}) error {
+	return tx.Model(&PointRequestRecipient{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// CloseIfAnswered closes an open request once no share is pending anymore
+func (r *Repository) CloseIfAnswered(tx *gorm.DB, requestID uint) error {
+	return tx.Exec(`UPDATE point_requests SET status = 'closed', updated_at = NOW()
+		WHERE id = ? AND status = 'open'
+		AND NOT EXISTS (SELECT 1 FROM point_request_recipients WHERE request_id = ? AND status = 'pending')`,
+		requestID, requestID).Error
+}
+
+// CancelPointRequest cancels an open request and its pending shares
+func (r *Repository) CancelPointRequest(tx *gorm.DB, requestID uint) error {
+	if err := tx.Model(&PointRequestRecipient{}).
+		Where("request_id = ? AND status = ?", requestID, "pending").
+		Update("status", "cancelled").Error; err != nil {
+		return err
+	}
+	return tx.Model(&PointRequest{}).Where("id = ?", requestID).Update("status", "cancelled").Error
+}
+
+// FindIncomingPointRequests retrieves the shares other users asked a user to pay
+func (r *Repository) FindIncomingPointRequests(userID uint, status string, limit, offset int) ([]IncomingPointRequest, int64, error) {
+	var requests []IncomingPointRequest
+	var total int64
+
+	query := r.db.Table("point_request_recipients prr").
+		Select(`prr.*, pr.requester_id, pr.description, pr.expires_at,
+			u.full_name as requester_name, u.nim_nip as requester_nim`).
+		Joins("JOIN point_requests pr ON pr.id = prr.request_id").
+		Joins("JOIN users u ON u.id = pr.requester_id").
+		Where("prr.user_id = ?", userID)
+	if status != "" {
+		query = query.Where("prr.status = ?", status)
+	}
+	query.Count(&total)
+
+	err := query.Limit(limit).Offset(offset).Order("prr.created_at DESC").Scan(&requests).Error
+	return requests, total, err
+}
+
+// FindOutgoingPointRequests retrieves the requests a user created with their recipients
+func (r *Repository) FindOutgoingPointRequests(requesterID uint, status string, limit, offset int) ([]PointRequest, int64, error) {
+	var requests []PointRequest
+	var total int64
+
+	query := r.db.Model(&PointRequest{}).Where("requester_id = ?", requesterID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	query.Count(&total)
+
+	err := query.Preload("Recipients").Limit(limit).Offset(offset).Order("created_at DESC").Find(&requests).Error
+	return requests, total, err
+}
+
+// ExpirePointRequests expires pending shares of requests past their expiry
+// and closes those requests. Returns the number of shares expired.
+func (r *Repository) ExpirePointRequests(now time.Time) (int64, error) {
+	var expired int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Exec(`UPDATE point_request_recipients prr
+			JOIN point_requests pr ON pr.id = prr.request_id
+			SET prr.status = 'expired', prr.updated_at = NOW()
+			WHERE prr.status = 'pending' AND pr.status = 'open' AND pr.expires_at < ?`, now)
+		if result.Error != nil {
+			return result.Error
+		}
+		expired = result.RowsAffected
+
+		return tx.Model(&PointRequest{}).
+			Where("status = ? AND expires_at < ?", "open", now).
+			Update("status", "closed").Error
+	})
+	return expired, err
+}
+
+// CreateSchedule inserts a scheduled transfer
+func (r *Repository) CreateSchedule(schedule *ScheduledTransfer) error {
+	return r.db.Create(schedule).Error
+}
+
+// FindSchedule retrieves a scheduled transfer by ID
+func (r *Repository) FindSchedule(id uint) (*ScheduledTransfer, error) {
+	var schedule ScheduledTransfer
+	err := r.db.First(&schedule, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrScheduleNotFound
+		}
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// FindSchedulesBySender retrieves the scheduled transfers a user created
+func (r *Repository) FindSchedulesBySender(userID uint, status string, limit, offset int) ([]ScheduledTransfer, int64, error) {
+	var schedules []ScheduledTransfer
+	var total int64
+
+	query := r.db.Model(&ScheduledTransfer{}).Where("sender_user_id = ?", userID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	query.Count(&total)
+
+	err := query.Limit(limit).Offset(offset).Order("created_at DESC").Find(&schedules).Error
+	return schedules, total, err
+}
+
+// FindDueSchedules retrieves active schedules whose next occurrence is due, oldest first
+func (r *Repository) FindDueSchedules(now time.Time, limit int) ([]ScheduledTransfer, error) {
+	var schedules []ScheduledTransfer
+	err := r.db.Where("status = ? AND next_run_at <= ?", "active", now).
+		Order("next_run_at ASC, id ASC").
+		Limit(limit).
+		Find(&schedules).Error
+	return schedules, err
+}
+
+// ClaimOccurrence advances a schedule past the occurrence at nextRunAt. It
+// only succeeds for the runner that still sees that occurrence, so a
+// concurrent runner cannot execute it twice.
+func (r *Repository) ClaimOccurrence(id uint, nextRunAt time.Time, updates map[string]interface{}) (bool, error) {
+	result := r.db.Model(&ScheduledTransfer{}).
+		Where("id = ? AND status = ? AND next_run_at = ?", id, "active", nextRunAt).
+		Updates(updates)
+	return result.RowsAffected > 0, result.Error
+}
+
+// UpdateSchedule updates scheduled transfer columns
+func (r *Repository) UpdateSchedule(id uint, updates map[string]interface{}) error {
+	return r.db.Model(&ScheduledTransfer{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// TransitionSchedule updates a schedule that still has status from.
+// Returns ErrScheduleState when its status changed in the meantime.
+func (r *Repository) TransitionSchedule(id uint, from string, updates map[string]interface{}) error {
+	result := r.db.Model(&ScheduledTransfer{}).Where("id = ? AND status = ?", id, from).Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrScheduleState
+	}
+	return nil
+}
+
+// CreateRun records the outcome of an occurrence
+func (r *Repository) CreateRun(run *ScheduledTransferRun) error {
+	return r.db.Create(run).Error
+}
+
+// FindRuns retrieves the occurrences of a schedule, newest first
+func (r *Repository) FindRuns(scheduleID uint, limit, offset int) ([]ScheduledTransferRun, int64, error) {
+	runs := []ScheduledTransferRun{}
+	var total int64
+
+	query := r.db.Model(&ScheduledTransferRun{}).Where("schedule_id = ?", scheduleID)
+	query.Count(&total)
+
+	err := query.Limit(limit).Offset(offset).Order("scheduled_at DESC, id DESC").Find(&runs).Error
+	return runs, total, err
+}
+
+// FindRules retrieves the transfer rules row
+func (r *Repository) FindRules(tx *gorm.DB) (*TransferRules, error) {
+	var rules TransferRules
+	err := tx.Order("id ASC").First(&rules).Error
+	return &rules, err
+}
+
+// SaveRules stores the transfer rules row
+func (r *Repository) SaveRules(rules *TransferRules) error {
+	return r.db.Save(rules).Error
+}
+
+// SumSentSince totals the points a wallet sent in transfers since a time
+func (r *Repository) SumSentSince(tx *gorm.DB, walletID uint, since time.Time) (int, error) {
+	var total int
+	err := tx.Model(&Transfer{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("sender_wallet_id = ? AND status <> ? AND created_at >= ?", walletID, "failed", since).
+		Scan(&total).Error
+	return total, err
+}
+
+// CountSentSince counts the transfers a wallet sent since a time
+func (r *Repository) CountSentSince(tx *gorm.DB, walletID uint, since time.Time) (int64, error) {
+	var count int64
+	err := tx.Model(&Transfer{}).
+		Where("sender_wallet_id = ? AND status <> ? AND created_at >= ?", walletID, "failed", since).
+		Count(&count).Error
+	return count, err
+}
+
+// transferEdge is a sender → receiver pair of wallets
+type transferEdge struct {
+	SenderWalletID   uint
+	ReceiverWalletID uint
+}
+
+// FindTransferEdges lists who the given wallets sent transfers to since a time
+func (r *Repository) FindTransferEdges(tx *gorm.DB, senderWalletIDs []uint, since time.Time, limit int) ([]transferEdge, error) {
+	var edges []transferEdge
+	err := tx.Model(&Transfer{}).
+		Distinct("sender_wallet_id", "receiver_wallet_id").
+		Where("sender_wallet_id IN ? AND status <> ? AND created_at >= ?", senderWalletIDs, "failed", since).
+		Limit(limit).
+		Scan(&edges).Error
+	return edges, err
+}
+
+// CreateFlag queues a transfer for review
+func (r *Repository) CreateFlag(tx *gorm.DB, flag *TransferFlag) error {
+	return tx.Create(flag).Error
+}
+
+// FindFlag retrieves a flag by ID
+func (r *Repository) FindFlag(id uint) (*TransferFlag, error) {
+	var flag TransferFlag
+	if err := r.db.First(&flag, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrFlagNotFound
+		}
+		return nil, err
+	}
+	return &flag, nil
+}
+
+// FindFlags lists flagged transfers with their transfer, newest first
+func (r *Repository) FindFlags(status string, limit, offset int) ([]TransferFlag, int64, error) {
+	flags := []TransferFlag{}
+	var total int64
+
+	query := r.db.Model(&TransferFlag{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	query.Count(&total)
+
+	err := query.Preload("Transfer").Limit(limit).Offset(offset).Order("created_at DESC, id DESC").Find(&flags).Error
+	return flags, total, err
+}
+
+// ReviewFlag closes an open flag. It fails with ErrFlagReviewed when the
+// flag was already reviewed.
+func (r *Repository) ReviewFlag(id uint, updates map[string]interface{}) error {
+	result := r.db.Model(&TransferFlag{}).Where("id = ? AND status = ?", id, "open").Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrFlagReviewed
+	}
+	return nil
+}
+
+// LockTransfer locks a transfer for update; nil when it does not exist
+func (r *Repository) LockTransfer(tx *gorm.DB, id uint) (*Transfer, error) {
+	var transfer Transfer
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&transfer, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+// UpdateTransfer updates transfer columns
+func (r *Repository) UpdateTransfer(tx *gorm.DB, id uint, updates map[string]interface{}) error {
+	return tx.Model(&Transfer{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// FindEscrows lists escrow transfers, optionally of one wallet (either side)
+// and status, newest first
+func (r *Repository) FindEscrows(walletID uint, status string, limit, offset int) ([]Transfer, int64, error) {
+	transfers := []Transfer{}
+	var total int64
+
+	query := r.db.Model(&Transfer{}).Where("type = ?", "escrow")
+	if walletID != 0 {
+		query = query.Where("sender_wallet_id = ? OR receiver_wallet_id = ?", walletID, walletID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	query.Count(&total)
+
+	err := query.Limit(limit).Offset(offset).Order("created_at DESC, id DESC").Find(&transfers).Error
+	return transfers, total, err
+}
+
+// FindDueEscrows finds delivered escrows past their release time and
+// undelivered escrows past their delivery deadline
+func (r *Repository) FindDueEscrows(now time.Time, limit int) ([]Transfer, error) {
+	var transfers []Transfer
+	err := r.db.Where("type = ?", "escrow").
+		Where("(status = ? AND release_at <= ?) OR (status = ? AND deliver_by <= ?)", "delivered", now, "escrowed", now).
+		Order("id ASC").
+		Limit(limit).
+		Find(&transfers).Error
+	return transfers, err
+}
@@ -0,0 +1,324 @@
+package transfer
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"wallet-point/internal/audit"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrFlagNotFound = errors.New("flagged transfer not found")
+	ErrFlagReviewed = errors.New("flagged transfer has already been reviewed")
+)
+
+// Error codes returned when a transfer rule blocks a transfer
+const (
+	CodeTransferDailyCap    = "TRANSFER_DAILY_CAP_EXCEEDED"
+	CodeTransferVelocity    = "TRANSFER_VELOCITY_EXCEEDED"
+	CodeAccountTooNew       = "ACCOUNT_TOO_NEW"
+	CodeReceiverRoleBlocked = "RECEIVER_ROLE_BLOCKED"
+)
+
+const (
+	// defaultCircularWindowHours applies until an admin stores the rules
+	defaultCircularWindowHours = 24
+	// maxCycleLength is the longest ring of transfers flagged as circular,
+	// counting the new transfer (A→B→C→D→A)
+	maxCycleLength = 4
+	// cycleSearchEdges bounds the transfers inspected per hop of the search
+	cycleSearchEdges = 1000
+)
+
+// RuleError is a transfer blocked by a transfer rule
+type RuleError struct {
+	code    string
+	status  int
+	message string
+}
+
+func (e *RuleError) Error() string   { return e.message }
+func (e *RuleError) Code() string    { return e.code }
+func (e *RuleError) HTTPStatus() int { return e.status }
+
+// SetAuditService configures where transfer rule decisions are recorded
+func (s *Service) SetAuditService(auditService *audit.AuditService) {
+	s.auditService = auditService
+}
+
+// GetTransferRules returns the rules in force. Without a stored row only
+// circular-flow flagging is enabled.
+func (s *Service) GetTransferRules() (*TransferRules, error) {
+	return s.loadRules(s.db)
+}
+
+func (s *Service) loadRules(tx *gorm.DB) (*TransferRules, error) {
+	rules, err := s.repo.FindRules(tx)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &TransferRules{BlockedReceiverRoles: []string{}, CircularWindowHours: defaultCircularWindowHours}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if rules.BlockedReceiverRoles == nil {
+		rules.BlockedReceiverRoles = []string{}
+	}
+	return rules, nil
+}
+
+// UpdateTransferRules replaces the rules; they apply to the next transfer
+func (s *Service) UpdateTransferRules(adminID uint, req *UpdateTransferRulesRequest) (*TransferRules, error) {
+	current, err := s.loadRules(s.db)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := &TransferRules{
+		ID:                   current.ID,
+		DailyAmountCap:       req.DailyAmountCap,
+		MaxTransfersPerHour:  req.MaxTransfersPerHour,
+		MinAccountAgeDays:    req.MinAccountAgeDays,
+		BlockedReceiverRoles: req.BlockedReceiverRoles,
+		CircularWindowHours:  req.CircularWindowHours,
+		UpdatedBy:            adminID,
+	}
+	if rules.BlockedReceiverRoles == nil {
+		rules.BlockedReceiverRoles = []string{}
+	}
+	if err := s.repo.SaveRules(rules); err != nil {
+		return nil, errors.New("failed to update transfer rules")
+	}
+	return rules, nil
+}
+
+// checkTransferRules rejects a transfer that breaks a rule. The sender's
+// wallet must be locked so concurrent transfers count each other.
+func (s *Service) checkTransferRules(tx *gorm.DB, rules *TransferRules, senderUserID, receiverUserID, senderWalletID uint, amount int) error {
+	ruleErr, err := s.evaluateRules(tx, rules, senderUserID, receiverUserID, senderWalletID, amount)
+	if err != nil {
+		return err
+	}
+	if ruleErr != nil {
+		s.recordBlocked(senderUserID,
+			fmt.Sprintf("Blocked transfer of %d points to user %d (%s): %s", amount, receiverUserID, ruleErr.code, ruleErr.message))
+		return ruleErr
+	}
+	return nil
+}
+
+func (s *Service) evaluateRules(tx *gorm.DB, rules *TransferRules, senderUserID, receiverUserID, senderWalletID uint, amount int) (*RuleError, error) {
+	var users []struct {
+		ID        uint
+		Role      string
+		CreatedAt time.Time
+	}
+	if err := tx.Table("users").Select("id, role, created_at").Where("id IN ?", []uint{senderUserID, receiverUserID}).Scan(&users).Error; err != nil {
+		return nil, err
+	}
+	for _, u := range users {
+		if u.ID == receiverUserID {
+			for _, role := range rules.BlockedReceiverRoles {
+				if u.Role == role {
+					return &RuleError{code: CodeReceiverRoleBlocked, status: http.StatusForbidden, message: fmt.Sprintf("transfers to %s accounts are not allowed", role)}, nil
+				}
+			}
+		}
+		if u.ID == senderUserID && rules.MinAccountAgeDays != nil {
+			if time.Since(u.CreatedAt) < time.Duration(*rules.MinAccountAgeDays)*24*time.Hour {
+				return &RuleError{code: CodeAccountTooNew, status: http.StatusForbidden, message: fmt.Sprintf("accounts must be at least %d days old to send transfers", *rules.MinAccountAgeDays)}, nil
+			}
+		}
+	}
+
+	now := time.Now()
+	if rules.MaxTransfersPerHour != nil {
+		count, err := s.repo.CountSentSince(tx, senderWalletID, now.Add(-time.Hour))
+		if err != nil {
+			return nil, err
+		}
+		if count >= int64(*rules.MaxTransfersPerHour) {
+			return &RuleError{code: CodeTransferVelocity, status: http.StatusTooManyRequests, message: fmt.Sprintf("at most %d transfers per hour are allowed", *rules.MaxTransfersPerHour)}, nil
+		}
+	}
+
+	if rules.DailyAmountCap != nil {
+		dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		sent, err := s.repo.SumSentSince(tx, senderWalletID, dayStart)
+		if err != nil {
+			return nil, err
+		}
+		if sent+amount > *rules.DailyAmountCap {
+			return &RuleError{code: CodeTransferDailyCap, status: http.StatusUnprocessableEntity, message: fmt.Sprintf("daily transfer cap of %d points reached (%d already sent today)", *rules.DailyAmountCap, sent)}, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// flagCircularFlow queues the transfer for review when it closes a ring of
+// transfers back to the sender within the rules' window. A direct pay-back
+// (A→B→A) is not flagged.
+func (s *Service) flagCircularFlow(tx *gorm.DB, rules *TransferRules, transfer *Transfer, senderUserID uint) error {
+	if rules.CircularWindowHours == 0 {
+		return nil
+	}
+
+	since := time.Now().Add(-time.Duration(rules.CircularWindowHours) * time.Hour)
+	cycle, err := s.findCycle(tx, transfer.SenderWalletID, transfer.ReceiverWalletID, since)
+	if err != nil || cycle == nil {
+		return err
+	}
+
+	path, err := s.describeWalletPath(tx, cycle)
+	if err != nil {
+		return err
+	}
+
+	flag := &TransferFlag{
+		TransferID: transfer.ID,
+		Rule:       "circular_flow",
+		Details:    fmt.Sprintf("Circular flow within %dh: %s", rules.CircularWindowHours, path),
+		Status:     "open",
+	}
+	if err := s.repo.CreateFlag(tx, flag); err != nil {
+		return err
+	}
+
+	return s.recordDecision(tx, senderUserID, "TRANSFER_FLAGGED", transfer.ID, flag.Details)
+}
+
+// findCycle searches the transfers since a time for a path from the receiver
+// back to the sender. It returns the ring of wallets starting and ending with
+// the sender, or nil.
+func (s *Service) findCycle(tx *gorm.DB, senderWalletID, receiverWalletID uint, since time.Time) ([]uint, error) {
+	parent := map[uint]uint{receiverWalletID: 0}
+	frontier := []uint{receiverWalletID}
+
+	for hop := 1; hop < maxCycleLength && len(frontier) > 0; hop++ {
+		edges, err := s.repo.FindTransferEdges(tx, frontier, since, cycleSearchEdges)
+		if err != nil {
+			return nil, err
+		}
+
+		var next []uint
+		for _, e := range edges {
+			if e.ReceiverWalletID == senderWalletID {
+				if hop == 1 {
+					continue
+				}
+				cycle := []uint{senderWalletID}
+				for w := e.SenderWalletID; w != 0; w = parent[w] {
+					cycle = append([]uint{w}, cycle...)
+				}
+				return append([]uint{senderWalletID}, cycle...), nil
+			}
+			if _, seen := parent[e.ReceiverWalletID]; seen {
+				continue
+			}
+			parent[e.ReceiverWalletID] = e.SenderWalletID
+			next = append(next, e.ReceiverWalletID)
+		}
+		frontier = next
+	}
+	return nil, nil
+}
+
+// describeWalletPath renders wallets as "user 12 → user 15 → ..."
+func (s *Service) describeWalletPath(tx *gorm.DB, walletIDs []uint) (string, error) {
+	var wallets []struct {
+		ID     uint
+		UserID uint
+	}
+	if err := tx.Table("wallets").Select("id, user_id").Where("id IN ?", walletIDs).Scan(&wallets).Error; err != nil {
+		return "", err
+	}
+	owners := make(map[uint]uint)
+	for _, w := range wallets {
+		owners[w.ID] = w.UserID
+	}
+
+	steps := make([]string, len(walletIDs))
+	for i, id := range walletIDs {
+		steps[i] = fmt.Sprintf("user %d", owners[id])
+	}
+	return strings.Join(steps, " → "), nil
+}
+
+// recordDecision writes a transfer rule decision to the audit log as part
+// of the transfer's tx
+func (s *Service) recordDecision(tx *gorm.DB, userID uint, action string, transferID uint, details string) error {
+	if s.auditService == nil {
+		return nil
+	}
+	return s.auditService.LogActivityWithTx(tx, audit.CreateAuditParams{
+		UserID:   userID,
+		Action:   action,
+		Entity:   "TRANSFER",
+		EntityID: transferID,
+		Details:  details,
+	})
+}
+
+// recordBlocked writes a blocked transfer to the audit log on its own
+// connection, as the transfer's tx rolls back
+func (s *Service) recordBlocked(userID uint, details string) {
+	if s.auditService == nil {
+		return
+	}
+	if err := s.auditService.LogActivity(audit.CreateAuditParams{
+		UserID:  userID,
+		Action:  "TRANSFER_BLOCKED",
+		Entity:  "TRANSFER",
+		Details: details,
+	}); err != nil {
+		log.Printf("transfer rules: failed to audit TRANSFER_BLOCKED for user %d: %v", userID, err)
+	}
+}
+
+func (s *Service) GetFlaggedTransfers(status string, limit, offset int) ([]TransferFlag, int64, error) {
+	flags, total, err := s.repo.FindFlags(status, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	transfers := make([]Transfer, 0, len(flags))
+	for _, f := range flags {
+		if f.Transfer != nil {
+			transfers = append(transfers, *f.Transfer)
+		}
+	}
+	if err := s.populateTransferDetails(transfers); err != nil {
+		return nil, 0, err
+	}
+	for i := range flags {
+		for j := range transfers {
+			if flags[i].TransferID == transfers[j].ID {
+				flags[i].Transfer = &transfers[j]
+			}
+		}
+	}
+	return flags, total, nil
+}
+
+// ReviewTransferFlag clears or confirms an open flag
+func (s *Service) ReviewTransferFlag(flagID, adminID uint, req *ReviewTransferFlagRequest) (*TransferFlag, error) {
+	if _, err := s.repo.FindFlag(flagID); err != nil {
+		return nil, err
+	}
+
+	err := s.repo.ReviewFlag(flagID, map[string]interface{}{
+		"status":      req.Status,
+		"reviewed_by": adminID,
+		"review_note": req.Note,
+		"reviewed_at": time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.FindFlag(flagID)
+}
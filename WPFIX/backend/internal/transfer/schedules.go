@@ -0,0 +1,386 @@
+package transfer
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+	"wallet-point/internal/notification"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrScheduleNotFound = errors.New("scheduled transfer not found")
+	ErrScheduleState    = errors.New("scheduled transfer cannot change from its current status")
+)
+
+const (
+	// maxConsecutiveSkips pauses a recurring schedule that keeps failing
+	maxConsecutiveSkips = 3
+	// dueSchedulesBatch bounds the occurrences executed per runner tick
+	dueSchedulesBatch = 200
+)
+
+// SetNotifier configures where users are told about skipped scheduled transfers
+func (s *Service) SetNotifier(notifier *notification.Service) {
+	s.notifier = notifier
+}
+
+// scheduleLocation anchors weekly and monthly recurrences to campus time
+func scheduleLocation() *time.Location {
+	location, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		return time.Local
+	}
+	return location
+}
+
+// occurrenceAt returns the n-th occurrence of a recurrence (0 = start).
+// Monthly schedules keep the start's day of month, clamped to the month's
+// last day (a schedule starting on the 31st runs on Feb 28/29).
+func occurrenceAt(start time.Time, frequency string, n int) time.Time {
+	start = start.In(scheduleLocation())
+	switch frequency {
+	case "weekly":
+		return start.AddDate(0, 0, 7*n)
+	case "monthly":
+		firstOfMonth := time.Date(start.Year(), start.Month()+time.Month(n), 1, start.Hour(), start.Minute(), start.Second(), 0, start.Location())
+		lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+		day := start.Day()
+		if day > lastDay {
+			day = lastDay
+		}
+		return firstOfMonth.AddDate(0, 0, day-1)
+	default:
+		return start
+	}
+}
+
+// CreateScheduledTransfer schedules a one-off or recurring transfer
+func (s *Service) CreateScheduledTransfer(senderUserID uint, req *CreateScheduledTransferRequest) (*ScheduledTransfer, error) {
+	receiverUserID, err := s.recipientUserID(req.Recipient, req.ReceiverUserID)
+	if err != nil {
+		return nil, err
+	}
+	req.ReceiverUserID = receiverUserID
+
+	if senderUserID == req.ReceiverUserID {
+		return nil, errors.New("cannot transfer points to yourself")
+	}
+	if _, err := s.walletService.GetWalletByUserID(req.ReceiverUserID); err != nil {
+		return nil, errors.New("receiver wallet not found: check if user exists and has a wallet")
+	}
+
+	now := time.Now()
+	if !req.StartAt.After(now) {
+		return nil, errors.New("start_at must be in the future")
+	}
+	if req.StartAt.After(now.AddDate(1, 0, 0)) {
+		return nil, errors.New("start_at must be within one year")
+	}
+	if req.EndAt != nil {
+		if req.Frequency == "once" {
+			return nil, errors.New("end_at only applies to recurring transfers")
+		}
+		if req.EndAt.Before(req.StartAt) {
+			return nil, errors.New("end_at must be after start_at")
+		}
+	}
+
+	schedule := &ScheduledTransfer{
+		SenderUserID:   senderUserID,
+		ReceiverUserID: req.ReceiverUserID,
+		Amount:         req.Amount,
+		Description:    req.Description,
+		Frequency:      req.Frequency,
+		StartAt:        req.StartAt,
+		EndAt:          req.EndAt,
+		NextRunAt:      req.StartAt,
+		Status:         "active",
+	}
+	if err := s.repo.CreateSchedule(schedule); err != nil {
+		return nil, errors.New("failed to create scheduled transfer")
+	}
+
+	created := []ScheduledTransfer{*schedule}
+	if err := s.populateScheduleDetails(created); err != nil {
+		return nil, err
+	}
+	return &created[0], nil
+}
+
+func (s *Service) GetScheduledTransfers(userID uint, status string, limit, offset int) ([]ScheduledTransfer, int64, error) {
+	schedules, total, err := s.repo.FindSchedulesBySender(userID, status, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := s.populateScheduleDetails(schedules); err != nil {
+		return nil, 0, err
+	}
+	return schedules, total, nil
+}
+
+// GetScheduledTransferRuns lists the occurrences of one of the user's schedules
+func (s *Service) GetScheduledTransferRuns(userID, scheduleID uint, limit, offset int) ([]ScheduledTransferRun, int64, error) {
+	if _, err := s.ownSchedule(userID, scheduleID); err != nil {
+		return nil, 0, err
+	}
+	return s.repo.FindRuns(scheduleID, limit, offset)
+}
+
+func (s *Service) ownSchedule(userID, scheduleID uint) (*ScheduledTransfer, error) {
+	schedule, err := s.repo.FindSchedule(scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	if schedule.SenderUserID != userID {
+		return nil, ErrScheduleNotFound
+	}
+	return schedule, nil
+}
+
+// PauseScheduledTransfer stops an active schedule until it is resumed
+func (s *Service) PauseScheduledTransfer(userID, scheduleID uint) (*ScheduledTransfer, error) {
+	schedule, err := s.ownSchedule(userID, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	if schedule.Status != "active" {
+		return nil, ErrScheduleState
+	}
+
+	if err := s.repo.TransitionSchedule(schedule.ID, "active", map[string]interface{}{"status": "paused"}); err != nil {
+		return nil, err
+	}
+	schedule.Status = "paused"
+	return schedule, nil
+}
+
+// ResumeScheduledTransfer reactivates a paused schedule. Recurring schedules
+// continue with their next future occurrence; occurrences missed while
+// paused are not made up.
+func (s *Service) ResumeScheduledTransfer(userID, scheduleID uint) (*ScheduledTransfer, error) {
+	schedule, err := s.ownSchedule(userID, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	if schedule.Status != "paused" {
+		return nil, ErrScheduleState
+	}
+
+	now := time.Now()
+	occurrences := schedule.Occurrences
+	next := schedule.NextRunAt
+	if schedule.Frequency != "once" {
+		for !next.After(now) {
+			occurrences++
+			next = occurrenceAt(schedule.StartAt, schedule.Frequency, occurrences)
+		}
+	}
+	status := "active"
+	if schedule.EndAt != nil && next.After(*schedule.EndAt) {
+		status = "completed"
+	}
+
+	err = s.repo.TransitionSchedule(schedule.ID, "paused", map[string]interface{}{
+		"status":            status,
+		"next_run_at":       next,
+		"occurrences":       occurrences,
+		"consecutive_skips": 0,
+	})
+	if err != nil {
+		return nil, err
+	}
+	schedule.Status, schedule.NextRunAt, schedule.Occurrences, schedule.ConsecutiveSkips = status, next, occurrences, 0
+	return schedule, nil
+}
+
+// CancelScheduledTransfer stops a schedule for good
+func (s *Service) CancelScheduledTransfer(userID, scheduleID uint) (*ScheduledTransfer, error) {
+	schedule, err := s.ownSchedule(userID, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	if schedule.Status != "active" && schedule.Status != "paused" {
+		return nil, ErrScheduleState
+	}
+
+	if err := s.repo.TransitionSchedule(schedule.ID, schedule.Status, map[string]interface{}{"status": "cancelled"}); err != nil {
+		return nil, err
+	}
+	schedule.Status = "cancelled"
+	return schedule, nil
+}
+
+// RunDueScheduledTransfers executes every due occurrence. Returns the number
+// of transfers made; skipped occurrences are recorded and notified instead.
+func (s *Service) RunDueScheduledTransfers() (int64, error) {
+	due, err := s.repo.FindDueSchedules(time.Now(), dueSchedulesBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	var executed int64
+	for i := range due {
+		ok, err := s.runOccurrence(&due[i])
+		if err != nil {
+			log.Printf("[ScheduledTransfer] schedule %d: %v", due[i].ID, err)
+			continue
+		}
+		if ok {
+			executed++
+		}
+	}
+	return executed, nil
+}
+
+// runOccurrence claims the schedule's due occurrence, advances it to the
+// next one and executes the transfer. Reports whether a transfer was made.
+func (s *Service) runOccurrence(schedule *ScheduledTransfer) (bool, error) {
+	now := time.Now()
+	scheduledAt := schedule.NextRunAt
+
+	// Advance to the next future occurrence; occurrences missed while the
+	// runner was down collapse into this one
+	occurrences := schedule.Occurrences + 1
+	next := scheduledAt
+	status := "completed"
+	if schedule.Frequency != "once" {
+		next = occurrenceAt(schedule.StartAt, schedule.Frequency, occurrences)
+		for !next.After(now) {
+			occurrences++
+			next = occurrenceAt(schedule.StartAt, schedule.Frequency, occurrences)
+		}
+		if schedule.EndAt == nil || !next.After(*schedule.EndAt) {
+			status = "active"
+		}
+	}
+
+	claimed, err := s.repo.ClaimOccurrence(schedule.ID, scheduledAt, map[string]interface{}{
+		"next_run_at": next,
+		"occurrences": occurrences,
+		"status":      status,
+		"last_run_at": now,
+	})
+	if err != nil || !claimed {
+		return false, err
+	}
+
+	description := fmt.Sprintf("Scheduled transfer #%d", schedule.ID)
+	if schedule.Description != "" {
+		description += ": " + schedule.Description
+	}
+
+	transfer, transferErr := s.CreateTransfer(schedule.SenderUserID, schedule.ReceiverUserID, schedule.Amount, description)
+	if transferErr == nil {
+		if err := s.repo.CreateRun(&ScheduledTransferRun{
+			ScheduleID:  schedule.ID,
+			ScheduledAt: scheduledAt,
+			Status:      "success",
+			TransferID:  &transfer.ID,
+		}); err != nil {
+			return true, err
+		}
+		return true, s.repo.UpdateSchedule(schedule.ID, map[string]interface{}{
+			"run_count":         gorm.Expr("run_count + 1"),
+			"consecutive_skips": 0,
+			"last_error":        "",
+		})
+	}
+
+	// Insufficient balance, frozen wallets or limits skip this occurrence only
+	reason := transferErr.Error()
+	if len(reason) > 255 {
+		reason = reason[:255]
+	}
+	if err := s.repo.CreateRun(&ScheduledTransferRun{
+		ScheduleID:  schedule.ID,
+		ScheduledAt: scheduledAt,
+		Status:      "skipped",
+		Error:       reason,
+	}); err != nil {
+		return false, err
+	}
+
+	updates := map[string]interface{}{
+		"skipped_count":     gorm.Expr("skipped_count + 1"),
+		"consecutive_skips": gorm.Expr("consecutive_skips + 1"),
+		"last_error":        reason,
+	}
+	paused := false
+	if schedule.Frequency == "once" {
+		updates["status"] = "failed"
+	} else if status == "active" && schedule.ConsecutiveSkips+1 >= maxConsecutiveSkips {
+		updates["status"] = "paused"
+		paused = true
+	}
+	if err := s.repo.UpdateSchedule(schedule.ID, updates); err != nil {
+		return false, err
+	}
+
+	s.notifySkipped(schedule, scheduledAt, reason, paused)
+	return false, nil
+}
+
+// notifySkipped tells the sender why an occurrence was skipped and the
+// receiver that the expected points did not arrive
+func (s *Service) notifySkipped(schedule *ScheduledTransfer, scheduledAt time.Time, reason string, paused bool) {
+	if s.notifier == nil {
+		return
+	}
+
+	when := scheduledAt.In(scheduleLocation()).Format("2006-01-02 15:04")
+	message := fmt.Sprintf("Your scheduled transfer of %d points due %s WIB was skipped: %s.", schedule.Amount, when, reason)
+	if paused {
+		message += fmt.Sprintf(" The schedule was paused after %d skipped runs in a row; resume it once the problem is solved.", maxConsecutiveSkips)
+	}
+	s.notifier.Notify(notification.CreateParams{
+		UserID:   schedule.SenderUserID,
+		Type:     "scheduled_transfer_skipped",
+		Title:    "Scheduled transfer skipped",
+		Message:  message,
+		Entity:   "SCHEDULED_TRANSFER",
+		EntityID: schedule.ID,
+	})
+
+	s.notifier.Notify(notification.CreateParams{
+		UserID:   schedule.ReceiverUserID,
+		Type:     "scheduled_transfer_skipped",
+		Title:    "Expected transfer not received",
+		Message:  fmt.Sprintf("A scheduled transfer of %d points to you due %s WIB could not be completed.", schedule.Amount, when),
+		Entity:   "SCHEDULED_TRANSFER",
+		EntityID: schedule.ID,
+	})
+}
+
+// populateScheduleDetails fills in the receiver names
+func (s *Service) populateScheduleDetails(schedules []ScheduledTransfer) error {
+	if len(schedules) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(schedules))
+	for i, sc := range schedules {
+		ids[i] = sc.ReceiverUserID
+	}
+
+	var users []struct {
+		ID       uint
+		FullName string
+		NimNip   string
+	}
+	if err := s.db.Table("users").Select("id, full_name, nim_nip").Where("id IN ?", ids).Scan(&users).Error; err != nil {
+		return err
+	}
+
+	for _, u := range users {
+		for i := range schedules {
+			if schedules[i].ReceiverUserID == u.ID {
+				schedules[i].ReceiverName = u.FullName
+				schedules[i].ReceiverNIM = u.NimNip
+			}
+		}
+	}
+	return nil
+}
@@ -3,15 +3,24 @@ package transfer
 import (
 	"errors"
 	"fmt"
+	"strings"
+	"time"
+	"wallet-point/internal/audit"
+	"wallet-point/internal/notification"
 	"wallet-point/internal/wallet"
+	"wallet-point/utils"
 
 	"gorm.io/gorm"
 )
 
+var ErrRecipientNotFound = errors.New("recipient not found")
+
 type Service struct {
 	repo          *Repository
 	walletRepo    *wallet.WalletRepository
 	walletService *wallet.WalletService
+	notifier      *notification.Service
+	auditService  *audit.AuditService
 	db            *gorm.DB
 }
 
@@ -25,6 +34,29 @@ func NewService(repo *Repository, walletRepo *wallet.WalletRepository, walletSer
 }
 
 func (s *Service) CreateTransfer(senderUserID, receiverUserID uint, amount int, description string) (*Transfer, error) {
+	var transfer *Transfer
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		transfer, err = s.executeTransfer(tx, senderUserID, receiverUserID, amount, description)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return transfer, nil
+}
+
+// executeTransfer moves points between two users inside tx
+func (s *Service) executeTransfer(tx *gorm.DB, senderUserID, receiverUserID uint, amount int, description string) (*Transfer, error) {
+	return s.postTransfer(tx, senderUserID, receiverUserID, amount, description, nil)
+}
+
+// postTransfer checks the transfer rules and posts a transfer. With a
+// deliverBy time it is an escrow: the points move into system:escrow instead
+// of the receiver's wallet.
+func (s *Service) postTransfer(tx *gorm.DB, senderUserID, receiverUserID uint, amount int, description string, deliverBy *time.Time) (*Transfer, error) {
 	if senderUserID == receiverUserID {
 		return nil, errors.New("cannot transfer points to yourself")
 	}
@@ -39,8 +71,17 @@ func (s *Service) CreateTransfer(senderUserID, receiverUserID uint, amount int,
 		return nil, errors.New("receiver wallet not found: check if user exists and has a wallet")
 	}
 
-	if senderWallet.Balance < amount {
-		return nil, errors.New("insufficient balance")
+	// Lock both wallets before counting so concurrent transfers of the same
+	// sender are checked one after another
+	if _, err := s.walletRepo.LockWallets(tx, senderWallet.ID, receiverWallet.ID); err != nil {
+		return nil, err
+	}
+	rules, err := s.loadRules(tx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkTransferRules(tx, rules, senderUserID, receiverUserID, senderWallet.ID, amount); err != nil {
+		return nil, err
 	}
 
 	transfer := &Transfer{
@@ -48,25 +89,55 @@ func (s *Service) CreateTransfer(senderUserID, receiverUserID uint, amount int,
 		ReceiverWalletID: receiverWallet.ID,
 		Amount:           amount,
 		Description:      description,
+		Type:             "direct",
 		Status:           "success",
 	}
 
-	err = s.db.Transaction(func(tx *gorm.DB) error {
-		// 1. Deduct from sender
-		if err := s.walletService.DebitWithTransaction(tx, senderWallet.ID, amount, "transfer_out", fmt.Sprintf("Transfer to user %d", receiverUserID)); err != nil {
-			return err
-		}
+	// 1. Move points from sender to receiver (or escrow) as one journal
+	journalType := "transfer"
+	legs := []wallet.LedgerLeg{
+		{
+			WalletID:    senderWallet.ID,
+			Direction:   "debit",
+			Amount:      amount,
+			Type:        "transfer_out",
+			Description: fmt.Sprintf("Transfer to user %d", receiverUserID),
+		},
+		{
+			WalletID:    receiverWallet.ID,
+			Direction:   "credit",
+			Amount:      amount,
+			Type:        "transfer_in",
+			Description: fmt.Sprintf("Transfer from user %d", senderUserID),
+		},
+	}
+	if deliverBy != nil {
+		journalType = "escrow"
+		transfer.Type = "escrow"
+		transfer.Status = "escrowed"
+		transfer.DeliverBy = deliverBy
+		legs[0].Description = fmt.Sprintf("Escrow for user %d", receiverUserID)
+		legs[1] = wallet.LedgerLeg{Account: wallet.AccountEscrow, Direction: "credit", Amount: amount}
+	}
 
-		// 2. Credit to receiver
-		if err := s.walletService.CreditWithTransaction(tx, receiverWallet.ID, amount, "transfer_in", fmt.Sprintf("Transfer from user %d", senderUserID)); err != nil {
-			return err
-		}
+	journal, _, err := s.walletService.PostJournal(tx, journalType, description, legs)
+	if err != nil {
+		return nil, err
+	}
 
-		// 3. Create transfer record
-		return s.repo.CreateWithTransaction(tx, transfer)
-	})
+	// 2. Create transfer record
+	transfer.JournalID = &journal.ID
+	if err := s.repo.CreateWithTransaction(tx, transfer); err != nil {
+		return nil, err
+	}
 
-	if err != nil {
+	// 3. Queue circular flows for review; the transfer itself goes through
+	if err := s.flagCircularFlow(tx, rules, transfer, senderUserID); err != nil {
+		return nil, err
+	}
+
+	if err := s.recordDecision(tx, senderUserID, "TRANSFER_RULES_PASSED", transfer.ID,
+		fmt.Sprintf("Transfer of %d points to user %d passed the transfer rules", amount, receiverUserID)); err != nil {
 		return nil, err
 	}
 
@@ -186,25 +257,64 @@ func (s *Service) populateTransferDetails(transfers []Transfer) error {
 	return nil
 }
 
+// FindRecipient shows who a user ID belongs to, masked. Users who opted out
+// of discovery are reported as not found.
 func (s *Service) FindRecipient(userID uint) (*RecipientSummary, error) {
-	// Check if user has a wallet
-	w, err := s.walletService.GetWalletByUserID(userID)
+	return s.findDiscoverable("users.id = ?", userID)
+}
+
+// ResolveRecipient finds a transfer recipient by NIM, email or handle.
+// "@name" is always a handle and anything else containing "@" is an email;
+// otherwise a NIM match wins over a handle. The result is masked.
+func (s *Service) ResolveRecipient(identifier string) (*RecipientSummary, error) {
+	identifier = strings.TrimSpace(identifier)
+	switch {
+	case identifier == "":
+		return nil, ErrRecipientNotFound
+	case strings.HasPrefix(identifier, "@"):
+		return s.findDiscoverable("users.handle = ?", strings.ToLower(identifier[1:]))
+	case strings.Contains(identifier, "@"):
+		return s.findDiscoverable("users.email = ?", strings.ToLower(identifier))
+	}
+
+	recipient, err := s.findDiscoverable("users.nim_nip = ?", identifier)
+	if errors.Is(err, ErrRecipientNotFound) {
+		return s.findDiscoverable("users.handle = ?", strings.ToLower(identifier))
+	}
+	return recipient, err
+}
+
+// recipientUserID returns the receiver of a transfer request: the resolved
+// identifier when given, else the raw user ID
+func (s *Service) recipientUserID(recipient string, receiverUserID uint) (uint, error) {
+	if recipient == "" {
+		return receiverUserID, nil
+	}
+	summary, err := s.ResolveRecipient(recipient)
 	if err != nil {
-		return nil, errors.New("user not found or has no wallet")
+		return 0, err
 	}
+	return summary.ID, nil
+}
 
+// findDiscoverable looks up an active, discoverable user with a wallet
+func (s *Service) findDiscoverable(condition string, value interface{}) (*RecipientSummary, error) {
 	var recipient RecipientSummary
-	err = s.db.Table("users").
-		Select("id, full_name, role, nim_nip as nim").
-		Where("id = ?", w.UserID).
+	err := s.db.Table("users").
+		Select("users.id, users.full_name, users.role, users.nim_nip as nim").
+		Joins("JOIN wallets ON wallets.user_id = users.id").
+		Where(condition, value).
+		Where("users.status = ? AND users.discoverable = ?", "active", true).
+		Limit(1).
 		Scan(&recipient).Error
-
 	if err != nil {
 		return nil, err
 	}
 	if recipient.ID == 0 {
-		return nil, errors.New("user not found")
+		return nil, ErrRecipientNotFound
 	}
 
+	recipient.FullName = utils.MaskName(recipient.FullName)
+	recipient.NIM = utils.MaskIdentifier(recipient.NIM)
 	return &recipient, nil
 }
@@ -225,7 +225,7 @@ func (h *UserHandler) ChangePassword(c *gin.Context) {
 
 // LookupUser handles looking up a user for public operations (like transfer)
 // @Summary Lookup user
-// @Description Get basic user info by ID (Public/Student)
+// @Description Get the masked name and role by ID, unless the user opted out of discovery (Public/Student)
 // @Tags Users
 // @Security BearerAuth
 // @Produce json
@@ -246,16 +246,17 @@ func (h *UserHandler) LookupUser(c *gin.Context) {
 		return
 	}
 
+	// Users who opted out of discovery look the same as unknown IDs
 	user, err := h.service.GetUserByID(uint(userID))
-	if err != nil {
+	if err != nil || !user.Discoverable {
 		utils.ErrorResponse(c, http.StatusNotFound, "User not found", nil)
 		return
 	}
 
-	// Return only safe info
+	// Return only safe, masked info
 	utils.SuccessResponse(c, http.StatusOK, "User found", gin.H{
 		"id":        user.ID,
-		"full_name": user.FullName,
+		"full_name": utils.MaskName(user.FullName),
 		"role":      user.Role,
 	})
 }
@@ -34,16 +34,17 @@ func (Wallet) TableName() string {
 }
 
 type UserWithWallet struct {
-	ID         uint       `json:"id"`
-	Email      string     `json:"email"`
-	FullName   string     `json:"full_name"`
-	NimNip     string     `json:"nim_nip"`
-	Role       string     `json:"role"`
-	Status     string     `json:"status"`
-	Balance    int        `json:"balance"`
-	LastSyncAt *time.Time `json:"last_sync_at,omitempty"`
-	CreatedAt  time.Time  `json:"created_at"`
-	UpdatedAt  time.Time  `json:"updated_at"`
+	ID           uint       `json:"id"`
+	Email        string     `json:"email"`
+	FullName     string     `json:"full_name"`
+	NimNip       string     `json:"nim_nip"`
+	Role         string     `json:"role"`
+	Status       string     `json:"status"`
+	Discoverable bool       `json:"discoverable"`
+	Balance      int        `json:"balance"`
+	LastSyncAt   *time.Time `json:"last_sync_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
 }
 
 type UpdateUserRequest struct {
@@ -0,0 +1,478 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DefaultAsset is the point asset every balance held before wallets had
+// several assets. Its balance stays in wallets.balance; other assets are
+// kept per wallet in wallet_balances.
+const DefaultAsset = "points"
+
+// CodeAssetMerchantOnly is returned when points of a merchant-only asset
+// would go anywhere but its merchants
+const CodeAssetMerchantOnly = "ASSET_MERCHANT_ONLY"
+
+var (
+	ErrAssetNotFound      = errors.New("point asset not found")
+	ErrAssetInactive      = errors.New("point asset is inactive")
+	ErrAssetCodeTaken     = errors.New("point asset code already exists")
+	ErrConversionNotFound = errors.New("no active conversion between these assets")
+	ErrConversionExists   = errors.New("a conversion rule between these assets already exists")
+)
+
+var assetCodePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{1,29}$`)
+
+// PointAsset is a pool of points with its own balances, e.g. academic
+// points, organisation points or event vouchers
+type PointAsset struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Code        string    `json:"code" gorm:"size:30;uniqueIndex;not null"`
+	Name        string    `json:"name" gorm:"size:100;not null"`
+	Description string    `json:"description" gorm:"size:255"`
+	MerchantIDs []uint    `json:"merchant_ids" gorm:"serializer:json"` // Spendable only at these merchants, empty = anywhere
+	Status      string    `json:"status" gorm:"type:enum('active','inactive');default:'active'"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (PointAsset) TableName() string {
+	return "point_assets"
+}
+
+// AssetBalance is a wallet's balance of a non-default asset
+type AssetBalance struct {
+	WalletID  uint      `json:"wallet_id" gorm:"primaryKey"`
+	Asset     string    `json:"asset" gorm:"primaryKey;size:30"`
+	Balance   int       `json:"balance" gorm:"default:0;not null"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (AssetBalance) TableName() string {
+	return "wallet_balances"
+}
+
+// ConversionRule lets students turn FromAmount points of one asset into
+// ToAmount points of another
+type ConversionRule struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	FromAsset  string    `json:"from_asset" gorm:"size:30;not null;uniqueIndex:idx_conversion_pair"`
+	ToAsset    string    `json:"to_asset" gorm:"size:30;not null;uniqueIndex:idx_conversion_pair"`
+	FromAmount int       `json:"from_amount" gorm:"not null"`
+	ToAmount   int       `json:"to_amount" gorm:"not null"`
+	Status     string    `json:"status" gorm:"type:enum('active','inactive');default:'active'"`
+	CreatedBy  uint      `json:"created_by"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func (ConversionRule) TableName() string {
+	return "asset_conversion_rules"
+}
+
+type CreateAssetRequest struct {
+	Code        string `json:"code" binding:"required,max=30"`
+	Name        string `json:"name" binding:"required,max=100"`
+	Description string `json:"description" binding:"max=255"`
+	MerchantIDs []uint `json:"merchant_ids"`
+}
+
+// UpdateAssetRequest changes an asset; its code cannot change
+type UpdateAssetRequest struct {
+	Name        string  `json:"name" binding:"omitempty,max=100"`
+	Description *string `json:"description" binding:"omitempty,max=255"`
+	MerchantIDs *[]uint `json:"merchant_ids"`
+	Status      string  `json:"status" binding:"omitempty,oneof=active inactive"`
+}
+
+type CreateConversionRuleRequest struct {
+	FromAsset  string `json:"from_asset" binding:"required,max=30"`
+	ToAsset    string `json:"to_asset" binding:"required,max=30"`
+	FromAmount int    `json:"from_amount" binding:"required,gt=0"`
+	ToAmount   int    `json:"to_amount" binding:"required,gt=0"`
+}
+
+type UpdateConversionRuleRequest struct {
+	FromAmount int    `json:"from_amount" binding:"omitempty,gt=0"`
+	ToAmount   int    `json:"to_amount" binding:"omitempty,gt=0"`
+	Status     string `json:"status" binding:"omitempty,oneof=active inactive"`
+}
+
+// ConvertRequest converts Amount points of FromAsset; Amount must be a
+// multiple of the rule's from_amount
+type ConvertRequest struct {
+	FromAsset string `json:"from_asset" binding:"required,max=30"`
+	ToAsset   string `json:"to_asset" binding:"required,max=30"`
+	Amount    int    `json:"amount" binding:"required,gt=0"`
+}
+
+// ConversionResult is what a conversion took and gave
+type ConversionResult struct {
+	JournalID uint   `json:"journal_id"`
+	FromAsset string `json:"from_asset"`
+	ToAsset   string `json:"to_asset"`
+	Debited   int    `json:"debited"`
+	Credited  int    `json:"credited"`
+}
+
+// WalletAssetBalance is one asset of a wallet with what can be spent
+type WalletAssetBalance struct {
+	Asset            string `json:"asset"`
+	Name             string `json:"name"`
+	Balance          int    `json:"balance"`
+	HeldBalance      int    `json:"held_balance"`
+	AvailableBalance int    `json:"available_balance"`
+	MerchantIDs      []uint `json:"merchant_ids"`
+}
+
+// assetKey identifies one asset balance of a wallet
+type assetKey struct {
+	walletID uint
+	asset    string
+}
+
+// asset returns the leg's asset code
+func (l LedgerLeg) asset() string {
+	if l.Asset == "" {
+		return DefaultAsset
+	}
+	return l.Asset
+}
+
+// GetAssets lists every point asset, the default asset first
+func (s *WalletService) GetAssets() ([]PointAsset, error) {
+	return s.repo.GetAssets()
+}
+
+// GetAsset returns an active asset by code. Empty means the default asset.
+func (s *WalletService) GetAsset(code string) (*PointAsset, error) {
+	if code == "" {
+		code = DefaultAsset
+	}
+	asset, err := s.repo.FindAsset(s.db, code)
+	if err != nil {
+		return nil, err
+	}
+	if asset.Status != "active" {
+		return nil, ErrAssetInactive
+	}
+	return asset, nil
+}
+
+// CreateAsset adds a point asset
+func (s *WalletService) CreateAsset(req *CreateAssetRequest) (*PointAsset, error) {
+	if !assetCodePattern.MatchString(req.Code) {
+		return nil, errors.New("asset code must be 2-30 lowercase letters, digits or underscores, starting with a letter")
+	}
+	if _, err := s.repo.FindAsset(s.db, req.Code); err == nil {
+		return nil, ErrAssetCodeTaken
+	} else if !errors.Is(err, ErrAssetNotFound) {
+		return nil, err
+	}
+	if err := s.checkMerchantIDs(req.MerchantIDs); err != nil {
+		return nil, err
+	}
+
+	asset := &PointAsset{
+		Code:        req.Code,
+		Name:        req.Name,
+		Description: req.Description,
+		MerchantIDs: req.MerchantIDs,
+		Status:      "active",
+	}
+	if asset.MerchantIDs == nil {
+		asset.MerchantIDs = []uint{}
+	}
+	if err := s.repo.CreateAsset(asset); err != nil {
+		return nil, err
+	}
+	return asset, nil
+}
+
+// UpdateAsset renames, restricts or deactivates an asset. The default
+// asset cannot be restricted to merchants or deactivated.
+func (s *WalletService) UpdateAsset(assetID uint, req *UpdateAssetRequest) (*PointAsset, error) {
+	asset, err := s.repo.FindAssetByID(assetID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != "" {
+		asset.Name = req.Name
+	}
+	if req.Description != nil {
+		asset.Description = *req.Description
+	}
+	if req.MerchantIDs != nil {
+		if asset.Code == DefaultAsset && len(*req.MerchantIDs) > 0 {
+			return nil, errors.New("the default asset cannot be restricted to merchants")
+		}
+		if err := s.checkMerchantIDs(*req.MerchantIDs); err != nil {
+			return nil, err
+		}
+		asset.MerchantIDs = *req.MerchantIDs
+	}
+	if req.Status != "" {
+		if asset.Code == DefaultAsset && req.Status != "active" {
+			return nil, errors.New("the default asset cannot be deactivated")
+		}
+		asset.Status = req.Status
+	}
+
+	if err := s.repo.SaveAsset(asset); err != nil {
+		return nil, err
+	}
+	return asset, nil
+}
+
+// checkMerchantIDs makes sure every merchant an asset is restricted to exists
+func (s *WalletService) checkMerchantIDs(ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if s.merchants == nil {
+		return errors.New("merchant profiles are not enabled")
+	}
+	for _, id := range ids {
+		if _, err := s.merchants.GetMerchantAccount(id); err != nil {
+			return fmt.Errorf("merchant %d: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// GetWalletAssetBalances lists every active asset with the wallet's balance,
+// the default asset first
+func (s *WalletService) GetWalletAssetBalances(w *Wallet) ([]WalletAssetBalance, error) {
+	assets, err := s.repo.GetAssets()
+	if err != nil {
+		return nil, err
+	}
+	stored, err := s.repo.GetAssetBalances(w.ID)
+	if err != nil {
+		return nil, err
+	}
+	held, err := s.repo.SumHoldsByAsset(w.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	balances := make([]WalletAssetBalance, 0, len(assets))
+	for _, asset := range assets {
+		balance := stored[asset.Code]
+		if asset.Code == DefaultAsset {
+			balance = w.Balance
+		}
+		// Inactive assets are still shown while the wallet holds some
+		if asset.Status != "active" && balance == 0 {
+			continue
+		}
+		balances = append(balances, WalletAssetBalance{
+			Asset:            asset.Code,
+			Name:             asset.Name,
+			Balance:          balance,
+			HeldBalance:      held[asset.Code],
+			AvailableBalance: balance - held[asset.Code],
+			MerchantIDs:      asset.MerchantIDs,
+		})
+	}
+	return balances, nil
+}
+
+// GetConversionRules lists conversion rules; activeOnly hides disabled ones
+func (s *WalletService) GetConversionRules(activeOnly bool) ([]ConversionRule, error) {
+	return s.repo.GetConversionRules(activeOnly)
+}
+
+// CreateConversionRule adds a conversion between two active assets
+func (s *WalletService) CreateConversionRule(adminID uint, req *CreateConversionRuleRequest) (*ConversionRule, error) {
+	if req.FromAsset == req.ToAsset {
+		return nil, errors.New("an asset cannot be converted into itself")
+	}
+	for _, code := range []string{req.FromAsset, req.ToAsset} {
+		if _, err := s.GetAsset(code); err != nil {
+			return nil, fmt.Errorf("%s: %w", code, err)
+		}
+	}
+	if _, err := s.repo.FindConversionRule(req.FromAsset, req.ToAsset); err == nil {
+		return nil, ErrConversionExists
+	}
+
+	rule := &ConversionRule{
+		FromAsset:  req.FromAsset,
+		ToAsset:    req.ToAsset,
+		FromAmount: req.FromAmount,
+		ToAmount:   req.ToAmount,
+		Status:     "active",
+		CreatedBy:  adminID,
+	}
+	if err := s.repo.CreateConversionRule(rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// UpdateConversionRule changes the rate of a rule or switches it on or off
+func (s *WalletService) UpdateConversionRule(ruleID uint, req *UpdateConversionRuleRequest) (*ConversionRule, error) {
+	rule, err := s.repo.FindConversionRuleByID(ruleID)
+	if err != nil {
+		return nil, err
+	}
+	if req.FromAmount > 0 {
+		rule.FromAmount = req.FromAmount
+	}
+	if req.ToAmount > 0 {
+		rule.ToAmount = req.ToAmount
+	}
+	if req.Status != "" {
+		rule.Status = req.Status
+	}
+	if err := s.repo.SaveConversionRule(rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// ConvertPoints converts a user's points from one asset into another at the
+// rate of an active rule. The points pass through the conversion account so
+// every asset in the journal stays balanced.
+func (s *WalletService) ConvertPoints(userID uint, req *ConvertRequest) (*ConversionResult, error) {
+	rule, err := s.repo.FindConversionRule(req.FromAsset, req.ToAsset)
+	if err != nil || rule.Status != "active" {
+		return nil, ErrConversionNotFound
+	}
+	if req.Amount%rule.FromAmount != 0 {
+		return nil, fmt.Errorf("amount must be a multiple of %d", rule.FromAmount)
+	}
+	credited := req.Amount / rule.FromAmount * rule.ToAmount
+
+	w, err := s.repo.FindByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	description := fmt.Sprintf("Convert %d %s to %d %s", req.Amount, req.FromAsset, credited, req.ToAsset)
+	journal, _, err := s.PostJournal(nil, "conversion", description, []LedgerLeg{
+		{
+			WalletID:    w.ID,
+			Asset:       req.FromAsset,
+			Direction:   "debit",
+			Amount:      req.Amount,
+			Type:        "conversion",
+			Description: description,
+		},
+		{Account: AccountConversion, Asset: req.FromAsset, Direction: "credit", Amount: req.Amount},
+		{Account: AccountConversion, Asset: req.ToAsset, Direction: "debit", Amount: credited},
+		{
+			WalletID:    w.ID,
+			Asset:       req.ToAsset,
+			Direction:   "credit",
+			Amount:      credited,
+			Type:        "conversion",
+			Description: description,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &ConversionResult{
+		JournalID: journal.ID,
+		FromAsset: req.FromAsset,
+		ToAsset:   req.ToAsset,
+		Debited:   req.Amount,
+		Credited:  credited,
+	}, nil
+}
+
+// checkAssets rejects legs in unknown or inactive assets and keeps points of
+// merchant-only assets from leaving wallets for anyone but those merchants.
+// Admin corrections may still move inactive assets.
+func (s *WalletService) checkAssets(tx *gorm.DB, journalType string, legs []LedgerLeg) error {
+	admin := adminJournalTypes[journalType]
+
+	assets := make(map[string]*PointAsset)
+	for _, leg := range legs {
+		code := leg.asset()
+		if code == DefaultAsset || assets[code] != nil {
+			continue
+		}
+		asset, err := s.repo.FindAsset(tx, code)
+		if err != nil {
+			return err
+		}
+		if asset.Status != "active" && !admin {
+			return fmt.Errorf("%s: %w", code, ErrAssetInactive)
+		}
+		assets[code] = asset
+	}
+
+	for code, asset := range assets {
+		if admin || len(asset.MerchantIDs) == 0 {
+			continue
+		}
+
+		spent := false
+		for _, leg := range legs {
+			if leg.asset() == code && leg.WalletID != 0 && leg.Direction == "debit" {
+				spent = true
+			}
+		}
+		if !spent {
+			continue
+		}
+
+		allowed := s.merchantWallets(asset.MerchantIDs)
+		for _, leg := range legs {
+			if leg.asset() == code && leg.WalletID != 0 && leg.Direction == "credit" && !allowed[leg.WalletID] {
+				return &WalletError{
+					code:    CodeAssetMerchantOnly,
+					status:  http.StatusForbidden,
+					message: fmt.Sprintf("%s can only be spent at its merchants", asset.Name),
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// merchantWallets returns the settlement wallets of the active merchants
+func (s *WalletService) merchantWallets(merchantIDs []uint) map[uint]bool {
+	wallets := make(map[uint]bool)
+	if s.merchants == nil {
+		return wallets
+	}
+	for _, id := range merchantIDs {
+		if account, err := s.merchants.GetMerchantAccount(id); err == nil {
+			wallets[account.WalletID] = true
+		}
+	}
+	return wallets
+}
+
+// assetAllowsMerchant reports whether an asset can be spent at a merchant
+func assetAllowsMerchant(asset *PointAsset, merchantID uint) bool {
+	if len(asset.MerchantIDs) == 0 {
+		return true
+	}
+	for _, id := range asset.MerchantIDs {
+		if id == merchantID {
+			return true
+		}
+	}
+	return false
+}
+
+// assetBalance returns a wallet's balance of an asset
+func (s *WalletService) assetBalance(tx *gorm.DB, w *Wallet, asset string) (int, error) {
+	if asset == "" || asset == DefaultAsset {
+		return w.Balance, nil
+	}
+	return s.repo.GetAssetBalance(tx, w.ID, asset)
+}
@@ -0,0 +1,219 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const defaultBillExpiry = 15 * time.Minute
+
+var (
+	ErrBillNotFound    = errors.New("bill not found")
+	ErrBillNotActive   = errors.New("bill has already been paid or has expired")
+	ErrBillAmount      = errors.New("amount is required for open-amount bills")
+	ErrBillOwnPayment  = errors.New("you cannot pay your own bill")
+	ErrBillOverMaximum = errors.New("amount exceeds the bill maximum")
+)
+
+// CreateBill creates a merchant-presented QR bill. The merchant's shared
+// wallet is the recipient; nothing is reserved until a student pays it.
+func (s *WalletService) CreateBill(req CreateBillRequest, merchantUserID uint) (*PaymentToken, error) {
+	account, err := s.merchantAccountFor(merchantUserID, req.TerminalID)
+	if err != nil {
+		return nil, err
+	}
+	merchantWallet, err := s.repo.FindByID(account.WalletID)
+	if err != nil {
+		return nil, errors.New("merchant wallet not found")
+	}
+	if err := walletStateError(merchantWallet); err != nil {
+		return nil, err
+	}
+
+	items := make([]BillItem, 0, len(req.Items))
+	itemTotal := 0
+	for _, item := range req.Items {
+		subtotal := item.Price * item.Quantity
+		itemTotal += subtotal
+		items = append(items, BillItem{
+			Name:     item.Name,
+			Quantity: item.Quantity,
+			Price:    item.Price,
+			Subtotal: subtotal,
+		})
+	}
+
+	amount, maxAmount := req.Amount, req.MaxAmount
+	if req.OpenAmount {
+		if amount > 0 {
+			return nil, errors.New("open-amount bills take the amount from the payer")
+		}
+		// Itemised open bills can be paid partially, up to the item total
+		if maxAmount == 0 {
+			maxAmount = itemTotal
+		}
+		if len(items) > 0 && maxAmount > itemTotal {
+			return nil, fmt.Errorf("max_amount cannot exceed the item total of %d", itemTotal)
+		}
+	} else {
+		if amount == 0 {
+			amount = itemTotal
+		}
+		if amount == 0 {
+			return nil, errors.New("amount or items are required for fixed bills")
+		}
+		if len(items) > 0 && amount != itemTotal {
+			return nil, fmt.Errorf("bill amount %d does not match the item total of %d", amount, itemTotal)
+		}
+		maxAmount = 0
+	}
+
+	merchantName := req.Merchant
+	if merchantName == "" {
+		merchantName = account.Name
+	}
+	var merchantID *uint
+	if account.MerchantID != 0 {
+		merchantID = &account.MerchantID
+	}
+
+	expiry := defaultBillExpiry
+	if req.ExpiryMinutes > 0 {
+		expiry = time.Duration(req.ExpiryMinutes) * time.Minute
+	}
+
+	tokenCode, err := newTokenCode()
+	if err != nil {
+		return nil, err
+	}
+
+	bill := &PaymentToken{
+		Token:       tokenCode,
+		Amount:      amount,
+		Merchant:    merchantName,
+		MerchantID:  merchantID,
+		TerminalID:  account.TerminalID,
+		CashierID:   &merchantUserID,
+		Expiry:      time.Now().Add(expiry),
+		WalletID:    merchantWallet.ID,
+		RecipientID: merchantUserID,
+		Type:        "bill",
+		Status:      "active",
+		Reference:   req.Reference,
+		OpenAmount:  req.OpenAmount,
+		MaxAmount:   maxAmount,
+		Items:       items,
+	}
+	if err := s.encodeTokenQR(bill); err != nil {
+		return nil, err
+	}
+
+	// Items are inserted with the token
+	if err := s.db.Create(bill).Error; err != nil {
+		return nil, err
+	}
+	return bill, nil
+}
+
+// PayBill settles a merchant bill from the payer's wallet. Fixed bills charge
+// their amount; open bills charge what the payer entered.
+func (s *WalletService) PayBill(tokenCode string, payerUserID uint, req PayBillRequest) (*PaymentToken, error) {
+	var bill PaymentToken
+	err := s.db.Preload("Items").Where("token = ? AND type = ?", tokenCode, "bill").First(&bill).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrBillNotFound
+		}
+		return nil, err
+	}
+
+	if bill.Status != "active" {
+		return nil, ErrBillNotActive
+	}
+	if time.Now().After(bill.Expiry) {
+		s.expireToken(&bill)
+		return nil, errors.New("bill has expired")
+	}
+
+	amount := bill.Amount
+	if bill.OpenAmount {
+		if req.Amount == 0 {
+			return nil, ErrBillAmount
+		}
+		if bill.MaxAmount > 0 && req.Amount > bill.MaxAmount {
+			return nil, ErrBillOverMaximum
+		}
+		amount = req.Amount
+	} else if req.Amount > 0 && req.Amount != bill.Amount {
+		return nil, fmt.Errorf("bill amount mismatch. Expected: %d, Found: %d", bill.Amount, req.Amount)
+	}
+
+	payerWallet, err := s.repo.FindByUserID(payerUserID)
+	if err != nil {
+		return nil, errors.New("payer wallet not found")
+	}
+	if payerWallet.ID == bill.WalletID {
+		return nil, ErrBillOwnPayment
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// 1. Move points from payer to merchant as one journal
+		description := fmt.Sprintf("Bill payment to %s", bill.Merchant)
+		if bill.Reference != "" {
+			description = fmt.Sprintf("%s (%s)", description, bill.Reference)
+		}
+		_, _, err := s.PostJournal(tx, "qr_payment", description, []LedgerLeg{
+			{
+				WalletID:    payerWallet.ID,
+				Direction:   "debit",
+				Amount:      amount,
+				Type:        "marketplace",
+				Description: description,
+				ReferenceID: &bill.ID,
+			},
+			{
+				WalletID:    bill.WalletID,
+				Direction:   "credit",
+				Amount:      amount,
+				Type:        "marketplace_sale",
+				Description: fmt.Sprintf("Bill paid by User ID %d: %s", payerUserID, description),
+				ReferenceID: &bill.ID,
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		// 2. Record who paid, unless another payment got there first
+		now := time.Now()
+		result := tx.Model(&PaymentToken{}).
+			Where("id = ? AND status = ?", bill.ID, "active").
+			Updates(map[string]interface{}{
+				"status":      "consumed",
+				"paid_amount": amount,
+				"paid_by":     payerWallet.ID,
+				"paid_at":     now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrBillNotActive
+		}
+
+		bill.Status = "consumed"
+		bill.PaidAmount = amount
+		bill.PaidBy = &payerWallet.ID
+		bill.PaidAt = &now
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publishTokenStatus(&bill)
+	return &bill, nil
+}
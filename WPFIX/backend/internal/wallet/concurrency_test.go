@@ -0,0 +1,195 @@
+package wallet_test
+
+// These tests race journals against a real MySQL database. They create their
+// own users and wallets, so point TEST_DB_DSN at a disposable database:
+//
+//	TEST_DB_DSN='root:@tcp(localhost:3306)/wallet_point_test?charset=utf8mb4&parseTime=True&loc=Local' go test ./internal/wallet/
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"wallet-point/internal/auth"
+	"wallet-point/internal/database"
+	"wallet-point/internal/wallet"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var (
+	migrateOnce sync.Once
+	userSeq     atomic.Int64
+)
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DB_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DB_DSN is not set")
+	}
+
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Stay under MySQL's default connection limit while goroutines queue on locks
+	sqlDB.SetMaxOpenConns(40)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	migrateOnce.Do(func() { database.Migrate(db) })
+	return db
+}
+
+// newTestWallet creates a student with a wallet holding balance points
+func newTestWallet(t *testing.T, db *gorm.DB, service *wallet.WalletService, balance int) uint {
+	t.Helper()
+	seq := fmt.Sprintf("%d-%d", time.Now().UnixNano(), userSeq.Add(1))
+	user := &auth.User{
+		Email:        "concurrency-" + seq + "@test.local",
+		PasswordHash: "-",
+		FullName:     "Concurrency Test",
+		NimNip:       "CT" + seq,
+		Role:         "mahasiswa",
+		Status:       "active",
+	}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	w := &wallet.Wallet{UserID: user.ID, Status: "active"}
+	if err := db.Create(w).Error; err != nil {
+		t.Fatalf("failed to create wallet: %v", err)
+	}
+
+	err := service.ResetWallet(&wallet.ResetWalletRequest{
+		WalletID:   w.ID,
+		NewBalance: balance,
+		Reason:     "Concurrency test setup",
+	}, 0)
+	if err != nil {
+		t.Fatalf("failed to fund wallet %d: %v", w.ID, err)
+	}
+	return w.ID
+}
+
+func assertReconciled(t *testing.T, service *wallet.WalletService, walletID uint) {
+	t.Helper()
+	report, err := service.ReconcileWallet(walletID)
+	if err != nil {
+		t.Fatalf("ReconcileWallet(%d) error = %v", walletID, err)
+	}
+	if !report.Consistent {
+		t.Errorf("wallet %d drifted from the ledger: %+v", walletID, *report)
+	}
+}
+
+func TestConcurrentDebitsNeverOverdraw(t *testing.T) {
+	db := openTestDB(t)
+	service := wallet.NewWalletService(wallet.NewWalletRepository(db), db)
+
+	const balance, amount, workers = 50, 1, 200
+	walletID := newTestWallet(t, db, service, balance)
+
+	var succeeded, insufficient atomic.Int64
+	errs := make(chan error, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			description := fmt.Sprintf("Concurrency test debit #%d", i)
+			_, _, err := service.PostJournal(nil, "marketplace", description, []wallet.LedgerLeg{
+				{WalletID: walletID, Direction: "debit", Amount: amount, Type: "marketplace", Description: description},
+				{Account: wallet.AccountRedemption, Direction: "credit", Amount: amount},
+			})
+			switch {
+			case err == nil:
+				succeeded.Add(1)
+			case errors.Is(err, wallet.ErrInsufficientBalance):
+				insufficient.Add(1)
+			default:
+				errs <- err
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("unexpected debit error: %v", err)
+	}
+	if got, want := succeeded.Load(), int64(balance/amount); got != want {
+		t.Errorf("%d debits succeeded, want %d", got, want)
+	}
+	if got, want := insufficient.Load(), int64(workers-balance/amount); got != want {
+		t.Errorf("%d debits were refused, want %d", got, want)
+	}
+
+	w, err := service.GetWalletByID(walletID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := balance - int(succeeded.Load())*amount; w.Balance != want || w.Balance < 0 {
+		t.Errorf("final balance = %d, want %d", w.Balance, want)
+	}
+	assertReconciled(t, service, walletID)
+}
+
+func TestConcurrentTransfersBothWays(t *testing.T) {
+	db := openTestDB(t)
+	service := wallet.NewWalletService(wallet.NewWalletRepository(db), db)
+
+	const balance, amount, workers = 20, 3, 200
+	a := newTestWallet(t, db, service, balance)
+	b := newTestWallet(t, db, service, balance)
+
+	// Opposite transfers deadlock unless wallets are always locked in the same order
+	errs := make(chan error, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		from, to := a, b
+		if i%2 == 1 {
+			from, to = b, a
+		}
+		wg.Add(1)
+		go func(from, to uint) {
+			defer wg.Done()
+			_, _, err := service.PostJournal(nil, "transfer", "Concurrency test transfer", []wallet.LedgerLeg{
+				{WalletID: from, Direction: "debit", Amount: amount, Type: "transfer_out", Description: "Concurrency test transfer"},
+				{WalletID: to, Direction: "credit", Amount: amount, Type: "transfer_in", Description: "Concurrency test transfer"},
+			})
+			if err != nil && !errors.Is(err, wallet.ErrInsufficientBalance) {
+				errs <- err
+			}
+		}(from, to)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("unexpected transfer error: %v", err)
+	}
+
+	wa, err := service.GetWalletByID(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wb, err := service.GetWalletByID(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wa.Balance < 0 || wb.Balance < 0 || wa.Balance+wb.Balance != 2*balance {
+		t.Errorf("balances = %d and %d, want non-negative balances summing to %d", wa.Balance, wb.Balance, 2*balance)
+	}
+	assertReconciled(t, service, a)
+	assertReconciled(t, service, b)
+}
@@ -0,0 +1,242 @@
+package wallet
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TermEnd is the last day of an academic term, repeating every year
+type TermEnd struct {
+	Month time.Month
+	Day   int
+}
+
+// ExpiryPolicy decides which credits expire and when. Lots of the listed
+// transaction types expire at the end of the term they were earned in.
+type ExpiryPolicy struct {
+	TermEnds      []TermEnd
+	Types         map[string]bool
+	WarningWindow time.Duration
+}
+
+// NewExpiryPolicy parses term ends ("MM-DD,MM-DD") and expiring transaction
+// types ("mission,task"). Term ends "off" or empty disable expiry.
+func NewExpiryPolicy(termEnds, types string, warningDays int) ExpiryPolicy {
+	policy := ExpiryPolicy{
+		Types:         make(map[string]bool),
+		WarningWindow: time.Duration(warningDays) * 24 * time.Hour,
+	}
+
+	if strings.EqualFold(strings.TrimSpace(termEnds), "off") {
+		termEnds = ""
+	}
+
+	for _, raw := range strings.Split(termEnds, ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		parts := strings.Split(raw, "-")
+		if len(parts) != 2 {
+			log.Printf("⚠️  Ignoring invalid term end %q (expected MM-DD)", raw)
+			continue
+		}
+		month, errMonth := strconv.Atoi(parts[0])
+		day, errDay := strconv.Atoi(parts[1])
+		if errMonth != nil || errDay != nil || month < 1 || month > 12 || day < 1 || day > 31 {
+			log.Printf("⚠️  Ignoring invalid term end %q (expected MM-DD)", raw)
+			continue
+		}
+		policy.TermEnds = append(policy.TermEnds, TermEnd{Month: time.Month(month), Day: day})
+	}
+
+	for _, t := range strings.Split(types, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			policy.Types[t] = true
+		}
+	}
+
+	return policy
+}
+
+// ExpiresAt returns when a credit of txnType earned at creditedAt expires:
+// the start of the day after the next term end. Nil means it never expires.
+func (p ExpiryPolicy) ExpiresAt(txnType string, creditedAt time.Time) *time.Time {
+	if len(p.TermEnds) == 0 || !p.Types[txnType] {
+		return nil
+	}
+
+	var next *time.Time
+	for _, year := range []int{creditedAt.Year(), creditedAt.Year() + 1} {
+		for _, end := range p.TermEnds {
+			at := time.Date(year, end.Month, end.Day+1, 0, 0, 0, 0, creditedAt.Location())
+			if at.After(creditedAt) && (next == nil || at.Before(*next)) {
+				next = &at
+			}
+		}
+	}
+	return next
+}
+
+// ExpiringLot is a credit whose unspent points expire soon
+type ExpiringLot struct {
+	TransactionID uint      `json:"transaction_id"`
+	Type          string    `json:"type"`
+	Amount        int       `json:"amount"`
+	ExpiresAt     time.Time `json:"expires_at"`
+}
+
+// ExpiringPoints summarises the points a wallet will lose inside the warning window
+type ExpiringPoints struct {
+	Total      int           `json:"total"`
+	NextExpiry *time.Time    `json:"next_expiry"`
+	Lots       []ExpiringLot `json:"lots"`
+}
+
+// SetExpiryPolicy configures which credits expire
+func (s *WalletService) SetExpiryPolicy(policy ExpiryPolicy) {
+	s.expiry = policy
+}
+
+// consumeLots takes a debit leg out of the wallet's lots, oldest first.
+// Balance that predates lots is older than any lot, so the debit spends it
+// before touching the lots.
+func (s *WalletService) consumeLots(tx *gorm.DB, walletID uint, leg LedgerLeg, balanceBefore int) error {
+	if leg.LotID != nil {
+		return s.repo.SetLotRemaining(tx, *leg.LotID, 0)
+	}
+
+	lots, err := s.repo.FindOpenLots(tx, walletID)
+	if err != nil {
+		return err
+	}
+
+	unlotted := balanceBefore
+	for _, lot := range lots {
+		unlotted -= lot.LotRemaining
+	}
+
+	remaining := leg.Amount - max(unlotted, 0)
+	for _, lot := range lots {
+		if remaining <= 0 {
+			break
+		}
+		take := lot.LotRemaining
+		if take > remaining {
+			take = remaining
+		}
+		if err := s.repo.SetLotRemaining(tx, lot.ID, lot.LotRemaining-take); err != nil {
+			return err
+		}
+		remaining -= take
+	}
+	return nil
+}
+
+// expireDueLots posts an expiry journal for every lot of a locked wallet
+// whose expiry date has passed, returning the number of points expired
+func (s *WalletService) expireDueLots(tx *gorm.DB, walletID uint, balances map[uint]int, now time.Time) (int, error) {
+	lots, err := s.repo.FindDueLots(tx, walletID, now)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, lot := range lots {
+		amount := lot.LotRemaining
+		if amount > balances[walletID] {
+			amount = balances[walletID]
+		}
+		if amount <= 0 {
+			if err := s.repo.SetLotRemaining(tx, lot.ID, 0); err != nil {
+				return 0, err
+			}
+			continue
+		}
+
+		lotID := lot.ID
+		description := fmt.Sprintf("Expired %d points from transaction #%d", amount, lot.ID)
+		_, _, err := s.postLocked(tx, "expiry", description, []LedgerLeg{
+			{
+				WalletID:    walletID,
+				Direction:   "debit",
+				Amount:      amount,
+				Type:        "expiry",
+				Description: description,
+				ReferenceID: &lotID,
+				LotID:       &lotID,
+			},
+			{Account: AccountExpired, Direction: "credit", Amount: amount},
+		}, balances)
+		if err != nil {
+			return 0, err
+		}
+		expired += amount
+	}
+
+	return expired, nil
+}
+
+// ExpirePoints posts expiry debits for every wallet holding lots past their
+// expiry date. Each wallet is handled in its own transaction.
+func (s *WalletService) ExpirePoints() (wallets int, points int, err error) {
+	now := time.Now()
+	walletIDs, err := s.repo.FindWalletsWithDueLots(now)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for _, walletID := range walletIDs {
+		var expired int
+		txErr := s.db.Transaction(func(tx *gorm.DB) error {
+			locked, err := s.repo.LockWallets(tx, walletID)
+			if err != nil {
+				return err
+			}
+			balances := map[uint]int{walletID: locked[walletID].Balance}
+			expired, err = s.expireDueLots(tx, walletID, balances, now)
+			return err
+		})
+		if txErr != nil {
+			log.Printf("[Expiry] failed to expire points of wallet %d: %v", walletID, txErr)
+			err = txErr
+			continue
+		}
+		if expired > 0 {
+			wallets++
+			points += expired
+		}
+	}
+
+	return wallets, points, err
+}
+
+// GetExpiringSoon returns the points of a wallet expiring inside the warning window
+func (s *WalletService) GetExpiringSoon(walletID uint) (*ExpiringPoints, error) {
+	now := time.Now()
+	lots, err := s.repo.GetExpiringLots(walletID, now, now.Add(s.expiry.WarningWindow))
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ExpiringPoints{Lots: []ExpiringLot{}}
+	for _, lot := range lots {
+		result.Total += lot.LotRemaining
+		result.Lots = append(result.Lots, ExpiringLot{
+			TransactionID: lot.ID,
+			Type:          lot.Type,
+			Amount:        lot.LotRemaining,
+			ExpiresAt:     *lot.ExpiresAt,
+		})
+	}
+	if len(result.Lots) > 0 {
+		result.NextExpiry = &result.Lots[0].ExpiresAt
+	}
+
+	return result, nil
+}
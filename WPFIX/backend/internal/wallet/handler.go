@@ -1,9 +1,12 @@
 package wallet
 
 import (
+	"errors"
+	"io"
 	"math"
 	"net/http"
 	"strconv"
+	"time"
 	"wallet-point/internal/audit"
 	"wallet-point/utils"
 
@@ -90,7 +93,7 @@ func (h *WalletHandler) AdjustPoints(c *gin.Context) {
 		if err.Error() == "wallet not found" {
 			statusCode = http.StatusNotFound
 		}
-		utils.ErrorResponse(c, statusCode, err.Error(), nil)
+		utils.ErrorFromErr(c, statusCode, err)
 		return
 	}
 
@@ -102,7 +105,7 @@ func (h *WalletHandler) AdjustPoints(c *gin.Context) {
 		Action:    "ADJUST_POINTS",
 		Entity:    "WALLET",
 		EntityID:  req.WalletID,
-		Details:   "Admin adjusted points: " + req.Direction + " " + strconv.Itoa(req.Amount) + " | Reason: " + req.Description,
+		Details:   "Admin adjusted points: " + req.Direction + " " + strconv.Itoa(req.Amount) + " " + assetOrDefault(req.Asset) + " | Reason: " + req.Description,
 		IPAddress: c.ClientIP(),
 		UserAgent: c.Request.UserAgent(),
 	})
@@ -133,7 +136,7 @@ func (h *WalletHandler) ResetWallet(c *gin.Context) {
 		if err.Error() == "wallet not found" {
 			statusCode = http.StatusNotFound
 		}
-		utils.ErrorResponse(c, statusCode, err.Error(), nil)
+		utils.ErrorFromErr(c, statusCode, err)
 		return
 	}
 
@@ -160,6 +163,7 @@ func (h *WalletHandler) ResetWallet(c *gin.Context) {
 // @Param type query string false "Filter by type"
 // @Param status query string false "Filter by status"
 // @Param direction query string false "Filter by direction"
+// @Param asset query string false "Filter by point asset code"
 // @Param from_date query string false "Filter from date (YYYY-MM-DD)"
 // @Param to_date query string false "Filter to date (YYYY-MM-DD)"
 // @Param page query int false "Page number" default(1)
@@ -175,6 +179,7 @@ func (h *WalletHandler) GetAllTransactions(c *gin.Context) {
 		Type:      c.Query("type"),
 		Status:    c.Query("status"),
 		Direction: c.Query("direction"),
+		Asset:     c.Query("asset"),
 		FromDate:  c.Query("from_date"),
 		ToDate:    c.Query("to_date"),
 		Page:      page,
@@ -233,6 +238,249 @@ func (h *WalletHandler) GetWalletTransactions(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusOK, "Transactions retrieved successfully", transactions)
 }
 
+// ReconcileWallet handles recomputing a wallet balance from the ledger
+// @Summary Reconcile wallet
+// @Description Recompute wallet balance from ledger entries and report drift (Admin only)
+// @Tags Admin - Wallets
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Wallet ID"
+// @Success 200 {object} utils.Response{data=ReconciliationReport}
+// @Failure 404 {object} utils.Response
+// @Router /admin/wallets/{id}/reconcile [get]
+func (h *WalletHandler) ReconcileWallet(c *gin.Context) {
+	walletID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid wallet ID", nil)
+		return
+	}
+
+	report, err := h.service.ReconcileWallet(uint(walletID))
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "wallet not found" {
+			statusCode = http.StatusNotFound
+		}
+		utils.ErrorResponse(c, statusCode, err.Error(), nil)
+		return
+	}
+
+	message := "Wallet is consistent with the ledger"
+	if !report.Consistent {
+		message = "Wallet drift detected"
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, message, report)
+}
+
+// GetWalletLimits handles getting the spending limits of a wallet
+// @Summary Get wallet limits
+// @Description Limits in force for a wallet (own overrides or role defaults) and current usage (Admin only)
+// @Tags Admin - Wallets
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Wallet ID"
+// @Success 200 {object} utils.Response{data=WalletLimits}
+// @Failure 404 {object} utils.Response
+// @Router /admin/wallets/{id}/limits [get]
+func (h *WalletHandler) GetWalletLimits(c *gin.Context) {
+	walletID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid wallet ID", nil)
+		return
+	}
+
+	limits, err := h.service.GetWalletLimits(uint(walletID))
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "wallet not found" {
+			statusCode = http.StatusNotFound
+		}
+		utils.ErrorResponse(c, statusCode, err.Error(), nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Wallet limits retrieved successfully", limits)
+}
+
+// UpdateWalletLimits handles setting the spending limits of a wallet
+// @Summary Update wallet limits
+// @Description Set daily/weekly debit limits and per-transaction maximum for a wallet. Null falls back to the role default (Admin only)
+// @Tags Admin - Wallets
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Wallet ID"
+// @Param request body UpdateLimitsRequest true "Limits"
+// @Success 200 {object} utils.Response{data=WalletLimits}
+// @Failure 400 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Router /admin/wallets/{id}/limits [put]
+func (h *WalletHandler) UpdateWalletLimits(c *gin.Context) {
+	adminID := c.GetUint("user_id")
+
+	walletID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid wallet ID", nil)
+		return
+	}
+
+	var req UpdateLimitsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	limits, err := h.service.UpdateWalletLimits(uint(walletID), &req)
+	if err != nil {
+		statusCode := http.StatusBadRequest
+		if err.Error() == "wallet not found" {
+			statusCode = http.StatusNotFound
+		}
+		utils.ErrorResponse(c, statusCode, err.Error(), nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Wallet limits updated successfully", limits)
+
+	// Log activity
+	h.auditService.LogActivity(audit.CreateAuditParams{
+		UserID:    adminID,
+		Action:    "UPDATE_WALLET_LIMITS",
+		Entity:    "WALLET",
+		EntityID:  uint(walletID),
+		Details:   "Admin updated wallet limits: daily " + formatLimit(req.DailyDebitLimit) + ", weekly " + formatLimit(req.WeeklyDebitLimit) + ", per transaction " + formatLimit(req.PerTransactionLimit),
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+}
+
+// UpdateWalletStatus handles freezing, closing or reactivating a wallet
+// @Summary Update wallet status
+// @Description Freeze, close or reactivate a wallet. Frozen wallets only accept admin corrections, closed wallets accept nothing (Admin only)
+// @Tags Admin - Wallets
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Wallet ID"
+// @Param request body UpdateStatusRequest true "New status"
+// @Success 200 {object} utils.Response{data=Wallet}
+// @Failure 400 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Router /admin/wallets/{id}/status [put]
+func (h *WalletHandler) UpdateWalletStatus(c *gin.Context) {
+	adminID := c.GetUint("user_id")
+
+	walletID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid wallet ID", nil)
+		return
+	}
+
+	var req UpdateStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	wallet, err := h.service.UpdateWalletStatus(uint(walletID), &req)
+	if err != nil {
+		statusCode := http.StatusBadRequest
+		if err.Error() == "wallet not found" {
+			statusCode = http.StatusNotFound
+		}
+		utils.ErrorResponse(c, statusCode, err.Error(), nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Wallet status updated successfully", wallet)
+
+	// Log activity
+	h.auditService.LogActivity(audit.CreateAuditParams{
+		UserID:    adminID,
+		Action:    "UPDATE_WALLET_STATUS",
+		Entity:    "WALLET",
+		EntityID:  uint(walletID),
+		Details:   "Admin set wallet status to " + req.Status + " | Reason: " + req.Reason,
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+}
+
+// GetRoleLimits handles listing default spending limits per role
+// @Summary Get role limits
+// @Description Default spending limits applied to wallets without their own limits (Admin only)
+// @Tags Admin - Wallets
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} utils.Response{data=[]RoleLimit}
+// @Router /admin/wallet-limits [get]
+func (h *WalletHandler) GetRoleLimits(c *gin.Context) {
+	limits, err := h.service.GetRoleLimits()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve role limits", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Role limits retrieved successfully", limits)
+}
+
+// UpdateRoleLimits handles setting default spending limits for a role
+// @Summary Update role limits
+// @Description Set the default daily/weekly debit limits and per-transaction maximum for a role. Null is unlimited (Admin only)
+// @Tags Admin - Wallets
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param role path string true "Role (admin, dosen, mahasiswa, merchant)"
+// @Param request body UpdateLimitsRequest true "Limits"
+// @Success 200 {object} utils.Response{data=RoleLimit}
+// @Failure 400 {object} utils.Response
+// @Router /admin/wallet-limits/{role} [put]
+func (h *WalletHandler) UpdateRoleLimits(c *gin.Context) {
+	adminID := c.GetUint("user_id")
+
+	role := c.Param("role")
+	switch role {
+	case "admin", "dosen", "mahasiswa", "merchant":
+	default:
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid role", nil)
+		return
+	}
+
+	var req UpdateLimitsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	limit, err := h.service.UpdateRoleLimits(role, &req)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update role limits", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Role limits updated successfully", limit)
+
+	// Log activity
+	h.auditService.LogActivity(audit.CreateAuditParams{
+		UserID:    adminID,
+		Action:    "UPDATE_ROLE_LIMITS",
+		Entity:    "WALLET",
+		Details:   "Admin updated " + role + " limits: daily " + formatLimit(req.DailyDebitLimit) + ", weekly " + formatLimit(req.WeeklyDebitLimit) + ", per transaction " + formatLimit(req.PerTransactionLimit),
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+}
+
+// formatLimit renders an optional limit for audit details
+func formatLimit(limit *int) string {
+	if limit == nil {
+		return "none"
+	}
+	return strconv.Itoa(*limit)
+}
+
 // GetLeaderboard handles getting leaderboard
 // @Summary Get leaderboard
 // @Description Get top users by wallet balance
@@ -260,7 +508,7 @@ func (h *WalletHandler) GetLeaderboard(c *gin.Context) {
 // @Tags Wallet
 // @Security BearerAuth
 // @Produce json
-// @Success 200 {object} utils.Response{data=Wallet}
+// @Success 200 {object} utils.Response{data=MyWalletResponse}
 // @Router /mahasiswa/wallet [get]
 func (h *WalletHandler) GetMyWallet(c *gin.Context) {
 	userID := c.GetUint("user_id")
@@ -271,7 +519,30 @@ func (h *WalletHandler) GetMyWallet(c *gin.Context) {
 		return
 	}
 
-	utils.SuccessResponse(c, http.StatusOK, "Wallet retrieved successfully", wallet)
+	expiring, err := h.service.GetExpiringSoon(wallet.ID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve expiring points", err.Error())
+		return
+	}
+
+	summary, err := h.service.GetBalanceSummary(wallet)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve available balance", err.Error())
+		return
+	}
+
+	balances, err := h.service.GetWalletAssetBalances(wallet)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve asset balances", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Wallet retrieved successfully", MyWalletResponse{
+		Wallet:         *wallet,
+		BalanceSummary: *summary,
+		ExpiringSoon:   expiring,
+		Balances:       balances,
+	})
 }
 
 // GetMyTransactions handles getting current user's transaction history
@@ -326,7 +597,7 @@ func (h *WalletHandler) GeneratePaymentToken(c *gin.Context) {
 
 	token, err := h.service.GeneratePaymentToken(req, userID, req.RecipientID)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		utils.ErrorFromErr(c, http.StatusBadRequest, err)
 		return
 	}
 
@@ -346,6 +617,103 @@ func (h *WalletHandler) CheckTokenStatus(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusOK, "Token info retrieved", token)
 }
 
+// StreamTokenStatus pushes the status of a payment token as Server-Sent
+// Events until it is consumed or expired, replacing status polling
+// @Summary Stream payment token status
+// @Description Server-Sent Events: a "status" event now and on every change; the stream ends once the token is consumed or expired
+// @Tags Payment
+// @Produce text/event-stream
+// @Param token path string true "Token code"
+// @Success 200 {object} TokenStatusEvent
+// @Router /payment/status/{token}/stream [get]
+func (h *WalletHandler) StreamTokenStatus(c *gin.Context) {
+	tokenCode := c.Param("token")
+
+	// Subscribe first so a change between the lookup and the stream is not lost
+	events, unsubscribe, err := h.service.SubscribeTokenStatus(tokenCode)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusServiceUnavailable, err.Error(), nil)
+		return
+	}
+	defer unsubscribe()
+
+	token, err := h.service.GetTokenDetails(tokenCode)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Token tidak valid atau sudah kadaluarsa", err.Error())
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Stop nginx from buffering the stream
+
+	c.SSEvent("status", NewTokenStatusEvent(token))
+	c.Writer.Flush()
+	if token.Status != "active" {
+		return
+	}
+
+	heartbeat := time.NewTicker(15 * time.Second)
+	defer heartbeat.Stop()
+	// Nothing else may touch the token when it lapses, so check it then
+	expiry := time.NewTimer(time.Until(token.Expiry) + time.Second)
+	defer expiry.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+
+		case msg, ok := <-events:
+			if !ok {
+				return false
+			}
+			event, ok := msg.(TokenStatusEvent)
+			if !ok {
+				return true
+			}
+			c.SSEvent("status", event)
+			return event.Status == "active"
+
+		case <-heartbeat.C:
+			// Comment line keeps proxies from closing an idle connection
+			_, err := io.WriteString(w, ": ping\n\n")
+			return err == nil
+
+		case <-expiry.C:
+			// Expires the token and publishes the change if it is still active
+			latest, err := h.service.GetTokenDetails(tokenCode)
+			if err != nil {
+				return false
+			}
+			if latest.Status != "active" {
+				c.SSEvent("status", NewTokenStatusEvent(latest))
+				return false
+			}
+			return true
+		}
+	})
+}
+
+// GetQRVerificationKeys publishes the public keys for signed QR payloads
+// @Summary QR verification keys
+// @Description Ed25519 public keys (JWK) for verifying WPT1 QR payment payloads offline
+// @Tags Payment
+// @Produce json
+// @Success 200 {object} utils.Response{data=QRVerificationKeys}
+// @Router /payment/keys [get]
+func (h *WalletHandler) GetQRVerificationKeys(c *gin.Context) {
+	keys, err := h.service.GetQRVerificationKeys()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, err.Error(), nil)
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=3600")
+	utils.SuccessResponse(c, http.StatusOK, "Verification keys retrieved", keys)
+}
+
 // ExecuteStudentPayment allows a student to pay for a scanned token
 func (h *WalletHandler) ExecuteStudentPayment(c *gin.Context) {
 	userID := c.GetUint("user_id")
@@ -358,12 +726,18 @@ func (h *WalletHandler) ExecuteStudentPayment(c *gin.Context) {
 		return
 	}
 
-	err := h.service.StudentPayToken(req.Token, userID)
+	tokenCode, err := h.service.ResolveTokenCode(req.Token)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
 		return
 	}
 
+	err = h.service.StudentPayToken(tokenCode, userID)
+	if err != nil {
+		utils.ErrorFromErr(c, http.StatusBadRequest, err)
+		return
+	}
+
 	utils.SuccessResponse(c, http.StatusOK, "Pembayaran berhasil!", nil)
 }
 
@@ -372,7 +746,8 @@ func (h *WalletHandler) MerchantScan(c *gin.Context) {
 	merchantID := c.GetUint("user_id")
 
 	var req struct {
-		Token string `json:"token" binding:"required"`
+		Token      string `json:"token" binding:"required"`
+		TerminalID uint   `json:"terminal_id"` // Defaults to the cashier's terminal
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -380,15 +755,103 @@ func (h *WalletHandler) MerchantScan(c *gin.Context) {
 		return
 	}
 
-	_, err := h.service.MerchantConsumeToken(req.Token, merchantID)
+	tokenCode, err := h.service.ResolveTokenCode(req.Token)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
 		return
 	}
 
+	_, err = h.service.MerchantConsumeToken(tokenCode, merchantID, req.TerminalID)
+	if err != nil {
+		utils.ErrorFromErr(c, http.StatusBadRequest, err)
+		return
+	}
+
 	utils.SuccessResponse(c, http.StatusOK, "Payment processed successfully", nil)
 }
 
+// CreateBill handles a merchant creating a QR bill for a customer to pay
+// @Summary Create merchant bill
+// @Description Create a fixed or open-amount QR bill with optional line items
+// @Tags Merchant
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body CreateBillRequest true "Bill details"
+// @Success 201 {object} utils.Response{data=PaymentToken}
+// @Router /merchant/bills [post]
+func (h *WalletHandler) CreateBill(c *gin.Context) {
+	merchantID := c.GetUint("user_id")
+
+	var req CreateBillRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	bill, err := h.service.CreateBill(req, merchantID)
+	if err != nil {
+		utils.ErrorFromErr(c, http.StatusBadRequest, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Bill created successfully", bill)
+}
+
+// PayBill handles a student paying a merchant bill
+// @Summary Pay merchant bill
+// @Description Pay a merchant bill; open-amount bills need the amount
+// @Tags Wallet
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param token path string true "Bill token, or the scanned QR payload"
+// @Param request body PayBillRequest false "Amount for open bills"
+// @Success 200 {object} utils.Response{data=PaymentToken}
+// @Router /mahasiswa/bills/{token}/pay [post]
+func (h *WalletHandler) PayBill(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	var req PayBillRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.ValidationErrorResponse(c, err.Error())
+			return
+		}
+	}
+
+	tokenCode, err := h.service.ResolveTokenCode(c.Param("token"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	bill, err := h.service.PayBill(tokenCode, userID, req)
+	if err != nil {
+		status := http.StatusBadRequest
+		switch {
+		case errors.Is(err, ErrBillNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, ErrBillNotActive):
+			status = http.StatusConflict
+		}
+		utils.ErrorFromErr(c, status, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Bill paid successfully", bill)
+
+	h.auditService.LogActivity(audit.CreateAuditParams{
+		UserID:    userID,
+		Action:    "PAY_BILL",
+		Entity:    "PAYMENT_TOKEN",
+		EntityID:  bill.ID,
+		Details:   "Paid " + strconv.Itoa(bill.PaidAmount) + " points to " + bill.Merchant,
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+}
+
 // GetMerchantStats handles retrieving merchant-specific dashboard statistics
 func (h *WalletHandler) GetMerchantStats(c *gin.Context) {
 	merchantID := c.GetUint("user_id")
@@ -412,3 +875,263 @@ func (h *WalletHandler) GetAdminStats(c *gin.Context) {
 
 	utils.SuccessResponse(c, http.StatusOK, "Admin stats retrieved", stats)
 }
+
+// assetErrorStatus maps point asset errors to HTTP status codes
+func assetErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrAssetNotFound), errors.Is(err, ErrConversionNotFound), err.Error() == "conversion rule not found", err.Error() == "wallet not found":
+		return http.StatusNotFound
+	case errors.Is(err, ErrAssetCodeTaken), errors.Is(err, ErrConversionExists):
+		return http.StatusConflict
+	}
+	return http.StatusBadRequest
+}
+
+// assetOrDefault names the asset of a request for audit details
+func assetOrDefault(asset string) string {
+	if asset == "" {
+		return DefaultAsset
+	}
+	return asset
+}
+
+// GetAssets handles listing point assets
+// @Summary List point assets
+// @Description Every point asset, the default asset first
+// @Tags Wallet
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} utils.Response{data=[]PointAsset}
+// @Router /assets [get]
+func (h *WalletHandler) GetAssets(c *gin.Context) {
+	assets, err := h.service.GetAssets()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve point assets", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Point assets retrieved successfully", assets)
+}
+
+// CreateAsset handles adding a point asset
+// @Summary Create point asset
+// @Description Add a point asset, optionally spendable only at some merchants (Admin only)
+// @Tags Admin - Wallets
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body CreateAssetRequest true "Asset"
+// @Success 201 {object} utils.Response{data=PointAsset}
+// @Failure 400 {object} utils.Response
+// @Failure 409 {object} utils.Response
+// @Router /admin/assets [post]
+func (h *WalletHandler) CreateAsset(c *gin.Context) {
+	adminID := c.GetUint("user_id")
+
+	var req CreateAssetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	asset, err := h.service.CreateAsset(&req)
+	if err != nil {
+		utils.ErrorResponse(c, assetErrorStatus(err), err.Error(), nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Point asset created successfully", asset)
+
+	h.auditService.LogActivity(audit.CreateAuditParams{
+		UserID:    adminID,
+		Action:    "CREATE_POINT_ASSET",
+		Entity:    "POINT_ASSET",
+		EntityID:  asset.ID,
+		Details:   "Admin created point asset " + asset.Code + " (" + asset.Name + ")",
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+}
+
+// UpdateAsset handles changing a point asset
+// @Summary Update point asset
+// @Description Rename, restrict to merchants or deactivate a point asset (Admin only)
+// @Tags Admin - Wallets
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Asset ID"
+// @Param request body UpdateAssetRequest true "Changes"
+// @Success 200 {object} utils.Response{data=PointAsset}
+// @Failure 400 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Router /admin/assets/{id} [put]
+func (h *WalletHandler) UpdateAsset(c *gin.Context) {
+	adminID := c.GetUint("user_id")
+
+	assetID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid asset ID", nil)
+		return
+	}
+
+	var req UpdateAssetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	asset, err := h.service.UpdateAsset(uint(assetID), &req)
+	if err != nil {
+		utils.ErrorResponse(c, assetErrorStatus(err), err.Error(), nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Point asset updated successfully", asset)
+
+	h.auditService.LogActivity(audit.CreateAuditParams{
+		UserID:    adminID,
+		Action:    "UPDATE_POINT_ASSET",
+		Entity:    "POINT_ASSET",
+		EntityID:  asset.ID,
+		Details:   "Admin updated point asset " + asset.Code + " (status " + asset.Status + ")",
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+}
+
+// GetConversionRules handles listing asset conversion rules
+// @Summary List conversion rules
+// @Description Rules for converting points between assets. Students only see active rules.
+// @Tags Wallet
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} utils.Response{data=[]ConversionRule}
+// @Router /admin/asset-conversions [get]
+// @Router /mahasiswa/wallet/conversions [get]
+func (h *WalletHandler) GetConversionRules(c *gin.Context) {
+	rules, err := h.service.GetConversionRules(c.GetString("role") != "admin")
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve conversion rules", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Conversion rules retrieved successfully", rules)
+}
+
+// CreateConversionRule handles adding an asset conversion rule
+// @Summary Create conversion rule
+// @Description Let students convert from_amount of one asset into to_amount of another (Admin only)
+// @Tags Admin - Wallets
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body CreateConversionRuleRequest true "Rule"
+// @Success 201 {object} utils.Response{data=ConversionRule}
+// @Failure 400 {object} utils.Response
+// @Failure 409 {object} utils.Response
+// @Router /admin/asset-conversions [post]
+func (h *WalletHandler) CreateConversionRule(c *gin.Context) {
+	adminID := c.GetUint("user_id")
+
+	var req CreateConversionRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	rule, err := h.service.CreateConversionRule(adminID, &req)
+	if err != nil {
+		utils.ErrorResponse(c, assetErrorStatus(err), err.Error(), nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Conversion rule created successfully", rule)
+
+	h.auditService.LogActivity(audit.CreateAuditParams{
+		UserID:    adminID,
+		Action:    "CREATE_CONVERSION_RULE",
+		Entity:    "CONVERSION_RULE",
+		EntityID:  rule.ID,
+		Details:   "Admin allowed converting " + strconv.Itoa(rule.FromAmount) + " " + rule.FromAsset + " into " + strconv.Itoa(rule.ToAmount) + " " + rule.ToAsset,
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+}
+
+// UpdateConversionRule handles changing an asset conversion rule
+// @Summary Update conversion rule
+// @Description Change the rate of a conversion rule or switch it on or off (Admin only)
+// @Tags Admin - Wallets
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Rule ID"
+// @Param request body UpdateConversionRuleRequest true "Changes"
+// @Success 200 {object} utils.Response{data=ConversionRule}
+// @Failure 400 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Router /admin/asset-conversions/{id} [put]
+func (h *WalletHandler) UpdateConversionRule(c *gin.Context) {
+	adminID := c.GetUint("user_id")
+
+	ruleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid rule ID", nil)
+		return
+	}
+
+	var req UpdateConversionRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	rule, err := h.service.UpdateConversionRule(uint(ruleID), &req)
+	if err != nil {
+		utils.ErrorResponse(c, assetErrorStatus(err), err.Error(), nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Conversion rule updated successfully", rule)
+
+	h.auditService.LogActivity(audit.CreateAuditParams{
+		UserID:    adminID,
+		Action:    "UPDATE_CONVERSION_RULE",
+		Entity:    "CONVERSION_RULE",
+		EntityID:  rule.ID,
+		Details:   "Admin set conversion " + rule.FromAsset + " → " + rule.ToAsset + " to " + strconv.Itoa(rule.FromAmount) + ":" + strconv.Itoa(rule.ToAmount) + " (" + rule.Status + ")",
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+}
+
+// ConvertPoints handles converting the student's points between assets
+// @Summary Convert points
+// @Description Convert points of one asset into another at the rate of an active rule
+// @Tags Wallet
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body ConvertRequest true "Conversion"
+// @Success 200 {object} utils.Response{data=ConversionResult}
+// @Failure 400 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Router /mahasiswa/wallet/convert [post]
+func (h *WalletHandler) ConvertPoints(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	var req ConvertRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	result, err := h.service.ConvertPoints(userID, &req)
+	if err != nil {
+		utils.ErrorFromErr(c, assetErrorStatus(err), err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Points converted successfully", result)
+}
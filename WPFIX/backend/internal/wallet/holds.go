@@ -0,0 +1,143 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// checkAvailable makes sure debits only spend points that are not reserved
+// by payment holds of the same asset. A leg capturing a hold may use the
+// points it reserved.
+func (s *WalletService) checkAvailable(tx *gorm.DB, legs []LedgerLeg, balances map[uint]int) error {
+	debits := make(map[assetKey]int)
+	captured := make(map[assetKey]int)
+	for _, leg := range legs {
+		if leg.WalletID == 0 || leg.Direction != "debit" {
+			continue
+		}
+		key := assetKey{walletID: leg.WalletID, asset: leg.asset()}
+		debits[key] += leg.Amount
+		if leg.TransactionID != nil {
+			amount, err := s.repo.FindPendingAmount(tx, *leg.TransactionID)
+			if err != nil {
+				return err
+			}
+			captured[key] += amount
+		}
+	}
+
+	for key, amount := range debits {
+		balance := balances[key.walletID]
+		if key.asset != DefaultAsset {
+			var err error
+			if balance, err = s.repo.GetAssetBalance(tx, key.walletID, key.asset); err != nil {
+				return err
+			}
+		}
+		held, err := s.repo.SumHolds(tx, key.walletID, key.asset)
+		if err != nil {
+			return err
+		}
+		if amount > balance-(held-captured[key]) {
+			return ErrInsufficientBalance
+		}
+	}
+	return nil
+}
+
+// placeHold reserves amount of an asset on a locked wallet as a pending debit
+func (s *WalletService) placeHold(tx *gorm.DB, w *Wallet, asset string, amount int, description string) (*WalletTransaction, error) {
+	leg := LedgerLeg{WalletID: w.ID, Asset: asset, Direction: "debit", Amount: amount}
+	if err := s.checkWalletRules(tx, "qr_payment", []LedgerLeg{leg}, map[uint]*Wallet{w.ID: w}); err != nil {
+		return nil, err
+	}
+
+	balance := w.Balance
+	if leg.asset() != DefaultAsset {
+		var err error
+		if balance, err = s.repo.GetAssetBalance(tx, w.ID, leg.asset()); err != nil {
+			return nil, err
+		}
+	}
+	held, err := s.repo.SumHolds(tx, w.ID, leg.asset())
+	if err != nil {
+		return nil, err
+	}
+	if balance-held < amount {
+		return nil, errors.New("insufficient points for this transaction")
+	}
+
+	hold := &WalletTransaction{
+		WalletID:    w.ID,
+		Asset:       leg.asset(),
+		Type:        "marketplace",
+		Amount:      amount,
+		Direction:   "debit",
+		Status:      "pending",
+		Description: description,
+		CreatedBy:   "system",
+	}
+	if err := s.repo.CreateTransaction(tx, hold); err != nil {
+		return nil, err
+	}
+	return hold, nil
+}
+
+// expireToken marks a token expired and gives its hold back
+func (s *WalletService) expireToken(token *PaymentToken) {
+	expired := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&PaymentToken{}).
+			Where("id = ? AND status = ?", token.ID, "active").
+			Update("status", "expired")
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		expired = true
+		if token.HoldID == nil {
+			return nil
+		}
+		return s.repo.ReleaseHold(tx, *token.HoldID)
+	})
+	token.Status = "expired"
+
+	if err == nil && expired {
+		s.publishTokenStatus(token)
+	}
+}
+
+// BalanceSummary splits a wallet's default asset balance into held and spendable points
+type BalanceSummary struct {
+	LedgerBalance    int `json:"ledger_balance"`
+	HeldBalance      int `json:"held_balance"`
+	AvailableBalance int `json:"available_balance"`
+}
+
+// GetBalanceSummary returns the ledger balance and what remains after holds
+func (s *WalletService) GetBalanceSummary(w *Wallet) (*BalanceSummary, error) {
+	return s.balanceSummary(nil, w)
+}
+
+// LockBalanceSummary locks a wallet in tx and returns its balance summary,
+// so the available points cannot change before tx posts against them
+func (s *WalletService) LockBalanceSummary(tx *gorm.DB, walletID uint) (*BalanceSummary, error) {
+	locked, err := s.repo.LockWallets(tx, walletID)
+	if err != nil {
+		return nil, err
+	}
+	return s.balanceSummary(tx, locked[walletID])
+}
+
+func (s *WalletService) balanceSummary(tx *gorm.DB, w *Wallet) (*BalanceSummary, error) {
+	held, err := s.repo.SumHolds(tx, w.ID, DefaultAsset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum holds: %w", err)
+	}
+	return &BalanceSummary{
+		LedgerBalance:    w.Balance,
+		HeldBalance:      held,
+		AvailableBalance: w.Balance - held,
+	}, nil
+}
@@ -0,0 +1,84 @@
+package wallet
+
+import (
+	"fmt"
+	"time"
+)
+
+// System ledger accounts sit on the other side of wallet legs when points
+// enter or leave circulation, so every journal stays balanced.
+const (
+	AccountIssuance   = "system:issuance"   // Points minted into wallets (missions, sync, manual credit)
+	AccountRedemption = "system:redemption" // Points taken out of circulation (manual debit, unclaimed sales)
+	AccountOpening    = "system:opening"    // Balances that existed before the ledger was introduced
+	AccountExpired    = "system:expired"    // Lots that reached their expiry date unspent
+	AccountSettlement = "system:settlement" // Merchant sales paid out in settlement batches
+	AccountEscrow     = "system:escrow"     // Escrow transfers waiting to be released or refunded
+	AccountConversion = "system:conversion" // Points exchanged from one asset into another
+)
+
+// WalletAccount returns the ledger account name for a wallet
+func WalletAccount(walletID uint) string {
+	return fmt.Sprintf("wallet:%d", walletID)
+}
+
+// LedgerJournal groups the legs of one balanced point movement
+type LedgerJournal struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Type        string    `json:"type" gorm:"size:50;not null;index"`
+	Description string    `json:"description" gorm:"size:500"`
+	Status      string    `json:"status" gorm:"type:enum('posted','partially_reversed','reversed');default:'posted'"`
+	ReversalOf  *uint     `json:"reversal_of" gorm:"index"` // Journal compensated by this one
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (LedgerJournal) TableName() string {
+	return "ledger_journals"
+}
+
+// LedgerEntry is a single debit or credit leg of a journal
+type LedgerEntry struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	JournalID uint      `json:"journal_id" gorm:"not null;index"`
+	Account   string    `json:"account" gorm:"size:100;not null;index"`
+	WalletID  *uint     `json:"wallet_id" gorm:"index"`
+	Asset     string    `json:"asset" gorm:"size:30;default:'points';not null;index"`
+	Direction string    `json:"direction" gorm:"type:enum('credit','debit');not null"`
+	Amount    int       `json:"amount" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (LedgerEntry) TableName() string {
+	return "ledger_entries"
+}
+
+// LedgerLeg describes one side of a journal to be posted.
+// Wallet legs set WalletID, system legs set Account instead.
+type LedgerLeg struct {
+	WalletID      uint
+	Account       string
+	Asset         string // Point asset code, empty = DefaultAsset
+	Direction     string
+	Amount        int
+	Type          string // wallet_transactions.type, wallet legs only
+	Description   string
+	ReferenceID   *uint
+	CreatedBy     string
+	LotID         *uint // Debit this specific lot instead of the oldest ones (expiry)
+	TransactionID *uint // Post into this pending hold instead of a new transaction (capture)
+}
+
+// ReconciliationReport compares a wallet's stored balance with its ledger
+type ReconciliationReport struct {
+	WalletID           uint           `json:"wallet_id"`
+	StoredBalance      int            `json:"stored_balance"`
+	LedgerBalance      int            `json:"ledger_balance"`
+	Drift              int            `json:"drift"`
+	LedgerEntries      int64          `json:"ledger_entries"`
+	LastBalanceAfter   *int           `json:"last_balance_after"`
+	BalanceAfterDrift  int            `json:"balance_after_drift"`
+	UnbalancedJournals []uint         `json:"unbalanced_journals"`
+	AssetDrift         map[string]int `json:"asset_drift"` // Other assets whose stored balance differs from the ledger
+	Consistent         bool           `json:"consistent"`
+	CheckedAt          time.Time      `json:"checked_at"`
+}
@@ -0,0 +1,311 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Error codes returned when a wallet rule blocks an operation
+const (
+	CodeWalletFrozen             = "WALLET_FROZEN"
+	CodeWalletClosed             = "WALLET_CLOSED"
+	CodeTransactionLimitExceeded = "TRANSACTION_LIMIT_EXCEEDED"
+	CodeDailyLimitExceeded       = "DAILY_LIMIT_EXCEEDED"
+	CodeWeeklyLimitExceeded      = "WEEKLY_LIMIT_EXCEEDED"
+)
+
+// WalletError is a blocked operation with a code the frontend can explain
+type WalletError struct {
+	code    string
+	status  int
+	message string
+}
+
+func (e *WalletError) Error() string   { return e.message }
+func (e *WalletError) Code() string    { return e.code }
+func (e *WalletError) HTTPStatus() int { return e.status }
+
+// adminJournalTypes are corrections made by admins or the system. They still
+// move points on frozen wallets and do not count towards spending limits.
+var adminJournalTypes = map[string]bool{
+	"adjustment":             true,
+	"reset":                  true,
+	"reversal":               true,
+	"expiry":                 true,
+	"settlement":             true,
+	"escrow_release":         true,
+	"escrow_refund":          true,
+	"mission_reward_reclaim": true,
+}
+
+// limitExemptTypes are wallet transaction types left out of spending totals
+var limitExemptTypes = []string{"adjustment", "reversal", "expiry", "settlement"}
+
+// RoleLimit holds the default spending limits for every wallet of a role.
+// Nil limits are unlimited.
+type RoleLimit struct {
+	Role                string    `json:"role" gorm:"primaryKey;size:20"`
+	DailyDebitLimit     *int      `json:"daily_debit_limit"`
+	WeeklyDebitLimit    *int      `json:"weekly_debit_limit"`
+	PerTransactionLimit *int      `json:"per_transaction_limit"`
+	UpdatedAt           time.Time `json:"updated_at"`
+}
+
+func (RoleLimit) TableName() string {
+	return "wallet_role_limits"
+}
+
+// UpdateLimitsRequest sets limits; null clears a wallet override
+type UpdateLimitsRequest struct {
+	DailyDebitLimit     *int `json:"daily_debit_limit" binding:"omitempty,gte=0"`
+	WeeklyDebitLimit    *int `json:"weekly_debit_limit" binding:"omitempty,gte=0"`
+	PerTransactionLimit *int `json:"per_transaction_limit" binding:"omitempty,gte=0"`
+}
+
+// UpdateStatusRequest freezes, closes or reactivates a wallet
+type UpdateStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=active frozen closed"`
+	Reason string `json:"reason" binding:"required,max=255"`
+}
+
+// WalletLimits shows the limits in force for a wallet and how much is used
+type WalletLimits struct {
+	WalletID            uint   `json:"wallet_id"`
+	Role                string `json:"role"`
+	Status              string `json:"status"`
+	DailyDebitLimit     *int   `json:"daily_debit_limit"`
+	WeeklyDebitLimit    *int   `json:"weekly_debit_limit"`
+	PerTransactionLimit *int   `json:"per_transaction_limit"`
+	SpentToday          int    `json:"spent_today"`
+	SpentThisWeek       int    `json:"spent_this_week"`
+	Overrides           bool   `json:"overrides"` // Wallet has its own limits
+}
+
+// checkWalletRules enforces wallet state and spending limits for the wallet
+// legs of a journal. Wallets must already be locked by the caller.
+func (s *WalletService) checkWalletRules(tx *gorm.DB, journalType string, legs []LedgerLeg, locked map[uint]*Wallet) error {
+	admin := adminJournalTypes[journalType]
+
+	debits := make(map[uint]int)
+	for _, leg := range legs {
+		if leg.WalletID == 0 {
+			continue
+		}
+		w := locked[leg.WalletID]
+
+		if w.Status == "closed" || (w.Status == "frozen" && !admin) {
+			return walletStateError(w)
+		}
+
+		// Captured holds were checked against the limits when they were placed.
+		// Limits are in default asset points.
+		if leg.Direction == "debit" && !admin && leg.TransactionID == nil && leg.asset() == DefaultAsset {
+			debits[w.ID] += leg.Amount
+		}
+	}
+
+	for walletID, amount := range debits {
+		if err := s.checkLimits(tx, locked[walletID], amount); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walletStateError explains why a frozen or closed wallet cannot be used, nil when active
+func walletStateError(w *Wallet) error {
+	switch w.Status {
+	case "closed":
+		return &WalletError{code: CodeWalletClosed, status: http.StatusForbidden, message: fmt.Sprintf("wallet %d is closed", w.ID)}
+	case "frozen":
+		return &WalletError{code: CodeWalletFrozen, status: http.StatusForbidden, message: fmt.Sprintf("wallet %d is frozen", w.ID)}
+	}
+	return nil
+}
+
+// checkLimits rejects a debit that exceeds the wallet's per-transaction,
+// daily or weekly limit
+func (s *WalletService) checkLimits(tx *gorm.DB, w *Wallet, amount int) error {
+	limits, err := s.effectiveLimits(tx, w)
+	if err != nil {
+		return err
+	}
+
+	if limits.PerTransactionLimit != nil && amount > *limits.PerTransactionLimit {
+		return &WalletError{
+			code:    CodeTransactionLimitExceeded,
+			status:  http.StatusUnprocessableEntity,
+			message: fmt.Sprintf("amount exceeds the per-transaction limit of %d points", *limits.PerTransactionLimit),
+		}
+	}
+
+	if limits.DailyDebitLimit == nil && limits.WeeklyDebitLimit == nil {
+		return nil
+	}
+
+	dayStart, weekStart := periodStarts(time.Now())
+	if limits.DailyDebitLimit != nil {
+		spent, err := s.repo.SumDebitsSince(tx, w.ID, dayStart, limitExemptTypes)
+		if err != nil {
+			return err
+		}
+		if spent+amount > *limits.DailyDebitLimit {
+			return &WalletError{
+				code:    CodeDailyLimitExceeded,
+				status:  http.StatusUnprocessableEntity,
+				message: fmt.Sprintf("daily spending limit of %d points reached (%d already spent today)", *limits.DailyDebitLimit, spent),
+			}
+		}
+	}
+	if limits.WeeklyDebitLimit != nil {
+		spent, err := s.repo.SumDebitsSince(tx, w.ID, weekStart, limitExemptTypes)
+		if err != nil {
+			return err
+		}
+		if spent+amount > *limits.WeeklyDebitLimit {
+			return &WalletError{
+				code:    CodeWeeklyLimitExceeded,
+				status:  http.StatusUnprocessableEntity,
+				message: fmt.Sprintf("weekly spending limit of %d points reached (%d already spent this week)", *limits.WeeklyDebitLimit, spent),
+			}
+		}
+	}
+	return nil
+}
+
+// effectiveLimits merges wallet overrides over the role defaults
+func (s *WalletService) effectiveLimits(tx *gorm.DB, w *Wallet) (*WalletLimits, error) {
+	role, err := s.repo.GetWalletRole(tx, w.ID)
+	if err != nil {
+		return nil, err
+	}
+	defaults, err := s.repo.FindRoleLimit(tx, role)
+	if err != nil {
+		return nil, err
+	}
+
+	limits := &WalletLimits{
+		WalletID:            w.ID,
+		Role:                role,
+		Status:              w.Status,
+		DailyDebitLimit:     w.DailyDebitLimit,
+		WeeklyDebitLimit:    w.WeeklyDebitLimit,
+		PerTransactionLimit: w.PerTransactionLimit,
+		Overrides:           w.DailyDebitLimit != nil || w.WeeklyDebitLimit != nil || w.PerTransactionLimit != nil,
+	}
+	if defaults != nil {
+		if limits.DailyDebitLimit == nil {
+			limits.DailyDebitLimit = defaults.DailyDebitLimit
+		}
+		if limits.WeeklyDebitLimit == nil {
+			limits.WeeklyDebitLimit = defaults.WeeklyDebitLimit
+		}
+		if limits.PerTransactionLimit == nil {
+			limits.PerTransactionLimit = defaults.PerTransactionLimit
+		}
+	}
+	return limits, nil
+}
+
+// periodStarts returns the start of today and of the current week (Monday)
+func periodStarts(now time.Time) (time.Time, time.Time) {
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	offset := (int(day.Weekday()) + 6) % 7
+	return day, day.AddDate(0, 0, -offset)
+}
+
+// GetWalletLimits returns the limits in force for a wallet and current usage
+func (s *WalletService) GetWalletLimits(walletID uint) (*WalletLimits, error) {
+	w, err := s.repo.FindByID(walletID)
+	if err != nil {
+		return nil, err
+	}
+
+	limits, err := s.effectiveLimits(s.db, w)
+	if err != nil {
+		return nil, err
+	}
+
+	dayStart, weekStart := periodStarts(time.Now())
+	if limits.SpentToday, err = s.repo.SumDebitsSince(s.db, w.ID, dayStart, limitExemptTypes); err != nil {
+		return nil, err
+	}
+	if limits.SpentThisWeek, err = s.repo.SumDebitsSince(s.db, w.ID, weekStart, limitExemptTypes); err != nil {
+		return nil, err
+	}
+	return limits, nil
+}
+
+// UpdateWalletLimits sets the wallet's own limits; nil values fall back to the role default
+func (s *WalletService) UpdateWalletLimits(walletID uint, req *UpdateLimitsRequest) (*WalletLimits, error) {
+	if _, err := s.repo.FindByID(walletID); err != nil {
+		return nil, err
+	}
+
+	err := s.repo.UpdateWallet(walletID, map[string]interface{}{
+		"daily_debit_limit":     req.DailyDebitLimit,
+		"weekly_debit_limit":    req.WeeklyDebitLimit,
+		"per_transaction_limit": req.PerTransactionLimit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetWalletLimits(walletID)
+}
+
+// UpdateWalletStatus freezes, closes or reactivates a wallet
+func (s *WalletService) UpdateWalletStatus(walletID uint, req *UpdateStatusRequest) (*Wallet, error) {
+	w, err := s.repo.FindByID(walletID)
+	if err != nil {
+		return nil, err
+	}
+	if w.Status == req.Status {
+		return nil, fmt.Errorf("wallet is already %s", req.Status)
+	}
+	if req.Status == "closed" {
+		if w.Balance != 0 {
+			return nil, errors.New("wallet must be emptied before it can be closed")
+		}
+		assets, err := s.repo.GetAssetBalances(walletID)
+		if err != nil {
+			return nil, err
+		}
+		for asset, balance := range assets {
+			if balance != 0 {
+				return nil, fmt.Errorf("wallet must be emptied of %s before it can be closed", asset)
+			}
+		}
+	}
+
+	err = s.repo.UpdateWallet(walletID, map[string]interface{}{
+		"status":        req.Status,
+		"status_reason": req.Reason,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.FindByID(walletID)
+}
+
+// GetRoleLimits lists the default limits of every role that has them
+func (s *WalletService) GetRoleLimits() ([]RoleLimit, error) {
+	return s.repo.GetRoleLimits()
+}
+
+// UpdateRoleLimits sets the default limits for a role
+func (s *WalletService) UpdateRoleLimits(role string, req *UpdateLimitsRequest) (*RoleLimit, error) {
+	limit := &RoleLimit{
+		Role:                role,
+		DailyDebitLimit:     req.DailyDebitLimit,
+		WeeklyDebitLimit:    req.WeeklyDebitLimit,
+		PerTransactionLimit: req.PerTransactionLimit,
+	}
+	if err := s.repo.SaveRoleLimit(limit); err != nil {
+		return nil, err
+	}
+	return limit, nil
+}
@@ -0,0 +1,64 @@
+package wallet
+
+import "errors"
+
+var ErrWrongMerchant = errors.New("this QR belongs to another merchant")
+
+// MerchantAccount is the merchant a payment is collected for and who at the
+// merchant collected it. Every terminal and cashier of a merchant shares
+// its settlement wallet.
+type MerchantAccount struct {
+	MerchantID uint // 0 for users that are not linked to a merchant profile
+	Name       string
+	WalletID   uint
+	TerminalID *uint
+	CashierID  uint
+}
+
+// MerchantDirectory resolves merchant profiles for the wallet service. It is
+// implemented by the merchant module, which itself depends on wallets.
+type MerchantDirectory interface {
+	// AccountForCashier returns the merchant an owner or cashier collects for,
+	// on the given terminal (0 = their default). Nil when the user is not
+	// linked to any merchant.
+	AccountForCashier(userID uint, terminalID uint) (*MerchantAccount, error)
+	// GetMerchantAccount returns an active merchant by ID
+	GetMerchantAccount(merchantID uint) (*MerchantAccount, error)
+}
+
+// SetMerchantDirectory configures how merchant profiles are resolved
+func (s *WalletService) SetMerchantDirectory(directory MerchantDirectory) {
+	s.merchants = directory
+}
+
+// merchantAccountFor returns the merchant a merchant-side user collects for.
+// Users without a merchant profile (e.g. admins) collect into their own wallet.
+func (s *WalletService) merchantAccountFor(userID uint, terminalID uint) (*MerchantAccount, error) {
+	if s.merchants != nil {
+		account, err := s.merchants.AccountForCashier(userID, terminalID)
+		if err != nil {
+			return nil, err
+		}
+		if account != nil {
+			return account, nil
+		}
+	}
+
+	w, err := s.repo.FindByUserID(userID)
+	if err != nil {
+		return nil, errors.New("merchant wallet not found")
+	}
+	var name string
+	s.db.Table("users").Where("id = ?", userID).Select("full_name").Scan(&name)
+
+	return &MerchantAccount{Name: name, WalletID: w.ID, CashierID: userID}, nil
+}
+
+// GetMerchantWallet returns the settlement wallet a merchant-side user collects into
+func (s *WalletService) GetMerchantWallet(userID uint) (*Wallet, error) {
+	account, err := s.merchantAccountFor(userID, 0)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.FindByID(account.WalletID)
+}
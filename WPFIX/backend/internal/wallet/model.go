@@ -5,12 +5,19 @@ import (
 )
 
 type Wallet struct {
-	ID         uint       `json:"id" gorm:"primaryKey"`
-	UserID     uint       `json:"user_id" gorm:"uniqueIndex;not null"`
-	Balance    int        `json:"balance" gorm:"default:0;not null"`
-	LastSyncAt *time.Time `json:"last_sync_at"`
-	CreatedAt  time.Time  `json:"created_at"`
-	UpdatedAt  time.Time  `json:"updated_at"`
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	UserID       uint       `json:"user_id" gorm:"uniqueIndex;not null"`
+	Balance      int        `json:"balance" gorm:"default:0;not null"`
+	Status       string     `json:"status" gorm:"type:enum('active','frozen','closed');default:'active';not null"`
+	StatusReason string     `json:"status_reason,omitempty" gorm:"size:255"`
+	LastSyncAt   *time.Time `json:"last_sync_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+
+	// Spending limit overrides, nil falls back to the role default
+	DailyDebitLimit     *int `json:"daily_debit_limit"`
+	WeeklyDebitLimit    *int `json:"weekly_debit_limit"`
+	PerTransactionLimit *int `json:"per_transaction_limit"`
 }
 
 func (Wallet) TableName() string {
@@ -18,16 +25,21 @@ func (Wallet) TableName() string {
 }
 
 type WalletTransaction struct {
-	ID          uint      `json:"id" gorm:"primaryKey"`
-	WalletID    uint      `json:"wallet_id" gorm:"not null"`
-	Type        string    `json:"type" gorm:"type:enum('mission','task','transfer_in','transfer_out','marketplace','marketplace_sale','external','adjustment','topup');not null"`
-	Amount      int       `json:"amount" gorm:"not null"`
-	Direction   string    `json:"direction" gorm:"type:enum('credit','debit');not null"`
-	ReferenceID *uint     `json:"reference_id"`
-	Status      string    `json:"status" gorm:"type:enum('success','failed','pending');default:'success'"`
-	Description string    `json:"description" gorm:"size:500"`
-	CreatedBy   string    `json:"created_by" gorm:"type:enum('system','admin','dosen');default:'system'"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	WalletID     uint       `json:"wallet_id" gorm:"not null"`
+	Type         string     `json:"type" gorm:"type:enum('mission','task','transfer_in','transfer_out','marketplace','marketplace_sale','external','adjustment','topup','reversal','expiry','settlement','conversion');not null"`
+	Asset        string     `json:"asset" gorm:"size:30;default:'points';not null;index"`
+	Amount       int        `json:"amount" gorm:"not null"`
+	Direction    string     `json:"direction" gorm:"type:enum('credit','debit');not null"`
+	ReferenceID  *uint      `json:"reference_id"`
+	JournalID    *uint      `json:"journal_id" gorm:"index"`
+	BalanceAfter *int       `json:"balance_after"`
+	Status       string     `json:"status" gorm:"type:enum('success','failed','pending','reversed','released','captured');default:'success'"` // pending = payment hold, captured once posted
+	Description  string     `json:"description" gorm:"size:500"`
+	CreatedBy    string     `json:"created_by" gorm:"type:enum('system','admin','dosen');default:'system'"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty" gorm:"index"`       // Credit lots only, nil never expires
+	LotRemaining int        `json:"lot_remaining" gorm:"default:0;not null"` // Unspent part of a credit lot
+	CreatedAt    time.Time  `json:"created_at"`
 }
 
 func (WalletTransaction) TableName() string {
@@ -42,27 +54,32 @@ type WalletWithUser struct {
 	NimNip     string     `json:"nim_nip"`
 	Role       string     `json:"role"`
 	Balance    int        `json:"balance"`
+	Status     string     `json:"status"`
 	LastSyncAt *time.Time `json:"last_sync_at,omitempty"`
 }
 
 type TransactionWithDetails struct {
-	ID          uint      `json:"id"`
-	WalletID    uint      `json:"wallet_id"`
-	UserEmail   string    `json:"user_email"`
-	UserName    string    `json:"user_name"`
-	NimNip      string    `json:"nim_nip"`
-	Type        string    `json:"type"`
-	Amount      int       `json:"amount"`
-	Direction   string    `json:"direction"`
-	ReferenceID *uint     `json:"reference_id"`
-	Status      string    `json:"status"`
-	Description string    `json:"description"`
-	CreatedBy   string    `json:"created_by"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID           uint      `json:"id"`
+	WalletID     uint      `json:"wallet_id"`
+	UserEmail    string    `json:"user_email"`
+	UserName     string    `json:"user_name"`
+	NimNip       string    `json:"nim_nip"`
+	Type         string    `json:"type"`
+	Asset        string    `json:"asset"`
+	Amount       int       `json:"amount"`
+	Direction    string    `json:"direction"`
+	ReferenceID  *uint     `json:"reference_id"`
+	JournalID    *uint     `json:"journal_id"`
+	BalanceAfter *int      `json:"balance_after"`
+	Status       string    `json:"status"`
+	Description  string    `json:"description"`
+	CreatedBy    string    `json:"created_by"`
+	CreatedAt    time.Time `json:"created_at"`
 }
 
 type AdjustmentRequest struct {
 	WalletID    uint   `json:"wallet_id" binding:"required"`
+	Asset       string `json:"asset" binding:"omitempty,max=30"` // Empty = default asset
 	Amount      int    `json:"amount" binding:"required,gt=0"`
 	Direction   string `json:"direction" binding:"required,oneof=credit debit"`
 	Description string `json:"description" binding:"required"`
@@ -78,6 +95,7 @@ type TransactionListParams struct {
 	Type      string
 	Status    string
 	Direction string
+	Asset     string
 	FromDate  string
 	ToDate    string
 	Page      int
@@ -100,3 +118,11 @@ type AdminStats struct {
 	TodayCredits      int64 `json:"today_credits"`
 	TodayDebits       int64 `json:"today_debits"`
 }
+
+// MyWalletResponse is the student's wallet with held, available and soon-expiring points
+type MyWalletResponse struct {
+	Wallet
+	BalanceSummary
+	ExpiringSoon *ExpiringPoints      `json:"expiring_soon"`
+	Balances     []WalletAssetBalance `json:"balances"` // Every asset, the default one first
+}
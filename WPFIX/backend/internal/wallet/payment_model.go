@@ -6,14 +6,29 @@ type PaymentToken struct {
 	ID           uint      `json:"id" gorm:"primaryKey"`
 	Token        string    `json:"token" gorm:"uniqueIndex;not null"`
 	QRCodeBase64 string    `json:"qr_code_base64" gorm:"type:text"`
+	QRPayload    string    `json:"qr_payload" gorm:"type:text"` // Content encoded in the QR image
 	Amount       int       `json:"amount" gorm:"not null"`
-	Merchant     string    `json:"merchant" gorm:"size:100"`
+	Merchant     string    `json:"merchant" gorm:"size:100"` // Display name
+	MerchantID   *uint     `json:"merchant_id" gorm:"index"` // Registered merchant, nil for free-text merchants
 	Expiry       time.Time `json:"expiry" gorm:"not null"`
 	WalletID     uint      `json:"wallet_id" gorm:"not null"` // Creator
 	RecipientID  uint      `json:"recipient_id"`              // Who gets the money
 	Status       string    `json:"status" gorm:"type:enum('active','consumed','expired');default:'active'"`
-	Type         string    `json:"type" gorm:"size:50"` // "purchase" or "transfer"
+	Type         string    `json:"type" gorm:"size:50"` // "purchase", "transfer" or "bill"
+	Asset        string    `json:"asset" gorm:"size:30;default:'points';not null"`
+	HoldID       *uint     `json:"hold_id"`     // Pending transaction reserving the amount (purchase tokens)
+	TerminalID   *uint     `json:"terminal_id"` // Merchant terminal that captured or issued the token
+	CashierID    *uint     `json:"cashier_id"`  // Merchant user that captured or issued the token
 	CreatedAt    time.Time `json:"created_at"`
+
+	// Merchant bills
+	Reference  string     `json:"reference,omitempty" gorm:"size:100"`
+	OpenAmount bool       `json:"open_amount"`           // Payer enters the amount
+	MaxAmount  int        `json:"max_amount,omitempty"`  // Upper bound for open amounts, 0 = none
+	PaidAmount int        `json:"paid_amount,omitempty"` // What the payer actually paid
+	PaidBy     *uint      `json:"paid_by,omitempty"`     // Payer wallet
+	PaidAt     *time.Time `json:"paid_at,omitempty"`
+	Items      []BillItem `json:"items,omitempty" gorm:"foreignKey:TokenID"`
 }
 
 func (PaymentToken) TableName() string {
@@ -22,7 +37,46 @@ func (PaymentToken) TableName() string {
 
 type PaymentTokenRequest struct {
 	Amount      int    `json:"amount" binding:"required,gt=0"`
-	Merchant    string `json:"merchant" binding:"required"`
+	MerchantID  uint   `json:"merchant_id"`                                    // Registered merchant
+	Merchant    string `json:"merchant" binding:"required_without=MerchantID"` // Free-text name (legacy)
 	Type        string `json:"type" binding:"required,oneof=purchase transfer"`
 	RecipientID uint   `json:"recipient_id"`
+	Asset       string `json:"asset" binding:"omitempty,max=30"` // Empty = default asset
+}
+
+// BillItem is a line on a merchant bill
+type BillItem struct {
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	TokenID  uint   `json:"-" gorm:"index;not null"`
+	Name     string `json:"name" gorm:"size:100;not null"`
+	Quantity int    `json:"quantity" gorm:"not null"`
+	Price    int    `json:"price" gorm:"not null"` // Per unit
+	Subtotal int    `json:"subtotal" gorm:"not null"`
+}
+
+func (BillItem) TableName() string {
+	return "bill_items"
+}
+
+type BillItemRequest struct {
+	Name     string `json:"name" binding:"required,max=100"`
+	Quantity int    `json:"quantity" binding:"required,gt=0"`
+	Price    int    `json:"price" binding:"required,gt=0"`
+}
+
+// CreateBillRequest is a merchant-presented QR bill. Fixed bills charge
+// Amount (or the item total); open bills let the payer enter the amount.
+type CreateBillRequest struct {
+	Amount        int               `json:"amount" binding:"omitempty,gt=0"`
+	OpenAmount    bool              `json:"open_amount"`
+	MaxAmount     int               `json:"max_amount" binding:"omitempty,gt=0"`
+	Merchant      string            `json:"merchant" binding:"omitempty,max=100"`
+	TerminalID    uint              `json:"terminal_id"`
+	Reference     string            `json:"reference" binding:"omitempty,max=100"`
+	Items         []BillItemRequest `json:"items" binding:"omitempty,dive"`
+	ExpiryMinutes int               `json:"expiry_minutes" binding:"omitempty,gt=0,lte=1440"`
+}
+
+type PayBillRequest struct {
+	Amount int `json:"amount" binding:"omitempty,gt=0"` // Required for open-amount bills
 }
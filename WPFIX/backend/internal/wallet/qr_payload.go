@@ -0,0 +1,217 @@
+package wallet
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Signed QR payloads look like WPT1.<claims>.<signature>, both parts
+// base64url without padding. The signature is Ed25519 over "WPT1.<claims>".
+const (
+	QRPayloadPrefix  = "WPT1."
+	QRPayloadVersion = 1
+	legacyQRPrefix   = "WPT:"
+)
+
+var (
+	ErrInvalidQRPayload = errors.New("invalid QR payment payload")
+	ErrQRSignature      = errors.New("QR payment payload signature is not valid")
+	ErrQRExpired        = errors.New("QR payment payload has expired")
+
+	tokenCodePattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+)
+
+// QRClaims is the content of a signed QR payment payload
+type QRClaims struct {
+	Version  int    `json:"v"`
+	KeyID    string `json:"kid"`
+	Token    string `json:"t"`
+	WalletID uint   `json:"w"`
+	Amount   int    `json:"a"`
+	Merchant string `json:"m,omitempty"`
+	Kind     string `json:"k,omitempty"` // "bill" for merchant-presented bills
+	Expiry   int64  `json:"e"`           // Unix seconds
+	Nonce    string `json:"n"`
+}
+
+// QRSigner signs and verifies QR payment payloads with an Ed25519 key
+type QRSigner struct {
+	privateKey ed25519.PrivateKey
+	publicKey  ed25519.PublicKey
+	keyID      string
+}
+
+// NewQRSigner loads the Ed25519 seed (base64, 32 bytes). Without a seed the
+// key is derived from fallbackSecret so every replica signs with the same
+// key; that is meant for development only.
+func NewQRSigner(seedBase64, fallbackSecret string) (*QRSigner, error) {
+	var seed []byte
+	if seedBase64 != "" {
+		decoded, err := base64.StdEncoding.DecodeString(seedBase64)
+		if err != nil || len(decoded) != ed25519.SeedSize {
+			return nil, fmt.Errorf("QR signing key must be %d bytes of base64", ed25519.SeedSize)
+		}
+		seed = decoded
+	} else {
+		derived := sha256.Sum256([]byte("wallet-point/qr-signing/" + fallbackSecret))
+		seed = derived[:]
+	}
+
+	privateKey := ed25519.NewKeyFromSeed(seed)
+	publicKey := privateKey.Public().(ed25519.PublicKey)
+	fingerprint := sha256.Sum256(publicKey)
+
+	return &QRSigner{
+		privateKey: privateKey,
+		publicKey:  publicKey,
+		keyID:      hex.EncodeToString(fingerprint[:8]),
+	}, nil
+}
+
+// Sign builds the signed payload for a token
+func (s *QRSigner) Sign(token *PaymentToken) (string, error) {
+	nonce := make([]byte, 8)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	var kind string
+	if token.Type == "bill" {
+		kind = "bill"
+	}
+
+	claims, err := json.Marshal(QRClaims{
+		Version:  QRPayloadVersion,
+		KeyID:    s.keyID,
+		Token:    token.Token,
+		WalletID: token.WalletID,
+		Amount:   token.Amount,
+		Merchant: token.Merchant,
+		Kind:     kind,
+		Expiry:   token.Expiry.Unix(),
+		Nonce:    hex.EncodeToString(nonce),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	signed := QRPayloadPrefix + base64.RawURLEncoding.EncodeToString(claims)
+	signature := ed25519.Sign(s.privateKey, []byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// Verify checks the signature and expiry of a signed payload
+func (s *QRSigner) Verify(payload string) (*QRClaims, error) {
+	if !strings.HasPrefix(payload, QRPayloadPrefix) {
+		return nil, ErrInvalidQRPayload
+	}
+	dot := strings.LastIndex(payload, ".")
+	if dot <= len(QRPayloadPrefix) {
+		return nil, ErrInvalidQRPayload
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(payload[dot+1:])
+	if err != nil {
+		return nil, ErrInvalidQRPayload
+	}
+	if !ed25519.Verify(s.publicKey, []byte(payload[:dot]), signature) {
+		return nil, ErrQRSignature
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(payload[len(QRPayloadPrefix):dot])
+	if err != nil {
+		return nil, ErrInvalidQRPayload
+	}
+	var claims QRClaims
+	if err := json.Unmarshal(raw, &claims); err != nil || claims.Version != QRPayloadVersion {
+		return nil, ErrInvalidQRPayload
+	}
+	if time.Now().Unix() > claims.Expiry {
+		return nil, ErrQRExpired
+	}
+	return &claims, nil
+}
+
+// JWK is a public key in JSON Web Key form (RFC 8037 for Ed25519)
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+}
+
+// QRVerificationKeys is published so merchant apps can verify payloads offline
+type QRVerificationKeys struct {
+	PayloadVersion int    `json:"payload_version"`
+	PayloadPrefix  string `json:"payload_prefix"`
+	Keys           []JWK  `json:"keys"`
+}
+
+// VerificationKeys returns the public keys merchants need to verify payloads
+func (s *QRSigner) VerificationKeys() *QRVerificationKeys {
+	return &QRVerificationKeys{
+		PayloadVersion: QRPayloadVersion,
+		PayloadPrefix:  QRPayloadPrefix,
+		Keys: []JWK{{
+			KeyType:   "OKP",
+			Curve:     "Ed25519",
+			X:         base64.RawURLEncoding.EncodeToString(s.publicKey),
+			KeyID:     s.keyID,
+			Use:       "sig",
+			Algorithm: "EdDSA",
+		}},
+	}
+}
+
+// SetQRSigner enables signed QR payloads
+func (s *WalletService) SetQRSigner(signer *QRSigner) {
+	s.signer = signer
+}
+
+// GetQRVerificationKeys returns the published verification keys
+func (s *WalletService) GetQRVerificationKeys() (*QRVerificationKeys, error) {
+	if s.signer == nil {
+		return nil, errors.New("signed QR payloads are not enabled")
+	}
+	return s.signer.VerificationKeys(), nil
+}
+
+// ResolveTokenCode accepts what a scanner read (a signed WPT1 payload, a
+// legacy WPT:<token>:<amount>:<merchant> string or a bare token code) and
+// returns the token code. Signed payloads must verify.
+func (s *WalletService) ResolveTokenCode(scanned string) (string, error) {
+	scanned = strings.TrimSpace(scanned)
+
+	switch {
+	case strings.HasPrefix(scanned, QRPayloadPrefix):
+		if s.signer == nil {
+			return "", errors.New("signed QR payloads are not enabled")
+		}
+		claims, err := s.signer.Verify(scanned)
+		if err != nil {
+			return "", err
+		}
+		return claims.Token, nil
+
+	case strings.HasPrefix(scanned, legacyQRPrefix):
+		// Legacy unsigned format, accepted while old QR codes are still around
+		parts := strings.Split(scanned, ":")
+		if len(parts) < 2 || !tokenCodePattern.MatchString(parts[1]) {
+			return "", ErrInvalidQRPayload
+		}
+		return parts[1], nil
+	}
+
+	return scanned, nil
+}
@@ -0,0 +1,148 @@
+package wallet
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestSigner(t *testing.T, secret string) *QRSigner {
+	t.Helper()
+	signer, err := NewQRSigner("", secret)
+	if err != nil {
+		t.Fatalf("NewQRSigner() error = %v", err)
+	}
+	return signer
+}
+
+// signClaims signs arbitrary claims the way Sign does
+func signClaims(t *testing.T, s *QRSigner, claims any) string {
+	t.Helper()
+	raw, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed := QRPayloadPrefix + base64.RawURLEncoding.EncodeToString(raw)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(ed25519.Sign(s.privateKey, []byte(signed)))
+}
+
+func TestQRSignerVerify(t *testing.T) {
+	signer := newTestSigner(t, "test-secret")
+	other := newTestSigner(t, "other-secret")
+
+	token := &PaymentToken{
+		Token:    "0123456789abcdef0123456789abcdef",
+		WalletID: 7,
+		Amount:   2500,
+		Merchant: "Kantin",
+		Type:     "bill",
+		Expiry:   time.Now().Add(5 * time.Minute),
+	}
+	payload, err := signer.Sign(token)
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	expired, err := signer.Sign(&PaymentToken{Token: token.Token, Amount: 10, Expiry: time.Now().Add(-time.Minute)})
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	foreign, err := other.Sign(token)
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+
+	dot := strings.LastIndex(payload, ".")
+	body, signature := payload[:dot], payload[dot+1:]
+
+	// Same signature over claims with a raised amount
+	var claims QRClaims
+	raw, _ := base64.RawURLEncoding.DecodeString(body[len(QRPayloadPrefix):])
+	if err := json.Unmarshal(raw, &claims); err != nil {
+		t.Fatal(err)
+	}
+	claims.Amount = 1
+	raised, _ := json.Marshal(claims)
+	tamperedClaims := QRPayloadPrefix + base64.RawURLEncoding.EncodeToString(raised) + "." + signature
+
+	sigBytes, _ := base64.RawURLEncoding.DecodeString(signature)
+	sigBytes[0] ^= 0x01
+	flippedSignature := body + "." + base64.RawURLEncoding.EncodeToString(sigBytes)
+
+	futureVersion := claims
+	futureVersion.Version = QRPayloadVersion + 1
+	futureVersion.Amount = token.Amount
+
+	tests := []struct {
+		name    string
+		payload string
+		wantErr error
+	}{
+		{"valid", payload, nil},
+		{"tampered claims", tamperedClaims, ErrQRSignature},
+		{"flipped signature bit", flippedSignature, ErrQRSignature},
+		{"truncated signature", body + "." + signature[:len(signature)-4], ErrQRSignature},
+		{"empty signature", body + ".", ErrQRSignature},
+		{"signed by another key", foreign, ErrQRSignature},
+		{"signature not base64", body + ".!!!", ErrInvalidQRPayload},
+		{"unsigned", body, ErrInvalidQRPayload},
+		{"wrong prefix", "WPT2." + payload[len(QRPayloadPrefix):], ErrInvalidQRPayload},
+		{"legacy payload", "WPT:" + token.Token + ":2500:Kantin", ErrInvalidQRPayload},
+		{"unknown version", signClaims(t, signer, futureVersion), ErrInvalidQRPayload},
+		{"signed garbage", signClaims(t, signer, "not claims"), ErrInvalidQRPayload},
+		{"expired", expired, ErrQRExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := signer.Verify(tt.payload)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if got.Token != token.Token || got.WalletID != token.WalletID || got.Amount != token.Amount ||
+				got.Merchant != token.Merchant || got.Kind != "bill" || got.KeyID != signer.keyID {
+				t.Errorf("Verify() claims = %+v, want those of %+v", got, token)
+			}
+		})
+	}
+}
+
+func TestNewQRSigner(t *testing.T) {
+	seed := base64.StdEncoding.EncodeToString(make([]byte, ed25519.SeedSize))
+
+	tests := []struct {
+		name    string
+		seed    string
+		wantErr bool
+	}{
+		{"seed", seed, false},
+		{"derived", "", false},
+		{"short seed", base64.StdEncoding.EncodeToString(make([]byte, 16)), true},
+		{"seed not base64", "not base64!", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewQRSigner(tt.seed, "secret")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewQRSigner() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Replicas deriving from the same secret must verify each other's payloads
+	a, b := newTestSigner(t, "shared"), newTestSigner(t, "shared")
+	payload, err := a.Sign(&PaymentToken{Token: "0123456789abcdef0123456789abcdef", Expiry: time.Now().Add(time.Minute)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Verify(payload); err != nil {
+		t.Errorf("payload of a replica failed to verify: %v", err)
+	}
+}
@@ -2,8 +2,12 @@ package wallet
 
 import (
 	"errors"
+	"fmt"
+	"sort"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type WalletRepository struct {
@@ -44,7 +48,7 @@ func (r *WalletRepository) FindByUserID(userID uint) (*Wallet, error) {
 func (r *WalletRepository) GetAllWithUsers() ([]WalletWithUser, error) {
 	var wallets []WalletWithUser
 	err := r.db.Table("wallets").
-		Select("wallets.id as wallet_id, users.id as user_id, users.email, users.full_name, users.nim_nip, users.role, wallets.balance, wallets.last_sync_at").
+		Select("wallets.id as wallet_id, users.id as user_id, users.email, users.full_name, users.nim_nip, users.role, wallets.balance, wallets.status, wallets.last_sync_at").
 		Joins("INNER JOIN users ON wallets.user_id = users.id").
 		Order("wallets.balance DESC").
 		Scan(&wallets).Error
@@ -70,6 +74,39 @@ func (r *WalletRepository) UpdateBalance(tx *gorm.DB, walletID uint, delta int)
 		Error
 }
 
+// LockWallets loads wallets with SELECT ... FOR UPDATE so their balances cannot
+// change until the transaction ends. Rows are locked in ascending ID order so
+// concurrent journals touching the same wallets cannot deadlock each other.
+func (r *WalletRepository) LockWallets(tx *gorm.DB, walletIDs ...uint) (map[uint]*Wallet, error) {
+	ids := make([]uint, 0, len(walletIDs))
+	seen := make(map[uint]bool)
+	for _, id := range walletIDs {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	var wallets []Wallet
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ?", ids).
+		Order("id ASC").
+		Find(&wallets).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(wallets) != len(ids) {
+		return nil, errors.New("wallet not found")
+	}
+
+	locked := make(map[uint]*Wallet, len(wallets))
+	for i := range wallets {
+		locked[wallets[i].ID] = &wallets[i]
+	}
+	return locked, nil
+}
+
 // SetBalance sets wallet balance to specific value (for reset)
 func (r *WalletRepository) SetBalance(tx *gorm.DB, walletID uint, newBalance int) error {
 	if tx == nil {
@@ -101,6 +138,9 @@ func (r *WalletRepository) GetTransactions(params TransactionListParams) ([]Tran
 	if params.Direction != "" {
 		query = query.Where("wallet_transactions.direction = ?", params.Direction)
 	}
+	if params.Asset != "" {
+		query = query.Where("wallet_transactions.asset = ?", params.Asset)
+	}
 	if params.FromDate != "" {
 		query = query.Where("wallet_transactions.created_at >= ?", params.FromDate)
 	}
@@ -147,3 +187,572 @@ func (r *WalletRepository) GetLeaderboard(limit int) ([]WalletWithUser, error) {
 		Scan(&results).Error
 	return results, err
 }
+
+// CreateJournal creates a ledger journal header
+func (r *WalletRepository) CreateJournal(tx *gorm.DB, journal *LedgerJournal) error {
+	if tx == nil {
+		tx = r.db
+	}
+	return tx.Create(journal).Error
+}
+
+// CreateLedgerEntry creates a single ledger leg
+func (r *WalletRepository) CreateLedgerEntry(tx *gorm.DB, entry *LedgerEntry) error {
+	if tx == nil {
+		tx = r.db
+	}
+	return tx.Create(entry).Error
+}
+
+// FindTransactionByID finds a wallet transaction by ID
+func (r *WalletRepository) FindTransactionByID(tx *gorm.DB, id uint) (*WalletTransaction, error) {
+	if tx == nil {
+		tx = r.db
+	}
+	var txn WalletTransaction
+	err := tx.First(&txn, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("transaction not found")
+		}
+		return nil, err
+	}
+	return &txn, nil
+}
+
+// LockJournal loads a journal with SELECT ... FOR UPDATE
+func (r *WalletRepository) LockJournal(tx *gorm.DB, journalID uint) (*LedgerJournal, error) {
+	var journal LedgerJournal
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&journal, journalID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("journal not found")
+		}
+		return nil, err
+	}
+	return &journal, nil
+}
+
+// UpdateJournal updates journal fields
+func (r *WalletRepository) UpdateJournal(tx *gorm.DB, journalID uint, updates map[string]interface{}) error {
+	if tx == nil {
+		tx = r.db
+	}
+	return tx.Model(&LedgerJournal{}).Where("id = ?", journalID).Updates(updates).Error
+}
+
+// GetJournalEntries gets all legs of a journal
+func (r *WalletRepository) GetJournalEntries(tx *gorm.DB, journalID uint) ([]LedgerEntry, error) {
+	if tx == nil {
+		tx = r.db
+	}
+	var entries []LedgerEntry
+	err := tx.Where("journal_id = ?", journalID).Order("id ASC").Find(&entries).Error
+	return entries, err
+}
+
+// GetJournalTransactions gets the wallet transactions posted by a journal
+func (r *WalletRepository) GetJournalTransactions(tx *gorm.DB, journalID uint) ([]WalletTransaction, error) {
+	if tx == nil {
+		tx = r.db
+	}
+	var txns []WalletTransaction
+	err := tx.Where("journal_id = ?", journalID).Order("id ASC").Find(&txns).Error
+	return txns, err
+}
+
+// UpdateJournalTransactionStatus sets the status of every wallet transaction in a journal
+func (r *WalletRepository) UpdateJournalTransactionStatus(tx *gorm.DB, journalID uint, status string) error {
+	if tx == nil {
+		tx = r.db
+	}
+	return tx.Model(&WalletTransaction{}).Where("journal_id = ?", journalID).Update("status", status).Error
+}
+
+// GetLedgerBalance sums the credit and debit legs posted to a wallet in the default asset
+func (r *WalletRepository) GetLedgerBalance(walletID uint) (int, int64, error) {
+	var result struct {
+		Balance int
+		Entries int64
+	}
+	err := r.db.Model(&LedgerEntry{}).
+		Select("COALESCE(SUM(CASE WHEN direction = 'credit' THEN amount ELSE -amount END), 0) as balance, COUNT(*) as entries").
+		Where("wallet_id = ? AND asset = ?", walletID, DefaultAsset).
+		Scan(&result).Error
+	return result.Balance, result.Entries, err
+}
+
+// GetLedgerAssetBalances sums a wallet's legs per non-default asset
+func (r *WalletRepository) GetLedgerAssetBalances(walletID uint) (map[string]int, error) {
+	var rows []struct {
+		Asset   string
+		Balance int
+	}
+	err := r.db.Model(&LedgerEntry{}).
+		Select("asset, COALESCE(SUM(CASE WHEN direction = 'credit' THEN amount ELSE -amount END), 0) as balance").
+		Where("wallet_id = ? AND asset <> ?", walletID, DefaultAsset).
+		Group("asset").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	balances := make(map[string]int, len(rows))
+	for _, row := range rows {
+		balances[row.Asset] = row.Balance
+	}
+	return balances, nil
+}
+
+// FindLastJournaledTransaction gets the latest wallet transaction posted through the ledger
+func (r *WalletRepository) FindLastJournaledTransaction(walletID uint) (*WalletTransaction, error) {
+	var txn WalletTransaction
+	err := r.db.Where("wallet_id = ? AND asset = ? AND journal_id IS NOT NULL", walletID, DefaultAsset).
+		Order("id DESC").
+		First(&txn).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &txn, nil
+}
+
+// FindUnbalancedJournals lists journals touching a wallet whose legs do not
+// net to zero in every asset
+func (r *WalletRepository) FindUnbalancedJournals(walletID uint) ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&LedgerEntry{}).
+		Select("DISTINCT journal_id").
+		Where("journal_id IN (?)", r.db.Model(&LedgerEntry{}).Select("journal_id").Where("wallet_id = ?", walletID)).
+		Group("journal_id, asset").
+		Having("SUM(CASE WHEN direction = 'credit' THEN amount ELSE -amount END) <> 0").
+		Pluck("journal_id", &ids).Error
+	return ids, err
+}
+
+// BackfillOpeningBalances posts an opening journal for wallets that hold
+// points but have no ledger entries yet, so reconciliation starts from zero drift
+func (r *WalletRepository) BackfillOpeningBalances() (int, error) {
+	var wallets []Wallet
+	err := r.db.Where("balance <> 0").
+		Where("NOT EXISTS (SELECT 1 FROM ledger_entries WHERE ledger_entries.wallet_id = wallets.id)").
+		Find(&wallets).Error
+	if err != nil {
+		return 0, err
+	}
+
+	for _, w := range wallets {
+		err := r.db.Transaction(func(tx *gorm.DB) error {
+			journal := &LedgerJournal{
+				Type:        "opening_balance",
+				Description: fmt.Sprintf("Opening balance for wallet %d", w.ID),
+				Status:      "posted",
+			}
+			if err := r.CreateJournal(tx, journal); err != nil {
+				return err
+			}
+
+			walletDirection, systemDirection := "credit", "debit"
+			amount := w.Balance
+			if amount < 0 {
+				walletDirection, systemDirection = "debit", "credit"
+				amount = -amount
+			}
+
+			walletID := w.ID
+			if err := r.CreateLedgerEntry(tx, &LedgerEntry{
+				JournalID: journal.ID,
+				Account:   WalletAccount(w.ID),
+				WalletID:  &walletID,
+				Direction: walletDirection,
+				Amount:    amount,
+			}); err != nil {
+				return err
+			}
+
+			return r.CreateLedgerEntry(tx, &LedgerEntry{
+				JournalID: journal.ID,
+				Account:   AccountOpening,
+				Direction: systemDirection,
+				Amount:    amount,
+			})
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(wallets), nil
+}
+
+// FindOpenLots returns a wallet's credit lots that still hold points, oldest first
+func (r *WalletRepository) FindOpenLots(tx *gorm.DB, walletID uint) ([]WalletTransaction, error) {
+	var lots []WalletTransaction
+	err := tx.Where("wallet_id = ? AND direction = ? AND lot_remaining > 0", walletID, "credit").
+		Order("id ASC").
+		Find(&lots).Error
+	return lots, err
+}
+
+// FindDueLots returns a wallet's open lots whose expiry date has passed
+func (r *WalletRepository) FindDueLots(tx *gorm.DB, walletID uint, now time.Time) ([]WalletTransaction, error) {
+	var lots []WalletTransaction
+	err := tx.Where("wallet_id = ? AND lot_remaining > 0 AND expires_at IS NOT NULL AND expires_at <= ?", walletID, now).
+		Order("id ASC").
+		Find(&lots).Error
+	return lots, err
+}
+
+// SetLotRemaining stores how many points of a lot are left unspent
+func (r *WalletRepository) SetLotRemaining(tx *gorm.DB, lotID uint, remaining int) error {
+	return tx.Model(&WalletTransaction{}).Where("id = ?", lotID).Update("lot_remaining", remaining).Error
+}
+
+// FindWalletsWithDueLots lists wallets holding lots that should have expired by now
+func (r *WalletRepository) FindWalletsWithDueLots(now time.Time) ([]uint, error) {
+	var walletIDs []uint
+	err := r.db.Model(&WalletTransaction{}).
+		Where("lot_remaining > 0 AND expires_at IS NOT NULL AND expires_at <= ?", now).
+		Distinct().
+		Pluck("wallet_id", &walletIDs).Error
+	return walletIDs, err
+}
+
+// GetExpiringLots returns open lots expiring between now and until, soonest first
+func (r *WalletRepository) GetExpiringLots(walletID uint, now, until time.Time) ([]WalletTransaction, error) {
+	var lots []WalletTransaction
+	err := r.db.Where("wallet_id = ? AND lot_remaining > 0 AND expires_at > ? AND expires_at <= ?", walletID, now, until).
+		Order("expires_at ASC, id ASC").
+		Find(&lots).Error
+	return lots, err
+}
+
+// ExpireStaleTokens marks active payment tokens past their expiry as expired,
+// releases the holds they reserved and returns the expired tokens
+func (r *WalletRepository) ExpireStaleTokens(now time.Time) ([]PaymentToken, error) {
+	var expired []PaymentToken
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var stale []PaymentToken
+		err := tx.Select("id", "token", "type", "amount", "merchant", "hold_id").
+			Where("status = ? AND expiry < ?", "active", now).
+			Find(&stale).Error
+		if err != nil || len(stale) == 0 {
+			return err
+		}
+
+		ids := make([]uint, 0, len(stale))
+		var holdIDs []uint
+		for _, token := range stale {
+			ids = append(ids, token.ID)
+			if token.HoldID != nil {
+				holdIDs = append(holdIDs, *token.HoldID)
+			}
+		}
+
+		result := tx.Model(&PaymentToken{}).
+			Where("id IN ? AND status = ?", ids, "active").
+			Update("status", "expired")
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected < int64(len(stale)) {
+			// Some were consumed concurrently; only report what this run expired
+			if err := tx.Where("id IN ? AND status = ?", ids, "expired").
+				Select("id", "token", "type", "amount", "merchant", "hold_id").
+				Find(&stale).Error; err != nil {
+				return err
+			}
+		}
+		for i := range stale {
+			stale[i].Status = "expired"
+		}
+		expired = stale
+
+		if len(holdIDs) == 0 {
+			return nil
+		}
+		return tx.Model(&WalletTransaction{}).
+			Where("id IN ? AND status = ?", holdIDs, "pending").
+			Update("status", "released").Error
+	})
+	return expired, err
+}
+
+// UpdateWallet updates wallet columns
+func (r *WalletRepository) UpdateWallet(walletID uint, updates map[string]interface{}) error {
+	return r.db.Model(&Wallet{}).Where("id = ?", walletID).Updates(updates).Error
+}
+
+// GetWalletRole returns the role of the user owning a wallet
+func (r *WalletRepository) GetWalletRole(tx *gorm.DB, walletID uint) (string, error) {
+	var role string
+	err := tx.Table("wallets").
+		Select("users.role").
+		Joins("INNER JOIN users ON wallets.user_id = users.id").
+		Where("wallets.id = ?", walletID).
+		Scan(&role).Error
+	return role, err
+}
+
+// FindRoleLimit returns the default limits of a role, nil when none are set
+func (r *WalletRepository) FindRoleLimit(tx *gorm.DB, role string) (*RoleLimit, error) {
+	var limit RoleLimit
+	err := tx.Where("role = ?", role).First(&limit).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &limit, nil
+}
+
+// GetRoleLimits lists every role default
+func (r *WalletRepository) GetRoleLimits() ([]RoleLimit, error) {
+	var limits []RoleLimit
+	err := r.db.Order("role ASC").Find(&limits).Error
+	return limits, err
+}
+
+// SaveRoleLimit creates or replaces the default limits of a role
+func (r *WalletRepository) SaveRoleLimit(limit *RoleLimit) error {
+	return r.db.Save(limit).Error
+}
+
+// SumDebitsSince totals a wallet's successful and held debits of the default
+// asset since a point in time, leaving out the given transaction types
+func (r *WalletRepository) SumDebitsSince(tx *gorm.DB, walletID uint, since time.Time, excludeTypes []string) (int, error) {
+	var total int
+	err := tx.Model(&WalletTransaction{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("wallet_id = ? AND asset = ? AND direction = ? AND status IN ? AND created_at >= ?", walletID, DefaultAsset, "debit", []string{"success", "pending"}, since).
+		Where("type NOT IN ?", excludeTypes).
+		Scan(&total).Error
+	return total, err
+}
+
+// SumHolds totals the pending holds on a wallet in one asset
+func (r *WalletRepository) SumHolds(tx *gorm.DB, walletID uint, asset string) (int, error) {
+	if tx == nil {
+		tx = r.db
+	}
+	var total int
+	err := tx.Model(&WalletTransaction{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("wallet_id = ? AND asset = ? AND direction = ? AND status = ?", walletID, asset, "debit", "pending").
+		Scan(&total).Error
+	return total, err
+}
+
+// SumHoldsByAsset totals the pending holds on a wallet per asset
+func (r *WalletRepository) SumHoldsByAsset(walletID uint) (map[string]int, error) {
+	var rows []struct {
+		Asset string
+		Total int
+	}
+	err := r.db.Model(&WalletTransaction{}).
+		Select("asset, COALESCE(SUM(amount), 0) as total").
+		Where("wallet_id = ? AND direction = ? AND status = ?", walletID, "debit", "pending").
+		Group("asset").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	held := make(map[string]int, len(rows))
+	for _, row := range rows {
+		held[row.Asset] = row.Total
+	}
+	return held, nil
+}
+
+// FindPendingAmount returns the amount of a hold that is still pending, 0 otherwise
+func (r *WalletRepository) FindPendingAmount(tx *gorm.DB, id uint) (int, error) {
+	var txn WalletTransaction
+	err := tx.Where("id = ? AND status = ?", id, "pending").First(&txn).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return txn.Amount, nil
+}
+
+// CaptureHold marks a pending hold as captured once its journal posts the
+// debit as a new transaction
+func (r *WalletRepository) CaptureHold(tx *gorm.DB, id uint) error {
+	result := tx.Model(&WalletTransaction{}).
+		Where("id = ? AND status = ?", id, "pending").
+		Update("status", "captured")
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("payment hold is no longer pending")
+	}
+	return nil
+}
+
+// ReleaseHold frees a pending hold so its points become available again
+func (r *WalletRepository) ReleaseHold(tx *gorm.DB, id uint) error {
+	if tx == nil {
+		tx = r.db
+	}
+	return tx.Model(&WalletTransaction{}).
+		Where("id = ? AND status = ?", id, "pending").
+		Update("status", "released").Error
+}
+
+// SeedDefaultAsset creates the default asset that balances held before
+// wallets had several assets belong to
+func (r *WalletRepository) SeedDefaultAsset() (bool, error) {
+	var count int64
+	if err := r.db.Model(&PointAsset{}).Where("code = ?", DefaultAsset).Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return false, nil
+	}
+	return true, r.db.Create(&PointAsset{
+		Code:        DefaultAsset,
+		Name:        "Poin",
+		Description: "General wallet points",
+		MerchantIDs: []uint{},
+		Status:      "active",
+	}).Error
+}
+
+// GetAssets lists every point asset, the default asset first
+func (r *WalletRepository) GetAssets() ([]PointAsset, error) {
+	var assets []PointAsset
+	if err := r.db.Order("id ASC").Find(&assets).Error; err != nil {
+		return nil, err
+	}
+	sort.SliceStable(assets, func(i, j int) bool { return assets[i].Code == DefaultAsset && assets[j].Code != DefaultAsset })
+	return assets, nil
+}
+
+// FindAsset finds a point asset by code
+func (r *WalletRepository) FindAsset(tx *gorm.DB, code string) (*PointAsset, error) {
+	if tx == nil {
+		tx = r.db
+	}
+	var asset PointAsset
+	if err := tx.Where("code = ?", code).First(&asset).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAssetNotFound
+		}
+		return nil, err
+	}
+	return &asset, nil
+}
+
+// FindAssetByID finds a point asset by ID
+func (r *WalletRepository) FindAssetByID(id uint) (*PointAsset, error) {
+	var asset PointAsset
+	if err := r.db.First(&asset, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAssetNotFound
+		}
+		return nil, err
+	}
+	return &asset, nil
+}
+
+// CreateAsset stores a new point asset
+func (r *WalletRepository) CreateAsset(asset *PointAsset) error {
+	return r.db.Create(asset).Error
+}
+
+// SaveAsset stores changes to a point asset
+func (r *WalletRepository) SaveAsset(asset *PointAsset) error {
+	return r.db.Save(asset).Error
+}
+
+// GetAssetBalances returns a wallet's non-default asset balances by asset code
+func (r *WalletRepository) GetAssetBalances(walletID uint) (map[string]int, error) {
+	var rows []AssetBalance
+	if err := r.db.Where("wallet_id = ?", walletID).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	balances := make(map[string]int, len(rows))
+	for _, row := range rows {
+		balances[row.Asset] = row.Balance
+	}
+	return balances, nil
+}
+
+// GetAssetBalance returns a wallet's balance of a non-default asset, 0 when it never held any
+func (r *WalletRepository) GetAssetBalance(tx *gorm.DB, walletID uint, asset string) (int, error) {
+	if tx == nil {
+		tx = r.db
+	}
+	var rows []AssetBalance
+	if err := tx.Where("wallet_id = ? AND asset = ?", walletID, asset).Limit(1).Find(&rows).Error; err != nil {
+		return 0, err
+	}
+	if len(rows) == 0 {
+		return 0, nil
+	}
+	return rows[0].Balance, nil
+}
+
+// AddAssetBalance changes a wallet's balance of a non-default asset,
+// creating the balance on its first credit
+func (r *WalletRepository) AddAssetBalance(tx *gorm.DB, walletID uint, asset string, delta int) error {
+	return tx.Clauses(clause.OnConflict{
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"balance":    gorm.Expr("balance + ?", delta),
+			"updated_at": time.Now(),
+		}),
+	}).Create(&AssetBalance{WalletID: walletID, Asset: asset, Balance: delta}).Error
+}
+
+// GetConversionRules lists conversion rules, optionally only the active ones
+func (r *WalletRepository) GetConversionRules(activeOnly bool) ([]ConversionRule, error) {
+	var rules []ConversionRule
+	query := r.db.Order("from_asset ASC, to_asset ASC")
+	if activeOnly {
+		query = query.Where("status = ?", "active")
+	}
+	err := query.Find(&rules).Error
+	return rules, err
+}
+
+// FindConversionRule finds the rule converting one asset into another
+func (r *WalletRepository) FindConversionRule(fromAsset, toAsset string) (*ConversionRule, error) {
+	var rule ConversionRule
+	if err := r.db.Where("from_asset = ? AND to_asset = ?", fromAsset, toAsset).First(&rule).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrConversionNotFound
+		}
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// FindConversionRuleByID finds a conversion rule by ID
+func (r *WalletRepository) FindConversionRuleByID(id uint) (*ConversionRule, error) {
+	var rule ConversionRule
+	if err := r.db.First(&rule, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("conversion rule not found")
+		}
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// CreateConversionRule stores a new conversion rule
+func (r *WalletRepository) CreateConversionRule(rule *ConversionRule) error {
+	return r.db.Create(rule).Error
+}
+
+// SaveConversionRule stores changes to a conversion rule
+func (r *WalletRepository) SaveConversionRule(rule *ConversionRule) error {
+	return r.db.Save(rule).Error
+}
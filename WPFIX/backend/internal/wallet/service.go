@@ -7,16 +7,24 @@ import (
 	"errors"
 	"fmt"
 	"log"
-	"math"
+	"net/http"
 	"time"
+	"wallet-point/internal/pubsub"
 
 	"github.com/skip2/go-qrcode"
 	"gorm.io/gorm"
 )
 
+// ErrInsufficientBalance is returned when a debit would take a wallet below zero
+var ErrInsufficientBalance = errors.New("insufficient balance")
+
 type WalletService struct {
-	repo *WalletRepository
-	db   *gorm.DB
+	repo      *WalletRepository
+	db        *gorm.DB
+	expiry    ExpiryPolicy
+	signer    *QRSigner
+	hub       pubsub.Hub
+	merchants MerchantDirectory
 }
 
 func NewWalletService(repo *WalletRepository, db *gorm.DB) *WalletService {
@@ -39,68 +47,59 @@ func (s *WalletService) GetWalletByID(walletID uint) (*Wallet, error) {
 // AdjustPoints adds or subtracts points from a wallet
 func (s *WalletService) AdjustPoints(req *AdjustmentRequest, adminID uint) error {
 	return s.db.Transaction(func(tx *gorm.DB) error {
-		delta := req.Amount
+		// Balance for debits is checked under the wallet lock in PostJournal
+		systemLeg := LedgerLeg{Account: AccountIssuance, Asset: req.Asset, Direction: "debit", Amount: req.Amount}
 		if req.Direction == "debit" {
-			delta = -req.Amount
+			systemLeg = LedgerLeg{Account: AccountRedemption, Asset: req.Asset, Direction: "credit", Amount: req.Amount}
 		}
 
-		// Check balance for debit
-		if req.Direction == "debit" {
-			wallet, err := s.repo.FindByID(req.WalletID)
-			if err != nil {
-				return err
-			}
-			if wallet.Balance < req.Amount {
-				return errors.New("insufficient balance")
-			}
-		}
-
-		err := s.repo.UpdateBalance(tx, req.WalletID, delta)
-		if err != nil {
-			return err
-		}
-
-		txn := &WalletTransaction{
-			WalletID:    req.WalletID,
-			Type:        "adjustment",
-			Amount:      req.Amount,
-			Direction:   req.Direction,
-			Status:      "success",
-			Description: req.Description,
-			CreatedBy:   "admin",
-		}
-
-		return s.repo.CreateTransaction(tx, txn)
+		_, _, err := s.PostJournal(tx, "adjustment", req.Description, []LedgerLeg{
+			{
+				WalletID:    req.WalletID,
+				Asset:       req.Asset,
+				Direction:   req.Direction,
+				Amount:      req.Amount,
+				Type:        "adjustment",
+				Description: req.Description,
+				CreatedBy:   "admin",
+			},
+			systemLeg,
+		})
+		return err
 	})
 }
 
 // ResetWallet resets a wallet to a specific balance
 func (s *WalletService) ResetWallet(req *ResetWalletRequest, adminID uint) error {
 	return s.db.Transaction(func(tx *gorm.DB) error {
-		wallet, err := s.repo.FindByID(req.WalletID)
+		locked, err := s.repo.LockWallets(tx, req.WalletID)
 		if err != nil {
 			return err
 		}
 
-		err = s.repo.SetBalance(tx, req.WalletID, req.NewBalance)
-		if err != nil {
-			return err
+		delta := req.NewBalance - locked[req.WalletID].Balance
+		if delta == 0 {
+			return nil
 		}
 
-		txn := &WalletTransaction{
+		// The reset is posted as the difference so the ledger keeps explaining the balance
+		walletLeg := LedgerLeg{
 			WalletID:    req.WalletID,
+			Direction:   "credit",
+			Amount:      delta,
 			Type:        "adjustment",
-			Amount:      int(math.Abs(float64(req.NewBalance - wallet.Balance))),
-			Direction:   "debit", // default direction, could be more specific
-			Status:      "success",
 			Description: "Reset Wallet: " + req.Reason,
 			CreatedBy:   "admin",
 		}
-		if req.NewBalance > wallet.Balance {
-			txn.Direction = "credit"
+		systemLeg := LedgerLeg{Account: AccountIssuance, Direction: "debit", Amount: delta}
+		if delta < 0 {
+			walletLeg.Direction = "debit"
+			walletLeg.Amount = -delta
+			systemLeg = LedgerLeg{Account: AccountRedemption, Direction: "credit", Amount: -delta}
 		}
 
-		return s.repo.CreateTransaction(tx, txn)
+		_, _, err = s.PostJournal(tx, "reset", walletLeg.Description, []LedgerLeg{walletLeg, systemLeg})
+		return err
 	})
 }
 
@@ -125,78 +124,199 @@ func (s *WalletService) GetAllWallets() ([]WalletWithUser, error) {
 	return s.repo.GetAllWithUsers()
 }
 
-// GeneratePaymentToken creates a secure token for QR payment
+// GeneratePaymentToken creates a secure token for QR payment.
+// Purchase tokens reserve the amount as a pending hold until they are
+// consumed or expire, so the points cannot be spent twice.
 func (s *WalletService) GeneratePaymentToken(req PaymentTokenRequest, userID uint, recipientID uint) (*PaymentToken, error) {
-	// 1. Validate wallet balance
+	// 1. Validate wallet state
 	wallet, err := s.repo.FindByUserID(userID)
 	if err != nil {
 		return nil, errors.New("wallet not found")
 	}
 
-	if wallet.Balance < req.Amount {
-		return nil, errors.New("insufficient points for this transaction")
+	if err := walletStateError(wallet); err != nil {
+		return nil, err
 	}
 
-	// 2. Generate secure random token
-	b := make([]byte, 16)
-	if _, err := rand.Read(b); err != nil {
+	asset, err := s.GetAsset(req.Asset)
+	if err != nil {
 		return nil, err
 	}
-	tokenCode := hex.EncodeToString(b)
 
-	// Generate QR Code Image
-	qrPayload := fmt.Sprintf("WPT:%s:%d:%s", tokenCode, req.Amount, req.Merchant)
-	png, err := qrcode.Encode(qrPayload, qrcode.Medium, 256)
+	// 2. Generate secure random token
+	tokenCode, err := newTokenCode()
 	if err != nil {
 		return nil, err
 	}
 
 	paymentToken := &PaymentToken{
-		Token:        tokenCode,
-		QRCodeBase64: base64.StdEncoding.EncodeToString(png),
-		Amount:       req.Amount,
-		Merchant:     req.Merchant,
-		Expiry:       time.Now().Add(10 * time.Minute),
-		WalletID:     wallet.ID,
-		RecipientID:  recipientID,
-		Type:         req.Type,
-		Status:       "active",
-	}
-
-	if err := s.db.Create(paymentToken).Error; err != nil {
+		Token:       tokenCode,
+		Amount:      req.Amount,
+		Merchant:    req.Merchant,
+		Expiry:      time.Now().Add(10 * time.Minute),
+		WalletID:    wallet.ID,
+		RecipientID: recipientID,
+		Type:        req.Type,
+		Asset:       asset.Code,
+		Status:      "active",
+	}
+
+	// Tokens for a registered merchant carry its ID and display name, so
+	// only that merchant's terminals can capture them
+	if req.MerchantID != 0 {
+		if s.merchants == nil {
+			return nil, errors.New("merchant profiles are not enabled")
+		}
+		account, err := s.merchants.GetMerchantAccount(req.MerchantID)
+		if err != nil {
+			return nil, err
+		}
+		paymentToken.MerchantID = &account.MerchantID
+		paymentToken.Merchant = account.Name
+	}
+
+	// Merchant-only assets are paid to one of their merchants
+	if len(asset.MerchantIDs) > 0 && (paymentToken.MerchantID == nil || !assetAllowsMerchant(asset, *paymentToken.MerchantID)) {
+		return nil, &WalletError{code: CodeAssetMerchantOnly, status: http.StatusForbidden, message: fmt.Sprintf("%s can only be spent at its merchants", asset.Name)}
+	}
+
+	// Generate QR Code Image
+	if err := s.encodeTokenQR(paymentToken); err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// 3. Reserve the amount under the wallet lock
+		if req.Type == "purchase" {
+			locked, err := s.repo.LockWallets(tx, wallet.ID)
+			if err != nil {
+				return err
+			}
+			hold, err := s.placeHold(tx, locked[wallet.ID], asset.Code, req.Amount, fmt.Sprintf("Hold for QR payment to %s", paymentToken.Merchant))
+			if err != nil {
+				return err
+			}
+			paymentToken.HoldID = &hold.ID
+		} else {
+			balance, err := s.assetBalance(tx, wallet, asset.Code)
+			if err != nil {
+				return err
+			}
+			if balance < req.Amount {
+				return errors.New("insufficient points for this transaction")
+			}
+		}
+
+		if err := tx.Create(paymentToken).Error; err != nil {
+			return err
+		}
+
+		// Link the hold back to its token
+		if paymentToken.HoldID != nil {
+			return tx.Model(&WalletTransaction{}).
+				Where("id = ?", *paymentToken.HoldID).
+				Update("reference_id", paymentToken.ID).Error
+		}
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	return paymentToken, nil
 }
 
-// ValidateAndConsumeToken verifies if a token is valid (legacy support for some modules)
-func (s *WalletService) ValidateAndConsumeToken(tokenCode string, userID uint, amount int) error {
+// newTokenCode returns a random 32 character hex token code
+func newTokenCode() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// encodeTokenQR fills the QR payload and image of a token. Signed payloads
+// can be verified offline; the legacy format is kept only when no signer is
+// configured.
+func (s *WalletService) encodeTokenQR(token *PaymentToken) error {
+	token.QRPayload = fmt.Sprintf("WPT:%s:%d:%s", token.Token, token.Amount, token.Merchant)
+	if s.signer != nil {
+		payload, err := s.signer.Sign(token)
+		if err != nil {
+			return err
+		}
+		token.QRPayload = payload
+	}
+
+	png, err := qrcode.Encode(token.QRPayload, qrcode.Medium, 256)
+	if err != nil {
+		return err
+	}
+	token.QRCodeBase64 = base64.StdEncoding.EncodeToString(png)
+	return nil
+}
+
+// ValidateAndConsumeToken checks a student's payment token against a
+// purchase of amount and marks it consumed in the purchase's tx. The caller
+// posts the payment in the same tx, capturing the token's hold if it has one.
+func (s *WalletService) ValidateAndConsumeToken(tx *gorm.DB, tokenCode string, userID uint, amount int, asset string) (*PaymentToken, error) {
 	var token PaymentToken
-	err := s.db.Where("token = ? AND status = ?", tokenCode, "active").First(&token).Error
+	err := tx.Where("token = ? AND status = ?", tokenCode, "active").First(&token).Error
 	if err != nil {
-		return errors.New("invalid or expired QR token")
+		return nil, errors.New("invalid or expired QR token")
 	}
 
 	if time.Now().After(token.Expiry) {
-		s.db.Model(&token).Update("status", "expired")
-		return errors.New("QR token has expired")
+		s.expireToken(&token)
+		return nil, errors.New("QR token has expired")
 	}
 
 	wallet, err := s.repo.FindByUserID(userID)
 	if err != nil || wallet.ID != token.WalletID {
-		return errors.New("token does not belong to this user")
+		return nil, errors.New("token does not belong to this user")
 	}
 
 	if token.Amount != amount {
-		return fmt.Errorf("token amount mismatch. Expected: %d, Found: %d", token.Amount, amount)
+		return nil, fmt.Errorf("token amount mismatch. Expected: %d, Found: %d", token.Amount, amount)
 	}
+	if asset == "" {
+		asset = DefaultAsset
+	}
+	if token.Asset != asset {
+		return nil, fmt.Errorf("token asset mismatch. Expected: %s, Found: %s", asset, token.Asset)
+	}
+
+	if err := s.consumeToken(tx, &token); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
 
-	return s.db.Model(&token).Update("status", "consumed").Error
+// PublishTokenStatus tells watchers of a token about its status once the
+// transaction that changed it has committed
+func (s *WalletService) PublishTokenStatus(token *PaymentToken) {
+	s.publishTokenStatus(token)
+}
+
+// consumeToken flips an active token to consumed, failing if it was already used
+func (s *WalletService) consumeToken(tx *gorm.DB, token *PaymentToken) error {
+	result := tx.Model(&PaymentToken{}).
+		Where("id = ? AND status = ?", token.ID, "active").
+		Update("status", "consumed")
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("QR token has already been used")
+	}
+	token.Status = "consumed"
+	return nil
 }
 
-// MerchantConsumeToken allows a merchant to scan and consume a student's payment token
-func (s *WalletService) MerchantConsumeToken(tokenCode string, merchantID uint) (*WalletTransaction, error) {
+// MerchantConsumeToken allows a merchant cashier to scan and consume a
+// student's payment token. The sale is credited to the merchant's shared
+// wallet and the token records the terminal and cashier that captured it.
+func (s *WalletService) MerchantConsumeToken(tokenCode string, cashierID uint, terminalID uint) (*WalletTransaction, error) {
 	var token PaymentToken
 	err := s.db.Where("token = ? AND status = ?", tokenCode, "active").First(&token).Error
 	if err != nil {
@@ -207,79 +327,98 @@ func (s *WalletService) MerchantConsumeToken(tokenCode string, merchantID uint)
 	}
 
 	if time.Now().After(token.Expiry) {
-		s.db.Model(&token).Update("status", "expired")
+		s.expireToken(&token)
 		return nil, errors.New("QR token has expired")
 	}
 
-	merchantWallet, err := s.repo.FindByUserID(merchantID)
+	// Bills are presented by the merchant and paid by the student
+	if token.Type == "bill" {
+		return nil, errors.New("this QR is a merchant bill and must be paid by the customer")
+	}
+
+	account, err := s.merchantAccountFor(cashierID, terminalID)
 	if err != nil {
-		return nil, errors.New("merchant wallet not found")
+		return nil, err
+	}
+	if token.MerchantID != nil && *token.MerchantID != account.MerchantID {
+		return nil, ErrWrongMerchant
 	}
 
 	err = s.db.Transaction(func(tx *gorm.DB) error {
-		// 1. Deduct from student
-		err := s.repo.UpdateBalance(tx, token.WalletID, -token.Amount)
+		// 1. Move points from student to merchant as one journal
+		description := fmt.Sprintf("QR Payment to %s", token.Merchant)
+		// Purchase tokens capture the hold placed when the token was generated
+		_, _, err := s.PostJournal(tx, "qr_payment", description, []LedgerLeg{
+			{
+				WalletID:      token.WalletID,
+				Asset:         token.Asset,
+				Direction:     "debit",
+				Amount:        token.Amount,
+				Type:          "marketplace",
+				Description:   description,
+				ReferenceID:   &token.ID,
+				TransactionID: token.HoldID,
+			},
+			{
+				WalletID:    account.WalletID,
+				Asset:       token.Asset,
+				Direction:   "credit",
+				Amount:      token.Amount,
+				Type:        "marketplace_sale",
+				Description: fmt.Sprintf("Sale via QR: %s", description),
+				ReferenceID: &token.ID,
+			},
+		})
 		if err != nil {
 			return err
 		}
 
-		// 2. Credit merchant
-		err = s.repo.UpdateBalance(tx, merchantWallet.ID, token.Amount)
-		if err != nil {
+		// 2. Update token status, unless another scan got there first
+		if err := s.consumeToken(tx, &token); err != nil {
 			return err
 		}
 
-		// 3. Create debit record for student
-		description := fmt.Sprintf("QR Payment to %s", token.Merchant)
-		studentTxn := &WalletTransaction{
-			WalletID:    token.WalletID,
-			Type:        "marketplace",
-			Amount:      token.Amount,
-			Direction:   "debit",
-			Status:      "success",
-			Description: description,
-		}
-
-		// 4. Create credit record for merchant
-		merchantTxn := &WalletTransaction{
-			WalletID:    merchantWallet.ID,
-			Type:        "marketplace_sale",
-			Amount:      token.Amount,
-			Direction:   "credit",
-			Status:      "success",
-			Description: fmt.Sprintf("Sale via QR: %s", description),
-		}
-
-		if err := tx.Create(studentTxn).Error; err != nil {
-			return err
-		}
-		if err := tx.Create(merchantTxn).Error; err != nil {
-			return err
-		}
+		// 3. Remember where the payment was captured
+		token.TerminalID = account.TerminalID
+		token.CashierID = &cashierID
+		return tx.Model(&PaymentToken{}).Where("id = ?", token.ID).Updates(map[string]interface{}{
+			"terminal_id": account.TerminalID,
+			"cashier_id":  cashierID,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
 
-		// 5. Update token status
-		if err := tx.Model(&token).Update("status", "consumed").Error; err != nil {
-			return err
-		}
+	s.publishTokenStatus(&token)
+	return nil, nil
+}
 
-		return nil
-	})
+// ExpireStaleTokens marks every active token past its expiry as expired
+// and tells anyone watching those tokens
+func (s *WalletService) ExpireStaleTokens() (int64, error) {
+	expired, err := s.repo.ExpireStaleTokens(time.Now())
+	if err != nil {
+		return 0, err
+	}
 
-	return nil, err
+	for i := range expired {
+		s.publishTokenStatus(&expired[i])
+	}
+	return int64(len(expired)), nil
 }
 
 // GetTokenDetails returns full token info regardless of status (active/consumed/expired)
 func (s *WalletService) GetTokenDetails(tokenCode string) (*PaymentToken, error) {
 	var token PaymentToken
-	err := s.db.Where("token = ?", tokenCode).First(&token).Error
+	err := s.db.Preload("Items").Where("token = ?", tokenCode).First(&token).Error
 	if err != nil {
 		return nil, errors.New("token tidak ditemukan")
 	}
 
 	// Dynamic check for expiry if still marked as active
 	if token.Status == "active" && time.Now().After(token.Expiry) {
-		token.Status = "expired"
-		s.db.Model(&token).Update("status", "expired")
+		s.expireToken(&token)
 	}
 
 	return &token, nil
@@ -293,18 +432,21 @@ func (s *WalletService) StudentPayToken(tokenCode string, scannerUserID uint) er
 	}
 
 	if time.Now().After(token.Expiry) {
+		s.expireToken(&token)
 		return errors.New("token kadaluarsa")
 	}
 
+	// Merchant bills pay the merchant; open amounts need the bill endpoint
+	if token.Type == "bill" {
+		_, err := s.PayBill(tokenCode, scannerUserID, PayBillRequest{})
+		return err
+	}
+
 	scannerWallet, err := s.repo.FindByUserID(scannerUserID)
 	if err != nil {
 		return errors.New("wallet pembayar tidak ditemukan")
 	}
 
-	if scannerWallet.Balance < token.Amount {
-		return errors.New("saldo tidak mencukupi")
-	}
-
 	// Recipient logic
 	var recipientID uint = token.RecipientID
 	if recipientID == 0 {
@@ -334,120 +476,565 @@ func (s *WalletService) StudentPayToken(tokenCode string, scannerUserID uint) er
 		recipientWallet = newWallet
 	}
 
-	return s.db.Transaction(func(tx *gorm.DB) error {
-		// 1. Deduct from scanner
-		if err := s.repo.UpdateBalance(tx, scannerWallet.ID, -token.Amount); err != nil {
-			return err
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// 1. Move points from scanner to recipient
+		// The creator paying their own token captures its hold
+		var captureID *uint
+		if token.HoldID != nil && token.WalletID == scannerWallet.ID {
+			captureID = token.HoldID
 		}
 
-		// 2. Credit to recipient
-		if err := s.repo.UpdateBalance(tx, recipientWallet.ID, token.Amount); err != nil {
+		desc := fmt.Sprintf("Bayar Mandiri: %s", token.Merchant)
+		_, _, err := s.PostJournal(tx, "qr_payment", desc, []LedgerLeg{
+			{
+				WalletID:      scannerWallet.ID,
+				Asset:         token.Asset,
+				Direction:     "debit",
+				Amount:        token.Amount,
+				Type:          "marketplace",
+				Description:   desc,
+				TransactionID: captureID,
+			},
+			{
+				WalletID:    recipientWallet.ID,
+				Asset:       token.Asset,
+				Direction:   "credit",
+				Amount:      token.Amount,
+				Type:        "marketplace_sale",
+				Description: fmt.Sprintf("Terima Bayar Mandiri dari User ID %d: %s", scannerUserID, token.Merchant),
+			},
+		})
+		if errors.Is(err, ErrInsufficientBalance) {
+			return errors.New("saldo tidak mencukupi")
+		}
+		if err != nil {
 			return err
 		}
 
-		// 3. Mark token as consumed
-		if err := tx.Model(&token).Update("status", "consumed").Error; err != nil {
-			return err
+		// 2. Someone else paid, so the creator's hold is no longer needed
+		if token.HoldID != nil && captureID == nil {
+			if err := s.repo.ReleaseHold(tx, *token.HoldID); err != nil {
+				return err
+			}
 		}
 
-		// 4. Create Transactions
-		desc := fmt.Sprintf("Bayar Mandiri: %s", token.Merchant)
-		tx.Create(&WalletTransaction{
-			WalletID:    scannerWallet.ID,
-			Type:        "marketplace",
-			Amount:      token.Amount,
+		// 3. Mark token as consumed, unless another request got there first
+		return s.consumeToken(tx, &token)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.publishTokenStatus(&token)
+	return nil
+}
+
+// DebitWithTransaction handles point deduction within an existing transaction
+func (s *WalletService) DebitWithTransaction(tx *gorm.DB, walletID uint, amount int, txnType string, description string) error {
+	// Post debit against the redemption account; the balance is checked under lock
+	_, _, err := s.PostJournal(tx, txnType, description, []LedgerLeg{
+		{
+			WalletID:    walletID,
 			Direction:   "debit",
-			Status:      "success",
-			Description: desc,
-		})
+			Amount:      amount,
+			Type:        txnType,
+			Description: description,
+		},
+		{Account: AccountRedemption, Direction: "credit", Amount: amount},
+	})
+	return err
+}
 
-		tx.Create(&WalletTransaction{
-			WalletID:    recipientWallet.ID,
-			Type:        "marketplace_sale",
-			Amount:      token.Amount,
+// CreditWithTransaction handles point addition within an existing transaction
+func (s *WalletService) CreditWithTransaction(tx *gorm.DB, walletID uint, amount int, txnType string, description string) error {
+	_, _, err := s.PostJournal(tx, txnType, description, []LedgerLeg{
+		{
+			WalletID:    walletID,
 			Direction:   "credit",
-			Status:      "success",
-			Description: fmt.Sprintf("Terima Bayar Mandiri dari User ID %d: %s", scannerUserID, token.Merchant),
-		})
-
-		return nil
+			Amount:      amount,
+			Type:        txnType,
+			Description: description,
+		},
+		{Account: AccountIssuance, Direction: "debit", Amount: amount},
 	})
+	return err
 }
 
-// DebitWithTransaction handles point deduction within an existing transaction
-func (s *WalletService) DebitWithTransaction(tx *gorm.DB, walletID uint, amount int, txnType string, description string) error {
-	// 1. Check balance
-	wallet, err := s.repo.FindByID(walletID)
+// ProcessMissionRewardWithTx handles mission rewards within a transaction,
+// paid in the mission's asset
+func (s *WalletService) ProcessMissionRewardWithTx(tx *gorm.DB, userID uint, asset string, amount int, missionTitle string, missionID uint, reviewerID uint) error {
+	wallet, err := s.repo.FindByUserID(userID)
 	if err != nil {
 		return err
 	}
-	if wallet.Balance < amount {
-		return errors.New("insufficient balance")
-	}
 
-	// 2. Update balance
-	if err := s.repo.UpdateBalance(tx, walletID, -amount); err != nil {
-		return err
+	description := "Reward for mission: " + missionTitle
+	_, _, err = s.PostJournal(tx, "mission_reward", description, []LedgerLeg{
+		{
+			WalletID:    wallet.ID,
+			Asset:       asset,
+			Direction:   "credit",
+			Amount:      amount,
+			Type:        "mission",
+			Description: description,
+			ReferenceID: &missionID,
+			CreatedBy:   "dosen",
+		},
+		{Account: AccountIssuance, Asset: asset, Direction: "debit", Amount: amount},
+	})
+	return err
+}
+
+// ReclaimMissionRewardWithTx takes back up to amount of a mission reward
+// after its grade was lowered. Points the student already spent, or that
+// payment holds reserve, stay with the student. It returns what was taken.
+func (s *WalletService) ReclaimMissionRewardWithTx(tx *gorm.DB, userID uint, asset string, amount int, missionTitle string, missionID uint) (int, error) {
+	wallet, err := s.repo.FindByUserID(userID)
+	if err != nil {
+		return 0, err
+	}
+	locked, err := s.repo.LockWallets(tx, wallet.ID)
+	if err != nil {
+		return 0, err
 	}
 
-	// 3. Create transaction record
-	txn := &WalletTransaction{
-		WalletID:    walletID,
-		Type:        txnType,
-		Amount:      amount,
+	leg := LedgerLeg{
+		WalletID:    wallet.ID,
+		Asset:       asset,
 		Direction:   "debit",
-		Status:      "success",
-		Description: description,
+		Type:        "adjustment",
+		Description: "Reward correction for mission: " + missionTitle,
+		ReferenceID: &missionID,
+		CreatedBy:   "dosen",
+	}
+
+	// Expired lots are not there to take back
+	balance := locked[wallet.ID].Balance
+	if leg.asset() == DefaultAsset {
+		balances := map[uint]int{wallet.ID: balance}
+		if _, err := s.expireDueLots(tx, wallet.ID, balances, time.Now()); err != nil {
+			return 0, err
+		}
+		balance = balances[wallet.ID]
+	} else if balance, err = s.repo.GetAssetBalance(tx, wallet.ID, leg.asset()); err != nil {
+		return 0, err
+	}
+	held, err := s.repo.SumHolds(tx, wallet.ID, leg.asset())
+	if err != nil {
+		return 0, err
 	}
 
-	return s.repo.CreateTransaction(tx, txn)
+	leg.Amount = min(amount, max(balance-held, 0))
+	if leg.Amount == 0 {
+		return 0, nil
+	}
+	_, _, err = s.PostJournal(tx, "mission_reward_reclaim", leg.Description, []LedgerLeg{
+		leg,
+		{Account: AccountIssuance, Asset: asset, Direction: "credit", Amount: leg.Amount},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return leg.Amount, nil
 }
 
-// CreditWithTransaction handles point addition within an existing transaction
-func (s *WalletService) CreditWithTransaction(tx *gorm.DB, walletID uint, amount int, txnType string, description string) error {
-	// 1. Update balance
-	if err := s.repo.UpdateBalance(tx, walletID, amount); err != nil {
+// ProcessPeerReviewRewardWithTx pays a student for completing a peer review
+// of a mission submission, in the mission's asset
+func (s *WalletService) ProcessPeerReviewRewardWithTx(tx *gorm.DB, userID uint, asset string, amount int, missionTitle string, missionID uint) error {
+	wallet, err := s.repo.FindByUserID(userID)
+	if err != nil {
 		return err
 	}
 
-	// 2. Create transaction record
-	txn := &WalletTransaction{
-		WalletID:    walletID,
-		Type:        txnType,
-		Amount:      amount,
-		Direction:   "credit",
-		Status:      "success",
+	description := "Reward for peer review: " + missionTitle
+	_, _, err = s.PostJournal(tx, "peer_review_reward", description, []LedgerLeg{
+		{
+			WalletID:    wallet.ID,
+			Asset:       asset,
+			Direction:   "credit",
+			Amount:      amount,
+			Type:        "mission",
+			Description: description,
+			ReferenceID: &missionID,
+			CreatedBy:   "system",
+		},
+		{Account: AccountIssuance, Asset: asset, Direction: "debit", Amount: amount},
+	})
+	return err
+}
+
+// PostJournal records a balanced set of ledger legs and applies every wallet
+// leg to its balance, storing the resulting balance on the wallet transaction.
+// Pass the caller's transaction so the journal commits with the business change.
+func (s *WalletService) PostJournal(tx *gorm.DB, journalType string, description string, legs []LedgerLeg) (*LedgerJournal, []WalletTransaction, error) {
+	if tx == nil {
+		var journal *LedgerJournal
+		var txns []WalletTransaction
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			journal, txns, err = s.PostJournal(tx, journalType, description, legs)
+			return err
+		})
+		return journal, txns, err
+	}
+
+	if err := validateLegs(legs); err != nil {
+		return nil, nil, err
+	}
+
+	// Lock every wallet in the journal before checking funds, so the balance
+	// check and the decrement are atomic against concurrent debits
+	var walletIDs []uint
+	for _, leg := range legs {
+		if leg.WalletID != 0 {
+			walletIDs = append(walletIDs, leg.WalletID)
+		}
+	}
+	locked, err := s.repo.LockWallets(tx, walletIDs...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	balances := make(map[uint]int, len(locked))
+	for id, w := range locked {
+		balances[id] = w.Balance
+	}
+
+	// Frozen/closed wallets and spending limits are checked under the same lock
+	if err := s.checkWalletRules(tx, journalType, legs, locked); err != nil {
+		return nil, nil, err
+	}
+	if err := s.checkAssets(tx, journalType, legs); err != nil {
+		return nil, nil, err
+	}
+
+	// Lots past their expiry date cannot be spent: expire them before debiting
+	if journalType != "expiry" {
+		for _, leg := range legs {
+			if leg.WalletID != 0 && leg.Direction == "debit" && leg.asset() == DefaultAsset {
+				if _, err := s.expireDueLots(tx, leg.WalletID, balances, time.Now()); err != nil {
+					return nil, nil, err
+				}
+			}
+		}
+	}
+
+	// Points reserved by payment holds cannot be spent elsewhere
+	if !adminJournalTypes[journalType] {
+		if err := s.checkAvailable(tx, legs, balances); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return s.postLocked(tx, journalType, description, legs, balances)
+}
+
+// postLocked writes a journal for wallets already locked by the caller,
+// keeping balances (wallet ID -> current default asset balance) up to date.
+// Legs in other assets move the wallet's balance of that asset instead.
+func (s *WalletService) postLocked(tx *gorm.DB, journalType string, description string, legs []LedgerLeg, balances map[uint]int) (*LedgerJournal, []WalletTransaction, error) {
+	journal := &LedgerJournal{
+		Type:        journalType,
 		Description: description,
+		Status:      "posted",
+	}
+	if err := s.repo.CreateJournal(tx, journal); err != nil {
+		return nil, nil, err
+	}
+
+	var txns []WalletTransaction
+	for _, leg := range legs {
+		entry := &LedgerEntry{
+			JournalID: journal.ID,
+			Account:   leg.Account,
+			Asset:     leg.asset(),
+			Direction: leg.Direction,
+			Amount:    leg.Amount,
+		}
+
+		if leg.WalletID != 0 {
+			walletID := leg.WalletID
+			entry.Account = WalletAccount(walletID)
+			entry.WalletID = &walletID
+
+			delta := leg.Amount
+			if leg.Direction == "debit" {
+				delta = -leg.Amount
+			}
+
+			var balance int
+			if leg.asset() == DefaultAsset {
+				balance = balances[walletID] + delta
+				if balance < 0 {
+					return nil, nil, ErrInsufficientBalance
+				}
+				balances[walletID] = balance
+
+				if err := s.repo.UpdateBalance(tx, walletID, delta); err != nil {
+					return nil, nil, err
+				}
+			} else {
+				current, err := s.repo.GetAssetBalance(tx, walletID, leg.asset())
+				if err != nil {
+					return nil, nil, err
+				}
+				balance = current + delta
+				if balance < 0 {
+					return nil, nil, ErrInsufficientBalance
+				}
+				if err := s.repo.AddAssetBalance(tx, walletID, leg.asset(), delta); err != nil {
+					return nil, nil, err
+				}
+			}
+
+			createdBy := leg.CreatedBy
+			if createdBy == "" {
+				createdBy = "system"
+			}
+
+			txn := WalletTransaction{
+				WalletID:     walletID,
+				Asset:        leg.asset(),
+				Type:         leg.Type,
+				Amount:       leg.Amount,
+				Direction:    leg.Direction,
+				ReferenceID:  leg.ReferenceID,
+				JournalID:    &journal.ID,
+				BalanceAfter: &balance,
+				Status:       "success",
+				Description:  leg.Description,
+				CreatedBy:    createdBy,
+			}
+
+			// Every default asset credit opens a lot; debits spend balance from before
+			// lots, then the oldest lots
+			if leg.asset() != DefaultAsset {
+				// Other assets do not expire
+			} else if leg.Direction == "credit" {
+				txn.LotRemaining = leg.Amount
+				txn.ExpiresAt = s.expiry.ExpiresAt(leg.Type, time.Now())
+			} else if err := s.consumeLots(tx, walletID, leg, balance+leg.Amount); err != nil {
+				return nil, nil, err
+			}
+
+			// Capturing a hold posts the debit as a new transaction, so it
+			// takes its place in the wallet's order, and closes the hold
+			if leg.TransactionID != nil {
+				if err := s.repo.CaptureHold(tx, *leg.TransactionID); err != nil {
+					return nil, nil, err
+				}
+			}
+			if err := s.repo.CreateTransaction(tx, &txn); err != nil {
+				return nil, nil, err
+			}
+			txns = append(txns, txn)
+		}
+
+		if err := s.repo.CreateLedgerEntry(tx, entry); err != nil {
+			return nil, nil, err
+		}
 	}
 
-	return s.repo.CreateTransaction(tx, txn)
+	return journal, txns, nil
 }
 
-// ProcessMissionRewardWithTx handles mission rewards within a transaction
-func (s *WalletService) ProcessMissionRewardWithTx(tx *gorm.DB, userID uint, amount int, missionTitle string, missionID uint, reviewerID uint) error {
-	wallet, err := s.repo.FindByUserID(userID)
+// validateLegs ensures a journal has positive legs whose debits equal its
+// credits in every asset
+func validateLegs(legs []LedgerLeg) error {
+	if len(legs) < 2 {
+		return errors.New("journal needs at least one debit and one credit leg")
+	}
+
+	net := make(map[string]int)
+	var debits, credits int
+	for _, leg := range legs {
+		if leg.Amount <= 0 {
+			return errors.New("ledger leg amount must be positive")
+		}
+		if leg.WalletID == 0 && leg.Account == "" {
+			return errors.New("ledger leg has no account")
+		}
+		switch leg.Direction {
+		case "debit":
+			debits += leg.Amount
+			net[leg.asset()] -= leg.Amount
+		case "credit":
+			credits += leg.Amount
+			net[leg.asset()] += leg.Amount
+		default:
+			return fmt.Errorf("invalid ledger direction: %s", leg.Direction)
+		}
+	}
+
+	if debits != credits {
+		return fmt.Errorf("unbalanced journal: debits %d, credits %d", debits, credits)
+	}
+	for asset, amount := range net {
+		if amount != 0 {
+			return fmt.Errorf("unbalanced journal: %s is off by %d", asset, amount)
+		}
+	}
+	return nil
+}
+
+// ReconcileWallet recomputes a wallet balance from the ledger and reports any drift
+func (s *WalletService) ReconcileWallet(walletID uint) (*ReconciliationReport, error) {
+	wallet, err := s.repo.FindByID(walletID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// Create transaction record
-	txn := &WalletTransaction{
-		WalletID:    wallet.ID,
-		Type:        "mission",
-		Amount:      amount,
-		Direction:   "credit",
-		Status:      "success",
-		Description: "Reward for mission: " + missionTitle,
-		ReferenceID: &missionID,
-		CreatedBy:   "dosen",
+	ledgerBalance, entries, err := s.repo.GetLedgerBalance(walletID)
+	if err != nil {
+		return nil, err
 	}
 
-	// Update balance
-	if err := s.repo.UpdateBalance(tx, wallet.ID, amount); err != nil {
-		return err
+	unbalanced, err := s.repo.FindUnbalancedJournals(walletID)
+	if err != nil {
+		return nil, err
+	}
+
+	last, err := s.repo.FindLastJournaledTransaction(walletID)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := s.repo.GetAssetBalances(walletID)
+	if err != nil {
+		return nil, err
+	}
+	ledgerAssets, err := s.repo.GetLedgerAssetBalances(walletID)
+	if err != nil {
+		return nil, err
+	}
+	assetDrift := make(map[string]int)
+	for asset, balance := range stored {
+		if drift := balance - ledgerAssets[asset]; drift != 0 {
+			assetDrift[asset] = drift
+		}
+	}
+	for asset, balance := range ledgerAssets {
+		if _, ok := stored[asset]; !ok && balance != 0 {
+			assetDrift[asset] = -balance
+		}
+	}
+
+	report := &ReconciliationReport{
+		WalletID:           wallet.ID,
+		StoredBalance:      wallet.Balance,
+		LedgerBalance:      ledgerBalance,
+		Drift:              wallet.Balance - ledgerBalance,
+		LedgerEntries:      entries,
+		UnbalancedJournals: unbalanced,
+		AssetDrift:         assetDrift,
+		CheckedAt:          time.Now(),
+	}
+	if report.UnbalancedJournals == nil {
+		report.UnbalancedJournals = []uint{}
+	}
+	if last != nil && last.BalanceAfter != nil {
+		report.LastBalanceAfter = last.BalanceAfter
+		report.BalanceAfterDrift = wallet.Balance - *last.BalanceAfter
+	}
+
+	report.Consistent = report.Drift == 0 && report.BalanceAfterDrift == 0 && len(unbalanced) == 0 && len(assetDrift) == 0
+	return report, nil
+}
+
+// GetTransactionByID finds a wallet transaction
+func (s *WalletService) GetTransactionByID(tx *gorm.DB, id uint) (*WalletTransaction, error) {
+	return s.repo.FindTransactionByID(tx, id)
+}
+
+// ReverseJournal posts compensating legs for a journal inside the caller's
+// transaction. numerator/denominator scales every leg for partial refunds
+// (1/1 reverses everything); final marks the original as fully reversed.
+// Compensating wallet legs reference the wallet transaction they undo.
+func (s *WalletService) ReverseJournal(tx *gorm.DB, journalID uint, numerator, denominator int, final bool, reason string) (*LedgerJournal, []WalletTransaction, error) {
+	if numerator <= 0 || denominator <= 0 || numerator > denominator {
+		return nil, nil, errors.New("invalid reversal portion")
+	}
+
+	// Lock the journal so two admins cannot reverse it at the same time
+	journal, err := s.repo.LockJournal(tx, journalID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if journal.Status == "reversed" {
+		return nil, nil, errors.New("transaction has already been reversed")
+	}
+	if journal.Type == "reversal" || journal.Type == "opening_balance" || journal.Type == "settlement" {
+		return nil, nil, fmt.Errorf("%s journals cannot be reversed", journal.Type)
+	}
+	if journal.Type == "escrow" || journal.Type == "escrow_release" || journal.Type == "escrow_refund" {
+		return nil, nil, errors.New("escrow transfers are settled by releasing or refunding them, not by reversal")
+	}
+
+	entries, err := s.repo.GetJournalEntries(tx, journalID)
+	if err != nil {
+		return nil, nil, err
+	}
+	originals, err := s.repo.GetJournalTransactions(tx, journalID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	originalByWallet := make(map[uint]uint)
+	for _, txn := range originals {
+		if _, ok := originalByWallet[txn.WalletID]; !ok {
+			originalByWallet[txn.WalletID] = txn.ID
+		}
+	}
+
+	legs := make([]LedgerLeg, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Amount*numerator%denominator != 0 {
+			return nil, nil, errors.New("partial reversal does not split into whole points")
+		}
+
+		leg := LedgerLeg{
+			Account:   entry.Account,
+			Asset:     entry.Asset,
+			Direction: "debit",
+			Amount:    entry.Amount * numerator / denominator,
+		}
+		if entry.Direction == "debit" {
+			leg.Direction = "credit"
+		}
+
+		if entry.WalletID != nil {
+			originalID := originalByWallet[*entry.WalletID]
+			leg.WalletID = *entry.WalletID
+			leg.Type = "reversal"
+			leg.Description = fmt.Sprintf("Reversal of transaction #%d: %s", originalID, reason)
+			leg.ReferenceID = &originalID
+			leg.CreatedBy = "admin"
+		}
+		legs = append(legs, leg)
 	}
 
-	return s.repo.CreateTransaction(tx, txn)
+	reversal, txns, err := s.PostJournal(tx, "reversal", fmt.Sprintf("Reversal of journal #%d: %s", journalID, reason), legs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.repo.UpdateJournal(tx, reversal.ID, map[string]interface{}{"reversal_of": journalID}); err != nil {
+		return nil, nil, err
+	}
+	reversal.ReversalOf = &journalID
+
+	status := "partially_reversed"
+	if final {
+		status = "reversed"
+		if err := s.repo.UpdateJournalTransactionStatus(tx, journalID, "reversed"); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := s.repo.UpdateJournal(tx, journalID, map[string]interface{}{"status": status}); err != nil {
+		return nil, nil, err
+	}
+
+	return reversal, txns, nil
 }
 
 type MerchantStats struct {
@@ -457,7 +1044,8 @@ type MerchantStats struct {
 }
 
 func (s *WalletService) GetMerchantStats(userID uint) (*MerchantStats, error) {
-	wallet, err := s.repo.FindByUserID(userID)
+	// Cashiers see the figures of the merchant they work for
+	wallet, err := s.GetMerchantWallet(userID)
 	if err != nil {
 		return nil, err
 	}
@@ -476,12 +1064,12 @@ func (s *WalletService) GetMerchantStats(userID uint) (*MerchantStats, error) {
 	var count int64
 
 	s.db.Model(&WalletTransaction{}).
-		Where("wallet_id = ? AND type = ? AND created_at >= ?", wallet.ID, "marketplace_sale", startOfDay).
+		Where("wallet_id = ? AND asset = ? AND type = ? AND created_at >= ?", wallet.ID, DefaultAsset, "marketplace_sale", startOfDay).
 		Select("COALESCE(SUM(amount), 0)").
 		Scan(&todaySales)
 
 	s.db.Model(&WalletTransaction{}).
-		Where("wallet_id = ? AND type = ? AND created_at >= ?", wallet.ID, "marketplace_sale", startOfDay).
+		Where("wallet_id = ? AND asset = ? AND type = ? AND created_at >= ?", wallet.ID, DefaultAsset, "marketplace_sale", startOfDay).
 		Count(&count)
 
 	stats.TodaySales = int(todaySales)
@@ -511,12 +1099,12 @@ func (s *WalletService) GetAdminStats() (*AdminStats, error) {
 	s.db.Model(&WalletTransaction{}).Where("created_at >= ?", startOfDay).Count(&stats.TodayTransactions)
 
 	s.db.Model(&WalletTransaction{}).
-		Where("created_at >= ? AND direction = ?", startOfDay, "credit").
+		Where("created_at >= ? AND asset = ? AND direction = ? AND status = ?", startOfDay, DefaultAsset, "credit", "success").
 		Select("COALESCE(SUM(amount), 0)").
 		Scan(&stats.TodayCredits)
 
 	s.db.Model(&WalletTransaction{}).
-		Where("created_at >= ? AND direction = ?", startOfDay, "debit").
+		Where("created_at >= ? AND asset = ? AND direction = ? AND status = ?", startOfDay, DefaultAsset, "debit", "success").
 		Select("COALESCE(SUM(amount), 0)").
 		Scan(&stats.TodayDebits)
 
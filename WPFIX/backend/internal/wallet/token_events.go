@@ -0,0 +1,59 @@
+package wallet
+
+import (
+	"errors"
+	"time"
+	"wallet-point/internal/pubsub"
+)
+
+// TokenStatusEvent is pushed to watchers of a payment token when its status changes
+type TokenStatusEvent struct {
+	Token      string    `json:"token"`
+	Type       string    `json:"type"`
+	Status     string    `json:"status"`
+	Amount     int       `json:"amount"`
+	PaidAmount int       `json:"paid_amount,omitempty"`
+	Merchant   string    `json:"merchant"`
+	At         time.Time `json:"at"`
+}
+
+// SetEventHub configures where token status changes are published
+func (s *WalletService) SetEventHub(hub pubsub.Hub) {
+	s.hub = hub
+}
+
+func tokenTopic(tokenCode string) string {
+	return "payment_token:" + tokenCode
+}
+
+// publishTokenStatus tells watchers of a token about its current status.
+// Call it after the change is committed.
+func (s *WalletService) publishTokenStatus(token *PaymentToken) {
+	if s.hub == nil {
+		return
+	}
+	s.hub.Publish(tokenTopic(token.Token), NewTokenStatusEvent(token))
+}
+
+// NewTokenStatusEvent describes the current status of a token
+func NewTokenStatusEvent(token *PaymentToken) TokenStatusEvent {
+	return TokenStatusEvent{
+		Token:      token.Token,
+		Type:       token.Type,
+		Status:     token.Status,
+		Amount:     token.Amount,
+		PaidAmount: token.PaidAmount,
+		Merchant:   token.Merchant,
+		At:         time.Now(),
+	}
+}
+
+// SubscribeTokenStatus watches a token for status changes. Subscribe before
+// reading the current status so no change is missed in between.
+func (s *WalletService) SubscribeTokenStatus(tokenCode string) (<-chan interface{}, func(), error) {
+	if s.hub == nil {
+		return nil, nil, errors.New("payment status streaming is not enabled")
+	}
+	events, unsubscribe := s.hub.Subscribe(tokenTopic(tokenCode))
+	return events, unsubscribe, nil
+}
@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"wallet-point/internal/idempotency"
+	"wallet-point/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const IdempotencyHeader = "Idempotency-Key"
+
+// responseRecorder keeps a copy of the response body for replaying
+type responseRecorder struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency honours the Idempotency-Key header on money-moving endpoints.
+// A repeated key replays the stored response, a key reused with a different
+// body is rejected with 422. Requests without the header pass through.
+// Must run after AuthMiddleware, keys are scoped per user.
+func Idempotency(service *idempotency.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > 255 {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Idempotency-Key must be at most 255 characters", nil)
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Failed to read request body", nil)
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.New()
+		hash.Write([]byte(c.Request.Method + " " + c.Request.URL.Path + "\n"))
+		hash.Write(body)
+		requestHash := hex.EncodeToString(hash.Sum(nil))
+
+		record, replay, err := service.Begin(c.GetUint("user_id"), key, c.Request.Method, c.Request.URL.Path, requestHash)
+		if err != nil {
+			switch {
+			case errors.Is(err, idempotency.ErrKeyReused):
+				utils.ErrorResponse(c, http.StatusUnprocessableEntity, err.Error(), nil)
+			case errors.Is(err, idempotency.ErrRequestInFlight):
+				utils.ErrorResponse(c, http.StatusConflict, err.Error(), nil)
+			default:
+				utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to check idempotency key", err.Error())
+			}
+			c.Abort()
+			return
+		}
+
+		if replay != nil {
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(replay.StatusCode, "application/json; charset=utf-8", []byte(replay.ResponseBody))
+			c.Abort()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+		c.Writer = recorder
+
+		// Server errors and panics are not stored so the client can retry with the same key
+		stored := false
+		defer func() {
+			if stored {
+				return
+			}
+			if err := service.Release(record); err != nil {
+				log.Printf("[Idempotency] failed to release key %q: %v", key, err)
+			}
+		}()
+
+		c.Next()
+
+		status := c.Writer.Status()
+		if status >= http.StatusInternalServerError {
+			return
+		}
+
+		if err := service.Complete(record, status, recorder.body.String()); err != nil {
+			log.Printf("[Idempotency] failed to store response for key %q: %v", key, err)
+			return
+		}
+		stored = true
+	}
+}
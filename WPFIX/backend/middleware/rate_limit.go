@@ -19,22 +19,25 @@ var (
 	mu      sync.Mutex
 )
 
-// RateLimiter limits requests per IP
-func RateLimiter(r rate.Limit, b int) gin.HandlerFunc {
-	// Cleanup routine to remove old clients
-	go func() {
-		for {
-			time.Sleep(time.Minute)
-			mu.Lock()
-			for ip, client := range clients {
-				if time.Since(client.lastSeen) > 3*time.Minute {
-					delete(clients, ip)
-				}
-			}
-			mu.Unlock()
+// PurgeRateLimiterClients removes limiter state of clients idle for longer
+// than maxIdle and returns how many were removed. Run by the job scheduler
+// on every replica, including those with JOBS_ENABLED=false.
+func PurgeRateLimiterClients(maxIdle time.Duration) int64 {
+	mu.Lock()
+	defer mu.Unlock()
+
+	var purged int64
+	for ip, client := range clients {
+		if time.Since(client.lastSeen) > maxIdle {
+			delete(clients, ip)
+			purged++
 		}
-	}()
+	}
+	return purged
+}
 
+// RateLimiter limits requests per IP
+func RateLimiter(r rate.Limit, b int) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ip := c.ClientIP()
 		mu.Lock()
@@ -1,11 +1,21 @@
 package routes
 
 import (
+	"log"
+	"time"
+	"wallet-point/config"
 	"wallet-point/internal/audit"
 	"wallet-point/internal/auth"
 	"wallet-point/internal/external" // Add this
+	"wallet-point/internal/idempotency"
+	"wallet-point/internal/jobs"
 	"wallet-point/internal/marketplace"
+	"wallet-point/internal/merchant"
 	"wallet-point/internal/mission"
+	"wallet-point/internal/notification"
+	"wallet-point/internal/pubsub"
+	"wallet-point/internal/reversal"
+	"wallet-point/internal/settlement"
 	"wallet-point/internal/transfer"
 	"wallet-point/internal/user"
 	"wallet-point/internal/wallet"
@@ -16,9 +26,11 @@ import (
 	"gorm.io/gorm"
 )
 
-func SetupRoutes(r *gin.Engine, db *gorm.DB, allowedOrigins string, jwtExpiry int) {
+// SetupRoutes wires every module and returns the job scheduler so the
+// caller decides whether this replica runs background jobs
+func SetupRoutes(r *gin.Engine, db *gorm.DB, cfg *config.Config) *jobs.Scheduler {
 	// Apply global middleware
-	r.Use(middleware.CORS(allowedOrigins))
+	r.Use(middleware.CORS(cfg.AllowedOrigins))
 	r.Use(middleware.Logger())
 	r.Use(middleware.SecurityHeaders())
 	r.Use(middleware.IPBasedRateLimiter())
@@ -42,16 +54,50 @@ func SetupRoutes(r *gin.Engine, db *gorm.DB, allowedOrigins string, jwtExpiry in
 	missionRepo := mission.NewMissionRepository(db)
 	transferRepo := transfer.NewRepository(db)
 	externalRepo := external.NewRepository(db) // Add this
+	idempotencyRepo := idempotency.NewRepository(db)
+	jobsRepo := jobs.NewRepository(db)
+	settlementRepo := settlement.NewRepository(db)
+	merchantRepo := merchant.NewRepository(db)
+	notificationRepo := notification.NewRepository(db)
 
 	// Initialize services
-	authService := auth.NewAuthService(authRepo, jwtExpiry)
+	authService := auth.NewAuthService(authRepo, cfg.JWTExpiryHours)
 	userService := user.NewUserService(userRepo)
 	walletService := wallet.NewWalletService(walletRepo, db)
+	walletService.SetExpiryPolicy(wallet.NewExpiryPolicy(cfg.PointExpiry.TermEnds, cfg.PointExpiry.Types, cfg.PointExpiry.WarningDays))
+	// Deriving the QR key from JWT_SECRET lets anyone holding that secret
+	// sign payments, so production needs a dedicated key
+	if cfg.QRSigningKey == "" {
+		if cfg.GinMode == gin.ReleaseMode {
+			log.Fatal("❌ QR_SIGNING_KEY must be set in release mode")
+		}
+		if cfg.JWTSecret == config.DefaultJWTSecret {
+			log.Fatal("❌ QR_SIGNING_KEY is not set and JWT_SECRET is the default; set QR_SIGNING_KEY")
+		}
+		log.Println("⚠️  WARNING: QR_SIGNING_KEY is not set, deriving the QR signing key from JWT_SECRET. Set a dedicated key before going to production.")
+	}
+	qrSigner, err := wallet.NewQRSigner(cfg.QRSigningKey, cfg.JWTSecret)
+	if err != nil {
+		log.Fatal("❌ Invalid QR signing key:", err)
+	}
+	walletService.SetQRSigner(qrSigner)
+	// In-process pub/sub; a multi-instance deployment needs a broker-backed pubsub.Hub
+	walletService.SetEventHub(pubsub.NewMemoryHub())
+	merchantService := merchant.NewService(merchantRepo, authRepo, walletService, db)
+	walletService.SetMerchantDirectory(merchantService)
 	marketplaceService := marketplace.NewMarketplaceService(marketplaceRepo, walletService, db)
 	auditService := audit.NewAuditService(auditRepo)
 	missionService := mission.NewMissionService(missionRepo, walletService, db)
+	notificationService := notification.NewService(notificationRepo)
 	transferService := transfer.NewService(transferRepo, walletRepo, walletService, db)
+	transferService.SetNotifier(notificationService)
+	transferService.SetAuditService(auditService)
 	externalService := external.NewService(externalRepo, walletRepo, walletService, marketplaceService, missionService, auditService, db) // Add this
+	idempotencyService := idempotency.NewService(idempotencyRepo, cfg.IdempotencyWindow)
+	reversalService := reversal.NewService(walletService, transferRepo, marketplaceRepo, db)
+	settlementService := settlement.NewService(settlementRepo, walletService, db, cfg.SettlementCutoff)
+	scheduler := jobs.NewScheduler(jobsRepo)
+	registerJobs(scheduler, walletService, missionService, transferService, idempotencyService, settlementService)
 
 	// Initialize handlers
 	authHandler := auth.NewAuthHandler(authService, auditService)
@@ -62,6 +108,14 @@ func SetupRoutes(r *gin.Engine, db *gorm.DB, allowedOrigins string, jwtExpiry in
 	missionHandler := mission.NewMissionHandler(missionService, auditService)
 	transferHandler := transfer.NewHandler(transferService, auditService)
 	externalHandler := external.NewHandler(externalService, auditService) // Add this
+	reversalHandler := reversal.NewHandler(reversalService, auditService)
+	jobsHandler := jobs.NewHandler(scheduler, auditService)
+	settlementHandler := settlement.NewHandler(settlementService)
+	merchantHandler := merchant.NewHandler(merchantService, auditService)
+	notificationHandler := notification.NewHandler(notificationService)
+
+	// Replays retried money-moving requests carrying an Idempotency-Key header
+	idempotent := middleware.Idempotency(idempotencyService)
 
 	// ========================================
 	// PUBLIC ROUTES
@@ -71,9 +125,22 @@ func SetupRoutes(r *gin.Engine, db *gorm.DB, allowedOrigins string, jwtExpiry in
 		authGroup.POST("/login", middleware.AuthRateLimiter(), authHandler.Login)
 		authGroup.GET("/me", middleware.AuthMiddleware(), authHandler.Me)
 		authGroup.PUT("/profile", middleware.AuthMiddleware(), authHandler.UpdateProfile)
+		authGroup.PUT("/privacy", middleware.AuthMiddleware(), authHandler.UpdatePrivacy)
 		authGroup.PUT("/password", middleware.AuthMiddleware(), authHandler.UpdatePassword)
 	}
 
+	// In-app notifications of the current user (any role)
+	notificationGroup := api.Group("/notifications")
+	notificationGroup.Use(middleware.AuthMiddleware())
+	{
+		notificationGroup.GET("", notificationHandler.GetMine)
+		notificationGroup.POST("/:id/read", notificationHandler.MarkRead)
+		notificationGroup.POST("/read-all", notificationHandler.MarkAllRead)
+	}
+
+	// Point assets, e.g. for picking the asset of a mission reward (any role)
+	api.GET("/assets", middleware.AuthMiddleware(), walletHandler.GetAssets)
+
 	// ========================================
 	// ADMIN ROUTES
 	// ========================================
@@ -93,12 +160,39 @@ func SetupRoutes(r *gin.Engine, db *gorm.DB, allowedOrigins string, jwtExpiry in
 		adminGroup.GET("/wallets", walletHandler.GetAllWallets)
 		adminGroup.GET("/wallets/:id", walletHandler.GetWalletByID)
 		adminGroup.GET("/wallets/:id/transactions", walletHandler.GetWalletTransactions)
-		adminGroup.POST("/wallet/adjustment", walletHandler.AdjustPoints)
+		adminGroup.GET("/wallets/:id/reconcile", walletHandler.ReconcileWallet)
+		adminGroup.GET("/wallets/:id/limits", walletHandler.GetWalletLimits)
+		adminGroup.PUT("/wallets/:id/limits", walletHandler.UpdateWalletLimits)
+		adminGroup.PUT("/wallets/:id/status", walletHandler.UpdateWalletStatus)
+		adminGroup.GET("/wallet-limits", walletHandler.GetRoleLimits)
+		adminGroup.PUT("/wallet-limits/:role", walletHandler.UpdateRoleLimits)
+		adminGroup.POST("/wallet/adjustment", idempotent, walletHandler.AdjustPoints)
 		adminGroup.POST("/wallet/reset", walletHandler.ResetWallet)
+		adminGroup.POST("/assets", walletHandler.CreateAsset)
+		adminGroup.PUT("/assets/:id", walletHandler.UpdateAsset)
+		adminGroup.GET("/asset-conversions", walletHandler.GetConversionRules)
+		adminGroup.POST("/asset-conversions", walletHandler.CreateConversionRule)
+		adminGroup.PUT("/asset-conversions/:id", walletHandler.UpdateConversionRule)
 
 		// Transaction Monitoring
 		adminGroup.GET("/transactions", walletHandler.GetAllTransactions)
+		adminGroup.POST("/transactions/:id/reverse", idempotent, reversalHandler.Reverse)
 		adminGroup.GET("/transfers", transferHandler.GetAllTransfers)
+		adminGroup.GET("/transfers/flagged", transferHandler.GetFlaggedTransfers)
+		adminGroup.POST("/transfers/flagged/:id/review", transferHandler.ReviewTransferFlag)
+		adminGroup.GET("/transfers/disputes", transferHandler.GetEscrowDisputes)
+		adminGroup.POST("/transfers/disputes/:id/resolve", transferHandler.ResolveEscrowDispute)
+		adminGroup.GET("/transfer-rules", transferHandler.GetTransferRules)
+		adminGroup.PUT("/transfer-rules", transferHandler.UpdateTransferRules)
+
+		// Merchant Management
+		adminGroup.GET("/merchants", merchantHandler.GetAll)
+		adminGroup.POST("/merchants", merchantHandler.Create)
+		adminGroup.PUT("/merchants/:id", merchantHandler.Update)
+
+		// Merchant Settlements
+		adminGroup.GET("/settlements", settlementHandler.GetAllSettlements)
+		adminGroup.GET("/settlements/:id/statement", settlementHandler.GetStatement)
 
 		// Marketplace Management
 		adminGroup.GET("/marketplace/transactions", marketplaceHandler.GetTransactions) // Add this
@@ -110,6 +204,8 @@ func SetupRoutes(r *gin.Engine, db *gorm.DB, allowedOrigins string, jwtExpiry in
 
 		// Audit Logs
 		adminGroup.GET("/audit-logs", auditHandler.GetAll)
+		adminGroup.GET("/jobs", jobsHandler.GetJobs)
+		adminGroup.POST("/jobs/:name/run", jobsHandler.RunJob)
 
 		// External Sources Management
 		adminGroup.GET("/external/sources", externalHandler.ListSources)
@@ -134,17 +230,27 @@ func SetupRoutes(r *gin.Engine, db *gorm.DB, allowedOrigins string, jwtExpiry in
 		dosenGroup.DELETE("/missions/:id", missionHandler.DeleteMission)
 		dosenGroup.GET("/missions", missionHandler.GetAllMissions)
 		dosenGroup.GET("/missions/:id", missionHandler.GetMissionByID)
+		dosenGroup.GET("/missions/:id/submissions/:student_id", missionHandler.GetSubmissionHistory)
+		dosenGroup.GET("/missions/:id/draws/:student_id", missionHandler.GetQuizDraw)
+		dosenGroup.GET("/missions/:id/peer-reviews", missionHandler.GetMissionPeerReviews)
+
+		// Question Bank
+		dosenGroup.GET("/question-bank", missionHandler.GetBankQuestions)
+		dosenGroup.POST("/question-bank", missionHandler.CreateBankQuestion)
+		dosenGroup.PUT("/question-bank/:id", missionHandler.UpdateBankQuestion)
+		dosenGroup.DELETE("/question-bank/:id", missionHandler.DeleteBankQuestion)
 
 		// Submission Validation
 		dosenGroup.GET("/submissions", missionHandler.GetAllSubmissions)
 		dosenGroup.POST("/submissions/:id/review", missionHandler.ReviewSubmission)
+		dosenGroup.POST("/submissions/:id/override", missionHandler.OverrideSubmission)
 
 		// Dashboard Stats
 		dosenGroup.GET("/stats", missionHandler.GetDosenStats)
 
 		// Monitoring & Manual Rewards
 		dosenGroup.GET("/students", userHandler.GetAll) // Reuse GetAll but restricted to Dosen
-		dosenGroup.POST("/reward", walletHandler.AdjustPoints)
+		dosenGroup.POST("/reward", idempotent, walletHandler.AdjustPoints)
 	}
 
 	// ========================================
@@ -156,20 +262,46 @@ func SetupRoutes(r *gin.Engine, db *gorm.DB, allowedOrigins string, jwtExpiry in
 	{
 		// Mission & Task Submission
 		mahasiswaGroup.GET("/missions", missionHandler.GetAllMissions)
-		mahasiswaGroup.GET("/missions/:id", missionHandler.GetMissionByID)
+		mahasiswaGroup.GET("/missions/:id", missionHandler.GetStudentMission)
+		mahasiswaGroup.GET("/missions/:id/submissions", missionHandler.GetMySubmissionHistory)
+		mahasiswaGroup.POST("/missions/:id/start", missionHandler.StartQuiz)
+		mahasiswaGroup.PUT("/missions/:id/session", missionHandler.SaveQuizAnswers)
 		mahasiswaGroup.POST("/missions/submit", missionHandler.SubmitMission)
 		mahasiswaGroup.GET("/submissions", missionHandler.GetAllSubmissions)
 
+		// Peer Review
+		mahasiswaGroup.GET("/reviews", missionHandler.GetMyPeerReviews)
+		mahasiswaGroup.GET("/reviews/received", missionHandler.GetReceivedPeerReviews)
+		mahasiswaGroup.POST("/reviews/:id", missionHandler.SubmitPeerReview)
+
 		// Transfer Points
-		mahasiswaGroup.POST("/transfer", transferHandler.CreateTransfer)
+		mahasiswaGroup.POST("/transfer", idempotent, transferHandler.CreateTransfer)
 		mahasiswaGroup.GET("/transfer/history", transferHandler.GetMyTransfers)
+		mahasiswaGroup.GET("/transfer/recipient", transferHandler.ResolveRecipient)
 		mahasiswaGroup.GET("/transfer/recipient/:id", transferHandler.GetRecipientInfo)
 		mahasiswaGroup.GET("/transfer/sent", transferHandler.GetSentTransfers)
 		mahasiswaGroup.GET("/transfer/received", transferHandler.GetReceivedTransfers)
+		mahasiswaGroup.POST("/transfer/requests", transferHandler.CreatePointRequest)
+		mahasiswaGroup.GET("/transfer/requests/incoming", transferHandler.GetIncomingPointRequests)
+		mahasiswaGroup.GET("/transfer/requests/outgoing", transferHandler.GetOutgoingPointRequests)
+		mahasiswaGroup.POST("/transfer/requests/:id/accept", idempotent, transferHandler.AcceptPointRequest)
+		mahasiswaGroup.POST("/transfer/requests/:id/decline", transferHandler.DeclinePointRequest)
+		mahasiswaGroup.POST("/transfer/requests/:id/cancel", transferHandler.CancelPointRequest)
+		mahasiswaGroup.POST("/transfer/schedules", transferHandler.CreateScheduledTransfer)
+		mahasiswaGroup.GET("/transfer/schedules", transferHandler.GetScheduledTransfers)
+		mahasiswaGroup.GET("/transfer/schedules/:id/runs", transferHandler.GetScheduledTransferRuns)
+		mahasiswaGroup.POST("/transfer/schedules/:id/pause", transferHandler.PauseScheduledTransfer)
+		mahasiswaGroup.POST("/transfer/schedules/:id/resume", transferHandler.ResumeScheduledTransfer)
+		mahasiswaGroup.POST("/transfer/schedules/:id/cancel", transferHandler.CancelScheduledTransfer)
+		mahasiswaGroup.POST("/transfer/escrows", idempotent, transferHandler.CreateEscrow)
+		mahasiswaGroup.GET("/transfer/escrows", transferHandler.GetMyEscrows)
+		mahasiswaGroup.POST("/transfer/escrows/:id/deliver", transferHandler.MarkEscrowDelivered)
+		mahasiswaGroup.POST("/transfer/escrows/:id/confirm", idempotent, transferHandler.ConfirmEscrow)
+		mahasiswaGroup.POST("/transfer/escrows/:id/dispute", transferHandler.DisputeEscrow)
 		mahasiswaGroup.GET("/users/lookup", userHandler.LookupUser) // Lookup user for transfer verification
 
 		// Marketplace Purchase
-		mahasiswaGroup.POST("/marketplace/purchase", marketplaceHandler.Purchase)
+		mahasiswaGroup.POST("/marketplace/purchase", idempotent, marketplaceHandler.Purchase)
 		mahasiswaGroup.GET("/marketplace/products", marketplaceHandler.GetAll) // Reuse GetAll, maybe add status filter later
 		mahasiswaGroup.GET("/marketplace/products/:id", marketplaceHandler.GetByID)
 
@@ -178,9 +310,13 @@ func SetupRoutes(r *gin.Engine, db *gorm.DB, allowedOrigins string, jwtExpiry in
 
 		// Personal Wallet
 		mahasiswaGroup.GET("/wallet", walletHandler.GetMyWallet)
+		mahasiswaGroup.GET("/wallet/conversions", walletHandler.GetConversionRules)
+		mahasiswaGroup.POST("/wallet/convert", idempotent, walletHandler.ConvertPoints)
 		mahasiswaGroup.GET("/transactions", walletHandler.GetMyTransactions) // Replaces old getTransactions use case
+		mahasiswaGroup.GET("/merchants", merchantHandler.GetDirectory)
 		mahasiswaGroup.POST("/payment/token", walletHandler.GeneratePaymentToken)
-		mahasiswaGroup.POST("/payment/execute", walletHandler.ExecuteStudentPayment)
+		mahasiswaGroup.POST("/payment/execute", idempotent, walletHandler.ExecuteStudentPayment)
+		mahasiswaGroup.POST("/bills/:token/pay", idempotent, walletHandler.PayBill)
 
 		// External Point Sync
 		mahasiswaGroup.POST("/external/sync", externalHandler.SyncPoints)
@@ -193,12 +329,25 @@ func SetupRoutes(r *gin.Engine, db *gorm.DB, allowedOrigins string, jwtExpiry in
 	merchantGroup.Use(middleware.AuthMiddleware())
 	merchantGroup.Use(middleware.RoleMiddleware("merchant", "admin"))
 	{
-		merchantGroup.POST("/payment/scan", walletHandler.MerchantScan)
+		merchantGroup.POST("/payment/scan", idempotent, walletHandler.MerchantScan)
+		merchantGroup.POST("/bills", walletHandler.CreateBill)
 		merchantGroup.GET("/stats", walletHandler.GetMerchantStats)
+		merchantGroup.GET("/settlements", settlementHandler.GetMySettlements)
+		merchantGroup.GET("/settlements/:id/statement", settlementHandler.GetStatement)
+
+		// Merchant profile, terminals and cashiers
+		merchantGroup.GET("/profile", merchantHandler.GetProfile)
+		merchantGroup.PUT("/profile", merchantHandler.UpdateProfile)
+		merchantGroup.POST("/terminals", merchantHandler.CreateTerminal)
+		merchantGroup.PUT("/terminals/:id", merchantHandler.UpdateTerminal)
+		merchantGroup.POST("/cashiers", merchantHandler.CreateCashier)
+		merchantGroup.DELETE("/cashiers/:id", merchantHandler.DeactivateCashier)
 	}
 
 	// Global QR Status Check
 	api.GET("/payment/status/:token", walletHandler.CheckTokenStatus)
+	api.GET("/payment/status/:token/stream", walletHandler.StreamTokenStatus)
+	api.GET("/payment/keys", walletHandler.GetQRVerificationKeys)
 
 	// Health check
 	api.GET("/health", func(c *gin.Context) {
@@ -207,4 +356,106 @@ func SetupRoutes(r *gin.Engine, db *gorm.DB, allowedOrigins string, jwtExpiry in
 			"message": "Wallet Point API is running",
 		})
 	})
+
+	return scheduler
+}
+
+// registerJobs declares the background jobs and their cron schedules
+func registerJobs(scheduler *jobs.Scheduler, walletService *wallet.WalletService, missionService *mission.MissionService, transferService *transfer.Service, idempotencyService *idempotency.Service, settlementService *settlement.Service) {
+	definitions := []jobs.Job{
+		{
+			Name:        "expire_payment_tokens",
+			Description: "Mark active QR payment tokens past their expiry as expired",
+			Schedule:    "* * * * *",
+			Run:         walletService.ExpireStaleTokens,
+		},
+		{
+			Name:        "expire_overdue_missions",
+			Description: "Flip active missions past their deadline to expired",
+			Schedule:    "*/5 * * * *",
+			Run:         missionService.ExpireOverdueMissions,
+		},
+		{
+			Name:        "close_expired_quiz_sessions",
+			Description: "Submit the autosaved answers of timed quizzes past their time limit",
+			Schedule:    "* * * * *",
+			Run:         missionService.CloseExpiredQuizSessions,
+		},
+		{
+			Name:        "assign_peer_reviews",
+			Description: "Assign the submissions of peer reviewed missions to reviewers once the deadline passes",
+			Schedule:    "*/5 * * * *",
+			Run:         missionService.AssignPeerReviews,
+		},
+		{
+			Name:        "close_peer_reviews",
+			Description: "Expire peer reviews past their window and grade submissions from the completed ones",
+			Schedule:    "*/5 * * * *",
+			Run:         missionService.ClosePeerReviews,
+		},
+		{
+			Name:        "expire_point_requests",
+			Description: "Expire unanswered point requests past their expiry",
+			Schedule:    "*/5 * * * *",
+			Run:         transferService.ExpirePointRequests,
+		},
+		{
+			Name:        "run_scheduled_transfers",
+			Description: "Execute due scheduled and recurring transfers; skipped runs are notified",
+			Schedule:    "* * * * *",
+			Run:         transferService.RunDueScheduledTransfers,
+		},
+		{
+			Name:        "settle_escrows",
+			Description: "Release undisputed deliveries after 72 hours and refund escrows not delivered in time",
+			Schedule:    "*/5 * * * *",
+			Run:         transferService.SettleDueEscrows,
+		},
+		{
+			Name:        "expire_points",
+			Description: "Post expiry debits for point lots past their term end",
+			Schedule:    "5 * * * *",
+			LeaseTTL:    30 * time.Minute,
+			Run: func() (int64, error) {
+				_, points, err := walletService.ExpirePoints()
+				return int64(points), err
+			},
+		},
+		{
+			Name:        "settle_merchants",
+			Description: "Close merchant sales up to the daily cutoff into settlement batches and settle open batches",
+			Schedule:    "10 * * * *",
+			LeaseTTL:    30 * time.Minute,
+			Run:         settlementService.RunCutoff,
+		},
+		{
+			Name:        "purge_rate_limiter",
+			Description: "Drop rate-limiter state of clients idle for 3 minutes",
+			Schedule:    "* * * * *",
+			Local:       true,
+			Run: func() (int64, error) {
+				return middleware.PurgeRateLimiterClients(3 * time.Minute), nil
+			},
+		},
+		{
+			Name:        "purge_idempotency_keys",
+			Description: "Delete idempotency keys past their replay window",
+			Schedule:    "30 3 * * *",
+			Run:         idempotencyService.PurgeExpired,
+		},
+		{
+			Name:        "purge_job_history",
+			Description: "Delete job runs older than 30 days",
+			Schedule:    "45 3 * * *",
+			Run: func() (int64, error) {
+				return scheduler.PurgeHistory(30 * 24 * time.Hour)
+			},
+		},
+	}
+
+	for _, job := range definitions {
+		if err := scheduler.Register(job); err != nil {
+			log.Fatalf("❌ Failed to register job %s: %v", job.Name, err)
+		}
+	}
 }
@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// MaskName keeps the first name and shortens the rest to initials, so
+// "Budi Santoso Wijaya" becomes "Budi S. W."
+func MaskName(name string) string {
+	words := strings.Fields(name)
+	if len(words) == 0 {
+		return ""
+	}
+	if len(words) == 1 {
+		return maskTail(words[0], 2)
+	}
+
+	masked := []string{words[0]}
+	for _, w := range words[1:] {
+		r, _ := utf8.DecodeRuneInString(w)
+		masked = append(masked, string(r)+".")
+	}
+	return strings.Join(masked, " ")
+}
+
+// MaskIdentifier keeps the first and last two characters of an identifier
+// such as a NIM, so "2023001" becomes "20***01"
+func MaskIdentifier(id string) string {
+	runes := []rune(id)
+	if len(runes) <= 4 {
+		return maskTail(id, 1)
+	}
+	return string(runes[:2]) + strings.Repeat("*", len(runes)-4) + string(runes[len(runes)-2:])
+}
+
+// maskTail keeps the first keep characters and stars the rest
+func maskTail(s string, keep int) string {
+	runes := []rune(s)
+	if len(runes) <= keep {
+		return s
+	}
+	return string(runes[:keep]) + strings.Repeat("*", len(runes)-keep)
+}
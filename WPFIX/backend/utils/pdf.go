@@ -0,0 +1,115 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// A4 page in points and the text layout used by TextPDF
+const (
+	pdfPageWidth    = 595
+	pdfPageHeight   = 842
+	pdfMargin       = 50
+	pdfFontSize     = 9
+	pdfLeading      = 12
+	pdfLinesPerPage = (pdfPageHeight - 2*pdfMargin - 2*pdfLeading) / pdfLeading
+)
+
+// TextPDF builds a plain A4 PDF of monospaced text lines. It covers simple
+// documents such as statements without pulling in a PDF library.
+type TextPDF struct {
+	title string
+	lines []string
+}
+
+func NewTextPDF(title string) *TextPDF {
+	return &TextPDF{title: title}
+}
+
+// Line appends a line of text; use an empty string for a blank line
+func (p *TextPDF) Line(text string) {
+	p.lines = append(p.lines, text)
+}
+
+// Linef appends a formatted line of text
+func (p *TextPDF) Linef(format string, args ...interface{}) {
+	p.lines = append(p.lines, fmt.Sprintf(format, args...))
+}
+
+// Bytes renders the document. The title is repeated on every page.
+func (p *TextPDF) Bytes() []byte {
+	pages := [][]string{}
+	for start := 0; start < len(p.lines) || start == 0; start += pdfLinesPerPage {
+		end := start + pdfLinesPerPage
+		if end > len(p.lines) {
+			end = len(p.lines)
+		}
+		pages = append(pages, p.lines[start:end])
+	}
+
+	// Objects 1-4 are fixed; each page adds a page object and a content stream
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"", // Page tree, filled in once page object numbers are known
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>",
+	}
+
+	kids := make([]string, 0, len(pages))
+	for i, lines := range pages {
+		pageNum := len(objects) + 1
+		kids = append(kids, fmt.Sprintf("%d 0 R", pageNum))
+
+		var content bytes.Buffer
+		fmt.Fprintf(&content, "BT /F1 12 Tf %d %d Td (%s) Tj ET\n", pdfMargin, pdfPageHeight-pdfMargin, pdfEscape(p.title))
+		fmt.Fprintf(&content, "BT /F2 8 Tf %d %d Td (Page %d of %d) Tj ET\n", pdfPageWidth-pdfMargin-60, pdfMargin/2, i+1, len(pages))
+		fmt.Fprintf(&content, "BT /F2 %d Tf %d TL %d %d Td\n", pdfFontSize, pdfLeading, pdfMargin, pdfPageHeight-pdfMargin-2*pdfLeading)
+		for _, line := range lines {
+			fmt.Fprintf(&content, "(%s) Tj T*\n", pdfEscape(line))
+		}
+		content.WriteString("ET\n")
+
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+				pdfPageWidth, pdfPageHeight, pageNum+1),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()),
+		)
+	}
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages))
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	return out.Bytes()
+}
+
+// pdfEscape makes text safe inside a PDF string literal. Characters outside
+// printable ASCII are replaced since the standard fonts cannot show them.
+func pdfEscape(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 32 || r > 126:
+			b.WriteByte('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
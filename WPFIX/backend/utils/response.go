@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"errors"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -37,3 +39,22 @@ func ValidationErrorResponse(c *gin.Context, errors interface{}) {
 		Errors:  errors,
 	})
 }
+
+// CodedError is an error with a machine-readable code the frontend can
+// use to explain why an operation was blocked
+type CodedError interface {
+	error
+	Code() string
+	HTTPStatus() int
+}
+
+// ErrorFromErr sends err with its own status and code when it is a
+// CodedError, otherwise with fallbackStatus and no code
+func ErrorFromErr(c *gin.Context, fallbackStatus int, err error) {
+	var coded CodedError
+	if errors.As(err, &coded) {
+		ErrorResponse(c, coded.HTTPStatus(), err.Error(), gin.H{"code": coded.Code()})
+		return
+	}
+	ErrorResponse(c, fallbackStatus, err.Error(), nil)
+}
@@ -137,6 +137,8 @@ class AdminController {
                     <button class="tab-btn ${activeTab === 'wallets' ? 'active' : ''}" onclick="AdminController.renderUsers('wallets')">Manajemen Dompet</button>
                     <button class="tab-btn ${activeTab === 'transactions' ? 'active' : ''}" onclick="AdminController.renderUsers('transactions')">Log Transaksi</button>
                     <button class="tab-btn ${activeTab === 'transfers' ? 'active' : ''}" onclick="AdminController.renderUsers('transfers')">Riwayat P2P</button>
+                    <button class="tab-btn ${activeTab === 'flagged' ? 'active' : ''}" onclick="AdminController.renderUsers('flagged')">Aturan & Tinjauan</button>
+                    <button class="tab-btn ${activeTab === 'assets' ? 'active' : ''}" onclick="AdminController.renderUsers('assets')">Jenis Poin</button>
                 </div>
                 <div id="tabContent"></div>
             </div>
@@ -151,6 +153,8 @@ class AdminController {
         else if (activeTab === 'wallets') await this.renderWallets();
         else if (activeTab === 'transactions') await this.renderTransactions();
         else if (activeTab === 'transfers') await this.renderTransfers();
+        else if (activeTab === 'flagged') await this.renderTransferReview();
+        else if (activeTab === 'assets') await this.renderAssets();
     }
 
     static async renderUserAccounts() {
@@ -338,6 +342,255 @@ class AdminController {
         } catch (e) { console.error(e); }
     }
 
+    static async renderTransferReview() {
+        const tabContent = document.getElementById('tabContent');
+        tabContent.innerHTML = `
+            <div class="table-wrapper" style="margin-bottom: 2rem;">
+                <div class="table-header"><h3>Aturan Transfer</h3></div>
+                <form id="transferRulesForm" onsubmit="AdminController.handleTransferRules(event)" style="padding: 1.5rem; display: grid; grid-template-columns: repeat(auto-fit, minmax(200px, 1fr)); gap: 1rem;">
+                    <div class="form-group"><label>Batas harian (poin)</label><input type="number" name="daily_amount_cap" min="1" class="form-input" placeholder="Tanpa batas"></div>
+                    <div class="form-group"><label>Maks. transfer per jam</label><input type="number" name="max_transfers_per_hour" min="1" class="form-input" placeholder="Tanpa batas"></div>
+                    <div class="form-group"><label>Umur akun minimal (hari)</label><input type="number" name="min_account_age_days" min="0" class="form-input" placeholder="Tanpa batas"></div>
+                    <div class="form-group"><label>Jendela alur melingkar (jam, 0 = mati)</label><input type="number" name="circular_window_hours" min="0" max="720" class="form-input" required></div>
+                    <div class="form-group"><label>Blokir transfer ke peran</label>
+                        <div style="display: flex; gap: 1rem; flex-wrap: wrap;">
+                            ${['admin', 'dosen', 'mahasiswa', 'merchant'].map(r => `<label><input type="checkbox" name="blocked_receiver_roles" value="${r}"> ${r}</label>`).join('')}
+                        </div>
+                    </div>
+                    <div class="form-actions" style="align-self: end;"><button type="submit" class="btn btn-primary">Simpan Aturan</button></div>
+                </form>
+            </div>
+            <div class="table-wrapper">
+                <div class="table-header"><h3>Antrian Tinjauan Transfer</h3></div>
+                <div style="overflow-x: auto;">
+                    <table class="premium-table" id="flagsTable">
+                        <thead>
+                            <tr>
+                                <th>Waktu</th>
+                                <th>Transfer</th>
+                                <th>Temuan</th>
+                                <th>Status</th>
+                                <th>Aksi</th>
+                            </tr>
+                        </thead>
+                        <tbody><tr><td colspan="5" class="text-center">Memuat Antrian...</td></tr></tbody>
+                    </table>
+                </div>
+            </div>
+            <div class="table-wrapper" style="margin-top: 2rem;">
+                <div class="table-header"><h3>Sengketa Escrow</h3></div>
+                <div style="overflow-x: auto;">
+                    <table class="premium-table" id="disputesTable">
+                        <thead>
+                            <tr>
+                                <th>Waktu</th>
+                                <th>Transaksi</th>
+                                <th>Alasan</th>
+                                <th>Aksi</th>
+                            </tr>
+                        </thead>
+                        <tbody><tr><td colspan="4" class="text-center">Memuat Sengketa...</td></tr></tbody>
+                    </table>
+                </div>
+            </div>
+        `;
+
+        try {
+            const rulesRes = await API.getTransferRules();
+            const rules = rulesRes.data;
+            const form = document.getElementById('transferRulesForm');
+            ['daily_amount_cap', 'max_transfers_per_hour', 'min_account_age_days'].forEach(f => form[f].value = rules[f] ?? '');
+            form.circular_window_hours.value = rules.circular_window_hours;
+            form.querySelectorAll('[name="blocked_receiver_roles"]').forEach(cb => cb.checked = rules.blocked_receiver_roles.includes(cb.value));
+
+            const result = await API.getFlaggedTransfers({ limit: 50 });
+            const flags = result.data.flags || [];
+            const badges = { open: 'badge-warning', cleared: 'badge-success', confirmed: 'badge-error' };
+            document.querySelector('#flagsTable tbody').innerHTML = flags.map(f => `
+                <tr>
+                    <td><small>${new Date(f.created_at).toLocaleString()}</small></td>
+                    <td><strong>${f.transfer ? `${f.transfer.sender_name} → ${f.transfer.receiver_name}` : '#' + f.transfer_id}</strong><br><small>${f.transfer ? f.transfer.amount.toLocaleString() + ' pts' : ''}</small></td>
+                    <td><small>${f.details}</small></td>
+                    <td><span class="badge ${badges[f.status]}">${f.status}</span>${f.review_note ? `<br><small>${f.review_note}</small>` : ''}</td>
+                    <td>${f.status === 'open' ? `
+                        <button class="btn btn-sm btn-secondary" onclick="AdminController.reviewTransferFlag(${f.id}, 'cleared')">Aman</button>
+                        <button class="btn btn-sm btn-secondary" onclick="AdminController.reviewTransferFlag(${f.id}, 'confirmed')">Penyalahgunaan</button>` : '-'}
+                    </td>
+                </tr>
+            `).join('') || '<tr><td colspan="5" class="text-center">Tidak ada transfer yang ditandai</td></tr>';
+
+            const disputesRes = await API.getEscrowDisputes({ limit: 50 });
+            const disputes = disputesRes.data.escrows || [];
+            document.querySelector('#disputesTable tbody').innerHTML = disputes.map(t => `
+                <tr>
+                    <td><small>${new Date(t.created_at).toLocaleString()}</small></td>
+                    <td><strong>${t.sender_name} → ${t.receiver_name}</strong><br><small>${t.description} • ${t.amount.toLocaleString()} pts</small></td>
+                    <td><small>${t.dispute_reason}</small></td>
+                    <td>
+                        <button class="btn btn-sm btn-secondary" onclick="AdminController.resolveEscrowDispute(${t.id}, 'release')">Ke Penjual</button>
+                        <button class="btn btn-sm btn-secondary" onclick="AdminController.resolveEscrowDispute(${t.id}, 'refund')">Kembalikan</button>
+                    </td>
+                </tr>
+            `).join('') || '<tr><td colspan="4" class="text-center">Tidak ada sengketa</td></tr>';
+        } catch (e) { console.error(e); }
+    }
+
+    static async handleTransferRules(e) {
+        e.preventDefault();
+        const form = e.target;
+        const optional = v => v === '' ? null : parseInt(v);
+        const data = {
+            daily_amount_cap: optional(form.daily_amount_cap.value),
+            max_transfers_per_hour: optional(form.max_transfers_per_hour.value),
+            min_account_age_days: optional(form.min_account_age_days.value),
+            circular_window_hours: parseInt(form.circular_window_hours.value),
+            blocked_receiver_roles: [...form.querySelectorAll('[name="blocked_receiver_roles"]:checked')].map(cb => cb.value)
+        };
+        try { await API.updateTransferRules(data); showToast('Aturan transfer disimpan'); } catch (err) { showToast(err.message, 'error'); }
+    }
+
+    static async reviewTransferFlag(id, status) {
+        const note = prompt(status === 'cleared' ? 'Catatan (opsional):' : 'Alasan penyalahgunaan:');
+        if (note === null) return;
+        try {
+            await API.reviewTransferFlag(id, { status, note });
+            showToast('Tinjauan disimpan');
+            this.renderUsers('flagged');
+        } catch (e) { showToast(e.message, 'error'); }
+    }
+
+    static async resolveEscrowDispute(id, action) {
+        const note = prompt('Catatan keputusan (opsional):');
+        if (note === null) return;
+        try {
+            await API.resolveEscrowDispute(id, { action, note });
+            showToast('Sengketa diselesaikan');
+            this.renderUsers('flagged');
+        } catch (e) { showToast(e.message, 'error'); }
+    }
+
+    static async renderAssets() {
+        const tabContent = document.getElementById('tabContent');
+        tabContent.innerHTML = `
+            <div class="table-wrapper" style="margin-bottom: 2rem;">
+                <div class="table-header"><h3>Jenis Poin</h3></div>
+                <form id="assetForm" onsubmit="AdminController.handleCreateAsset(event)" style="padding: 1.5rem; display: grid; grid-template-columns: repeat(auto-fit, minmax(180px, 1fr)); gap: 1rem;">
+                    <div class="form-group"><label>Kode</label><input type="text" name="code" class="form-input" pattern="[a-z][a-z0-9_]{1,29}" placeholder="poin_organisasi" required></div>
+                    <div class="form-group"><label>Nama</label><input type="text" name="name" class="form-input" maxlength="100" required></div>
+                    <div class="form-group"><label>Deskripsi</label><input type="text" name="description" class="form-input" maxlength="255"></div>
+                    <div class="form-group"><label>ID Merchant (opsional)</label><input type="text" name="merchant_ids" class="form-input" placeholder="3,7 — kosong = semua"></div>
+                    <div class="form-actions" style="align-self: end;"><button type="submit" class="btn btn-primary">+ Tambah Jenis</button></div>
+                </form>
+                <div style="overflow-x: auto;">
+                    <table class="premium-table" id="assetsTable">
+                        <thead>
+                            <tr>
+                                <th>Kode</th>
+                                <th>Nama</th>
+                                <th>Merchant</th>
+                                <th>Status</th>
+                                <th>Aksi</th>
+                            </tr>
+                        </thead>
+                        <tbody><tr><td colspan="5" class="text-center">Memuat Jenis Poin...</td></tr></tbody>
+                    </table>
+                </div>
+            </div>
+            <div class="table-wrapper">
+                <div class="table-header"><h3>Nilai Tukar</h3></div>
+                <form id="conversionForm" onsubmit="AdminController.handleCreateConversion(event)" style="padding: 1.5rem; display: grid; grid-template-columns: repeat(auto-fit, minmax(160px, 1fr)); gap: 1rem;">
+                    <div class="form-group"><label>Dari</label><select name="from_asset" class="form-input" required></select></div>
+                    <div class="form-group"><label>Jumlah Asal</label><input type="number" name="from_amount" class="form-input" min="1" required></div>
+                    <div class="form-group"><label>Ke</label><select name="to_asset" class="form-input" required></select></div>
+                    <div class="form-group"><label>Jumlah Tujuan</label><input type="number" name="to_amount" class="form-input" min="1" required></div>
+                    <div class="form-actions" style="align-self: end;"><button type="submit" class="btn btn-primary">+ Tambah Nilai Tukar</button></div>
+                </form>
+                <div style="overflow-x: auto;">
+                    <table class="premium-table" id="conversionsTable">
+                        <thead>
+                            <tr>
+                                <th>Nilai Tukar</th>
+                                <th>Status</th>
+                                <th>Aksi</th>
+                            </tr>
+                        </thead>
+                        <tbody><tr><td colspan="3" class="text-center">Memuat Nilai Tukar...</td></tr></tbody>
+                    </table>
+                </div>
+            </div>
+        `;
+
+        try {
+            const [assetsRes, rulesRes] = await Promise.all([API.getAssets(), API.getConversionRules()]);
+            const assets = assetsRes.data || [];
+            const rules = rulesRes.data || [];
+            const badges = { active: 'badge-success', inactive: 'badge-error' };
+
+            document.querySelector('#assetsTable tbody').innerHTML = assets.map(a => `
+                <tr>
+                    <td><code>${a.code}</code></td>
+                    <td><strong>${a.name}</strong><br><small>${a.description || ''}</small></td>
+                    <td><small>${(a.merchant_ids || []).length > 0 ? a.merchant_ids.join(', ') : 'Semua'}</small></td>
+                    <td><span class="badge ${badges[a.status]}">${a.status}</span></td>
+                    <td>${a.code === 'points' ? '-' : `
+                        <button class="btn btn-sm btn-secondary" onclick="AdminController.updateAsset(${a.id}, { status: '${a.status === 'active' ? 'inactive' : 'active'}' })">${a.status === 'active' ? 'Nonaktifkan' : 'Aktifkan'}</button>`}
+                    </td>
+                </tr>
+            `).join('') || '<tr><td colspan="5" class="text-center">Belum ada jenis poin</td></tr>';
+
+            const options = assets.filter(a => a.status === 'active').map(a => `<option value="${a.code}">${a.name}</option>`).join('');
+            const form = document.getElementById('conversionForm');
+            form.from_asset.innerHTML = options;
+            form.to_asset.innerHTML = options;
+
+            document.querySelector('#conversionsTable tbody').innerHTML = rules.map(r => `
+                <tr>
+                    <td><strong>${r.from_amount} ${r.from_asset} → ${r.to_amount} ${r.to_asset}</strong></td>
+                    <td><span class="badge ${badges[r.status]}">${r.status}</span></td>
+                    <td><button class="btn btn-sm btn-secondary" onclick="AdminController.updateConversion(${r.id}, { status: '${r.status === 'active' ? 'inactive' : 'active'}' })">${r.status === 'active' ? 'Nonaktifkan' : 'Aktifkan'}</button></td>
+                </tr>
+            `).join('') || '<tr><td colspan="3" class="text-center">Belum ada nilai tukar</td></tr>';
+        } catch (e) { console.error(e); }
+    }
+
+    static async handleCreateAsset(e) {
+        e.preventDefault();
+        const data = Object.fromEntries(new FormData(e.target).entries());
+        data.merchant_ids = data.merchant_ids.split(',').map(id => parseInt(id)).filter(id => id > 0);
+        try {
+            await API.createAsset(data);
+            showToast('Jenis poin ditambahkan');
+            this.renderUsers('assets');
+        } catch (err) { showToast(err.message, 'error'); }
+    }
+
+    static async updateAsset(id, data) {
+        try {
+            await API.updateAsset(id, data);
+            showToast('Jenis poin diperbarui');
+            this.renderUsers('assets');
+        } catch (e) { showToast(e.message, 'error'); }
+    }
+
+    static async handleCreateConversion(e) {
+        e.preventDefault();
+        const data = Object.fromEntries(new FormData(e.target).entries());
+        data.from_amount = parseInt(data.from_amount); data.to_amount = parseInt(data.to_amount);
+        try {
+            await API.createConversionRule(data);
+            showToast('Nilai tukar ditambahkan');
+            this.renderUsers('assets');
+        } catch (err) { showToast(err.message, 'error'); }
+    }
+
+    static async updateConversion(id, data) {
+        try {
+            await API.updateConversionRule(id, data);
+            showToast('Nilai tukar diperbarui');
+            this.renderUsers('assets');
+        } catch (e) { showToast(e.message, 'error'); }
+    }
+
     // ==========================
     // MODULE: DATA PRODUK (Integrated)
     // ==========================
@@ -602,6 +855,8 @@ class AdminController {
     static async showProductModal(id = null) {
         let product = null;
         if (id) { try { const res = await API.request(`/admin/products/${id}`, 'GET'); product = res.data; } catch (e) { console.error(e); } }
+        let assets = [];
+        try { const res = await API.getAssets(); assets = (res.data || []).filter(a => a.status === 'active'); } catch (e) { console.error(e); }
         const modalHtml = `
             <div class="modal-overlay" onclick="closeModal(event)">
                 <div class="modal-card">
@@ -614,6 +869,12 @@ class AdminController {
                                 <div class="form-group"><label>Harga</label><input type="number" name="price" value="${product?.price || ''}" required min="1"></div>
                                 <div class="form-group"><label>Stok</label><input type="number" name="stock" value="${product?.stock || 0}" required min="0"></div>
                             </div>
+                            <div class="form-group">
+                                <label>Dibayar dengan</label>
+                                <select name="asset">
+                                    ${assets.map(a => `<option value="${a.code}" ${(product?.asset || 'points') === a.code ? 'selected' : ''}>${a.name}</option>`).join('')}
+                                </select>
+                            </div>
                             <div class="form-group">
                                 <label>URL Gambar (Opsional)</label>
                                 <input type="text" name="image_url" value="${product?.image_url || ''}" placeholder="https://example.com/image.jpg">
@@ -57,6 +57,10 @@ class API {
         return API.request('/auth/profile', 'PUT', data);
     }
 
+    static async updatePrivacy(data) {
+        return API.request('/auth/privacy', 'PUT', data);
+    }
+
     static async updatePassword(data) {
         return API.request('/auth/password', 'PUT', data);
     }
@@ -144,6 +148,54 @@ class API {
         return API.request('/admin/transfers', 'GET', null, params);
     }
 
+    static async getFlaggedTransfers(params = {}) {
+        return API.request('/admin/transfers/flagged', 'GET', null, params);
+    }
+
+    static async reviewTransferFlag(id, data) {
+        return API.request(`/admin/transfers/flagged/${id}/review`, 'POST', data);
+    }
+
+    static async getEscrowDisputes(params = {}) {
+        return API.request('/admin/transfers/disputes', 'GET', null, params);
+    }
+
+    static async resolveEscrowDispute(id, data) {
+        return API.request(`/admin/transfers/disputes/${id}/resolve`, 'POST', data);
+    }
+
+    static async getTransferRules() {
+        return API.request('/admin/transfer-rules', 'GET');
+    }
+
+    static async updateTransferRules(data) {
+        return API.request('/admin/transfer-rules', 'PUT', data);
+    }
+
+    static async getAssets() {
+        return API.request('/assets', 'GET');
+    }
+
+    static async createAsset(data) {
+        return API.request('/admin/assets', 'POST', data);
+    }
+
+    static async updateAsset(id, data) {
+        return API.request(`/admin/assets/${id}`, 'PUT', data);
+    }
+
+    static async getConversionRules() {
+        return API.request('/admin/asset-conversions', 'GET');
+    }
+
+    static async createConversionRule(data) {
+        return API.request('/admin/asset-conversions', 'POST', data);
+    }
+
+    static async updateConversionRule(id, data) {
+        return API.request(`/admin/asset-conversions/${id}`, 'PUT', data);
+    }
+
     static async getMarketplaceTransactions(params = {}) {
         return API.request('/admin/marketplace/transactions', 'GET', null, params);
     }
@@ -174,6 +226,10 @@ class API {
         return API.request('/mahasiswa/transfer', 'POST', data);
     }
 
+    static async findRecipient(q) {
+        return API.request('/mahasiswa/transfer/recipient', 'GET', null, { q });
+    }
+
     static async lookupUser(id) {
         return API.request(`/mahasiswa/users/lookup`, 'GET', null, { id });
     }
@@ -182,6 +238,53 @@ class API {
         return API.request('/mahasiswa/transfer/history', 'GET', null, params);
     }
 
+    static async createEscrow(data) {
+        return API.request('/mahasiswa/transfer/escrows', 'POST', data);
+    }
+
+    static async getEscrows(params = {}) {
+        return API.request('/mahasiswa/transfer/escrows', 'GET', null, params);
+    }
+
+    static async changeEscrow(id, action, data = null) {
+        return API.request(`/mahasiswa/transfer/escrows/${id}/${action}`, 'POST', data);
+    }
+
+    static async createScheduledTransfer(data) {
+        return API.request('/mahasiswa/transfer/schedules', 'POST', data);
+    }
+
+    static async getScheduledTransfers(params = {}) {
+        return API.request('/mahasiswa/transfer/schedules', 'GET', null, params);
+    }
+
+    static async changeScheduledTransfer(id, action) {
+        return API.request(`/mahasiswa/transfer/schedules/${id}/${action}`, 'POST');
+    }
+
+    static async createPointRequest(data) {
+        return API.request('/mahasiswa/transfer/requests', 'POST', data);
+    }
+
+    static async getPointRequests(direction, params = {}) {
+        return API.request(`/mahasiswa/transfer/requests/${direction}`, 'GET', null, params);
+    }
+
+    static async answerPointRequest(id, action) {
+        return API.request(`/mahasiswa/transfer/requests/${id}/${action}`, 'POST');
+    }
+
+    // ========================================
+    // NOTIFICATIONS (all roles)
+    // ========================================
+    static async getNotifications(params = {}) {
+        return API.request('/notifications', 'GET', null, params);
+    }
+
+    static async markAllNotificationsRead() {
+        return API.request('/notifications/read-all', 'POST');
+    }
+
     // ========================================
     // DOSEN: Mission Management
     // ========================================
@@ -209,6 +312,34 @@ class API {
         return API.request(`/dosen/submissions/${id}/review`, 'POST', data);
     }
 
+    static async overrideSubmission(id, data) {
+        return API.request(`/dosen/submissions/${id}/override`, 'POST', data);
+    }
+
+    static async getQuizDraw(missionId, studentId) {
+        return API.request(`/dosen/missions/${missionId}/draws/${studentId}`, 'GET');
+    }
+
+    static async getMissionPeerReviews(missionId) {
+        return API.request(`/dosen/missions/${missionId}/peer-reviews`, 'GET');
+    }
+
+    static async getBankQuestions(params = {}) {
+        return API.request('/dosen/question-bank', 'GET', null, params);
+    }
+
+    static async createBankQuestion(data) {
+        return API.request('/dosen/question-bank', 'POST', data);
+    }
+
+    static async updateBankQuestion(id, data) {
+        return API.request(`/dosen/question-bank/${id}`, 'PUT', data);
+    }
+
+    static async deleteBankQuestion(id) {
+        return API.request(`/dosen/question-bank/${id}`, 'DELETE');
+    }
+
     static async getDosenStats() {
         return API.request('/dosen/stats', 'GET');
     }
@@ -240,6 +371,30 @@ class API {
         return API.request('/mahasiswa/missions/submit', 'POST', data);
     }
 
+    static async getSubmissionHistory(missionId) {
+        return API.request(`/mahasiswa/missions/${missionId}/submissions`, 'GET');
+    }
+
+    static async startQuiz(missionId) {
+        return API.request(`/mahasiswa/missions/${missionId}/start`, 'POST');
+    }
+
+    static async saveQuizAnswers(missionId, answers) {
+        return API.request(`/mahasiswa/missions/${missionId}/session`, 'PUT', { answers });
+    }
+
+    static async getPeerReviews(params = {}) {
+        return API.request('/mahasiswa/reviews', 'GET', null, params);
+    }
+
+    static async getReceivedPeerReviews() {
+        return API.request('/mahasiswa/reviews/received', 'GET');
+    }
+
+    static async submitPeerReview(id, data) {
+        return API.request(`/mahasiswa/reviews/${id}`, 'POST', data);
+    }
+
     static async getSubmissions(params = {}) {
         // Mahasiswa looking at history
         return API.request('/mahasiswa/submissions', 'GET', null, params);
@@ -265,6 +420,14 @@ class API {
         }
     }
 
+    static async getMyConversions() {
+        return API.request('/mahasiswa/wallet/conversions', 'GET');
+    }
+
+    static async convertPoints(data) {
+        return API.request('/mahasiswa/wallet/convert', 'POST', data);
+    }
+
     static async purchaseProduct(data) {
         return API.request('/mahasiswa/marketplace/purchase', 'POST', data);
     }
@@ -277,10 +440,108 @@ class API {
         return API.request(`/payment/status/${token}`, 'GET');
     }
 
+    // Calls onStatus(data) on every status change of a payment token.
+    // Uses the SSE stream when available and falls back to polling.
+    // Returns a function that stops watching.
+    static watchTokenStatus(token, onStatus, pollInterval = 5000) {
+        let stopped = false;
+        let timer = null;
+        let source = null;
+
+        const poll = () => {
+            timer = setInterval(async () => {
+                try {
+                    const res = await API.checkTokenStatus(token);
+                    onStatus(res.data);
+                } catch (e) {
+                    console.error("Polling error:", e);
+                }
+            }, pollInterval);
+        };
+
+        if (window.EventSource) {
+            source = new EventSource(`${CONFIG.API_BASE_URL}/payment/status/${token}/stream`);
+            source.addEventListener('status', e => {
+                const data = JSON.parse(e.data);
+                onStatus(data);
+                if (data.status !== 'active') source.close();
+            });
+            source.onerror = () => {
+                // Stream unavailable (old backend or proxy), poll instead
+                if (source.readyState === EventSource.CLOSED && !stopped && !timer) poll();
+            };
+        } else {
+            poll();
+        }
+
+        return () => {
+            stopped = true;
+            if (source) source.close();
+            if (timer) clearInterval(timer);
+        };
+    }
+
     static async executePayment(token) {
         return API.request('/mahasiswa/payment/execute', 'POST', { token });
     }
 
+    static async payBill(token, amount = null) {
+        return API.request(`/mahasiswa/bills/${encodeURIComponent(token)}/pay`, 'POST', amount ? { amount } : {});
+    }
+
+    static async createBill(data) {
+        return API.request('/merchant/bills', 'POST', data);
+    }
+
+    static async getMerchantProfile() {
+        return API.request('/merchant/profile', 'GET');
+    }
+
+    static async updateMerchantProfile(data) {
+        return API.request('/merchant/profile', 'PUT', data);
+    }
+
+    static async createTerminal(data) {
+        return API.request('/merchant/terminals', 'POST', data);
+    }
+
+    static async updateTerminal(id, data) {
+        return API.request(`/merchant/terminals/${id}`, 'PUT', data);
+    }
+
+    static async createCashier(data) {
+        return API.request('/merchant/cashiers', 'POST', data);
+    }
+
+    static async deactivateCashier(id) {
+        return API.request(`/merchant/cashiers/${id}`, 'DELETE');
+    }
+
+    static async getMerchantSettlements(params = {}) {
+        return API.request('/merchant/settlements', 'GET', null, params);
+    }
+
+    // Downloads a settlement statement (csv or pdf) through the authenticated API
+    static async downloadStatement(batchId, format = 'csv') {
+        const response = await fetch(`${CONFIG.API_BASE_URL}/merchant/settlements/${batchId}/statement?format=${format}`, {
+            headers: API.getHeaders()
+        });
+        if (!response.ok) {
+            throw new Error('Gagal mengunduh laporan');
+        }
+
+        const blob = await response.blob();
+        const link = document.createElement('a');
+        link.href = URL.createObjectURL(blob);
+        link.download = `settlement-${batchId}.${format}`;
+        link.click();
+        URL.revokeObjectURL(link.href);
+    }
+
+    static async getQRVerificationKeys() {
+        return API.request('/payment/keys', 'GET');
+    }
+
     static async syncExternalPoints(data) {
         return API.request('/mahasiswa/external/sync', 'POST', data);
     }
@@ -344,3 +605,55 @@ class API {
         }
     }
 }
+
+/* Payment QR payloads: signed "WPT1.<claims>.<signature>" and legacy "WPT:token:amount:merchant" */
+class QRPayload {
+    static parse(text) {
+        if (text.startsWith('WPT1.')) {
+            const parts = text.split('.');
+            if (parts.length !== 3) return null;
+            try {
+                const claims = JSON.parse(atob(parts[1].replace(/-/g, '+').replace(/_/g, '/')));
+                return { token: claims.t, amount: claims.a, merchant: claims.m || 'Merchant', expiry: claims.e, signed: true, raw: text };
+            } catch (e) {
+                return null;
+            }
+        }
+        if (text.startsWith('WPT:')) {
+            const parts = text.split(':');
+            if (parts.length < 3) return null;
+            return { token: parts[1], amount: parseInt(parts[2]), merchant: parts[3] || 'Merchant', signed: false, raw: text };
+        }
+        return null;
+    }
+
+    // Verifies a signed payload offline with the cached public key.
+    // Returns true/false, or null when the browser cannot check Ed25519.
+    static async verify(text) {
+        if (!window.crypto || !crypto.subtle) return null;
+
+        let keys = JSON.parse(localStorage.getItem('qrVerificationKeys') || 'null');
+        if (!keys) {
+            try {
+                keys = (await API.getQRVerificationKeys()).data.keys;
+                localStorage.setItem('qrVerificationKeys', JSON.stringify(keys));
+            } catch (e) {
+                return null; // Offline and nothing cached yet
+            }
+        }
+
+        const dot = text.lastIndexOf('.');
+        const sig = Uint8Array.from(atob(text.substring(dot + 1).replace(/-/g, '+').replace(/_/g, '/')), c => c.charCodeAt(0));
+        const data = new TextEncoder().encode(text.substring(0, dot));
+
+        try {
+            for (const jwk of keys) {
+                const key = await crypto.subtle.importKey('jwk', { kty: jwk.kty, crv: jwk.crv, x: jwk.x }, { name: 'Ed25519' }, false, ['verify']);
+                if (await crypto.subtle.verify({ name: 'Ed25519' }, key, sig, data)) return true;
+            }
+            return false;
+        } catch (e) {
+            return null; // Ed25519 not supported by this browser
+        }
+    }
+}
@@ -17,6 +17,7 @@ document.addEventListener('DOMContentLoaded', async () => {
         // Initialize Navigation and View
         renderNavigation(user.role);
         handleNavigation('dashboard', user.role);
+        loadNotificationCount();
 
     } catch (error) {
         console.error('Dashboard Init Error:', error);
@@ -30,6 +31,44 @@ function updateUserProfile(user) {
     document.getElementById('userAvatar').textContent = (user.full_name || user.email).charAt(0).toUpperCase();
 }
 
+async function loadNotificationCount() {
+    try {
+        const res = await API.getNotifications({ unread: true, limit: 1 });
+        document.getElementById('notificationCount').textContent = res.data.unread > 0 ? res.data.unread : '';
+    } catch (e) {
+        console.error('Notification count error:', e);
+    }
+}
+
+async function showNotifications() {
+    try {
+        const res = await API.getNotifications({ limit: 20 });
+        const items = res.data.notifications.map(n => `
+            <div style="padding: 0.75rem 0; border-bottom: 1px solid var(--border); ${n.read_at ? 'opacity: 0.6;' : ''}">
+                <div style="font-weight: 600;">${n.title}</div>
+                <div style="color: var(--text-muted); font-size: 0.9rem;">${n.message}</div>
+                <small style="color: var(--text-muted);">${new Date(n.created_at).toLocaleString()}</small>
+            </div>
+        `).join('');
+
+        document.body.insertAdjacentHTML('beforeend', `
+            <div class="modal-overlay" onclick="closeModal(event)">
+                <div class="modal-card">
+                    <div class="modal-head"><h3>Notifikasi</h3><button class="btn-icon" onclick="closeModal()">×</button></div>
+                    <div class="modal-body">${items || '<p style="color: var(--text-muted);">Belum ada notifikasi</p>'}</div>
+                </div>
+            </div>
+        `);
+
+        if (res.data.unread > 0) {
+            await API.markAllNotificationsRead();
+            document.getElementById('notificationCount').textContent = '';
+        }
+    } catch (e) {
+        showToast('Gagal memuat notifikasi: ' + e.message, 'error');
+    }
+}
+
 function showToast(message, type = 'success') {
     let container = document.getElementById('toast-container');
     if (!container) {
@@ -76,6 +115,7 @@ function renderNavigation(role) {
         items.push(
             { label: 'Dashboard', href: '#dashboard', active: true },
             { label: 'Buat Quis', href: '#quizzes' },
+            { label: 'Bank Soal', href: '#question-bank' },
             { label: 'Buat Misi', href: '#missions' },
             { label: 'Approval', href: '#submissions' },
             { label: 'Data Siswa', href: '#dosen-students' }
@@ -85,13 +125,17 @@ function renderNavigation(role) {
             { label: 'Dashboard', href: '#dashboard', active: true },
             { label: 'Pindai QR', href: '#scan' },
             { label: 'Misi', href: '#missions' },
+            { label: 'Review Teman', href: '#reviews' },
             { label: 'MarketPlace', href: '#shop' },
             { label: 'Transfer Poin', href: '#transfer' },
             { label: 'Wallet', href: '#history' }
         );
     } else if (role === 'merchant') {
         items.push(
-            { label: 'Dashboard', href: '#merchant-dashboard', active: true }
+            { label: 'Dashboard', href: '#merchant-dashboard', active: true },
+            { label: 'Buat Tagihan', href: '#merchant-bill' },
+            { label: 'Settlement', href: '#merchant-settlements' },
+            { label: 'Toko', href: '#merchant-profile' }
         );
     }
 
@@ -155,6 +199,9 @@ function handleNavigation(target, role) {
             case 'quizzes':
                 DosenController.renderQuizzes();
                 break;
+            case 'question-bank':
+                DosenController.renderQuestionBank();
+                break;
             case 'missions':
                 DosenController.renderMissions();
                 break;
@@ -179,6 +226,9 @@ function handleNavigation(target, role) {
             case 'missions':
                 MahasiswaController.renderMissions();
                 break;
+            case 'reviews':
+                MahasiswaController.renderPeerReviews();
+                break;
             case 'shop':
                 MahasiswaController.renderShop();
                 break;
@@ -204,6 +254,18 @@ function handleNavigation(target, role) {
             case 'merchant-scanner':
                 MerchantController.renderMerchantScanner();
                 break;
+            case 'merchant-bill':
+                MerchantController.renderBillForm();
+                title.textContent = 'Buat Tagihan';
+                break;
+            case 'merchant-settlements':
+                MerchantController.renderSettlements();
+                title.textContent = 'Settlement';
+                break;
+            case 'merchant-profile':
+                MerchantController.renderMerchantProfile();
+                title.textContent = 'Profil Toko';
+                break;
             case 'profile':
                 ProfileController.renderProfile();
                 break;
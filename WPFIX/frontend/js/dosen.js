@@ -92,7 +92,7 @@ class DosenController {
                     </td>
                     <td>
                         <span class="badge" style="background: rgba(99, 102, 241, 0.1); color: var(--primary); border: 1px solid rgba(99, 102, 241, 0.2);">
-                            ${q.questions?.length || 0} Pertanyaan
+                            ${q.draw_count > 0 ? `${q.draw_count} Soal Acak dari Bank` : `${q.questions?.length || 0} Pertanyaan`}
                         </span>
                     </td>
                     <td>
@@ -184,6 +184,12 @@ class DosenController {
                                                 <input type="datetime-local" name="deadline" value="${quiz?.deadline ? new Date(quiz.deadline).toISOString().slice(0, 16) : ''}" style="border-radius: 10px;">
                                             </div>
                                         </div>
+                                        ${this.attemptPolicyFields(quiz)}
+                                        ${this.payoutFields(quiz)}
+                                        <div class="form-group">
+                                            <label style="font-weight: 600; color: var(--text-main);">Durasi Pengerjaan (menit, 0 = tanpa batas)</label>
+                                            <input type="number" name="time_limit_minutes" value="${quiz?.time_limit_minutes || 0}" min="0" max="600" style="border-radius: 10px;">
+                                        </div>
                                         <div style="background: rgba(99, 102, 241, 0.05); padding: 1rem; border-radius: var(--radius-md); font-size: 0.85rem; color: var(--primary); border: 1px dashed var(--primary-light);">
                                             <strong>Tip Pro:</strong> Kuis dengan poin lebih tinggi cenderung memiliki keterlibatan siswa yang lebih baik. Pastikan tenggat waktu masuk akal!
                                         </div>
@@ -191,8 +197,35 @@ class DosenController {
                                 </div>
                             </div>
 
+                            <!-- Question Bank Draw -->
+                            <div class="card" style="margin-bottom: 2rem; border-left: 4px solid var(--secondary); padding: 1.5rem;">
+                                <h4 style="margin: 0 0 0.25rem 0; color: var(--text-main);">🎲 Ambil Soal dari Bank</h4>
+                                <p style="margin: 0 0 1rem 0; font-size: 0.85rem; color: var(--text-muted);">Isi jumlah soal untuk memberi tiap siswa soal acak dari bank dengan urutan soal dan opsi yang diacak. Biarkan 0 untuk memakai soal di bawah.</p>
+                                <div style="display: grid; grid-template-columns: 1fr 2fr 1fr 1fr; gap: 1rem;">
+                                    <div class="form-group">
+                                        <label style="font-weight: 600; color: var(--text-main);">Jumlah Soal</label>
+                                        <input type="number" name="draw_count" value="${quiz?.draw_count || 0}" min="0" max="100" oninput="DosenController.onDrawCountChange()" style="border-radius: 10px;">
+                                    </div>
+                                    <div class="form-group">
+                                        <label style="font-weight: 600; color: var(--text-main);">Tag (pisahkan dengan koma)</label>
+                                        <input type="text" name="draw_tags" value="${(quiz?.draw_tags || []).join(', ')}" placeholder="misal, aljabar, persamaan" style="border-radius: 10px;">
+                                    </div>
+                                    <div class="form-group">
+                                        <label style="font-weight: 600; color: var(--text-main);">Mata Kuliah</label>
+                                        <input type="text" name="draw_course" value="${quiz?.draw_course || ''}" placeholder="Semua" style="border-radius: 10px;">
+                                    </div>
+                                    <div class="form-group">
+                                        <label style="font-weight: 600; color: var(--text-main);">Tingkat Kesulitan</label>
+                                        <select name="draw_difficulty" style="border-radius: 10px; background-color: #f8fafc;">
+                                            <option value="">Semua</option>
+                                            ${['easy', 'medium', 'hard'].map(d => `<option value="${d}" ${quiz?.draw_difficulty === d ? 'selected' : ''}>${this.difficultyLabel(d)}</option>`).join('')}
+                                        </select>
+                                    </div>
+                                </div>
+                            </div>
+
                             <!-- Questions Sections -->
-                            <div id="questionsContainer">
+                            <fieldset id="questionsContainer" style="border: none; padding: 0; margin: 0;">
                                 <div style="display: flex; justify-content: space-between; align-items: center; margin-bottom: 1.5rem; background: #fff; position: sticky; top: 0; z-index: 10; padding: 0.5rem 0;">
                                     <h3 style="margin:0; color: var(--text-main); display: flex; align-items: center; gap: 0.75rem;">
                                         <span style="background: var(--secondary); color: white; width: 28px; height: 28px; border-radius: 50%; display: flex; align-items: center; justify-content: center; font-size: 0.9rem;">?</span>
@@ -205,7 +238,7 @@ class DosenController {
                                 <div id="questionsList">
                                     <!-- Dynamic fields -->
                                 </div>
-                            </div>
+                            </fieldset>
                         </form>
                     </div>
 
@@ -238,6 +271,20 @@ class DosenController {
         } else {
             this.addQuestionField(null, 1);
         }
+        this.onDrawCountChange();
+    }
+
+    // A drawn quiz takes its questions from the bank, so the hand-written
+    // ones are hidden and left out of validation
+    static onDrawCountChange() {
+        const drawing = parseInt(document.querySelector('input[name="draw_count"]').value) > 0;
+        const container = document.getElementById('questionsContainer');
+        container.disabled = drawing;
+        container.style.display = drawing ? 'none' : 'block';
+    }
+
+    static difficultyLabel(difficulty) {
+        return { easy: 'Mudah', medium: 'Sedang', hard: 'Sulit' }[difficulty] || difficulty;
     }
 
     static addQuestionField(data = null, index = null) {
@@ -254,6 +301,12 @@ class DosenController {
         }
 
         const qId = Date.now() + Math.random().toString(16).slice(2);
+        const matchType = data?.match_type || 'exact';
+        const isChoice = matchType === 'exact' || matchType === 'multi_select';
+        let multiAnswers = [];
+        if (matchType === 'multi_select') {
+            try { multiAnswers = JSON.parse(data.answer); } catch (e) { multiAnswers = []; }
+        }
         const html = `
             <div class="question-item card fade-in" style="padding: 1.5rem; margin-bottom: 2rem; border: 1px solid var(--border); border-left: 5px solid var(--primary); box-shadow: var(--shadow-md); position: relative; border-radius: var(--radius-lg);" id="q-${qId}">
                 <div style="display: flex; justify-content: space-between; align-items: center; margin-bottom: 1.25rem;">
@@ -268,17 +321,32 @@ class DosenController {
                     </button>
                 </div>
 
-                <div style="display: grid; grid-template-columns: 1fr 1fr; gap: 1.5rem;">
+                <div style="display: grid; grid-template-columns: 2fr 1fr; gap: 1rem; margin-bottom: 1rem;">
+                    <select class="question-match" onchange="DosenController.onMatchTypeChange('${qId}')" style="border-radius: 10px;">
+                        <option value="exact" ${matchType === 'exact' ? 'selected' : ''}>Pilihan tunggal</option>
+                        <option value="multi_select" ${matchType === 'multi_select' ? 'selected' : ''}>Pilihan jamak (semua harus tepat)</option>
+                        <option value="case_insensitive" ${matchType === 'case_insensitive' ? 'selected' : ''}>Isian teks (abaikan huruf besar)</option>
+                        <option value="numeric" ${matchType === 'numeric' ? 'selected' : ''}>Isian angka</option>
+                    </select>
+                    <input type="number" class="question-weight" value="${data?.weight || 1}" min="1" max="100" title="Bobot" placeholder="Bobot" style="border-radius: 10px;">
+                </div>
+
+                <div class="question-free" style="display: ${isChoice ? 'none' : 'grid'}; grid-template-columns: 2fr 1fr; gap: 1rem;">
+                    <input type="text" class="question-answer" placeholder="Jawaban benar" value="${!isChoice ? (data?.answer || '') : ''}" ${!isChoice ? 'required' : ''} style="border-radius: 10px;">
+                    <input type="number" class="question-tolerance" placeholder="Toleransi ±" value="${data?.tolerance || ''}" min="0" step="any" style="border-radius: 10px; display: ${matchType === 'numeric' ? 'block' : 'none'};">
+                </div>
+
+                <div class="question-choices" style="display: ${isChoice ? 'grid' : 'none'}; grid-template-columns: 1fr 1fr; gap: 1.5rem;">
                     ${['A', 'B', 'C', 'D'].map((letter, i) => {
             const optionValue = data?.options ? (data.options[i] || '') : '';
-            const isCorrect = data?.answer === optionValue && optionValue !== '';
+            const isCorrect = optionValue !== '' && (matchType === 'multi_select' ? multiAnswers.includes(optionValue) : data?.answer === optionValue);
             return `
                         <div style="display: flex; align-items: center; gap: 0.75rem; background: #f8fafc; padding: 0.75rem 1rem; border-radius: 12px; border: 1px solid #e2e8f0; transition: all 0.2s;">
-                            <input type="radio" name="correct-${qId}" value="${i}" ${isCorrect ? 'checked' : (i === 0 && !data ? 'checked' : '')} 
+                            <input type="${matchType === 'multi_select' ? 'checkbox' : 'radio'}" name="correct-${qId}" value="${i}" ${isCorrect ? 'checked' : (i === 0 && !data ? 'checked' : '')} 
                                    style="width: 18px; height: 18px; cursor: pointer; accent-color: var(--primary);">
                             <div style="flex: 1; display: flex; align-items: center; gap: 0.5rem;">
                                 <span style="font-weight: 800; color: #64748b; min-width: 15px;">${letter}</span>
-                                <input type="text" class="question-option" placeholder="Option ${letter}" value="${optionValue}" required 
+                                <input type="text" class="question-option" placeholder="Option ${letter}" value="${optionValue}" ${isChoice ? 'required' : ''} 
                                        style="background: transparent; border: none; padding: 0.25rem 0; font-size: 0.95rem; width: 100%;"
                                        oninput="this.closest('div').parentElement.querySelector('input[type=radio]').value = ${i};">
                             </div>
@@ -288,7 +356,7 @@ class DosenController {
                 </div>
                 
                 <div style="margin-top: 1rem; font-size: 0.8rem; color: var(--text-muted); display: flex; align-items: center; gap: 0.5rem;">
-                    <span style="color: var(--success);">●</span> Pilih tombol radio di sebelah jawaban yang benar.
+                    <span style="color: var(--success);">●</span> Tandai jawaban yang benar, atau isi jawaban untuk soal isian.
                 </div>
             </div>
         `;
@@ -296,6 +364,21 @@ class DosenController {
         this.reindexQuestions();
     }
 
+    static onMatchTypeChange(qId) {
+        const el = document.getElementById(`q-${qId}`);
+        const matchType = el.querySelector('.question-match').value;
+        const isChoice = matchType === 'exact' || matchType === 'multi_select';
+
+        el.querySelector('.question-choices').style.display = isChoice ? 'grid' : 'none';
+        el.querySelector('.question-free').style.display = isChoice ? 'none' : 'grid';
+        el.querySelector('.question-tolerance').style.display = matchType === 'numeric' ? 'block' : 'none';
+        el.querySelectorAll('.question-option').forEach(o => o.required = isChoice);
+        el.querySelector('.question-answer').required = !isChoice;
+        el.querySelectorAll(`input[name="correct-${qId}"]`).forEach(input => {
+            input.type = matchType === 'multi_select' ? 'checkbox' : 'radio';
+        });
+    }
+
     static reindexQuestions() {
         const questions = document.querySelectorAll('.question-item');
         const counterElem = document.getElementById('qCount');
@@ -321,38 +404,61 @@ class DosenController {
         });
     }
 
+    // readQuestionField reads one question card into a question request
+    static readQuestionField(el) {
+        const question = el.querySelector('.question-text').value;
+        const matchType = el.querySelector('.question-match').value;
+        const weight = parseInt(el.querySelector('.question-weight').value) || 1;
+        const optionInputs = el.querySelectorAll('.question-option');
+        const qId = el.id.replace('q-', '');
+
+        if (matchType === 'case_insensitive' || matchType === 'numeric') {
+            const answer = el.querySelector('.question-answer').value.trim();
+            const tolerance = parseFloat(el.querySelector('.question-tolerance').value) || 0;
+            return { question, options: [], answer, match_type: matchType, tolerance, weight };
+        }
+
+        const options = Array.from(optionInputs).map(o => o.value).filter(v => v.trim() !== "");
+        const checked = Array.from(el.querySelectorAll(`input[name="correct-${qId}"]:checked`)).map(i => parseInt(i.value));
+
+        // The answer is the text of the selected option(s)
+        let answer;
+        if (matchType === 'multi_select') {
+            answer = JSON.stringify(checked.map(i => optionInputs[i]?.value).filter(v => v));
+        } else {
+            const selectedIdx = checked.length > 0 ? checked[0] : 0;
+            answer = optionInputs[selectedIdx] ? optionInputs[selectedIdx].value : "";
+        }
+
+        return { question, options, answer, match_type: matchType, weight };
+    }
+
     static async handleQuizSubmit(e, id) {
         e.preventDefault();
         const formData = new FormData(e.target);
         const data = Object.fromEntries(formData.entries());
         data.type = 'quiz';
         data.points = parseInt(data.points);
+        this.readPayout(data);
+        data.draw_count = parseInt(data.draw_count) || 0;
+        data.time_limit_minutes = parseInt(data.time_limit_minutes) || 0;
+        data.draw_tags = data.draw_tags.split(',').map(t => t.trim()).filter(t => t);
+        this.readAttemptPolicy(data);
 
         // Collect questions
         const questionsList = [];
-        document.querySelectorAll('.question-item').forEach(el => {
-            const question = el.querySelector('.question-text').value;
-            const optionInputs = el.querySelectorAll('.question-option');
-            const options = Array.from(optionInputs).map(o => o.value).filter(v => v.trim() !== "");
-
-            // Get selected radio button index
-            const qId = el.id.replace('q-', '');
-            const selectedRadio = el.querySelector(`input[name="correct-${qId}"]:checked`);
-            const selectedIdx = selectedRadio ? parseInt(selectedRadio.value) : 0;
-
-            // The answer is the text of the selected option
-            const answer = optionInputs[selectedIdx] ? optionInputs[selectedIdx].value : "";
+        const questionItems = data.draw_count > 0 ? [] : document.querySelectorAll('.question-item');
+        questionItems.forEach(el => questionsList.push(this.readQuestionField(el)));
 
-            questionsList.push({ question, options, answer });
-        });
-
-        if (questionsList.length === 0) {
+        if (data.draw_count > 0) {
+            delete data.questions;
+        } else if (questionsList.length === 0) {
             showToast("Setidaknya satu pertanyaan diperlukan", "error");
             return;
+        } else {
+            data.questions = questionsList;
         }
 
-        data.questions = questionsList;
-
         if (data.deadline) {
             data.deadline = new Date(data.deadline).toISOString();
         } else {
@@ -472,6 +578,11 @@ class DosenController {
                                     onclick="DosenController.renderSubmissions('pending', ${m.id})" title="Lihat Pengiriman">
                                 📊 Pengiriman
                             </button>
+                            ${m.peer_reviewers > 0 ? `
+                            <button class="btn btn-sm" style="background: #f1f5f9; border-radius: 12px; font-size: 0.75rem; padding: 0.4rem 0.8rem;"
+                                    onclick="DosenController.showPeerReviews(${m.id})" title="Review Teman">
+                                🤝 Review
+                            </button>` : ''}
                             <button class="btn-icon" style="background: #f1f5f9;" onclick="DosenController.showMissionModal(${m.id})" title="Sempurnakan Tugas">
                                 <span style="font-size: 0.9rem;">✏️</span>
                             </button>
@@ -496,10 +607,15 @@ class DosenController {
                 mission = res.data.missions.find(m => m.id === id);
             } catch (e) { console.error(e); }
         }
+        let assets = [];
+        try {
+            const res = await API.getAssets();
+            assets = (res.data || []).filter(a => a.status === 'active');
+        } catch (e) { console.error(e); }
 
         const modalHtml = `
             <div class="modal-overlay" onclick="closeModal(event)">
-                <div class="modal-card" style="max-width: 650px; width: 95%; overflow: hidden; border-radius: var(--radius-xl);">
+                <div class="modal-card" style="max-width: 800px; width: 95%; overflow: hidden; border-radius: var(--radius-xl);">
                     <div class="modal-head" style="background: var(--primary); color: white; padding: 1.5rem 2rem;">
                         <div>
                             <h3 style="margin:0; font-weight: 700;">${id ? '🛠️ Sempurnakan Misi' : '✨ Arsiteki Misi Baru'}</h3>
@@ -508,7 +624,7 @@ class DosenController {
                         <button class="btn-icon" onclick="closeModal()" style="color: white; font-size: 1.5rem;">×</button>
                     </div>
 
-                    <div class="modal-body" style="padding: 2rem;">
+                    <div class="modal-body" style="padding: 2rem; max-height: 80vh; overflow-y: auto;">
                         <form id="missionForm" onsubmit="DosenController.handleMissionSubmit(event, ${id})">
                             <div class="form-group">
                                 <label style="font-weight: 600; color: var(--text-main);">Nama Proyek / Judul Misi</label>
@@ -536,9 +652,30 @@ class DosenController {
                                 </div>
                             </div>
 
+                            ${this.attemptPolicyFields(mission)}
+
+                            <div class="form-group">
+                                <label style="font-weight: 600; color: var(--text-main);">Jenis Poin Hadiah</label>
+                                <select name="asset" style="border-radius: 10px; background-color: #f8fafc;">
+                                    ${assets.map(a => `<option value="${a.code}" ${(mission?.asset || 'points') === a.code ? 'selected' : ''}>${a.name}</option>`).join('')}
+                                </select>
+                            </div>
+
                             <div class="form-group">
                                 <label style="font-weight: 600; color: var(--text-main);">Tenggat Penyelesaian</label>
-                                <input type="datetime-local" name="deadline" value="${mission?.deadline ? new Date(mission.deadline).toISOString().slice(0, 16) : ''}" style="border-radius: 10px;">
+                                <input type="datetime-local" name="deadline" value="${mission?.deadline ? new Date(mission.deadline).toISOString().slice(0, 16) : ''}" ${mission?.peer_assigned_at ? 'disabled' : ''} style="border-radius: 10px;">
+                            </div>
+
+                            ${this.peerReviewFields(mission)}
+
+                            <div class="card" style="padding: 1.5rem; border-left: 4px solid var(--secondary);">
+                                <div style="display: flex; justify-content: space-between; align-items: center; margin-bottom: 0.5rem;">
+                                    <h4 style="margin: 0; color: var(--text-main);">📋 Rubrik Penilaian (opsional)</h4>
+                                    <button type="button" class="btn btn-secondary" onclick="DosenController.addRubricCriterion()" style="padding: 0.4rem 1rem; font-size: 0.85rem;">+ Kriteria</button>
+                                </div>
+                                <p style="margin: 0 0 1rem 0; font-size: 0.85rem; color: var(--text-muted);">Penilai memilih satu tingkat per kriteria; skor total (0–100) menentukan poin sesuai aturan pembayaran. Tanpa rubrik, misi yang lulus dibayar penuh.</p>
+                                <div id="rubricList"></div>
+                                ${this.payoutFields(mission)}
                             </div>
 
                             <div class="form-actions" style="margin-top: 2rem; border-top: 1px solid var(--border); padding-top: 1.5rem; display: flex; justify-content: flex-end; gap: 1rem;">
@@ -553,6 +690,151 @@ class DosenController {
             </div>
         `;
         document.body.insertAdjacentHTML('beforeend', modalHtml);
+        (mission?.rubric || []).forEach(c => this.addRubricCriterion(c));
+    }
+
+    static addRubricCriterion(criterion = null) {
+        const levels = (criterion?.levels || [
+            { label: 'Sangat Baik', points: 4 }, { label: 'Baik', points: 3 }, { label: 'Cukup', points: 2 }, { label: 'Kurang', points: 1 }
+        ]).map(l => `${l.label}=${l.points}`).join(', ');
+        document.getElementById('rubricList').insertAdjacentHTML('beforeend', `
+            <div class="rubric-criterion" style="display: grid; grid-template-columns: 2fr 80px 3fr auto; gap: 0.75rem; align-items: end; margin-bottom: 0.75rem;">
+                <div class="form-group" style="margin: 0;">
+                    <label style="font-size: 0.8rem;">Kriteria</label>
+                    <input type="text" class="rubric-name" value="${criterion?.name || ''}" required placeholder="misal, Analisis" style="border-radius: 10px;">
+                </div>
+                <div class="form-group" style="margin: 0;">
+                    <label style="font-size: 0.8rem;">Bobot</label>
+                    <input type="number" class="rubric-weight" value="${criterion?.weight || 1}" min="1" required style="border-radius: 10px;">
+                </div>
+                <div class="form-group" style="margin: 0;">
+                    <label style="font-size: 0.8rem;">Tingkat (label=poin, dipisah koma)</label>
+                    <input type="text" class="rubric-levels" value="${levels}" required style="border-radius: 10px;">
+                </div>
+                <button type="button" class="btn-icon" style="color: var(--error); background: rgba(239, 68, 68, 0.05);" onclick="this.closest('.rubric-criterion').remove()" title="Hapus Kriteria">&times;</button>
+            </div>
+        `);
+    }
+
+    static readRubric() {
+        return Array.from(document.querySelectorAll('.rubric-criterion')).map(el => ({
+            name: el.querySelector('.rubric-name').value.trim(),
+            weight: parseInt(el.querySelector('.rubric-weight').value) || 1,
+            levels: el.querySelector('.rubric-levels').value.split(',').map(l => l.trim()).filter(l => l).map(l => {
+                const [label, points] = l.split('=');
+                return { label: label.trim(), points: parseInt(points) || 0 };
+            })
+        }));
+    }
+
+    // payoutFields configures how a score pays: proportionally, in full from
+    // a pass score, or by score bands
+    static payoutFields(mission) {
+        const rule = mission?.payout_rule || 'proportional';
+        const bands = (mission?.payout_bands || []).map(b => `${b.min_score}=${b.percent}`).join(', ');
+        return `
+            <div style="display: grid; grid-template-columns: 1fr 1fr 1.5fr; gap: 1rem;">
+                <div class="form-group">
+                    <label style="font-weight: 600; color: var(--text-main);">Pembayaran Poin</label>
+                    <select name="payout_rule" style="border-radius: 10px; background-color: #f8fafc;">
+                        <option value="proportional" ${rule === 'proportional' ? 'selected' : ''}>Sebanding skor</option>
+                        <option value="threshold" ${rule === 'threshold' ? 'selected' : ''}>Penuh jika lulus</option>
+                        <option value="bands" ${rule === 'bands' ? 'selected' : ''}>Per rentang skor</option>
+                    </select>
+                </div>
+                <div class="form-group">
+                    <label style="font-weight: 600; color: var(--text-main);">Nilai Lulus (%)</label>
+                    <input type="number" name="pass_score" value="${mission?.pass_score || 0}" min="0" max="100" style="border-radius: 10px;">
+                </div>
+                <div class="form-group">
+                    <label style="font-weight: 600; color: var(--text-main);">Rentang (skor min=% poin)</label>
+                    <input type="text" name="payout_bands" value="${bands}" placeholder="misal, 85=100, 70=75, 55=50" style="border-radius: 10px;">
+                </div>
+            </div>
+        `;
+    }
+
+    static readPayout(data) {
+        data.pass_score = parseInt(data.pass_score) || 0;
+        data.payout_bands = (data.payout_bands || '').split(',').map(b => b.trim()).filter(b => b).map(b => {
+            const [minScore, percent] = b.split('=');
+            return { min_score: parseInt(minScore) || 0, percent: parseInt(percent) || 0 };
+        });
+    }
+
+    // peerReviewFields hands submissions to other students after the
+    // deadline. Once reviews are assigned their schedule is fixed.
+    static peerReviewFields(mission) {
+        const locked = mission?.peer_assigned_at ? 'disabled' : '';
+        return `
+            <div class="card" style="padding: 1.5rem; border-left: 4px solid var(--primary); margin-bottom: 1.5rem;">
+                <h4 style="margin: 0 0 0.5rem 0; color: var(--text-main);">🤝 Review Teman (opsional)</h4>
+                <p style="margin: 0 0 1rem 0; font-size: 0.85rem; color: var(--text-muted);">Setelah tenggat, setiap pengiriman dibagikan secara anonim ke beberapa mahasiswa lain. Skor akhir menggabungkan skor mereka; Anda tetap bisa menimpa nilainya.${locked ? ' Review sudah dibagikan, jadwalnya tidak bisa diubah.' : ''}</p>
+                <div style="display: grid; grid-template-columns: repeat(4, 1fr); gap: 1rem;">
+                    <div class="form-group">
+                        <label style="font-weight: 600; color: var(--text-main);">Reviewer / Tugas</label>
+                        <input type="number" name="peer_reviewers" value="${mission?.peer_reviewers || 0}" min="0" max="10" ${locked} style="border-radius: 10px;">
+                    </div>
+                    <div class="form-group">
+                        <label style="font-weight: 600; color: var(--text-main);">Hadiah / Review</label>
+                        <input type="number" name="peer_reward" value="${mission?.peer_reward || 0}" min="0" style="border-radius: 10px;">
+                    </div>
+                    <div class="form-group">
+                        <label style="font-weight: 600; color: var(--text-main);">Gabungan Skor</label>
+                        <select name="peer_aggregate" style="border-radius: 10px; background-color: #f8fafc;">
+                            <option value="median" ${mission?.peer_aggregate !== 'trimmed_mean' ? 'selected' : ''}>Median</option>
+                            <option value="trimmed_mean" ${mission?.peer_aggregate === 'trimmed_mean' ? 'selected' : ''}>Rata-rata terpangkas</option>
+                        </select>
+                    </div>
+                    <div class="form-group">
+                        <label style="font-weight: 600; color: var(--text-main);">Waktu Review (jam)</label>
+                        <input type="number" name="peer_review_hours" value="${mission?.peer_review_hours || 72}" min="1" max="720" ${locked} style="border-radius: 10px;">
+                    </div>
+                </div>
+            </div>
+        `;
+    }
+
+    static readPeerReview(data) {
+        data.peer_reward = parseInt(data.peer_reward) || 0;
+        if (data.peer_reviewers !== undefined) {
+            data.peer_reviewers = parseInt(data.peer_reviewers) || 0;
+            data.peer_review_hours = parseInt(data.peer_review_hours) || 72;
+        }
+    }
+
+    static attemptPolicyFields(mission) {
+        return `
+            <div style="display: grid; grid-template-columns: repeat(4, 1fr); gap: 1rem;">
+                <div class="form-group">
+                    <label style="font-weight: 600; color: var(--text-main);">Maks. Percobaan</label>
+                    <input type="number" name="max_attempts" value="${mission?.max_attempts || 1}" min="1" max="20" style="border-radius: 10px;">
+                </div>
+                <div class="form-group">
+                    <label style="font-weight: 600; color: var(--text-main);">Jeda (menit)</label>
+                    <input type="number" name="cooldown_minutes" value="${mission?.cooldown_minutes || 0}" min="0" max="10080" style="border-radius: 10px;">
+                </div>
+                <div class="form-group">
+                    <label style="font-weight: 600; color: var(--text-main);">Kirim Ulang</label>
+                    <select name="resubmit_policy" style="border-radius: 10px; background-color: #f8fafc;">
+                        <option value="after_rejection" ${mission?.resubmit_policy !== 'after_review' ? 'selected' : ''}>Hanya jika ditolak</option>
+                        <option value="after_review" ${mission?.resubmit_policy === 'after_review' ? 'selected' : ''}>Setelah dinilai</option>
+                    </select>
+                </div>
+                <div class="form-group">
+                    <label style="font-weight: 600; color: var(--text-main);">Skor Dihitung</label>
+                    <select name="score_rule" style="border-radius: 10px; background-color: #f8fafc;">
+                        <option value="latest" ${mission?.score_rule !== 'best' ? 'selected' : ''}>Terakhir</option>
+                        <option value="best" ${mission?.score_rule === 'best' ? 'selected' : ''}>Terbaik</option>
+                    </select>
+                </div>
+            </div>
+        `;
+    }
+
+    static readAttemptPolicy(data) {
+        data.max_attempts = parseInt(data.max_attempts) || 1;
+        data.cooldown_minutes = parseInt(data.cooldown_minutes) || 0;
     }
 
     static async handleMissionSubmit(e, id) {
@@ -567,6 +849,10 @@ class DosenController {
             return;
         }
         data.points = points;
+        this.readAttemptPolicy(data);
+        this.readPayout(data);
+        this.readPeerReview(data);
+        data.rubric = this.readRubric();
 
         if (data.deadline) {
             try {
@@ -593,6 +879,48 @@ class DosenController {
         }
     }
 
+    static async showPeerReviews(missionId) {
+        try {
+            const res = await API.getMissionPeerReviews(missionId);
+            const reviews = res.data || [];
+            const badges = { assigned: 'badge-warning', completed: 'badge-success', expired: 'badge-error' };
+
+            // Group the reviews by the submission they score
+            const bySubmission = {};
+            reviews.forEach(r => {
+                (bySubmission[r.submission_id] = bySubmission[r.submission_id] || []).push(r);
+            });
+
+            const modalHtml = `
+                <div class="modal-overlay" onclick="closeModal(event)">
+                    <div class="modal-card" style="max-width: 720px;">
+                        <div class="modal-head"><h3>🤝 Review Teman</h3><button class="btn-icon" onclick="closeModal()">×</button></div>
+                        <div class="modal-body">
+                            ${reviews.length === 0 ? `<p style="color: var(--text-muted); text-align: center; padding: 2rem;">Review belum dibagikan. Review dibagikan setelah tenggat misi berakhir.</p>` : ''}
+                            ${Object.values(bySubmission).map(group => `
+                                <div style="padding: 1rem 0; border-bottom: 1px solid var(--border);">
+                                    <div style="display: flex; justify-content: space-between; margin-bottom: 0.5rem;">
+                                        <strong>${group[0].student_name}</strong>
+                                        <button class="btn btn-sm" style="font-size: 0.75rem; padding: 0.3rem 0.8rem;" onclick="closeModal(); DosenController.showReviewModal(${group[0].submission_id})">Lihat / Timpa Nilai</button>
+                                    </div>
+                                    ${group.map(r => `
+                                        <div style="display: flex; justify-content: space-between; gap: 1rem; font-size: 0.85rem; padding: 0.25rem 0;">
+                                            <span>${r.reviewer_name}${r.comment ? ` — <em>${r.comment}</em>` : ''}</span>
+                                            <span><span class="badge ${badges[r.status]}">${r.status}</span> <strong>${r.status === 'completed' ? r.score : '-'}</strong></span>
+                                        </div>
+                                    `).join('')}
+                                </div>
+                            `).join('')}
+                        </div>
+                    </div>
+                </div>
+            `;
+            document.body.insertAdjacentHTML('beforeend', modalHtml);
+        } catch (error) {
+            showToast(error.message, 'error');
+        }
+    }
+
     static async deleteMission(id) {
         if (!confirm("Apakah Anda yakin ingin menghapus misi ini?")) return;
         try {
@@ -705,6 +1033,7 @@ class DosenController {
                         <div style="font-size: 0.85rem;">
                             <div style="color: var(--text-main);">${new Date(s.created_at).toLocaleDateString()}</div>
                             <small style="color: var(--text-muted);">${new Date(s.created_at).toLocaleTimeString([], { hour: '2-digit', minute: '2-digit' })}</small>
+                            ${s.started_at ? `<div style="margin-top: 0.25rem; font-size: 0.75rem; color: var(--text-muted);">⏱️ ${this.quizDuration(s)}</div>` : ''}
                         </div>
                     </td>
                     <td>
@@ -728,6 +1057,14 @@ class DosenController {
         }
     }
 
+    // quizDuration shows when a timed attempt started and finished
+    static quizDuration(s) {
+        const time = d => new Date(d).toLocaleTimeString([], { hour: '2-digit', minute: '2-digit', second: '2-digit' });
+        if (!s.finished_at) return `Mulai ${time(s.started_at)}`;
+        const seconds = Math.round((new Date(s.finished_at) - new Date(s.started_at)) / 1000);
+        return `${time(s.started_at)} – ${time(s.finished_at)} (${Math.floor(seconds / 60)}m ${seconds % 60}d)`;
+    }
+
     static async showReviewModal(id) {
         try {
             const resSub = await API.getDosenSubmissions();
@@ -737,6 +1074,17 @@ class DosenController {
             const resMission = await API.getMissionByID(submission.mission_id);
             const mission = resMission.data;
 
+            // A drawn quiz is graded against the student's own questions
+            let questions = mission.questions || [];
+            if (mission.type === 'quiz' && mission.draw_count > 0) {
+                try {
+                    const resDraw = await API.getQuizDraw(mission.id, submission.student_id);
+                    questions = resDraw.data.questions || [];
+                } catch (e) {
+                    console.error("Failed to load quiz draw", e);
+                }
+            }
+
             let artifactContent = '';
             if (mission.type === 'quiz') {
                 let answers = [];
@@ -762,9 +1110,10 @@ class DosenController {
                                     </tr>
                                 </thead>
                                 <tbody>
-                                    ${(mission.questions || []).map(q => {
+                                    ${questions.map(q => {
                     const studentAns = answers.find(a => a.question_id === q.id)?.answer || '-';
-                    const isCorrect = studentAns === q.answer;
+                    const grade = (submission.grade_details || []).find(g => g.question_id === q.id);
+                    const isCorrect = grade ? grade.correct : studentAns === q.answer;
                     return `
                                             <tr style="border-bottom: 1px solid #f1f5f9;">
                                                 <td style="padding: 1rem; font-size: 0.85rem; color: #1e293b; font-weight: 500;">${q.question}</td>
@@ -789,6 +1138,24 @@ class DosenController {
                 `;
             }
 
+            const isOverride = submission.status !== 'pending';
+            const isQuiz = mission.type === 'quiz';
+            const rubric = mission.rubric || [];
+            this.reviewRubric = rubric;
+            // Peer reviewed missions are scored out of 100 like quizzes
+            const isPeer = mission.peer_reviewers > 0;
+            let peerReviews = [];
+            if (isPeer) {
+                try {
+                    const resPeer = await API.getMissionPeerReviews(mission.id);
+                    peerReviews = (resPeer.data || []).filter(r => r.submission_id === submission.id);
+                } catch (e) {
+                    console.error("Failed to load peer reviews", e);
+                }
+            }
+            const approvedScore = isQuiz || isPeer ? 100 : mission.points;
+            const currentScore = isOverride ? submission.score : approvedScore;
+
             const modalHtml = `
                 <div class="modal-overlay" onclick="closeModal(event)">
                     <div class="modal-card" style="max-width: 850px; border-radius: var(--radius-xl); overflow: hidden; display: flex; flex-direction: column; max-height: 90vh;">
@@ -802,6 +1169,22 @@ class DosenController {
                         <div class="modal-body" style="padding: 2rem; background: #f8fafc; overflow-y: auto;">
                             <label style="font-size: 0.75rem; text-transform: uppercase; letter-spacing: 0.1em; color: var(--text-muted); font-weight: 800; display: block; margin-bottom: 0.75rem;">ARTEFAK PENGIRIMAN SISWA</label>
                             
+                            ${submission.auto_graded ? `
+                                <div style="background: rgba(99, 102, 241, 0.05); border: 1px dashed var(--primary-light); color: var(--primary); padding: 1rem; border-radius: 12px; margin-bottom: 1rem; font-size: 0.9rem;">
+                                    🤖 Dinilai otomatis: skor <strong>${submission.score}%</strong>, hadiah <strong>${submission.reward_amount}</strong> poin
+                                </div>` : ''}
+
+                            ${peerReviews.length > 0 ? `
+                                <div style="background: white; border: 1px solid #e2e8f0; border-radius: 12px; padding: 1rem; margin-bottom: 1rem;">
+                                    <strong style="display: block; margin-bottom: 0.5rem; color: #1e293b;">🤝 Review Teman (${mission.peer_aggregate === 'trimmed_mean' ? 'rata-rata terpangkas' : 'median'})</strong>
+                                    ${peerReviews.map(r => `
+                                        <div style="display: flex; justify-content: space-between; gap: 1rem; font-size: 0.85rem; padding: 0.25rem 0;">
+                                            <span>${r.reviewer_name}${r.comment ? ` — <em>${r.comment}</em>` : ''}</span>
+                                            <strong>${r.status === 'completed' ? r.score : r.status}</strong>
+                                        </div>
+                                    `).join('')}
+                                </div>` : ''}
+
                             ${artifactContent}
 
                             ${submission.file_url ? (() => {
@@ -818,32 +1201,40 @@ class DosenController {
                 })() : ''}
 
                             <div style="margin-top: 2rem; padding-top: 2rem; border-top: 2px dashed #e2e8f0;">
-                                <form id="reviewForm" onsubmit="DosenController.handleReviewSubmit(event, ${id}, ${mission.points})">
+                                <form id="reviewForm" onsubmit="DosenController.handleReviewSubmit(event, ${id}, ${isOverride})">
                                     <div style="text-align:center; margin-bottom:1.5rem;">
-                                        <h4 style="margin-bottom:0.5rem; color:var(--text-main);">Keputusan Validasi</h4>
-                                        <p style="color:var(--text-muted); font-size:0.9rem;">Tentukan apakah siswa lulus misi ini. Poin akan otomatis diberikan jika lulus.</p>
+                                        <h4 style="margin-bottom:0.5rem; color:var(--text-main);">${isOverride ? 'Ubah Penilaian' : 'Keputusan Validasi'}</h4>
+                                        <p style="color:var(--text-muted); font-size:0.9rem;">${isOverride
+                    ? 'Nilai baru menggantikan penilaian sebelumnya. Selisih hadiah akan ditambahkan atau ditarik kembali.'
+                    : 'Tentukan apakah siswa lulus misi ini. Poin akan otomatis diberikan jika lulus.'}</p>
                                     </div>
 
                                     <div style="display: flex; gap: 1rem; justify-content: center; margin-bottom: 2rem;">
                                         <label style="cursor: pointer; flex: 1;">
-                                            <input type="radio" name="status" value="rejected" style="display:none;" onchange="document.getElementById('scoreInput').value = 0; document.querySelectorAll('.review-opt').forEach(e=>e.classList.remove('active')); this.parentElement.querySelector('div').classList.add('active');">
-                                            <div class="review-opt" style="padding: 1.5rem; border: 2px solid #e2e8f0; border-radius: 16px; text-align: center; transition:all 0.2s;">
+                                            <input type="radio" name="status" value="rejected" ${isOverride && submission.status === 'rejected' ? 'checked' : ''} style="display:none;" onchange="document.getElementById('scoreInput').value = 0; document.querySelectorAll('.review-opt').forEach(e=>e.classList.remove('active')); this.parentElement.querySelector('div').classList.add('active');">
+                                            <div class="review-opt ${isOverride && submission.status === 'rejected' ? 'active' : ''}" style="padding: 1.5rem; border: 2px solid #e2e8f0; border-radius: 16px; text-align: center; transition:all 0.2s;">
                                                 <div style="font-size: 2rem; margin-bottom: 0.5rem;">❌</div>
                                                 <div style="font-weight: 700; color:var(--text-muted);">Perlu Perbaikan</div>
                                                 <small>0 Poin</small>
                                             </div>
                                         </label>
                                         <label style="cursor: pointer; flex: 1;">
-                                            <input type="radio" name="status" value="approved" checked style="display:none;" onchange="document.getElementById('scoreInput').value = ${mission.points}; document.querySelectorAll('.review-opt').forEach(e=>e.classList.remove('active')); this.parentElement.querySelector('div').classList.add('active');">
-                                            <div class="review-opt active" style="padding: 1.5rem; border: 2px solid var(--success); background:rgba(16, 185, 129, 0.05); border-radius: 16px; text-align: center; transition:all 0.2s;">
+                                            <input type="radio" name="status" value="approved" ${!isOverride || submission.status === 'approved' ? 'checked' : ''} style="display:none;" onchange="document.getElementById('scoreInput').value = ${approvedScore}; document.querySelectorAll('.review-opt').forEach(e=>e.classList.remove('active')); this.parentElement.querySelector('div').classList.add('active');">
+                                            <div class="review-opt ${!isOverride || submission.status === 'approved' ? 'active' : ''}" style="padding: 1.5rem; border: 2px solid var(--success); background:rgba(16, 185, 129, 0.05); border-radius: 16px; text-align: center; transition:all 0.2s;">
                                                 <div style="font-size: 2rem; margin-bottom: 0.5rem;">✅</div>
                                                 <div style="font-weight: 700; color:var(--success);">Lulus & Valid</div>
-                                                <small>${mission.points} Poin</small>
+                                                <small>${isQuiz || isPeer || rubric.length > 0 ? `Hingga ${mission.points}` : mission.points} Poin</small>
                                             </div>
                                         </label>
                                     </div>
 
-                                    <input type="hidden" name="score" id="scoreInput" value="${mission.points}">
+                                    ${rubric.length > 0 ? this.rubricReviewFields(rubric, submission.rubric_result || []) : ''}
+
+                                    ${isQuiz || (isPeer && rubric.length === 0) ? `
+                                    <div class="form-group">
+                                        <label style="font-weight: 700; color: #1e293b;">Skor (%)</label>
+                                        <input type="number" name="score" id="scoreInput" value="${currentScore}" min="0" max="100" style="border-radius: 12px;">
+                                    </div>` : `<input type="hidden" name="score" id="scoreInput" value="${currentScore}">`}
 
                                     <div class="form-group">
                                         <label style="font-weight: 700; color: #1e293b;">Catatan Evaluasi / Feedback (Opsional)</label>
@@ -865,20 +1256,68 @@ class DosenController {
                 </div>
             `;
             document.body.insertAdjacentHTML('beforeend', modalHtml);
+            if (rubric.length > 0) this.updateRubricTotal();
         } catch (error) {
             console.error(error);
             showToast("Gagal memuat detail pemeriksaan", "error");
         }
     }
 
-    static async handleReviewSubmit(e, id, missionPoints) {
+    static rubricReviewFields(rubric, previous) {
+        return `
+            <div style="background: white; border: 1px solid #e2e8f0; border-radius: 12px; padding: 1rem; margin-bottom: 1.5rem;">
+                <div style="display: flex; justify-content: space-between; align-items: center; margin-bottom: 0.75rem;">
+                    <strong style="color: #1e293b;">📋 Rubrik Penilaian</strong>
+                    <span style="font-weight: 700; color: var(--primary);">Skor: <span id="rubricTotal">0</span>/100</span>
+                </div>
+                ${rubric.map(c => {
+            const prev = previous.find(r => r.criterion === c.name);
+            return `
+                    <div style="display: grid; grid-template-columns: 1.5fr 1.5fr 2fr; gap: 0.75rem; align-items: center; margin-bottom: 0.5rem;">
+                        <div><strong>${c.name}</strong> <small style="color: var(--text-muted);">×${c.weight}</small></div>
+                        <select class="rubric-pick" data-criterion="${c.name}" onchange="DosenController.updateRubricTotal()" style="border-radius: 10px;">
+                            ${c.levels.map(l => `<option value="${l.label}" ${prev?.level === l.label ? 'selected' : ''}>${l.label} (${l.points})</option>`).join('')}
+                        </select>
+                        <input type="text" class="rubric-comment" value="${prev?.comment || ''}" placeholder="Komentar (opsional)" style="border-radius: 10px;">
+                    </div>`;
+        }).join('')}
+            </div>
+        `;
+    }
+
+    // updateRubricTotal mirrors the server's rubric score: each criterion's
+    // level as a share of its best level, weighted
+    static updateRubricTotal() {
+        let earned = 0, total = 0;
+        document.querySelectorAll('.rubric-pick').forEach(sel => {
+            const c = this.reviewRubric.find(r => r.name === sel.dataset.criterion);
+            const best = Math.max(...c.levels.map(l => l.points));
+            const level = c.levels.find(l => l.label === sel.value);
+            earned += c.weight * level.points / best;
+            total += c.weight;
+        });
+        const score = total > 0 ? Math.floor(earned * 100 / total) : 0;
+        document.getElementById('rubricTotal').textContent = score;
+        document.getElementById('scoreInput').value = score;
+    }
+
+    static async handleReviewSubmit(e, id, isOverride) {
         e.preventDefault();
         const formData = new FormData(e.target);
         const data = Object.fromEntries(formData.entries());
         data.score = parseInt(data.score);
+        const picks = e.target.querySelectorAll('.rubric-pick');
+        if (picks.length > 0) {
+            data.rubric_scores = Array.from(picks).map((sel, i) => ({
+                criterion: sel.dataset.criterion,
+                level: sel.value,
+                comment: e.target.querySelectorAll('.rubric-comment')[i].value.trim()
+            }));
+        }
 
         try {
-            await API.reviewSubmission(id, data);
+            if (isOverride) await API.overrideSubmission(id, data);
+            else await API.reviewSubmission(id, data);
             showToast("Ulasan berhasil dikirim ✨");
             closeModal();
             DosenController.renderSubmissions();
@@ -887,6 +1326,184 @@ class DosenController {
         }
     }
 
+    // ==========================
+    // MODULE: QUESTION BANK
+    // ==========================
+    static async renderQuestionBank(filters = {}) {
+        const content = document.getElementById('mainContent');
+        content.innerHTML = `
+            <div class="fade-in">
+                <div class="table-header" style="display: flex; justify-content: space-between; align-items: center; margin-bottom: 2rem;">
+                    <div>
+                        <h2 style="font-weight: 700; color: var(--text-main);">Bank Soal</h2>
+                        <p style="color: var(--text-muted);">Kumpulan soal dengan tag, tingkat kesulitan dan mata kuliah untuk diambil acak oleh kuis</p>
+                    </div>
+                    <button class="btn btn-primary" onclick="DosenController.showBankQuestionModal()">
+                        <span style="font-size: 1.2rem;">+</span> Soal Baru
+                    </button>
+                </div>
+
+                <form id="bankFilterForm" onsubmit="event.preventDefault(); DosenController.renderQuestionBank(Object.fromEntries(new FormData(this).entries()));"
+                      style="display: grid; grid-template-columns: 2fr 1fr 1fr 1fr auto; gap: 1rem; margin-bottom: 1.5rem;">
+                    <input type="text" name="search" value="${filters.search || ''}" placeholder="Cari pertanyaan..." style="border-radius: 10px;">
+                    <input type="text" name="tag" value="${filters.tag || ''}" placeholder="Tag" style="border-radius: 10px;">
+                    <input type="text" name="course" value="${filters.course || ''}" placeholder="Mata kuliah" style="border-radius: 10px;">
+                    <select name="difficulty" style="border-radius: 10px;">
+                        <option value="">Semua tingkat</option>
+                        ${['easy', 'medium', 'hard'].map(d => `<option value="${d}" ${filters.difficulty === d ? 'selected' : ''}>${this.difficultyLabel(d)}</option>`).join('')}
+                    </select>
+                    <button type="submit" class="btn btn-secondary">Filter</button>
+                </form>
+
+                <div class="table-wrapper">
+                    <div style="overflow-x: auto;">
+                        <table class="premium-table" id="bankTable">
+                            <thead>
+                                <tr>
+                                    <th>Pertanyaan</th>
+                                    <th>Tag</th>
+                                    <th>Mata Kuliah</th>
+                                    <th>Kesulitan</th>
+                                    <th>Bobot</th>
+                                    <th class="text-right">Aksi</th>
+                                </tr>
+                            </thead>
+                            <tbody><tr><td colspan="6" class="text-center">Memuat Bank Soal...</td></tr></tbody>
+                        </table>
+                    </div>
+                </div>
+            </div>
+        `;
+
+        try {
+            const params = { limit: 100 };
+            ['search', 'tag', 'course', 'difficulty'].forEach(k => { if (filters[k]) params[k] = filters[k]; });
+            const result = await API.getBankQuestions(params);
+            const questions = result.data.questions || [];
+            const tbody = document.querySelector('#bankTable tbody');
+            const me = JSON.parse(localStorage.getItem('user'));
+            this.bankQuestions = questions;
+
+            if (questions.length === 0) {
+                tbody.innerHTML = `
+                    <tr>
+                        <td colspan="6" class="text-center" style="padding: 4rem 1rem;">
+                            <div style="font-size: 3rem; margin-bottom: 1rem; opacity: 0.3;">🗂️</div>
+                            <h3 style="color: var(--text-muted);">Belum ada soal di bank</h3>
+                        </td>
+                    </tr>
+                `;
+                return;
+            }
+
+            tbody.innerHTML = questions.map(q => `
+                <tr class="fade-in-item">
+                    <td>
+                        <strong style="color: var(--text-main);">${q.question}</strong><br>
+                        <small style="color: var(--text-muted);">Jawaban: ${q.answer}</small>
+                    </td>
+                    <td>${(q.tags || []).map(t => `<span class="badge" style="background: rgba(99, 102, 241, 0.1); color: var(--primary); margin: 0 0.25rem 0.25rem 0;">${t}</span>`).join('') || '-'}</td>
+                    <td>${q.course || '-'}</td>
+                    <td><span class="badge ${q.difficulty === 'easy' ? 'badge-success' : (q.difficulty === 'hard' ? 'badge-error' : 'badge-warning')}">${this.difficultyLabel(q.difficulty)}</span></td>
+                    <td>${q.weight}</td>
+                    <td class="text-right">
+                        ${q.owner_id === me?.id ? `
+                        <div style="display: flex; justify-content: flex-end; gap: 0.5rem;">
+                            <button class="btn-icon" style="background: #f1f5f9;" onclick="DosenController.showBankQuestionModal(${q.id})" title="Edit Soal">
+                                <span style="font-size: 0.9rem;">✏️</span>
+                            </button>
+                            <button class="btn-icon" style="background: rgba(239, 68, 68, 0.05); color: var(--error);" onclick="DosenController.deleteBankQuestion(${q.id})" title="Hapus Soal">
+                                <span style="font-size: 0.9rem;">🗑️</span>
+                            </button>
+                        </div>` : ''}
+                    </td>
+                </tr>
+            `).join('');
+        } catch (error) {
+            console.error(error);
+            showToast("Gagal memuat bank soal", "error");
+        }
+    }
+
+    static showBankQuestionModal(id = null) {
+        const question = id ? (this.bankQuestions || []).find(q => q.id === id) : null;
+
+        const modalHtml = `
+            <div class="modal-overlay" onclick="closeModal(event)">
+                <div class="modal-card" style="max-width: 800px; width: 95%;">
+                    <div class="modal-head">
+                        <h3>${id ? 'Edit Soal Bank' : 'Tambah Soal ke Bank'}</h3>
+                        <button class="btn-icon" onclick="closeModal()">×</button>
+                    </div>
+                    <div class="modal-body">
+                        <form id="bankQuestionForm" onsubmit="DosenController.handleBankQuestionSubmit(event, ${id})">
+                            <div style="display: grid; grid-template-columns: 2fr 1fr 1fr; gap: 1rem;">
+                                <div class="form-group">
+                                    <label>Tag (pisahkan dengan koma)</label>
+                                    <input type="text" name="tags" value="${(question?.tags || []).join(', ')}" placeholder="misal, aljabar, persamaan">
+                                </div>
+                                <div class="form-group">
+                                    <label>Mata Kuliah</label>
+                                    <input type="text" name="course" value="${question?.course || ''}" maxlength="100">
+                                </div>
+                                <div class="form-group">
+                                    <label>Tingkat Kesulitan</label>
+                                    <select name="difficulty">
+                                        ${['easy', 'medium', 'hard'].map(d => `<option value="${d}" ${(question?.difficulty || 'medium') === d ? 'selected' : ''}>${this.difficultyLabel(d)}</option>`).join('')}
+                                    </select>
+                                </div>
+                            </div>
+                            <div id="questionsList"></div>
+                            <div class="form-actions" style="display: flex; gap: 1rem; justify-content: flex-end;">
+                                <button type="button" class="btn btn-secondary" onclick="closeModal()">Batal</button>
+                                <button type="submit" class="btn btn-primary">Simpan Soal</button>
+                            </div>
+                        </form>
+                    </div>
+                </div>
+            </div>
+        `;
+        document.body.insertAdjacentHTML('beforeend', modalHtml);
+        this.addQuestionField(question, 1);
+    }
+
+    static async handleBankQuestionSubmit(e, id) {
+        e.preventDefault();
+        const formData = new FormData(e.target);
+        const el = e.target.querySelector('.question-item');
+        const question = this.readQuestionField(el);
+        const data = {
+            ...question,
+            tags: formData.get('tags').split(',').map(t => t.trim()).filter(t => t),
+            course: formData.get('course'),
+            difficulty: formData.get('difficulty')
+        };
+
+        try {
+            if (id) {
+                await API.updateBankQuestion(id, data);
+            } else {
+                await API.createBankQuestion(data);
+            }
+            showToast("Soal berhasil disimpan ke bank");
+            closeModal();
+            DosenController.renderQuestionBank();
+        } catch (error) {
+            showToast(error.message, "error");
+        }
+    }
+
+    static async deleteBankQuestion(id) {
+        if (!confirm("Hapus soal ini dari bank? Siswa yang sudah mendapat soal ini tetap menyimpannya.")) return;
+        try {
+            await API.deleteBankQuestion(id);
+            showToast("Soal dihapus dari bank");
+            DosenController.renderQuestionBank();
+        } catch (error) {
+            showToast(error.message, "error");
+        }
+    }
+
     // ==========================
     // MODULE: STUDENT MONITORING
     // ==========================
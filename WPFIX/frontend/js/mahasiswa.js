@@ -47,8 +47,8 @@ class MahasiswaController {
             // Map submissions by mission_id for easy lookup
             const subMap = {};
             submissions.forEach(s => {
-                // If multiple submissions, take the latest one or the one that is approved
-                if (!subMap[s.mission_id] || subMap[s.mission_id].status !== 'approved') {
+                // Missions can have several attempts; the latest one decides what's next
+                if (!subMap[s.mission_id] || subMap[s.mission_id].attempt < s.attempt) {
                     subMap[s.mission_id] = s;
                 }
             });
@@ -65,8 +65,8 @@ class MahasiswaController {
                 filtered = missions.filter(m => {
                     const sub = subMap[m.id];
 
-                    // If approved, hide from available list (it's in history)
-                    if (sub && sub.status === 'approved') return false;
+                    // If approved, hide from available list (it's in history) unless it can be retried
+                    if (sub && sub.status === 'approved' && !(m.resubmit_policy === 'after_review' && sub.attempt < m.max_attempts)) return false;
 
                     // If pending, show it but mark as 'Pending'
                     // If rejected, show it and mark as 'Retry'
@@ -93,6 +93,8 @@ class MahasiswaController {
                 const isPending = sub && sub.status === 'pending';
                 const isRejected = sub && sub.status === 'rejected';
                 const isApproved = sub && sub.status === 'approved';
+                const attemptsLeft = sub ? m.max_attempts - sub.attempt : m.max_attempts;
+                const startAttempt = m.type === 'quiz' ? `MahasiswaController.takeQuiz(${m.id})` : `MahasiswaController.showSubmitModal(${m.id})`;
 
                 let statusBadge = '';
                 let actionBtn = '';
@@ -100,16 +102,25 @@ class MahasiswaController {
                 if (isPending) {
                     statusBadge = '<span class="badge badge-warning">Sedang Ditinjau</span>';
                     actionBtn = `<button class="btn" disabled style="width:100%; padding:1rem; border-radius:0; background:#f1f5f9; color:var(--text-muted);">Menunggu Review ⏳</button>`;
-                } else if (isRejected) {
+                } else if (isRejected && attemptsLeft > 0) {
                     statusBadge = '<span class="badge badge-error">Perlu Perbaikan</span>';
                     actionBtn = `
                         <button class="btn btn-primary" style="border-radius: 0; width: 100%; padding: 1rem; background: var(--error); border: none;" 
-                                onclick="${m.type === 'quiz' ? `MahasiswaController.takeQuiz(${m.id})` : `MahasiswaController.showSubmitModal(${m.id})`}">
-                            Perbaiki & Kirim Ulang 🔄
+                                onclick="${startAttempt}">
+                            Perbaiki & Kirim Ulang 🔄 (sisa ${attemptsLeft}x)
+                        </button>`;
+                } else if (isRejected) {
+                    statusBadge = '<span class="badge badge-error">Ditolak</span>';
+                    actionBtn = `<button class="btn" disabled style="width:100%; padding:1rem; border-radius:0; background:#f1f5f9; color:var(--text-muted);">Kesempatan Habis</button>`;
+                } else if (isApproved && m.resubmit_policy === 'after_review' && attemptsLeft > 0 && filterType !== 'history') {
+                    statusBadge = '<span class="badge badge-success">Selesai ✅</span>';
+                    actionBtn = `
+                        <button class="btn btn-primary" style="border-radius: 0; width: 100%; padding: 1rem; border: none;" onclick="${startAttempt}">
+                            Coba Lagi untuk Skor Lebih Baik 🔁 (sisa ${attemptsLeft}x)
                         </button>`;
                 } else if (isApproved) {
                     statusBadge = '<span class="badge badge-success">Selesai ✅</span>';
-                    actionBtn = `<button class="btn" disabled style="width:100%; padding:1rem; border-radius:0; background:#f1f5f9; color:var(--success); font-weight:700;">Lulus! +${sub.score || m.points} Pts</button>`;
+                    actionBtn = `<button class="btn" disabled style="width:100%; padding:1rem; border-radius:0; background:#f1f5f9; color:var(--success); font-weight:700;">Lulus! +${sub.reward_amount || m.points} Pts</button>`;
                 } else {
                     actionBtn = `
                         <button class="btn btn-primary" style="border-radius: 0; width: 100%; padding: 1rem; background: ${m.type === 'quiz' ? 'linear-gradient(to right, #6366f1, #a855f7)' : 'var(--primary)'}; border: none;" 
@@ -120,7 +131,7 @@ class MahasiswaController {
 
                 return `
                 <div class="card fade-in-item" style="display: flex; flex-direction: column; justify-content: space-between; overflow: hidden; border: 1px solid var(--border); transition: all 0.3s cubic-bezier(0.4, 0, 0.2, 1); cursor: default; position: relative;">
-                    ${m.type === 'quiz' ? '<div style="position: absolute; top: 12px; right: 12px; background: rgba(99, 102, 241, 0.1); color: var(--primary); padding: 4px 10px; border-radius: 20px; font-size: 0.75rem; font-weight: 700; border: 1px solid rgba(99, 102, 241, 0.2);">KUIS CEPAT</div>' : ''}
+                    ${m.type === 'quiz' ? `<div style="position: absolute; top: 12px; right: 12px; background: rgba(99, 102, 241, 0.1); color: var(--primary); padding: 4px 10px; border-radius: 20px; font-size: 0.75rem; font-weight: 700; border: 1px solid rgba(99, 102, 241, 0.2);">${m.time_limit_minutes > 0 ? `⏱️ ${m.time_limit_minutes} MENIT` : 'KUIS CEPAT'}</div>` : ''}
                     
                     <div style="padding: 1.5rem;">
                         <div style="display: flex; align-items: flex-start; gap: 1rem; margin-bottom: 1.5rem;">
@@ -133,7 +144,9 @@ class MahasiswaController {
                             </div>
                         </div>
 
-                        ${statusBadge ? `<div style="margin-bottom:1rem;">${statusBadge}</div>` : ''}
+                        ${statusBadge ? `<div style="margin-bottom:1rem;">${statusBadge}
+                            <a href="#" onclick="event.preventDefault(); MahasiswaController.showAttemptHistory(${m.id})" style="font-size: 0.8rem; margin-left: 0.5rem;">Percobaan ${sub.attempt}/${m.max_attempts}</a>
+                        </div>` : ''}
 
                         <p style="color: var(--text-muted); font-size: 0.9rem; line-height: 1.5; margin-bottom: 1.5rem; display: -webkit-box; -webkit-line-clamp: 2; -webkit-box-orient: vertical; overflow: hidden;">
                             ${m.description || 'Selesaikan misi ini untuk mendapatkan pengakuan dan poin.'}
@@ -172,6 +185,48 @@ class MahasiswaController {
         }
     }
 
+    static async showAttemptHistory(missionId) {
+        try {
+            const res = await API.getSubmissionHistory(missionId);
+            const h = res.data;
+            const badges = { pending: 'badge-warning', approved: 'badge-success', rejected: 'badge-error' };
+            const modalHtml = `
+                <div class="modal-overlay" onclick="closeModal(event)">
+                    <div class="modal-card" style="max-width: 520px;">
+                        <div class="modal-head"><h3>Riwayat Percobaan</h3><button class="btn-icon" onclick="closeModal()">×</button></div>
+                        <div class="modal-body">
+                            <p style="color: var(--text-muted); margin-bottom: 1rem;">
+                                ${h.attempts_used}/${h.max_attempts} percobaan •
+                                Skor ${h.score_rule === 'best' ? 'terbaik' : 'terakhir'}: <strong>${h.effective_score ?? '-'}</strong>
+                                ${h.rewarded ? ' • Hadiah sudah diterima' : ''}
+                            </p>
+                            ${h.attempts.map(a => `
+                                <div style="padding: 0.75rem 0; border-bottom: 1px solid var(--border);">
+                                    <div style="display: flex; justify-content: space-between;">
+                                        <strong>#${a.attempt}</strong>
+                                        <span class="badge ${badges[a.status]}">${a.status}</span>
+                                    </div>
+                                    <small style="color: var(--text-muted);">${new Date(a.created_at).toLocaleString()} • Skor ${a.status === 'pending' ? '-' : a.score}</small>
+                                    ${a.review_note ? `<div style="font-size: 0.85rem; margin-top: 0.25rem;">"${a.review_note}"</div>` : ''}
+                                    ${(a.rubric_result || []).map(r => `
+                                        <div style="font-size: 0.8rem; margin-top: 0.25rem; display: flex; justify-content: space-between; gap: 1rem;">
+                                            <span>${r.criterion} <span style="color: var(--text-muted);">×${r.weight}</span>${r.comment ? ` — <em>${r.comment}</em>` : ''}</span>
+                                            <strong>${r.level} (${r.points}/${r.max_points})</strong>
+                                        </div>
+                                    `).join('')}
+                                </div>
+                            `).join('')}
+                            ${!h.can_submit && h.blocked_reason ? `<p style="margin-top: 1rem; color: var(--text-muted); font-size: 0.85rem;">${h.blocked_reason}</p>` : ''}
+                        </div>
+                    </div>
+                </div>
+            `;
+            document.body.insertAdjacentHTML('beforeend', modalHtml);
+        } catch (e) {
+            showToast(e.message, 'error');
+        }
+    }
+
     static filterMissions(type, btn) {
         document.querySelectorAll('.tab-btn').forEach(b => {
             b.classList.remove('active');
@@ -197,8 +252,13 @@ class MahasiswaController {
                 return;
             }
 
-            let currentQuestion = 0;
-            const answers = [];
+            // Opens the attempt on the server, or resumes it with the
+            // answers autosaved so far and the time left
+            const sessionRes = await API.startQuiz(id);
+            const session = sessionRes.data;
+            const answers = session.answers || [];
+            let currentQuestion = mission.questions.findIndex(q => !answers.some(a => a.question_id === q.id));
+            if (currentQuestion === -1) currentQuestion = mission.questions.length - 1;
 
             const renderQuestion = () => {
                 const q = mission.questions[currentQuestion];
@@ -215,9 +275,15 @@ class MahasiswaController {
                         
                         <h3 style="font-weight: 700; color: var(--text-main); margin-bottom: 2rem; line-height: 1.4;">${q.question}</h3>
                         
+                        ${q.match_type === 'multi_select' ? '<p style="color: var(--text-muted); margin-top: -1.25rem; margin-bottom: 1.5rem;">Pilih semua jawaban yang benar</p>' : ''}
+                        ${q.match_type === 'case_insensitive' || q.match_type === 'numeric' ? `
+                            <input type="${q.match_type === 'numeric' ? 'number' : 'text'}" id="quizFreeAnswer" step="any" class="form-input" placeholder="Ketik jawaban Anda" style="padding: 1.25rem; border-radius: 12px; font-weight: 600;">
+                        ` : ''}
                         <div style="display: grid; gap: 1rem;">
                             ${(q.options || []).map((opt, i) => `
-                                <div class="quiz-option" onclick="this.parentElement.querySelectorAll('.quiz-option').forEach(o => o.classList.remove('selected')); this.classList.add('selected')" 
+                                <div class="quiz-option" onclick="${q.match_type === 'multi_select'
+                        ? "this.classList.toggle('selected')"
+                        : "this.parentElement.querySelectorAll('.quiz-option').forEach(o => o.classList.remove('selected')); this.classList.add('selected')"}" 
                                      data-val="${opt}"
                                      style="padding: 1.25rem; background: white; border: 2px solid var(--border); border-radius: 12px; cursor: pointer; transition: all 0.2s; font-weight: 600; display: flex; align-items: center; gap: 1rem;">
                                     <div style="width: 24px; height: 24px; border-radius: 50%; border: 2px solid var(--border); display: flex; align-items: center; justify-content: center; font-size: 0.8rem; color: var(--text-muted);">
@@ -236,7 +302,10 @@ class MahasiswaController {
                     <div class="modal-card" style="max-width: 650px; min-height: 500px; display: flex; flex-direction: column;">
                         <div class="modal-head" style="background: #fdfcfd; border-bottom: 1px solid var(--border); padding: 1rem 2rem;">
                             <h3 style="margin:0; font-size: 1.1rem; color: var(--text-main);">${mission.title}</h3>
-                            <button class="btn-icon" onclick="MahasiswaController.confirmCloseQuiz()">×</button>
+                            <div style="display: flex; align-items: center; gap: 1rem;">
+                                ${session.remaining_seconds != null ? '<span id="quizTimer" style="font-weight: 700; font-variant-numeric: tabular-nums; color: var(--primary);">⏱️ --:--</span>' : ''}
+                                <button class="btn-icon" onclick="MahasiswaController.confirmCloseQuiz()">×</button>
+                            </div>
                         </div>
                         <div class="modal-body" id="quizModalBody" style="flex: 1; padding: 3rem 2rem;">
                             <!-- Dynamic Content -->
@@ -255,56 +324,110 @@ class MahasiswaController {
 
             document.body.insertAdjacentHTML('beforeend', modalHtml);
             renderQuestion();
+            if (currentQuestion === mission.questions.length - 1) {
+                document.getElementById('nextBtn').textContent = 'Selesaikan Penilaian';
+            }
+
+            const submitQuiz = async () => {
+                clearInterval(this.quizTimer);
+                try {
+                    document.getElementById('nextBtn').disabled = true;
+                    document.getElementById('nextBtn').textContent = 'Menghitung Skor...';
+
+                    const submitData = {
+                        mission_id: id,
+                        answers: answers
+                    };
+
+                    const result = await API.submitMissionSubmission(submitData);
+                    const graded = result.data;
+                    if (graded.auto_graded) {
+                        showToast(`Skor ${graded.score}% • ${graded.reward_amount > 0 ? `+${graded.reward_amount} poin` : 'belum ada hadiah'}`, graded.status === 'approved' ? 'success' : 'warning');
+                    } else {
+                        showToast("Kuis berhasil dikirim! Mengalihkan ke misi...");
+                    }
+                    document.getElementById('quizModal').remove();
+                    MahasiswaController.renderMissions();
+                } catch (e) {
+                    showToast(e.message, "error");
+                    document.getElementById('nextBtn').disabled = false;
+                    document.getElementById('nextBtn').textContent = 'Selesaikan Penilaian';
+                }
+            };
+
+            // Server-side time limit: count down and hand in what is answered
+            // when it runs out
+            if (session.remaining_seconds != null) {
+                const endsAt = Date.now() + session.remaining_seconds * 1000;
+                const tick = () => {
+                    const left = Math.max(0, Math.round((endsAt - Date.now()) / 1000));
+                    const timer = document.getElementById('quizTimer');
+                    if (!timer) {
+                        clearInterval(this.quizTimer);
+                        return;
+                    }
+                    timer.textContent = `⏱️ ${String(Math.floor(left / 60)).padStart(2, '0')}:${String(left % 60).padStart(2, '0')}`;
+                    timer.style.color = left <= 60 ? 'var(--error)' : 'var(--primary)';
+                    if (left === 0) {
+                        showToast("Waktu habis, jawaban Anda dikirim", "warning");
+                        submitQuiz();
+                    }
+                };
+                clearInterval(this.quizTimer);
+                this.quizTimer = setInterval(tick, 1000);
+                tick();
+            }
 
             document.getElementById('nextBtn').addEventListener('click', async () => {
-                const selected = document.querySelector('.quiz-option.selected');
-                if (!selected) {
+                const q = mission.questions[currentQuestion];
+                const selected = [...document.querySelectorAll('.quiz-option.selected')].map(o => o.dataset.val);
+                const freeInput = document.getElementById('quizFreeAnswer');
+
+                let answer;
+                if (freeInput) {
+                    answer = freeInput.value.trim();
+                } else if (q.match_type === 'multi_select') {
+                    answer = JSON.stringify(selected);
+                } else {
+                    answer = selected[0];
+                }
+                if (!answer || (!freeInput && selected.length === 0)) {
                     showToast("Silakan pilih jawaban", "warning");
                     return;
                 }
 
-                answers.push({
-                    question_id: mission.questions[currentQuestion].id,
-                    answer: selected.dataset.val
-                });
+                const existing = answers.find(a => a.question_id === q.id);
+                if (existing) {
+                    existing.answer = answer;
+                } else {
+                    answers.push({
+                        question_id: q.id,
+                        answer: answer
+                    });
+                }
 
                 if (currentQuestion < mission.questions.length - 1) {
+                    // Autosave so a closed tab or expired timer keeps the answers
+                    API.saveQuizAnswers(id, answers).catch(e => showToast(e.message, "error"));
                     currentQuestion++;
                     renderQuestion();
                     if (currentQuestion === mission.questions.length - 1) {
                         document.getElementById('nextBtn').textContent = 'Selesaikan Penilaian';
                     }
                 } else {
-                    // Submit Quiz
-                    try {
-                        document.getElementById('nextBtn').disabled = true;
-                        document.getElementById('nextBtn').textContent = 'Menghitung Skor...';
-
-                        const submitData = {
-                            mission_id: id,
-                            answers: answers
-                        };
-
-                        await API.submitMissionSubmission(submitData);
-                        showToast("Kuis berhasil dikirim! Mengalihkan ke misi...");
-                        document.getElementById('quizModal').remove();
-                        MahasiswaController.renderMissions();
-                    } catch (e) {
-                        showToast(e.message, "error");
-                        document.getElementById('nextBtn').disabled = false;
-                        document.getElementById('nextBtn').textContent = 'Selesaikan Penilaian';
-                    }
+                    submitQuiz();
                 }
             });
 
         } catch (e) {
             console.error(e);
-            showToast("Gagal memulai kuis", "error");
+            showToast(e.message || "Gagal memulai kuis", "error");
         }
     }
 
     static confirmCloseQuiz() {
-        if (confirm("Apakah Anda yakin ingin keluar? Kemajuan Anda tidak akan disimpan.")) {
+        if (confirm("Apakah Anda yakin ingin keluar? Jawaban yang sudah disimpan tetap ada, tetapi waktu kuis terus berjalan.")) {
+            clearInterval(this.quizTimer);
             const m = document.getElementById('quizModal');
             if (m) m.remove();
         }
@@ -331,6 +454,26 @@ class MahasiswaController {
                                 <p style="margin: 0.5rem 0 0 0; color: var(--text-muted); font-size: 0.9rem;">${mission.description || 'Tidak ada instruksi khusus yang diberikan.'}</p>
                             </div>
 
+                            ${(mission.rubric || []).length > 0 ? `
+                                <div style="margin-bottom: 2rem; background: #f8fafc; border: 1px solid var(--border); border-radius: 12px; padding: 1rem;">
+                                    <strong style="display: block; margin-bottom: 0.5rem;">📋 Rubrik Penilaian</strong>
+                                    ${mission.rubric.map(c => `
+                                        <div style="font-size: 0.85rem; margin-bottom: 0.4rem;">
+                                            <strong>${c.name}</strong> <span style="color: var(--text-muted);">(bobot ${c.weight})</span>:
+                                            ${c.levels.map(l => `${l.label} ${l.points}`).join(' • ')}
+                                        </div>
+                                    `).join('')}
+                                </div>
+                            ` : ''}
+
+                            ${mission.peer_reviewers > 0 ? `
+                                <div style="margin-bottom: 2rem; background: rgba(99, 102, 241, 0.05); border: 1px solid rgba(99, 102, 241, 0.2); border-radius: 12px; padding: 1rem; font-size: 0.85rem;">
+                                    🤝 <strong>Review teman:</strong> setelah tenggat, tugas Anda dinilai secara anonim oleh ${mission.peer_reviewers} teman
+                                    (${mission.peer_aggregate === 'trimmed_mean' ? 'rata-rata tanpa nilai tertinggi & terendah' : 'nilai tengah'}),
+                                    dan Anda akan mereview tugas mereka${mission.peer_reward > 0 ? ` dengan hadiah ${mission.peer_reward} poin per review` : ''}.
+                                </div>
+                            ` : ''}
+
                             <form id="missionSubmitForm" onsubmit="MahasiswaController.handleMissionSubmission(event, ${mission.id})">
                                 <div class="form-group">
                                     <label style="font-weight: 600;">Laporan / Jawaban Teks</label>
@@ -384,6 +527,197 @@ class MahasiswaController {
         }
     }
 
+    // ==========================
+    // MODULE: PEER REVIEW
+    // ==========================
+    static async renderPeerReviews() {
+        const content = document.getElementById('mainContent');
+        content.innerHTML = `
+            <div class="fade-in">
+                <div class="page-header" style="margin-bottom: 2rem;">
+                    <h2 style="font-weight: 700; color: var(--text-main);">Review Teman</h2>
+                    <p style="color: var(--text-muted);">Nilai tugas teman secara anonim dan dapatkan poin untuk setiap review</p>
+                </div>
+
+                <h3 style="font-weight: 700; margin-bottom: 1rem;">📝 Tugas Review</h3>
+                <div id="peerReviewList" class="stats-grid" style="grid-template-columns: repeat(auto-fill, minmax(320px, 1fr)); margin-bottom: 2.5rem;">
+                    <div class="text-center" style="grid-column: 1/-1; padding: 2rem;">Memuat...</div>
+                </div>
+
+                <h3 style="font-weight: 700; margin-bottom: 1rem;">💬 Feedback untuk Tugas Saya</h3>
+                <div id="peerFeedbackList">
+                    <div class="text-center" style="padding: 2rem;">Memuat...</div>
+                </div>
+            </div>
+        `;
+
+        await Promise.all([this.loadPeerReviews(), this.loadPeerFeedback()]);
+    }
+
+    static async loadPeerReviews() {
+        const list = document.getElementById('peerReviewList');
+        try {
+            const res = await API.getPeerReviews();
+            this.peerReviews = res.data || [];
+
+            if (this.peerReviews.length === 0) {
+                list.innerHTML = `
+                    <div style="grid-column: 1/-1; text-align: center; padding: 3rem; color: var(--text-muted);">
+                        <div style="font-size: 3rem; opacity: 0.2;">🤝</div>
+                        Belum ada tugas review. Review dibagikan setelah tenggat misi berakhir.
+                    </div>`;
+                return;
+            }
+
+            const badges = { assigned: 'badge-warning', completed: 'badge-success', expired: 'badge-error' };
+            const labels = { assigned: 'Menunggu', completed: 'Selesai', expired: 'Terlewat' };
+            list.innerHTML = this.peerReviews.map(r => `
+                <div class="card" style="display: flex; flex-direction: column; justify-content: space-between; overflow: hidden; border: 1px solid var(--border);">
+                    <div style="padding: 1.5rem;">
+                        <div style="display: flex; justify-content: space-between; align-items: flex-start; gap: 1rem; margin-bottom: 0.75rem;">
+                            <h4 style="margin: 0; font-weight: 700;">${r.mission_title}</h4>
+                            <span class="badge ${badges[r.status]}">${labels[r.status]}</span>
+                        </div>
+                        <small style="color: var(--text-muted);">
+                            ${r.status === 'completed'
+                                ? `Skor Anda: <strong>${r.score}</strong> • +${r.reward_amount} ${r.asset}`
+                                : `Batas review: ${new Date(r.due_at).toLocaleString()}${r.peer_reward > 0 ? ` • Hadiah ${r.peer_reward} ${r.asset}` : ''}`}
+                        </small>
+                    </div>
+                    ${r.status === 'assigned' ? `
+                        <button class="btn btn-primary" style="border-radius: 0; width: 100%; padding: 1rem; border: none;" onclick="MahasiswaController.showPeerReviewModal(${r.id})">
+                            Review Sekarang ✍️
+                        </button>` : ''}
+                </div>
+            `).join('');
+        } catch (e) {
+            list.innerHTML = `<div style="grid-column: 1/-1; text-align: center; color: var(--error);">${e.message}</div>`;
+        }
+    }
+
+    static async loadPeerFeedback() {
+        const list = document.getElementById('peerFeedbackList');
+        try {
+            const res = await API.getReceivedPeerReviews();
+            const feedback = res.data || [];
+
+            if (feedback.length === 0) {
+                list.innerHTML = `<div class="card" style="padding: 2rem; text-align: center; color: var(--text-muted);">Belum ada feedback dari teman.</div>`;
+                return;
+            }
+
+            list.innerHTML = feedback.map(f => `
+                <div class="card" style="padding: 1.25rem; margin-bottom: 0.75rem; border: 1px solid var(--border);">
+                    <div style="display: flex; justify-content: space-between;">
+                        <strong>${f.mission_title}</strong>
+                        <span style="font-weight: 800;">${f.score}/100</span>
+                    </div>
+                    <small style="color: var(--text-muted);">Reviewer anonim • ${f.completed_at ? new Date(f.completed_at).toLocaleString() : ''}</small>
+                    ${f.comment ? `<div style="font-size: 0.9rem; margin-top: 0.5rem;">"${f.comment}"</div>` : ''}
+                    ${(f.rubric_result || []).map(c => `
+                        <div style="font-size: 0.8rem; margin-top: 0.25rem; display: flex; justify-content: space-between; gap: 1rem;">
+                            <span>${c.criterion} <span style="color: var(--text-muted);">×${c.weight}</span>${c.comment ? ` — <em>${c.comment}</em>` : ''}</span>
+                            <strong>${c.level} (${c.points}/${c.max_points})</strong>
+                        </div>
+                    `).join('')}
+                </div>
+            `).join('');
+        } catch (e) {
+            list.innerHTML = `<div style="text-align: center; color: var(--error);">${e.message}</div>`;
+        }
+    }
+
+    static showPeerReviewModal(id) {
+        const review = (this.peerReviews || []).find(r => r.id === id);
+        if (!review) return;
+        const rubric = review.rubric || [];
+        let fileUrl = review.file_url;
+        if (fileUrl && !fileUrl.startsWith('http')) {
+            fileUrl = `${CONFIG.API_BASE_URL.replace('/api/v1', '')}${fileUrl.startsWith('/') ? '' : '/'}${fileUrl}`;
+        }
+
+        const modalHtml = `
+            <div class="modal-overlay" onclick="closeModal(event)">
+                <div class="modal-card" style="max-width: 640px; border-radius: var(--radius-xl); overflow: hidden;">
+                    <div class="modal-head" style="background: var(--primary); color: white;">
+                        <h3>✍️ Review Tugas Teman</h3>
+                        <button class="btn-icon" onclick="closeModal()" style="color:white;">×</button>
+                    </div>
+                    <div class="modal-body" style="padding: 2rem;">
+                        <div style="margin-bottom: 1.5rem; border-left: 3px solid var(--primary); padding-left: 1rem;">
+                            <h4 style="margin:0;">${review.mission_title}</h4>
+                            <p style="margin: 0.5rem 0 0 0; color: var(--text-muted); font-size: 0.9rem;">${review.mission_description || ''}</p>
+                        </div>
+
+                        <div style="background: #f8fafc; border: 1px solid var(--border); border-radius: 12px; padding: 1rem; margin-bottom: 1.5rem;">
+                            <strong style="display: block; margin-bottom: 0.5rem;">Jawaban</strong>
+                            <div style="white-space: pre-wrap; font-size: 0.9rem;">${review.content || '-'}</div>
+                            ${fileUrl ? `<a href="${fileUrl}" target="_blank" style="display: inline-block; margin-top: 0.75rem; font-size: 0.85rem;">📎 Lihat lampiran</a>` : ''}
+                        </div>
+
+                        <form onsubmit="MahasiswaController.handlePeerReviewSubmit(event, ${review.id})">
+                            ${rubric.length > 0 ? rubric.map((c, i) => `
+                                <div class="form-group peer-rubric-item" data-criterion="${c.name}">
+                                    <label style="font-weight: 600;">${c.name} <span style="color: var(--text-muted); font-weight: 400;">(bobot ${c.weight})</span></label>
+                                    ${c.description ? `<small style="display: block; color: var(--text-muted); margin-bottom: 0.25rem;">${c.description}</small>` : ''}
+                                    <select class="form-input" name="level_${i}" required>
+                                        <option value="">Pilih level</option>
+                                        ${c.levels.map(l => `<option value="${l.label}">${l.label} (${l.points})${l.description ? ` — ${l.description}` : ''}</option>`).join('')}
+                                    </select>
+                                    <input type="text" class="form-input" name="comment_${i}" placeholder="Komentar (opsional)" style="margin-top: 0.5rem;">
+                                </div>
+                            `).join('') : `
+                                <div class="form-group">
+                                    <label style="font-weight: 600;">Skor (0-100)</label>
+                                    <input type="number" class="form-input" name="score" min="0" max="100" required>
+                                </div>
+                            `}
+                            <div class="form-group">
+                                <label style="font-weight: 600;">Komentar</label>
+                                <textarea class="form-input" name="comment" placeholder="Apa yang sudah baik dan apa yang bisa diperbaiki?" style="min-height: 100px;"></textarea>
+                            </div>
+                            <div class="form-actions" style="margin-top: 2rem; display: flex; gap: 1rem;">
+                                <button type="button" class="btn btn-secondary" onclick="closeModal()" style="flex:1; border-radius: 12px;">Batal</button>
+                                <button type="submit" class="btn btn-primary" style="flex:2; border-radius: 12px; font-weight: 700;">Kirim Review</button>
+                            </div>
+                        </form>
+                    </div>
+                </div>
+            </div>
+        `;
+        document.body.insertAdjacentHTML('beforeend', modalHtml);
+    }
+
+    static async handlePeerReviewSubmit(e, id) {
+        e.preventDefault();
+        const form = e.target;
+        const data = { comment: form.comment.value };
+
+        const items = form.querySelectorAll('.peer-rubric-item');
+        if (items.length > 0) {
+            data.rubric_scores = Array.from(items).map((item, i) => ({
+                criterion: item.dataset.criterion,
+                level: form[`level_${i}`].value,
+                comment: form[`comment_${i}`].value
+            }));
+        } else {
+            data.score = parseInt(form.score.value);
+        }
+
+        const submitBtn = form.querySelector('button[type="submit"]');
+        submitBtn.disabled = true;
+        try {
+            const res = await API.submitPeerReview(id, data);
+            const reward = res.data.reward_amount;
+            showToast(reward > 0 ? `Review terkirim! +${reward} poin` : 'Review terkirim!', 'success');
+            closeModal();
+            this.loadPeerReviews();
+        } catch (error) {
+            showToast(error.message || 'Gagal mengirim review', 'error');
+            submitBtn.disabled = false;
+        }
+    }
+
     // ==========================
     // MODULE: REWARDS STORE (Marketplace)
     // ==========================
@@ -575,6 +909,8 @@ class MahasiswaController {
                     </button>
                 </div>
 
+                <div id="assetBalances" style="display: grid; grid-template-columns: repeat(auto-fill, minmax(200px, 1fr)); gap: 1rem; margin-bottom: 2rem;"></div>
+
                 <div class="table-wrapper">
                     <table class="premium-table" id="ledgerTable">
                         <thead>
@@ -592,6 +928,8 @@ class MahasiswaController {
             </div>
         `;
 
+        this.loadAssetBalances();
+
         try {
             const user = JSON.parse(localStorage.getItem('user'));
             const res = await API.getTransactions(user.id);
@@ -612,7 +950,7 @@ class MahasiswaController {
                     </td>
                     <td>
                         <span style="font-weight: 700; color: ${t.type.includes('reward') || t.type.includes('topup') || t.type.includes('receive') ? 'var(--success)' : 'var(--error)'};">
-                            ${t.type.includes('reward') || t.type.includes('topup') || t.type.includes('receive') ? '+' : '-'}${t.amount.toLocaleString()} Pts
+                            ${t.type.includes('reward') || t.type.includes('topup') || t.type.includes('receive') ? '+' : '-'}${t.amount.toLocaleString()} ${t.asset && t.asset !== 'points' ? t.asset : 'Pts'}
                         </span>
                     </td>
                     <td style="font-weight: 600; color: var(--text-main);">${t.balance_after?.toLocaleString() || '-'}</td>
@@ -627,6 +965,78 @@ class MahasiswaController {
 
 
 
+    static async loadAssetBalances() {
+        const container = document.getElementById('assetBalances');
+        if (!container) return;
+
+        try {
+            const [walletRes, rulesRes] = await Promise.all([API.getWallet(), API.getMyConversions()]);
+            const balances = walletRes.data.balances || [];
+            this.conversionRules = rulesRes.data || [];
+
+            container.innerHTML = balances.map(b => `
+                <div class="stat-card" style="padding: 1.25rem; border-radius: 16px; background: white; border: 1px solid var(--border);">
+                    <div style="color: var(--text-muted); font-size: 0.85rem;">${b.name}</div>
+                    <div style="font-size: 1.5rem; font-weight: 800; color: var(--text-main);">${b.available_balance.toLocaleString()}</div>
+                    ${b.held_balance > 0 ? `<small style="color: var(--warning);">${b.held_balance.toLocaleString()} ditahan</small>` : ''}
+                    ${(b.merchant_ids || []).length > 0 ? `<small style="display: block; color: var(--text-muted);">Hanya di merchant tertentu</small>` : ''}
+                </div>
+            `).join('') + (this.conversionRules.length > 0 ? `
+                <button class="btn btn-secondary" onclick="MahasiswaController.showConvertForm()" style="border-radius: 16px; font-weight: 700;">🔁 Tukar Antar Poin</button>
+            ` : '');
+        } catch (e) {
+            console.error(e);
+        }
+    }
+
+    static showConvertForm() {
+        const rules = this.conversionRules || [];
+        const modalHtml = `
+            <div class="modal-overlay" id="convertModal">
+                <div class="modal-card" style="max-width: 440px; padding: 2rem;">
+                    <h3 style="margin-bottom: 0.5rem;">Tukar Antar Poin</h3>
+                    <p style="color: var(--text-muted); margin-bottom: 1.5rem;">Jumlah harus kelipatan dari nilai tukar</p>
+                    <form id="convertForm">
+                        <div class="form-group">
+                            <label>Nilai Tukar</label>
+                            <select name="rule" class="form-input" required>
+                                ${rules.map((r, i) => `<option value="${i}">${r.from_amount} ${r.from_asset} → ${r.to_amount} ${r.to_asset}</option>`).join('')}
+                            </select>
+                        </div>
+                        <div class="form-group">
+                            <label>Jumlah yang Ditukar</label>
+                            <input type="number" name="amount" class="form-input" min="1" required>
+                        </div>
+                        <div style="display:grid; grid-template-columns: 1fr 1fr; gap: 1rem;">
+                            <button type="button" class="btn btn-secondary" onclick="document.getElementById('convertModal').remove()">Batal</button>
+                            <button type="submit" class="btn btn-primary">Tukar</button>
+                        </div>
+                    </form>
+                </div>
+            </div>
+        `;
+        document.body.insertAdjacentHTML('beforeend', modalHtml);
+
+        document.getElementById('convertForm').addEventListener('submit', async e => {
+            e.preventDefault();
+            const form = new FormData(e.target);
+            const rule = rules[parseInt(form.get('rule'))];
+
+            try {
+                const res = await API.convertPoints({
+                    from_asset: rule.from_asset,
+                    to_asset: rule.to_asset,
+                    amount: parseInt(form.get('amount'))
+                });
+                document.getElementById('convertModal').remove();
+                showToast(`${res.data.debited} ${res.data.from_asset} ditukar menjadi ${res.data.credited} ${res.data.to_asset}`, 'success');
+                this.renderLedger();
+            } catch (err) {
+                showToast(err.message, 'error');
+            }
+        });
+    }
+
     // ==========================
     // MODULE: TRANSFER POINTS
     // ==========================
@@ -654,6 +1064,30 @@ class MahasiswaController {
                         <button class="btn btn-primary" style="padding: 1.5rem 3rem; border-radius: 50px; font-weight: 700; font-size: 1.1rem; display: flex; align-items: center; gap: 0.75rem; box-shadow: 0 10px 15px -3px rgba(99, 102, 241, 0.4);" onclick="MahasiswaController.showTransferForm()">
                             <span>💸</span> Kirim Poin Baru
                         </button>
+                        <button class="btn btn-secondary" style="padding: 1.5rem 2rem; border-radius: 50px; font-weight: 700; font-size: 1.1rem; margin-left: 1rem;" onclick="MahasiswaController.showPointRequestForm()">
+                            <span>🤝</span> Minta Poin
+                        </button>
+                    </div>
+
+                    <div class="card" id="escrowsCard" style="padding: 0; border: 1px solid var(--border); overflow: hidden; margin-bottom: 2rem; display: none;">
+                        <div style="padding: 1.5rem; border-bottom: 1px solid var(--border); background: #f8fafc;">
+                            <h4 style="margin:0; color: var(--text-main);">Escrow Berjalan</h4>
+                        </div>
+                        <div id="escrowsList" style="padding: 1rem 1.5rem;"></div>
+                    </div>
+
+                    <div class="card" id="schedulesCard" style="padding: 0; border: 1px solid var(--border); overflow: hidden; margin-bottom: 2rem; display: none;">
+                        <div style="padding: 1.5rem; border-bottom: 1px solid var(--border); background: #f8fafc;">
+                            <h4 style="margin:0; color: var(--text-main);">Transfer Terjadwal</h4>
+                        </div>
+                        <div id="schedulesList" style="padding: 1rem 1.5rem;"></div>
+                    </div>
+
+                    <div class="card" id="pointRequestsCard" style="padding: 0; border: 1px solid var(--border); overflow: hidden; margin-bottom: 2rem; display: none;">
+                        <div style="padding: 1.5rem; border-bottom: 1px solid var(--border); background: #f8fafc;">
+                            <h4 style="margin:0; color: var(--text-main);">Permintaan Poin</h4>
+                        </div>
+                        <div id="pointRequestsList" style="padding: 1rem 1.5rem;"></div>
                     </div>
 
                     <div class="card" style="padding: 0; border: 1px solid var(--border); overflow: hidden; min-height: 400px; display: flex; flex-direction: column;">
@@ -691,7 +1125,7 @@ class MahasiswaController {
                             <div class="form-group">
                                 <label style="font-weight: 600;">Penerima</label>
                                 <div style="position:relative;">
-                                    <input type="number" name="receiver_id" id="receiverIdInput" placeholder="Masukkan ID Siswa / NIM" required style="border-radius: 12px; padding: 1rem; font-size: 1rem; width: 100%; box-sizing: border-box; border: 2px solid #e2e8f0;" oninput="MahasiswaController.checkReceiver(this.value)">
+                                    <input type="text" name="recipient" id="receiverIdInput" placeholder="NIM, email atau @handle" required style="border-radius: 12px; padding: 1rem; font-size: 1rem; width: 100%; box-sizing: border-box; border: 2px solid #e2e8f0;" oninput="MahasiswaController.checkReceiver(this.value)">
                                     <div id="receiverFeedback" style="margin-top: 0.5rem; font-size: 0.9rem; min-height: 1.2em; font-weight: 600;"></div>
                                 </div>
                             </div>
@@ -701,6 +1135,31 @@ class MahasiswaController {
                                 <input type="number" name="amount" min="1" placeholder="e.g. 50" required style="border-radius: 12px; font-weight: 700; color: var(--text-main); padding: 1rem; font-size: 1rem; width: 100%; box-sizing: border-box; border: 2px solid #e2e8f0;">
                             </div>
 
+                            <div class="form-group">
+                                <label style="font-weight: 600;">Waktu Kirim</label>
+                                <select name="frequency" class="form-input" onchange="document.getElementById('scheduleFields').style.display = this.value === 'now' ? 'none' : 'grid'" style="border-radius: 12px; padding: 1rem; width: 100%; box-sizing: border-box; border: 2px solid #e2e8f0;">
+                                    <option value="now">Sekarang</option>
+                                    <option value="once">Sekali, di waktu tertentu</option>
+                                    <option value="weekly">Setiap minggu</option>
+                                    <option value="monthly">Setiap bulan</option>
+                                </select>
+                                <div id="scheduleFields" style="display: none; grid-template-columns: 1fr 1fr; gap: 0.5rem; margin-top: 0.5rem;">
+                                    <div><small>Mulai</small><input type="datetime-local" name="start_at" class="form-input"></div>
+                                    <div><small>Berakhir (opsional)</small><input type="date" name="end_at" class="form-input"></div>
+                                </div>
+                            </div>
+
+                            <div class="form-group">
+                                <label style="display:flex; gap:0.5rem; align-items:center; font-weight: 600;">
+                                    <input type="checkbox" name="escrow" style="width:auto;" onchange="document.getElementById('escrowFields').style.display = this.checked ? 'block' : 'none'">
+                                    🔒 Escrow untuk jual-beli barang
+                                </label>
+                                <div id="escrowFields" style="display: none; margin-top: 0.5rem;">
+                                    <small style="color: var(--text-muted);">Poin ditahan sampai penerima menandai barang terkirim dan Anda mengonfirmasi. Isi pesan dengan nama barang.</small>
+                                    <div><small>Batas pengiriman (hari)</small><input type="number" name="deliver_within_days" min="1" max="30" value="7" class="form-input"></div>
+                                </div>
+                            </div>
+
                             <div class="form-group">
                                 <label style="font-weight: 600;">Pesan (Opsional)</label>
                                 <textarea name="description" placeholder="Untuk proyek kelompok..." style="min-height: 100px; border-radius: 12px; padding: 1rem; width: 100%; box-sizing: border-box; border: 2px solid #e2e8f0;"></textarea>
@@ -720,6 +1179,9 @@ class MahasiswaController {
 
         // Initial Load
         this.loadTransferHistory();
+        this.loadPointRequests();
+        this.loadScheduledTransfers();
+        this.loadEscrows();
 
         // Get Balance
         try {
@@ -741,20 +1203,20 @@ class MahasiswaController {
         this.currentRecipient = null;
     }
 
-    static showTransferForm(receiverId = null) {
+    static showTransferForm(recipient = null) {
         document.getElementById('transferMenu').style.display = 'none';
         document.getElementById('transferFormContainer').style.display = 'block';
 
-        if (receiverId) {
+        if (recipient) {
             const input = document.getElementById('receiverIdInput');
             if (input) {
-                input.value = receiverId;
-                this.checkReceiver(receiverId);
+                input.value = recipient;
+                this.checkReceiver(recipient);
             }
         }
     }
 
-    static checkReceiver(id) {
+    static checkReceiver(identifier) {
         const feedback = document.getElementById('receiverFeedback');
         const btn = document.querySelector('#transferForm button[type="submit"]');
 
@@ -763,7 +1225,7 @@ class MahasiswaController {
 
         if (this.checkTimeout) clearTimeout(this.checkTimeout);
 
-        if (!id) {
+        if (!identifier) {
             feedback.innerHTML = '';
             if (btn) btn.disabled = false;
             return;
@@ -775,7 +1237,7 @@ class MahasiswaController {
 
         this.checkTimeout = setTimeout(async () => {
             try {
-                const res = await API.lookupUser(id);
+                const res = await API.findRecipient(identifier);
                 const user = res.data;
                 // Don't allow self-transfer
                 const currentUser = JSON.parse(localStorage.getItem('user'));
@@ -802,13 +1264,68 @@ class MahasiswaController {
         const formData = new FormData(e.target);
         const data = Object.fromEntries(formData.entries());
         data.amount = parseInt(data.amount);
-        data.receiver_user_id = parseInt(data.receiver_id);
-        delete data.receiver_id;
+        data.recipient = data.recipient.trim();
 
         const btn = e.target.querySelector('button[type="submit"]');
 
-        // Get name from stored check or fallback to ID
-        const recipientName = this.currentRecipient ? this.currentRecipient.full_name : `ID: ${data.receiver_user_id}`;
+        // Escrow deals hold the points until the item is delivered
+        if (data.escrow) {
+            if (!data.description) {
+                showToast('Tuliskan barang yang dibeli pada pesan', 'error');
+                return;
+            }
+            try {
+                btn.disabled = true;
+                await API.createEscrow({
+                    recipient: data.recipient,
+                    amount: data.amount,
+                    description: data.description,
+                    deliver_within_days: parseInt(data.deliver_within_days) || 7
+                });
+                showToast('Poin ditahan dalam escrow', 'success');
+                this.renderTransfer();
+            } catch (err) {
+                showToast(err.message, 'error');
+                btn.disabled = false;
+            }
+            return;
+        }
+        delete data.escrow;
+        delete data.deliver_within_days;
+
+        // Scheduled and recurring transfers run later on the server
+        const frequency = data.frequency;
+        delete data.frequency;
+        if (frequency !== 'now') {
+            if (!data.start_at) {
+                showToast('Pilih waktu mulai transfer terjadwal', 'error');
+                return;
+            }
+            const schedule = {
+                recipient: data.recipient,
+                amount: data.amount,
+                description: data.description,
+                frequency,
+                start_at: new Date(data.start_at).toISOString()
+            };
+            if (data.end_at && frequency !== 'once') schedule.end_at = new Date(`${data.end_at}T23:59:59`).toISOString();
+
+            try {
+                btn.disabled = true;
+                await API.createScheduledTransfer(schedule);
+                showToast('Transfer terjadwal disimpan', 'success');
+                this.renderTransfer();
+            } catch (err) {
+                showToast(err.message, 'error');
+                btn.disabled = false;
+            }
+            return;
+        }
+        delete data.start_at;
+        delete data.end_at;
+
+        // Get the masked name from the stored check or fall back to the identifier
+        const recipientName = this.currentRecipient ? this.currentRecipient.full_name : data.recipient;
 
         try {
             btn.textContent = 'Memverifikasi Transaksi...';
@@ -943,6 +1460,212 @@ class MahasiswaController {
         }
     }
 
+    /* Escrow deals: the receiver delivers, the sender confirms, either may dispute */
+    static async loadEscrows() {
+        try {
+            const user = JSON.parse(localStorage.getItem('user'));
+            const [res, walletRes] = await Promise.all([
+                API.getEscrows({ limit: 50 }),
+                API.getWallet(user.id)
+            ]);
+            const myWalletId = walletRes.data.id;
+            const escrows = (res.data.escrows || []).filter(t => ['escrowed', 'delivered', 'disputed'].includes(t.status));
+            const card = document.getElementById('escrowsCard');
+            if (!card) return;
+            card.style.display = escrows.length ? 'block' : 'none';
+
+            const labels = { escrowed: 'Menunggu pengiriman', delivered: 'Terkirim, menunggu konfirmasi', disputed: 'Dalam sengketa' };
+            document.getElementById('escrowsList').innerHTML = escrows.map(t => {
+                const isSender = t.sender_wallet_id === myWalletId;
+                const actions = [];
+                if (!isSender && t.status === 'escrowed') actions.push(`<button class="btn btn-sm btn-primary" onclick="MahasiswaController.changeEscrow(${t.id}, 'deliver')">Barang Terkirim</button>`);
+                if (isSender && t.status !== 'disputed') actions.push(`<button class="btn btn-sm btn-primary" onclick="MahasiswaController.changeEscrow(${t.id}, 'confirm')">Konfirmasi</button>`);
+                if (t.status !== 'disputed') actions.push(`<button class="btn btn-sm btn-secondary" onclick="MahasiswaController.changeEscrow(${t.id}, 'dispute')">Sengketakan</button>`);
+                const deadline = t.status === 'delivered' ? `Rilis otomatis ${new Date(t.release_at).toLocaleString()}` : t.status === 'escrowed' ? `Kirim sebelum ${new Date(t.deliver_by).toLocaleString()}` : t.dispute_reason;
+                return `
+                    <div style="display:flex; justify-content:space-between; align-items:center; padding: 0.75rem 0; border-bottom: 1px solid var(--border);">
+                        <div>
+                            <div style="font-weight:600;">${t.description} • ${t.amount.toLocaleString()} poin ${isSender ? 'ke ' + t.receiver_name : 'dari ' + t.sender_name}</div>
+                            <small style="color:var(--text-muted);">${labels[t.status]} • ${deadline}</small>
+                        </div>
+                        <div style="display:flex; gap:0.5rem;">${actions.join('')}</div>
+                    </div>
+                `;
+            }).join('');
+        } catch (e) {
+            console.error(e);
+        }
+    }
+
+    static async changeEscrow(id, action) {
+        let body = null;
+        if (action === 'dispute') {
+            const reason = prompt('Alasan sengketa:');
+            if (!reason) return;
+            body = { reason };
+        } else if (action === 'confirm' && !confirm('Lepaskan poin ke penjual?')) {
+            return;
+        }
+        try {
+            await API.changeEscrow(id, action, body);
+            showToast('Escrow diperbarui', 'success');
+            this.loadEscrows();
+        } catch (e) {
+            showToast(e.message, 'error');
+        }
+    }
+
+    /* Scheduled and recurring transfers */
+    static async loadScheduledTransfers() {
+        try {
+            const res = await API.getScheduledTransfers({ limit: 50 });
+            const schedules = (res.data.schedules || []).filter(sc => ['active', 'paused'].includes(sc.status));
+            const card = document.getElementById('schedulesCard');
+            if (!card) return;
+            card.style.display = schedules.length ? 'block' : 'none';
+
+            const labels = { once: 'Sekali', weekly: 'Mingguan', monthly: 'Bulanan' };
+            document.getElementById('schedulesList').innerHTML = schedules.map(sc => `
+                <div style="display:flex; justify-content:space-between; align-items:center; padding: 0.75rem 0; border-bottom: 1px solid var(--border);">
+                    <div>
+                        <div style="font-weight:600;">${sc.amount.toLocaleString()} poin ke ${sc.receiver_name || 'ID ' + sc.receiver_user_id} • ${labels[sc.frequency]}</div>
+                        <small style="color:var(--text-muted);">
+                            ${sc.status === 'paused' ? 'Dijeda' : 'Berikutnya ' + new Date(sc.next_run_at).toLocaleString()}
+                            ${sc.last_error ? ' • Terakhir dilewati: ' + sc.last_error : ''}
+                        </small>
+                    </div>
+                    <div style="display:flex; gap:0.5rem;">
+                        <button class="btn btn-sm btn-secondary" onclick="MahasiswaController.changeScheduledTransfer(${sc.id}, '${sc.status === 'paused' ? 'resume' : 'pause'}')">${sc.status === 'paused' ? 'Lanjutkan' : 'Jeda'}</button>
+                        <button class="btn btn-sm btn-secondary" onclick="MahasiswaController.changeScheduledTransfer(${sc.id}, 'cancel')">Batalkan</button>
+                    </div>
+                </div>
+            `).join('');
+        } catch (e) {
+            console.error(e);
+        }
+    }
+
+    static async changeScheduledTransfer(id, action) {
+        if (action === 'cancel' && !confirm('Batalkan transfer terjadwal ini?')) return;
+        try {
+            await API.changeScheduledTransfer(id, action);
+            this.loadScheduledTransfers();
+        } catch (e) {
+            showToast(e.message, 'error');
+        }
+    }
+
+    /* Point requests: incoming shares to answer and my own open requests */
+    static async loadPointRequests() {
+        try {
+            const [incomingRes, outgoingRes] = await Promise.all([
+                API.getPointRequests('incoming', { status: 'pending' }),
+                API.getPointRequests('outgoing', { status: 'open' })
+            ]);
+            const incoming = incomingRes.data.requests || [];
+            const outgoing = outgoingRes.data.requests || [];
+            const card = document.getElementById('pointRequestsCard');
+            if (!card) return;
+            card.style.display = incoming.length || outgoing.length ? 'block' : 'none';
+
+            const incomingHtml = incoming.map(r => `
+                <div style="display:flex; justify-content:space-between; align-items:center; padding: 0.75rem 0; border-bottom: 1px solid var(--border);">
+                    <div>
+                        <div style="font-weight:600;">${r.requester_name} meminta ${r.amount.toLocaleString()} poin</div>
+                        <small style="color:var(--text-muted);">${r.description || 'Tidak ada catatan'} • berlaku s/d ${new Date(r.expires_at).toLocaleString()}</small>
+                    </div>
+                    <div style="display:flex; gap:0.5rem;">
+                        <button class="btn btn-sm btn-secondary" onclick="MahasiswaController.answerPointRequest(${r.request_id}, 'decline')">Tolak</button>
+                        <button class="btn btn-sm btn-primary" onclick="MahasiswaController.answerPointRequest(${r.request_id}, 'accept')">Bayar</button>
+                    </div>
+                </div>
+            `).join('');
+
+            const outgoingHtml = outgoing.map(r => `
+                <div style="display:flex; justify-content:space-between; align-items:center; padding: 0.75rem 0; border-bottom: 1px solid var(--border);">
+                    <div>
+                        <div style="font-weight:600;">Permintaan #${r.id}: ${r.total_amount.toLocaleString()} poin</div>
+                        <small style="color:var(--text-muted);">${(r.recipients || []).map(x => `${x.full_name} (${x.amount}, ${x.status})`).join(', ')}</small>
+                    </div>
+                    <button class="btn btn-sm btn-secondary" onclick="MahasiswaController.answerPointRequest(${r.id}, 'cancel')">Batalkan</button>
+                </div>
+            `).join('');
+
+            document.getElementById('pointRequestsList').innerHTML = incomingHtml + outgoingHtml;
+        } catch (e) {
+            console.error(e);
+        }
+    }
+
+    static async answerPointRequest(id, action) {
+        try {
+            await API.answerPointRequest(id, action);
+            showToast(action === 'accept' ? 'Permintaan poin dibayar' : action === 'decline' ? 'Permintaan poin ditolak' : 'Permintaan poin dibatalkan', 'success');
+            this.renderTransfer();
+        } catch (e) {
+            showToast(e.message, 'error');
+        }
+    }
+
+    static showPointRequestForm() {
+        const modalHtml = `
+            <div class="modal-overlay" id="pointRequestModal">
+                <div class="modal-card" style="max-width: 480px; padding: 2rem;">
+                    <h3 style="margin-bottom: 0.5rem;">Minta Poin</h3>
+                    <p style="color: var(--text-muted); margin-bottom: 1.5rem;">Satu baris per teman: <code>ID</code> atau <code>ID;jumlah</code></p>
+                    <form id="pointRequestForm">
+                        <div class="form-group">
+                            <label>Teman</label>
+                            <textarea name="recipients" class="form-input" rows="4" placeholder="12;25&#10;15;25" required></textarea>
+                        </div>
+                        <div class="form-group">
+                            <label>Total dibagi rata (opsional)</label>
+                            <input type="number" name="split_amount" class="form-input" min="1" placeholder="Kosongkan jika jumlah per orang diisi">
+                        </div>
+                        <div class="form-group">
+                            <label><input type="checkbox" name="include_self"> Saya ikut menanggung bagian</label>
+                        </div>
+                        <div class="form-group">
+                            <label>Catatan</label>
+                            <input type="text" name="description" class="form-input" maxlength="255" placeholder="Patungan makan siang">
+                        </div>
+                        <div style="display:grid; grid-template-columns: 1fr 1fr; gap: 1rem;">
+                            <button type="button" class="btn btn-secondary" onclick="document.getElementById('pointRequestModal').remove()">Batal</button>
+                            <button type="submit" class="btn btn-primary">Kirim Permintaan</button>
+                        </div>
+                    </form>
+                </div>
+            </div>
+        `;
+        document.body.insertAdjacentHTML('beforeend', modalHtml);
+
+        document.getElementById('pointRequestForm').addEventListener('submit', async e => {
+            e.preventDefault();
+            const form = new FormData(e.target);
+            const data = {
+                recipients: form.get('recipients').split('\n')
+                    .map(line => line.trim())
+                    .filter(line => line)
+                    .map(line => {
+                        const [id, amount] = line.split(';');
+                        return { user_id: parseInt(id), amount: parseInt(amount) || 0 };
+                    }),
+                split_amount: parseInt(form.get('split_amount')) || 0,
+                include_self: form.get('include_self') === 'on',
+                description: form.get('description')
+            };
+
+            try {
+                await API.createPointRequest(data);
+                document.getElementById('pointRequestModal').remove();
+                showToast('Permintaan poin terkirim', 'success');
+                this.loadPointRequests();
+            } catch (err) {
+                showToast(err.message, 'error');
+            }
+        });
+    }
+
     // ==========================
     // MODULE: QR SCANNER & SMART FLOW
     // ==========================
@@ -1023,19 +1746,24 @@ class MahasiswaController {
         console.log("QR Scanned:", text);
 
         if (text.startsWith("WPUSER:")) {
-            const userId = text.split(":")[1];
+            const recipient = text.slice("WPUSER:".length);
             showToast("Pengguna ditemukan! Membuka form transfer...", "success");
             setTimeout(() => {
                 handleNavigation('transfer', 'mahasiswa');
-                setTimeout(() => this.showTransferForm(userId), 500);
+                setTimeout(() => this.showTransferForm(recipient), 500);
             }, 500);
         } else if (text.startsWith("WPPROD:")) {
             const prodId = text.split(":")[1];
             showToast("Produk ditemukan! Menyiapkan checkout...", "success");
             this.triggerPurchaseFromQR(prodId);
-        } else if (text.startsWith("WPT:")) {
-            const parts = text.split(":");
-            this.handleSelfPayment(parts);
+        } else if (text.startsWith("WPT:") || text.startsWith("WPT1.")) {
+            const payload = QRPayload.parse(text);
+            if (!payload) {
+                showToast("Format QR tidak dikenali", "warning");
+                this.renderScanner();
+                return;
+            }
+            this.handleSelfPayment(['WPT', payload.token, payload.amount, payload.merchant]);
         } else {
             showToast("Format QR tidak dikenali", "warning");
             this.renderScanner(); // Restart
@@ -1060,6 +1788,11 @@ class MahasiswaController {
                 throw new Error("Data pembayaran tidak lengkap. Pastikan server backend sudah direstart.");
             }
 
+            if (token && token.type === 'bill') {
+                this.showBillPayment(token);
+                return;
+            }
+
             const modalHtml = `
                 <div class="modal-overlay" id="selfPayConfirmModal">
                     <div class="modal-card" style="max-width: 450px; border-radius: 28px; padding: 2.5rem; text-align: center; box-shadow: var(--shadow-lg);">
@@ -1094,6 +1827,73 @@ class MahasiswaController {
         }
     }
 
+    static showBillPayment(bill) {
+        const itemsHtml = (bill.items || []).map(item => `
+            <div style="display:flex; justify-content:space-between; font-size: 0.9rem;">
+                <span>${item.quantity}x ${item.name}</span>
+                <span>${item.subtotal.toLocaleString()} Pts</span>
+            </div>
+        `).join('');
+
+        const amountHtml = bill.open_amount
+            ? `<input type="number" id="billPayAmount" class="form-input" min="1" ${bill.max_amount ? `max="${bill.max_amount}"` : ''} placeholder="Masukkan nominal${bill.max_amount ? ' (maks ' + bill.max_amount.toLocaleString() + ')' : ''}">`
+            : `<span style="font-weight: 900; color: var(--primary); font-size: 1.4rem;">💎 ${bill.amount.toLocaleString()} Pts</span>`;
+
+        const modalHtml = `
+            <div class="modal-overlay" id="selfPayConfirmModal">
+                <div class="modal-card" style="max-width: 450px; border-radius: 28px; padding: 2.5rem; text-align: center; box-shadow: var(--shadow-lg);">
+                    <div style="font-size: 3.5rem; margin-bottom: 1.5rem;">🧾</div>
+                    <h2 style="font-weight: 800; color: var(--text-main); margin-bottom: 0.5rem;">Bayar Tagihan</h2>
+                    <p style="color: var(--text-muted); margin-bottom: 2rem;">${bill.merchant}${bill.reference ? ' • ' + bill.reference : ''}</p>
+
+                    <div style="background: #f8fafc; padding: 1.5rem; border-radius: 20px; margin-bottom: 2rem; border: 1px solid var(--border); text-align: left;">
+                        ${itemsHtml}
+                        <div style="display:flex; justify-content:space-between; align-items:center; margin-top: 1rem;">
+                            <span>Total:</span>
+                            ${amountHtml}
+                        </div>
+                    </div>
+
+                    <div style="display: grid; grid-template-columns: 1fr 1fr; gap: 1rem;">
+                        <button class="btn btn-secondary" onclick="document.getElementById('selfPayConfirmModal').remove(); MahasiswaController.renderScanner();" style="padding: 1rem; border-radius: 15px; font-weight: 600; background: #f1f5f9; border: none; color: var(--text-muted);">Batal</button>
+                        <button class="btn btn-primary" id="confirmSelfPayBtn" onclick="MahasiswaController.confirmBillPayment('${bill.token}', ${bill.open_amount})" style="padding: 1rem; border-radius: 15px; font-weight: 700; background: linear-gradient(135deg, #6366f1, #a855f7); border: none;">
+                            Bayar Sekarang 🚀
+                        </button>
+                    </div>
+                </div>
+            </div>
+        `;
+        document.body.insertAdjacentHTML('beforeend', modalHtml);
+    }
+
+    static async confirmBillPayment(tokenCode, openAmount) {
+        let amount = null;
+        if (openAmount) {
+            amount = parseInt(document.getElementById('billPayAmount').value);
+            if (!amount || amount < 1) {
+                showToast("Masukkan nominal pembayaran", "warning");
+                return;
+            }
+        }
+
+        const btn = document.getElementById('confirmSelfPayBtn');
+        btn.disabled = true;
+        btn.innerHTML = '<span class="spinner"></span> Memproses...';
+
+        try {
+            await API.payBill(tokenCode, amount);
+            document.getElementById('selfPayConfirmModal').remove();
+            showToast("Tagihan berhasil dibayar!", "success");
+            setTimeout(() => {
+                handleNavigation('dashboard', 'mahasiswa');
+            }, 1500);
+        } catch (e) {
+            showToast("Gagal membayar: " + e.message, "error");
+            btn.disabled = false;
+            btn.innerHTML = "Bayar Sekarang 🚀";
+        }
+    }
+
     static async confirmSelfPayment(tokenCode) {
         const btn = document.getElementById('confirmSelfPayBtn');
         btn.disabled = true;
@@ -1118,27 +1918,19 @@ class MahasiswaController {
     }
 
     static startPaymentPolling(tokenData) {
-        if (this.paymentPollingInterval) clearInterval(this.paymentPollingInterval);
+        if (this.stopPaymentWatch) this.stopPaymentWatch();
 
         this.renderPaymentIndicator(tokenData);
 
-        this.paymentPollingInterval = setInterval(async () => {
-            try {
-                const res = await API.checkTokenStatus(tokenData.token);
-                const status = res.data.status;
-
-                if (status === 'consumed') {
-                    this.handlePaymentComplete(tokenData);
-                } else if (status === 'expired') {
-                    showToast(`Pembayaran ${tokenData.merchant} telah kadaluarsa.`, "warning");
-                    this.stopPaymentBackground();
-                }
-                // If 'active', just continue polling...
-            } catch (e) {
-                // Connection error or token deleted
-                console.error("Polling error:", e);
+        this.stopPaymentWatch = API.watchTokenStatus(tokenData.token, data => {
+            if (data.status === 'consumed') {
+                this.handlePaymentComplete(tokenData);
+            } else if (data.status === 'expired') {
+                showToast(`Pembayaran ${tokenData.merchant} telah kadaluarsa.`, "warning");
+                this.stopPaymentBackground();
             }
-        }, 5000);
+            // If 'active', keep waiting...
+        });
     }
 
     static handlePaymentComplete(tokenData) {
@@ -1155,7 +1947,10 @@ class MahasiswaController {
     }
 
     static stopPaymentBackground() {
-        clearInterval(this.paymentPollingInterval);
+        if (this.stopPaymentWatch) {
+            this.stopPaymentWatch();
+            this.stopPaymentWatch = null;
+        }
         localStorage.removeItem('active_payment_token');
         const indicator = document.getElementById('background-payment-indicator');
         if (indicator) indicator.remove();
@@ -1200,15 +1995,15 @@ class MahasiswaController {
                     <h3 style="margin-bottom: 0.5rem;">ID Wallet Saya</h3>
                     <p style="color: var(--text-muted); margin-bottom: 2rem;">Tunjukkan kode ini untuk menerima transfer</p>
                     <div id="my-qr-container" style="display: flex; justify-content: center; margin-bottom: 2rem; background: white; padding: 1rem; border-radius: 16px; border: 1px solid var(--border);"></div>
-                    <div style="font-weight: 800; font-size: 1.2rem; color: var(--primary); background: #f1f5f9; padding: 0.5rem; border-radius: 8px;">${user.nim_nip || user.id}</div>
+                    <div style="font-weight: 800; font-size: 1.2rem; color: var(--primary); background: #f1f5f9; padding: 0.5rem; border-radius: 8px;">${user.handle ? '@' + user.handle : (user.nim_nip || user.id)}</div>
                     <button class="btn btn-primary" onclick="closeModal()" style="width: 100%; margin-top: 2rem;">Tutup</button>
                 </div>
             </div>
         `;
         document.body.insertAdjacentHTML('beforeend', modalHtml);
 
-        // Use QR Code Library or just QR text (Server-side generated preferred, but let's just make it simple)
-        const qrContent = `WPUSER:${user.id}`;
+        // Handle or NIM, resolved like a typed recipient
+        const qrContent = `WPUSER:${user.handle ? '@' + user.handle : user.nim_nip}`;
         new QRCode(document.getElementById("my-qr-container"), {
             text: qrContent,
             width: 256,
@@ -1371,8 +2166,8 @@ class MahasiswaController {
         `;
         document.body.insertAdjacentHTML('beforeend', modalHtml);
 
-        // Format is WPT:tokenCode:amount:merchant (from backend service.go)
-        const qrContent = `WPT:${tokenData.token}:${tokenData.amount}:${tokenData.merchant}`;
+        // Signed payload from the backend, legacy WPT:tokenCode:amount:merchant as fallback
+        const qrContent = tokenData.qr_payload || `WPT:${tokenData.token}:${tokenData.amount}:${tokenData.merchant}`;
         new QRCode(document.getElementById("payment-qr-container"), {
             text: qrContent,
             width: 256,
@@ -31,6 +31,9 @@ class MerchantController {
                         <h2 style="font-weight: 700; color: var(--text-main);">Terminal Pembayaran Kasir</h2>
                         <p style="color: var(--text-muted);">Scan QR Token Mahasiswa untuk memproses pembayaran</p>
                     </div>
+                    <select id="merchantTerminal" class="form-input" style="max-width: 220px;" onchange="MerchantController.selectTerminal(this.value)">
+                        <option value="0">Terminal default</option>
+                    </select>
                 </div>
 
                 <div class="card" style="max-width: 600px; margin: 0 auto; padding: 2.5rem; text-align: center; border-radius: 24px; box-shadow: var(--shadow-lg);">
@@ -69,6 +72,35 @@ class MerchantController {
         `;
 
         this.startScanner();
+        this.loadTerminalOptions();
+    }
+
+    /* Terminal this device captures payments on, remembered per browser */
+    static terminalId() {
+        return parseInt(localStorage.getItem('merchantTerminalId')) || 0;
+    }
+
+    static selectTerminal(id) {
+        localStorage.setItem('merchantTerminalId', parseInt(id) || 0);
+    }
+
+    static async loadTerminalOptions() {
+        try {
+            const res = await API.getMerchantProfile();
+            const select = document.getElementById('merchantTerminal');
+            if (!select) return;
+
+            res.data.terminals
+                .filter(t => t.status === 'active')
+                .forEach(t => select.insertAdjacentHTML('beforeend', `<option value="${t.id}">${t.code} - ${t.name}</option>`));
+            select.value = String(this.terminalId());
+            if (select.selectedIndex < 0) {
+                select.value = '0';
+                this.selectTerminal(0);
+            }
+        } catch (e) {
+            // Merchants without a profile scan into their own wallet
+        }
     }
 
     static html5QrCode = null;
@@ -111,21 +143,29 @@ class MerchantController {
     }
 
     static async handleScan(data) {
-        // Format: WPT:tokenCode:amount:merchant
-        if (!data.startsWith("WPT:")) {
+        // Formats: WPT1.<claims>.<signature> (signed) or WPT:tokenCode:amount:merchant (legacy)
+        const payload = QRPayload.parse(data);
+        if (!payload) {
             showToast("QR Code tidak valid untuk pembayaran", "error");
             return;
         }
 
-        const parts = data.split(":");
-        if (parts.length < 3) {
-            showToast("Format QR Code salah", "error");
-            return;
+        if (payload.signed) {
+            // Check authenticity before anything is queued, works without network once keys are cached
+            const valid = await QRPayload.verify(data);
+            if (valid === false) {
+                showToast("QR Code palsu: tanda tangan tidak valid", "error");
+                return;
+            }
+            if (payload.expiry && Date.now() / 1000 > payload.expiry) {
+                showToast("QR Code sudah kadaluarsa", "error");
+                return;
+            }
         }
 
-        const token = parts[1];
-        const amount = parts[2];
-        const merchant = parts[3] || "Merchant";
+        const token = payload.token;
+        const amount = payload.amount;
+        const merchant = payload.merchant;
 
         // Stop scanner while processing
         if (this.html5QrCode) {
@@ -157,7 +197,7 @@ class MerchantController {
                 btn.disabled = true;
                 btn.innerHTML = '<span class="spinner"></span> Memproses...';
 
-                await API.request('/merchant/payment/scan', 'POST', { token: token });
+                await API.request('/merchant/payment/scan', 'POST', { token: payload.raw, terminal_id: this.terminalId() });
 
                 showToast("Pembayaran Berhasil Diproses!", "success");
                 this.resetScanner();
@@ -174,4 +214,309 @@ class MerchantController {
         document.getElementById('waitingMsg').style.display = 'block';
         this.startScanner();
     }
+
+    /* Merchant-presented QR bills */
+    static stopBillWatch = null;
+
+    static renderBillForm() {
+        if (this.stopBillWatch) this.stopBillWatch();
+
+        const content = document.getElementById('mainContent');
+        content.innerHTML = `
+            <div class="fade-in">
+                <div class="table-header" style="margin-bottom: 2rem;">
+                    <div>
+                        <h2 style="font-weight: 700; color: var(--text-main);">Buat Tagihan QR</h2>
+                        <p style="color: var(--text-muted);">Tampilkan QR ke mahasiswa untuk dibayar dari dompetnya</p>
+                    </div>
+                </div>
+
+                <div class="card" id="billCard" style="max-width: 600px; margin: 0 auto; padding: 2.5rem; border-radius: 24px; box-shadow: var(--shadow-lg);">
+                    <form id="billForm">
+                        <div class="form-group">
+                            <label><input type="checkbox" id="billOpenAmount"> Nominal diisi mahasiswa</label>
+                        </div>
+                        <div class="form-group">
+                            <label>Nominal (Pts)</label>
+                            <input type="number" id="billAmount" class="form-input" min="1" placeholder="Kosongkan untuk total item">
+                        </div>
+                        <div class="form-group">
+                            <label>Referensi (opsional)</label>
+                            <input type="text" id="billReference" class="form-input" maxlength="100" placeholder="No. pesanan / meja">
+                        </div>
+                        <div class="form-group">
+                            <label>Item (opsional, satu per baris: nama;jumlah;harga)</label>
+                            <textarea id="billItems" class="form-input" rows="4" placeholder="Nasi Goreng;1;15&#10;Es Teh;2;5"></textarea>
+                        </div>
+                        <button type="submit" class="btn btn-primary" style="width: 100%;">Buat Tagihan</button>
+                    </form>
+                </div>
+            </div>
+        `;
+
+        document.getElementById('billOpenAmount').addEventListener('change', e => {
+            document.getElementById('billAmount').previousElementSibling.textContent = e.target.checked ? 'Nominal Maksimum (opsional)' : 'Nominal (Pts)';
+        });
+        document.getElementById('billForm').addEventListener('submit', e => {
+            e.preventDefault();
+            this.submitBill();
+        });
+    }
+
+    static async submitBill() {
+        const open = document.getElementById('billOpenAmount').checked;
+        const amount = parseInt(document.getElementById('billAmount').value) || 0;
+        const items = document.getElementById('billItems').value
+            .split('\n')
+            .map(line => line.trim())
+            .filter(line => line)
+            .map(line => {
+                const [name, quantity, price] = line.split(';');
+                return { name: (name || '').trim(), quantity: parseInt(quantity) || 1, price: parseInt(price) || 0 };
+            });
+
+        const data = {
+            terminal_id: this.terminalId(),
+            open_amount: open,
+            reference: document.getElementById('billReference').value.trim(),
+            items
+        };
+        if (amount > 0) {
+            if (open) data.max_amount = amount;
+            else data.amount = amount;
+        }
+
+        try {
+            const res = await API.createBill(data);
+            this.showBill(res.data);
+        } catch (e) {
+            showToast(e.message, "error");
+        }
+    }
+
+    static showBill(bill) {
+        const itemsHtml = (bill.items || []).map(item => `
+            <div style="display:flex; justify-content:space-between;">
+                <span>${item.quantity}x ${item.name}</span>
+                <span>${item.subtotal.toLocaleString()} Pts</span>
+            </div>
+        `).join('');
+
+        document.getElementById('billCard').innerHTML = `
+            <div style="text-align: center;">
+                <img src="data:image/png;base64,${bill.qr_code_base64}" alt="QR Tagihan" style="width: 256px; height: 256px;">
+                <h3 style="margin: 1rem 0 0.25rem;">${bill.open_amount ? 'Nominal diisi pembayar' : bill.amount.toLocaleString() + ' Pts'}</h3>
+                <p style="color: var(--text-muted);">${bill.reference || bill.merchant}</p>
+                <div style="font-family: monospace; font-size: 0.9rem; text-align: left; margin: 1rem 0;">${itemsHtml}</div>
+                <p id="billStatus" style="font-weight: 700; color: var(--primary);">Menunggu pembayaran...</p>
+                <button class="btn btn-secondary" onclick="MerchantController.renderBillForm()" style="margin-top: 1rem;">Tagihan Baru</button>
+            </div>
+        `;
+
+        this.stopBillWatch = API.watchTokenStatus(bill.token, data => {
+            const statusElem = document.getElementById('billStatus');
+
+            if (data.status === 'consumed') {
+                this.stopBillWatch();
+                if (statusElem) statusElem.textContent = `Lunas: ${data.paid_amount.toLocaleString()} Pts`;
+                showToast("Tagihan telah dibayar!", "success");
+            } else if (data.status === 'expired') {
+                this.stopBillWatch();
+                if (statusElem) statusElem.textContent = 'Tagihan kadaluarsa';
+            }
+        }, 3000);
+    }
+
+    /* Settlement batches and statements */
+    static async renderSettlements() {
+        const content = document.getElementById('mainContent');
+        content.innerHTML = `<div class="fade-in"><p style="color: var(--text-muted);">Memuat settlement...</p></div>`;
+
+        try {
+            const res = await API.getMerchantSettlements({ limit: 50 });
+            const { batches, current } = res.data;
+            const fmt = d => new Date(d).toLocaleString('id-ID', { dateStyle: 'medium', timeStyle: 'short' });
+
+            const rows = batches.map(b => `
+                <tr>
+                    <td>#${b.id}</td>
+                    <td>${fmt(b.period_start)} - ${fmt(b.period_end)}</td>
+                    <td>${b.sales_count}</td>
+                    <td>${b.net_amount.toLocaleString()} Pts</td>
+                    <td>${b.settled_amount.toLocaleString()} Pts</td>
+                    <td>
+                        <span class="badge ${b.status === 'settled' ? 'badge-success' : 'badge-warning'}">${b.status}</span>
+                        ${b.shortfall > 0 ? `<small style="display: block; color: var(--error);">Kurang ${b.shortfall.toLocaleString()} Pts</small>` : ''}
+                    </td>
+                    <td>
+                        <button class="btn btn-secondary btn-sm" onclick="MerchantController.downloadStatement(${b.id}, 'csv')">CSV</button>
+                        <button class="btn btn-secondary btn-sm" onclick="MerchantController.downloadStatement(${b.id}, 'pdf')">PDF</button>
+                    </td>
+                </tr>
+            `).join('');
+
+            content.innerHTML = `
+                <div class="fade-in">
+                    <div class="card" style="padding: 1.5rem; margin-bottom: 1.5rem;">
+                        <h3 style="margin: 0 0 0.5rem;">Periode Berjalan</h3>
+                        <p style="color: var(--text-muted); margin: 0;">Sejak ${fmt(current.period_start)}: ${current.sales_count} transaksi, ${current.net_amount.toLocaleString()} Pts bersih</p>
+                    </div>
+                    <div class="card">
+                        <table class="premium-table">
+                            <thead>
+                                <tr><th>Batch</th><th>Periode</th><th>Transaksi</th><th>Bersih</th><th>Disettle</th><th>Status</th><th>Laporan</th></tr>
+                            </thead>
+                            <tbody>
+                                ${rows || '<tr><td colspan="7" style="text-align:center; color: var(--text-muted);">Belum ada settlement</td></tr>'}
+                            </tbody>
+                        </table>
+                    </div>
+                </div>
+            `;
+        } catch (e) {
+            showToast("Gagal memuat settlement: " + e.message, "error");
+        }
+    }
+
+    static async downloadStatement(batchId, format) {
+        try {
+            await API.downloadStatement(batchId, format);
+        } catch (e) {
+            showToast(e.message, "error");
+        }
+    }
+
+    /* Merchant profile, terminals and cashiers */
+    static async renderMerchantProfile() {
+        const content = document.getElementById('mainContent');
+        content.innerHTML = `<div class="fade-in"><p style="color: var(--text-muted);">Memuat profil toko...</p></div>`;
+
+        try {
+            const res = await API.getMerchantProfile();
+            const { merchant, role, terminals, cashiers } = res.data;
+            const isOwner = role === 'owner';
+
+            const terminalRows = terminals.map(t => `
+                <tr>
+                    <td>${t.code}</td>
+                    <td>${t.name}</td>
+                    <td><span class="badge ${t.status === 'active' ? 'badge-success' : 'badge-warning'}">${t.status}</span></td>
+                    <td>${isOwner ? `<button class="btn btn-secondary btn-sm" onclick="MerchantController.toggleTerminal(${t.id}, '${t.status === 'active' ? 'inactive' : 'active'}')">${t.status === 'active' ? 'Nonaktifkan' : 'Aktifkan'}</button>` : ''}</td>
+                </tr>
+            `).join('');
+
+            const cashierRows = (cashiers || []).map(k => `
+                <tr>
+                    <td>${k.full_name}</td>
+                    <td>${k.email}</td>
+                    <td>${(terminals.find(t => t.id === k.terminal_id) || {}).code || '-'}</td>
+                    <td><span class="badge ${k.status === 'active' ? 'badge-success' : 'badge-warning'}">${k.status}</span></td>
+                    <td>${k.status === 'active' ? `<button class="btn btn-secondary btn-sm" onclick="MerchantController.deactivateCashier(${k.id})">Nonaktifkan</button>` : ''}</td>
+                </tr>
+            `).join('');
+
+            content.innerHTML = `
+                <div class="fade-in">
+                    <div class="card" style="padding: 1.5rem; margin-bottom: 1.5rem;">
+                        <form id="merchantProfileForm" style="display: grid; grid-template-columns: repeat(auto-fit, minmax(200px, 1fr)); gap: 1rem; align-items: end;">
+                            <div class="form-group"><label>Nama Toko</label><input name="name" class="form-input" maxlength="100" value="${merchant.name}" ${isOwner ? '' : 'disabled'}></div>
+                            <div class="form-group"><label>Kategori</label><input name="category" class="form-input" maxlength="50" value="${merchant.category || ''}" ${isOwner ? '' : 'disabled'}></div>
+                            <div class="form-group"><label>URL Logo</label><input name="logo_url" class="form-input" maxlength="500" value="${merchant.logo_url || ''}" ${isOwner ? '' : 'disabled'}></div>
+                            ${isOwner ? '<button type="submit" class="btn btn-primary">Simpan</button>' : ''}
+                        </form>
+                    </div>
+
+                    <div class="card" style="margin-bottom: 1.5rem;">
+                        <h3 style="padding: 1rem 1.5rem 0;">Terminal</h3>
+                        <table class="premium-table">
+                            <thead><tr><th>Kode</th><th>Nama</th><th>Status</th><th></th></tr></thead>
+                            <tbody>${terminalRows || '<tr><td colspan="4" style="text-align:center; color: var(--text-muted);">Belum ada terminal</td></tr>'}</tbody>
+                        </table>
+                        ${isOwner ? `
+                        <form id="terminalForm" style="display: flex; gap: 0.5rem; padding: 1rem 1.5rem;">
+                            <input name="code" class="form-input" maxlength="30" placeholder="Kode (mis. KSR-1)" required>
+                            <input name="name" class="form-input" maxlength="100" placeholder="Nama terminal" required>
+                            <button type="submit" class="btn btn-primary">Tambah</button>
+                        </form>` : ''}
+                    </div>
+
+                    ${isOwner ? `
+                    <div class="card">
+                        <h3 style="padding: 1rem 1.5rem 0;">Kasir</h3>
+                        <table class="premium-table">
+                            <thead><tr><th>Nama</th><th>Email</th><th>Terminal</th><th>Status</th><th></th></tr></thead>
+                            <tbody>${cashierRows || '<tr><td colspan="5" style="text-align:center; color: var(--text-muted);">Belum ada kasir</td></tr>'}</tbody>
+                        </table>
+                        <form id="cashierForm" style="display: grid; grid-template-columns: repeat(auto-fit, minmax(160px, 1fr)); gap: 0.5rem; padding: 1rem 1.5rem;">
+                            <input name="full_name" class="form-input" placeholder="Nama lengkap" required>
+                            <input name="email" type="email" class="form-input" placeholder="Email" required>
+                            <input name="nim_nip" class="form-input" placeholder="ID Kasir" required>
+                            <input name="password" type="password" class="form-input" minlength="6" placeholder="Password" required>
+                            <select name="terminal_id" class="form-input">
+                                <option value="">Tanpa terminal tetap</option>
+                                ${terminals.filter(t => t.status === 'active').map(t => `<option value="${t.id}">${t.code} - ${t.name}</option>`).join('')}
+                            </select>
+                            <button type="submit" class="btn btn-primary">Tambah Kasir</button>
+                        </form>
+                    </div>` : ''}
+                </div>
+            `;
+
+            if (!isOwner) return;
+
+            document.getElementById('merchantProfileForm').addEventListener('submit', async e => {
+                e.preventDefault();
+                try {
+                    await API.updateMerchantProfile(Object.fromEntries(new FormData(e.target).entries()));
+                    showToast("Profil toko diperbarui", "success");
+                } catch (err) {
+                    showToast(err.message, "error");
+                }
+            });
+            document.getElementById('terminalForm').addEventListener('submit', async e => {
+                e.preventDefault();
+                try {
+                    await API.createTerminal(Object.fromEntries(new FormData(e.target).entries()));
+                    showToast("Terminal ditambahkan", "success");
+                    this.renderMerchantProfile();
+                } catch (err) {
+                    showToast(err.message, "error");
+                }
+            });
+            document.getElementById('cashierForm').addEventListener('submit', async e => {
+                e.preventDefault();
+                const data = Object.fromEntries(new FormData(e.target).entries());
+                data.terminal_id = data.terminal_id ? parseInt(data.terminal_id) : null;
+                try {
+                    await API.createCashier(data);
+                    showToast("Kasir ditambahkan", "success");
+                    this.renderMerchantProfile();
+                } catch (err) {
+                    showToast(err.message, "error");
+                }
+            });
+        } catch (e) {
+            showToast("Gagal memuat profil toko: " + e.message, "error");
+        }
+    }
+
+    static async toggleTerminal(id, status) {
+        try {
+            await API.updateTerminal(id, { status });
+            this.renderMerchantProfile();
+        } catch (e) {
+            showToast(e.message, "error");
+        }
+    }
+
+    static async deactivateCashier(id) {
+        if (!confirm('Nonaktifkan kasir ini? Login kasir juga akan dinonaktifkan.')) return;
+        try {
+            await API.deactivateCashier(id);
+            showToast("Kasir dinonaktifkan", "success");
+            this.renderMerchantProfile();
+        } catch (e) {
+            showToast(e.message, "error");
+        }
+    }
 }
@@ -39,6 +39,30 @@ class ProfileController {
                         </div>
                     </div>
 
+                    <!-- Privacy -->
+                    <div class="table-wrapper" style="margin:0">
+                        <div class="table-header">
+                            <h3>Privasi & Handle</h3>
+                        </div>
+                        <div style="padding: 1.5rem;">
+                            <form id="privacyForm" onsubmit="ProfileController.handleUpdatePrivacy(event)">
+                                <div class="form-group">
+                                    <label>Handle</label>
+                                    <input type="text" name="handle" placeholder="mis. budi_s" maxlength="30">
+                                    <small style="color:var(--text-muted)">Orang lain dapat mengirim poin ke @handle Anda.</small>
+                                </div>
+                                <div class="form-group">
+                                    <label style="display:flex; gap:0.5rem; align-items:center;">
+                                        <input type="checkbox" name="discoverable" style="width:auto;"> Dapat ditemukan lewat NIM, email atau handle
+                                    </label>
+                                </div>
+                                <div class="form-actions" style="margin-top: 1.5rem">
+                                    <button type="submit" class="btn btn-primary btn-block">Simpan Privasi</button>
+                                </div>
+                            </form>
+                        </div>
+                    </div>
+
                     <!-- Change Password -->
                     <div class="table-wrapper" style="margin:0">
                         <div class="table-header">
@@ -67,6 +91,38 @@ class ProfileController {
                 </div>
             </div>
         `;
+
+        this.loadPrivacy();
+    }
+
+    static async loadPrivacy() {
+        try {
+            const res = await API.getProfile();
+            const form = document.getElementById('privacyForm');
+            form.handle.value = res.data.handle || '';
+            form.discoverable.checked = res.data.discoverable;
+        } catch (e) {
+            console.error(e);
+        }
+    }
+
+    static async handleUpdatePrivacy(e) {
+        e.preventDefault();
+        const form = e.target;
+
+        try {
+            const res = await API.updatePrivacy({
+                handle: form.handle.value.trim(),
+                discoverable: form.discoverable.checked
+            });
+            showToast("Pengaturan privasi disimpan");
+
+            const user = JSON.parse(localStorage.getItem('user'));
+            user.handle = res.data.handle;
+            localStorage.setItem('user', JSON.stringify(user));
+        } catch (error) {
+            showToast(error.message, "error");
+        }
     }
 
     static async handleUpdateProfile(e) {